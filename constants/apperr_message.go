@@ -44,9 +44,62 @@ const (
 	PharmacyManagerNotMatchErrMsg  = "pharmacy manager doesn't same"
 	OrderStatusNotPendingErrMsg    = "order status is not pending, cannot update status to processing"
 	OrderStatusNotProcessingErrMsg = "order status is not processing, cannot update status to shipped"
-	OrderStatusNotShippedErrMsg    = "order status is not shipped, cannot update status to completed"
+	OrderStatusNotShippedErrMsg    = "order status is not shipped or delivered, cannot update status to completed"
+	OrderNotDeliverableErrMsg      = "order status is not shipped, cannot update status to delivered"
 	FileIsNotImageErrMsg           = "file must be image"
 	CannotUploadPaymentProofErrMsg = "order status is not pending, cannot upload payment proof"
 	CannotCancelOrderErrMsg        = "sorry, your order cannot be canceled"
 	StockIsNotEnoughErrMsg         = "stock is not enough"
+	PaymentNotSupportedErrMsg      = "payment method is not supported"
+	InvalidCallbackSignErrMsg      = "invalid callback signature"
+	PaymentAmountNotMatchErrMsg    = "payment amount doesn't match the order"
+	OrderNotPayableErrMsg          = "order status is not pending, cannot create payment"
+	OrderAlreadyPaidErrMsg         = "order is already paid"
+	CartItemNotFoundErrMsg         = "some cart items are not found"
+	OrderShippingRequiredErrMsg    = "shipping method is required for every pharmacy"
+	ShippingNotAvailableErrMsg     = "shipping method is not available for this pharmacy"
+	ShippingFeeChangedErrMsg       = "shipping fee has changed, please review your order"
+	OrderPriceChangedErrMsg        = "product price has changed, please review your order"
+	VoucherCodeNotUniqueErrMsg     = "voucher with this code already exists"
+	InvalidVoucherErrMsg           = "voucher is invalid"
+	VoucherScopeNotFoundErrMsg     = "voucher category, product or pharmacy is not found"
+	InvalidVoucherDiscountErrMsg   = "percentage discount must be between 0 and 100"
+	InvalidVoucherPeriodErrMsg     = "voucher end date must be after its start date"
+	VoucherNotActiveErrMsg         = "voucher is not active"
+	VoucherQuotaExceededErrMsg     = "voucher usage limit has been reached"
+	VoucherNotApplicableErrMsg     = "voucher doesn't apply to any item in this order"
+	VoucherMinSpendErrMsg          = "order total doesn't meet the voucher minimum spend"
+	OrderDiscountChangedErrMsg     = "discount has changed, please review your order"
+	OrderNotReturnableErrMsg       = "order status is not completed, cannot request a return"
+	ReturnWindowPassedErrMsg       = "return window for this order has passed"
+	InvalidReturnItemErrMsg        = "return items must be part of the order and within the ordered quantity"
+	RefundNotRequestedErrMsg       = "refund is not waiting for approval"
+	RefundNotApprovedErrMsg        = "refund is not approved, cannot pay out"
+	InvalidChatReceiptErrMsg       = "receipt must point to a chat of this consultation"
+	DoctorOfflineErrMsg            = "doctor is offline, please choose another doctor"
+	ConsultationNotWaitingErrMsg   = "consultation request is no longer waiting"
+	ConsultationNotAcceptedErrMsg  = "consultation has not been accepted by the doctor"
+	ConsultationNotPayableErrMsg   = "consultation is not waiting for payment"
+	ConsultationNotPaidErrMsg      = "consultation has not been paid"
+	ConsultationEndedErrMsg        = "consultation has already ended"
+	InvalidRatingInputErrMsg       = "rating must be a number between 1 and 5"
+	ConsultationNotEndedErrMsg     = "consultation can only be reviewed after it ends"
+	ReviewAlreadyExistsErrMsg      = "consultation has already been reviewed"
+	ReviewAlreadyHiddenErrMsg      = "review is already hidden"
+	ReviewNotHiddenErrMsg          = "review is not hidden"
+	InvalidScheduleErrMsg          = "schedule times must be HH:MM, end after they start and not overlap"
+	SlotNotAvailableErrMsg         = "appointment slot is not available"
+	SlotAlreadyBookedErrMsg        = "appointment slot has already been booked"
+	AppointmentNotBookedErrMsg     = "appointment can no longer be canceled"
+	ScheduleExceptionClashErrMsg   = "doctor has appointments booked in that time"
+	ConsultationNotStartedErrMsg   = "consultation has not started yet"
+	PrescriptionExpiryErrMsg       = "prescription cannot expire in the past"
+	PrescriptionExpiredErrMsg      = "prescription has expired"
+	PrescriptionNoRefillsErrMsg    = "prescription has no refills left"
+	PrescriptionRequiredErrMsg     = "product requires a valid prescription"
+	InvalidDocumentErrMsg          = "document could not be verified"
+	PrescribedQuantityErrMsg       = "quantity exceeds the prescribed quantity"
+	PrescriptionRefillsUsedErrMsg  = "refills cannot be fewer than the refills already used"
+	InvalidPaymentStatusErrMsg     = "payment status is not recognized"
+	PaymentChargeStaleErrMsg       = "order changed while the payment was being created, please try again"
 )
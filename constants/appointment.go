@@ -0,0 +1,27 @@
+package constants
+
+import "time"
+
+const (
+	AppointmentStatusBooked   = "booked"
+	AppointmentStatusStarted  = "started"
+	AppointmentStatusCanceled = "canceled"
+)
+
+const (
+	AppointmentSlotDuration   = 30 * time.Minute
+	AppointmentReminderLead   = 1 * time.Hour
+	AppointmentBookingDays    = 30
+	AppointmentCanceledReason = "appointment was canceled before it started"
+)
+
+const (
+	ScheduleTimeLayout = "15:04"
+	ScheduleDateLayout = "2006-01-02"
+)
+
+const (
+	AppointmentUserReminderMsg   = "Reminder: your consultation with dr. %s starts at %s."
+	AppointmentDoctorReminderMsg = "Reminder: your consultation with %s starts at %s."
+	AppointmentReminderLayout    = "Monday, 02 January 2006 15:04"
+)
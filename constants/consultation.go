@@ -0,0 +1,55 @@
+package constants
+
+import "time"
+
+const (
+	ConsultationStatusUnpaid    = "unpaid"
+	ConsultationStatusScheduled = "scheduled"
+	ConsultationStatusWaiting   = "waiting"
+	ConsultationStatusAccepted  = "accepted"
+	ConsultationStatusExpired   = "expired"
+)
+
+const (
+	ConsultationDeclined = "declined"
+	ConsultationTimedOut = "timed_out"
+)
+
+const (
+	ConsultationEventRequested  = "consultation_requested"
+	ConsultationEventAccepted   = "consultation_accepted"
+	ConsultationEventReassigned = "consultation_reassigned"
+	ConsultationEventExpired    = "consultation_expired"
+	ConsultationEventEnded      = "consultation_ended"
+)
+
+const (
+	ConsultationPaymentTimeout  = 15 * time.Minute
+	ConsultationRequestTimeout  = 2 * time.Minute
+	ConsultationDefaultDuration = 15 * time.Minute
+	ConsultationDurationSample  = 20
+	ConsultationMaxMinutes      = 60
+	ConsultationIdleMinutes     = 15
+)
+
+const (
+	SystemChatType            = "system"
+	TextChatType              = "text"
+	FileChatType              = "file"
+	ConsultationMaxReachedMsg = "consultation ended automatically after reaching its time limit"
+	ConsultationIdleEndedMsg  = "consultation ended automatically after a period of inactivity"
+	ConsultationNoShowMsg     = "consultation ended because the doctor did not show up for the appointment"
+)
+
+const (
+	ConsultationPaymentRefPrefix = "CONSULTATION-"
+	ConsultationRefundReason     = "no doctor accepted the consultation"
+	ConsultationCanceledReason   = "consultation was canceled before a doctor accepted it"
+	ConsultationLatePaidReason   = "consultation was no longer awaiting payment"
+	ConsultationNoShowReason     = "doctor did not show up for the appointment"
+)
+
+const (
+	DoctorPresenceHeartbeat = 30 * time.Second
+	DoctorPresenceTtl       = 90 * time.Second
+)
@@ -0,0 +1,24 @@
+package constants
+
+const (
+	DocumentTypePrescription = "prescription"
+	DocumentTypeCertificate  = "certificate"
+)
+
+const (
+	DocumentStatusValid   = "valid"
+	DocumentStatusExpired = "expired"
+	DocumentStatusRevoked = "revoked"
+)
+
+const (
+	DocumentToken            = "token"
+	DocumentTokenAudience    = "document"
+	DocumentVerifyPath       = "/documents/verify/"
+	DocumentNumberDateLayout = "20060102"
+)
+
+var DocumentNumberPrefixes = map[string]string{
+	DocumentTypePrescription: "RX",
+	DocumentTypeCertificate:  "SKD",
+}
@@ -3,4 +3,5 @@ package constants
 const (
 	VerificationEmailSubject = "Email Verification"
 	CredentialEmailSubject   = "Account Credential"
+	AppointmentEmailSubject  = "Upcoming Consultation Appointment"
 )
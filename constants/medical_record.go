@@ -0,0 +1,6 @@
+package constants
+
+const (
+	MedicalConditionAllergy = "allergy"
+	MedicalConditionChronic = "chronic"
+)
@@ -10,5 +10,6 @@ const (
 )
 
 const (
-	OrderId = "orderId"
+	OrderId    = "orderId"
+	CheckoutId = "checkoutId"
 )
\ No newline at end of file
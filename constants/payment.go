@@ -0,0 +1,33 @@
+package constants
+
+const (
+	ManualPaymentProvider = "manual"
+	FakePaymentProvider   = "fake"
+)
+
+const (
+	PaymentMethodManualTransfer = "manual_transfer"
+	PaymentMethodVirtualAccount = "virtual_account"
+	PaymentMethodEWallet        = "e_wallet"
+)
+
+const (
+	PaymentStatusPending = "pending"
+	PaymentStatusPaid    = "paid"
+	PaymentStatusFailed  = "failed"
+	PaymentStatusExpired = "expired"
+)
+
+const (
+	PaymentSignatureHeader = "X-Callback-Signature"
+	PaymentProvider        = "provider"
+	OrderLatePaidReason    = "order was canceled before its payment settled"
+	OrderPaidTwiceReason   = "order was already paid by another charge"
+)
+
+const (
+	InvoicePaymentUnpaid   = "Unpaid"
+	InvoicePaymentWaiting  = "Waiting for confirmation"
+	InvoicePaymentPaid     = "Paid"
+	InvoicePaymentCanceled = "Canceled"
+)
@@ -2,6 +2,9 @@ package constants
 
 const (
 	ViolatesUniqueConstraintPgErrCode     = "23505"
+	ViolatesExclusionConstraintPgErrCode  = "23P01"
 	InvalidInputFundsSourcePgErrCode      = "22P02"
 	VioletesForeignKeyConstraintPgErrCode = "23503"
+	SerializationFailurePgErrCode         = "40001"
+	DeadlockDetectedPgErrCode             = "40P01"
 )
@@ -0,0 +1,20 @@
+package constants
+
+const (
+	RefundTypeCancellation = "cancellation"
+	RefundTypeReturn       = "return"
+	RefundTypeConsultation = "consultation"
+	RefundTypeOverpayment  = "overpayment"
+)
+
+const (
+	RefundStatusRequested = "requested"
+	RefundStatusApproved  = "approved"
+	RefundStatusRejected  = "rejected"
+	RefundStatusPaidOut   = "paid_out"
+)
+
+const (
+	OrderReturnWindowDays = 7
+	ReturnedStockDesc     = "returned order"
+)
@@ -25,4 +25,5 @@ const (
 	ResponseMsgChangePasswordSuccess   = "successfully change password"
 	ResponseMsgCreateOrder             = "successfully create order"
 	ResponseMsgGetOrder                = "successfully get orders"
+	ResponseMsgCreatePayment           = "successfully create payment"
 )
@@ -6,4 +6,5 @@ const (
 	PharmacyManagerRole = "pharmacyManager"
 	AdminRole           = "admin"
 	PublicRole          = "public"
+	SystemRole          = "system"
 )
@@ -0,0 +1,8 @@
+package constants
+
+import "time"
+
+const (
+	MaxTransactionRetries   = 3
+	TransactionRetryBackoff = 50 * time.Millisecond
+)
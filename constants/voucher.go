@@ -0,0 +1,6 @@
+package constants
+
+const (
+	VoucherDiscountPercentage = "percentage"
+	VoucherDiscountFixed      = "fixed"
+)
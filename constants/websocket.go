@@ -0,0 +1,42 @@
+package constants
+
+import "time"
+
+const (
+	MemoryPubSub   = "memory"
+	PostgresPubSub = "postgres"
+)
+
+const (
+	WsPubSubChannel        = "ws_messages"
+	WsPubSubBufferSize     = 256
+	WsPubSubReconnectDelay = 5 * time.Second
+	WsClientBufferSize     = 64
+)
+
+const (
+	WsWriteWait      = 10 * time.Second
+	WsPongWait       = 60 * time.Second
+	WsPingPeriod     = (WsPongWait * 9) / 10
+	WsMaxMessageSize = 4096
+)
+
+const (
+	LastSeenMessageId = "last_seen_message_id"
+	WsTicket          = "ticket"
+	WsTicketAudience  = "ws"
+	WsTicketDuration  = 30 * time.Second
+)
+
+const (
+	WsEventJoin        = "join"
+	WsEventLeave       = "leave"
+	WsEventTypingStart = "typing_start"
+	WsEventTypingStop  = "typing_stop"
+	WsEventDelivered   = "delivered"
+	WsEventRead        = "read"
+)
+
+// WsServerSenderId marks messages published by the server rather than relayed
+// from a client, whose ids are either "<user id>-<role>" or a uuid.
+const WsServerSenderId = "server"
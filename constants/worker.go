@@ -0,0 +1,20 @@
+package constants
+
+import "time"
+
+const (
+	OrderExpiryInterval        = 1 * time.Minute
+	OrderExpiryBatchSize       = 50
+	OrderExpiredReason         = "payment deadline passed"
+	OrderAutoCompleteInterval  = 1 * time.Hour
+	OrderAutoCompleteBatchSize = 50
+	OrderAutoCompleteDays      = 7
+	OrderAutoCompleteReason    = "automatically confirmed"
+	ConsultationQueueInterval  = 15 * time.Second
+	ConsultationQueueBatchSize = 50
+	ConsultationCloseInterval  = 1 * time.Minute
+	ConsultationCloseBatchSize = 50
+	AppointmentInterval        = 1 * time.Minute
+	AppointmentBatchSize       = 50
+	WsTicketPruneInterval      = 10 * time.Minute
+)
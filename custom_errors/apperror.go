@@ -32,6 +32,8 @@ var (
 	ErrNonNumberCoordinate = errors.New(constants.NonNumberCoordinateErrMsg)
 	ErrFileNotImage        = errors.New(constants.FileIsNotImageErrMsg)
 	ErrNotEnoughStock      = errors.New(constants.StockIsNotEnoughErrMsg)
+	ErrPaymentNotSupported = errors.New(constants.PaymentNotSupportedErrMsg)
+	ErrCallbackSignature   = errors.New(constants.InvalidCallbackSignErrMsg)
 )
 
 type AppError struct {
@@ -227,3 +229,19 @@ func NotEnoughStock() *AppError {
 		err:     ErrNotEnoughStock,
 	}
 }
+
+func PaymentMethodNotSupported() *AppError {
+	return &AppError{
+		Code:    http.StatusBadRequest,
+		Message: constants.PaymentNotSupportedErrMsg,
+		err:     ErrPaymentNotSupported,
+	}
+}
+
+func InvalidCallbackSignature() *AppError {
+	return &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: constants.InvalidCallbackSignErrMsg,
+		err:     ErrCallbackSignature,
+	}
+}
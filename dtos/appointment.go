@@ -0,0 +1,61 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type AppointmentRequest struct {
+	DoctorId         int64     `json:"doctor_id" binding:"required"`
+	StartAt          time.Time `json:"start_at" binding:"required"`
+	DependentId      int64     `json:"dependent_id"`
+	PatientGenderId  int64     `json:"patient_gender_id" binding:"required_without=DependentId"`
+	PatientName      string    `json:"patient_name" binding:"required_without=DependentId"`
+	PatientBirthDate string    `json:"patient_birth_date" binding:"required_without=DependentId,omitempty,datetime=2006-01-02"`
+}
+
+type AppointmentResponse struct {
+	Id                 int64     `json:"id"`
+	ConsultationId     int64     `json:"consultation_id"`
+	ConsultationStatus string    `json:"consultation_status"`
+	DoctorId           int64     `json:"doctor_id"`
+	DoctorName         string    `json:"doctor_name"`
+	UserId             int64     `json:"user_id"`
+	UserName           string    `json:"user_name"`
+	StartAt            time.Time `json:"start_at"`
+	EndAt              time.Time `json:"end_at"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type GetAllAppointmentsResponse struct {
+	PaginationInfo PaginationResponse    `json:"pagination_info"`
+	Data           []AppointmentResponse `json:"appointments"`
+}
+
+func ConvertToAppointmentResponse(appointment entities.Appointment) AppointmentResponse {
+	return AppointmentResponse{
+		Id:                 appointment.Id,
+		ConsultationId:     appointment.ConsultationId,
+		ConsultationStatus: appointment.ConsultationStatus,
+		DoctorId:           appointment.DoctorId,
+		DoctorName:         appointment.DoctorName,
+		UserId:             appointment.UserId,
+		UserName:           appointment.UserName,
+		StartAt:            appointment.StartAt,
+		EndAt:              appointment.EndAt,
+		Status:             appointment.Status,
+		CreatedAt:          appointment.CreatedAt,
+	}
+}
+
+func ConvertToAppointmentResponses(appointments []entities.Appointment) []AppointmentResponse {
+	result := []AppointmentResponse{}
+
+	for _, appointment := range appointments {
+		result = append(result, ConvertToAppointmentResponse(appointment))
+	}
+
+	return result
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type CreateCartItemRequest struct {
-	Quantity          int   `json:"quantity" binding:"required"`
-	PharmacyProductId int64 `json:"pharmacy_product_id" binding:"required"`
+	Quantity           int    `json:"quantity" binding:"required"`
+	PharmacyProductId  int64  `json:"pharmacy_product_id" binding:"required"`
+	PrescriptionItemId *int64 `json:"prescription_item_id"`
 }
 
 type UpdateCartItemRequest struct {
@@ -17,21 +17,35 @@ type ChatFileRequest struct {
 }
 
 type ChatResponse struct {
-	Id         int64     `json:"id"`
-	IsFromUser bool      `json:"is_from_user"`
-	Content    string    `json:"content"`
-	Type       string    `json:"type"`
-	CreatedAt  time.Time `json:"created_at"`
+	Id          int64      `json:"id"`
+	IsFromUser  bool       `json:"is_from_user"`
+	Content     string     `json:"content"`
+	Type        string     `json:"type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+	ReadAt      *time.Time `json:"read_at"`
 }
 
 func ConvertToChatResponse(chat entities.Chat) ChatResponse {
-	return ChatResponse{
-		Id:         chat.Id,
-		IsFromUser: chat.IsFromUser,
-		Content:    chat.Content,
-		Type:       chat.Type,
-		CreatedAt:  chat.CreatedAt,
+	chatResponse := ChatResponse{
+		Id:          chat.Id,
+		IsFromUser:  chat.IsFromUser,
+		Content:     chat.Content,
+		Type:        chat.Type,
+		CreatedAt:   chat.CreatedAt,
+		DeliveredAt: nil,
+		ReadAt:      nil,
 	}
+
+	if chat.DeliveredAt.Valid {
+		chatResponse.DeliveredAt = &chat.DeliveredAt.Time
+	}
+
+	if chat.ReadAt.Valid {
+		chatResponse.ReadAt = &chat.ReadAt.Time
+	}
+
+	return chatResponse
 }
 
 func ConvertToChatResponses(chats []entities.Chat) []ChatResponse {
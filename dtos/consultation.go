@@ -1,9 +1,12 @@
 package dtos
 
 import (
+	"database/sql"
+	"math"
 	"time"
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
 )
 
 type CertificateRequest struct {
@@ -15,23 +18,33 @@ type CertificateRequest struct {
 
 type ConsultationRequest struct {
 	DoctorId         int64  `json:"doctor_id" binding:"required"`
-	PatientGenderId  int64  `json:"patient_gender_id" binding:"required"`
-	PatientName      string `json:"patient_name" binding:"required"`
-	PatientBirthDate string `json:"patient_birth_date" binding:"required,datetime=2006-01-02"`
+	DependentId      int64  `json:"dependent_id"`
+	PatientGenderId  int64  `json:"patient_gender_id" binding:"required_without=DependentId"`
+	PatientName      string `json:"patient_name" binding:"required_without=DependentId"`
+	PatientBirthDate string `json:"patient_birth_date" binding:"required_without=DependentId,omitempty,datetime=2006-01-02"`
 }
 
 type ConsultationResponse struct {
-	Id               int64          `json:"id"`
-	Doctor           DoctorResponse `json:"doctor"`
-	User             UserResponse   `json:"user"`
-	PatientGender    GenderResponse `json:"patient_gender"`
-	PatientName      string         `json:"patient_name"`
-	PatientBirthDate string         `json:"patient_birth_date"`
-	CertificateUrl   *string        `json:"certificate_url"`
-	PrescriptionUrl  *string        `json:"prescription_url"`
-	EndedAt          *time.Time     `json:"ended_at"`
-	CreatedAt        time.Time      `json:"created_at"`
-	Chats            []ChatResponse `json:"chats,omitempty"`
+	Id               int64           `json:"id"`
+	Doctor           DoctorResponse  `json:"doctor"`
+	User             UserResponse    `json:"user"`
+	PatientGender    GenderResponse  `json:"patient_gender"`
+	PatientName      string          `json:"patient_name"`
+	PatientBirthDate string          `json:"patient_birth_date"`
+	CertificateUrl   *string         `json:"certificate_url"`
+	PrescriptionUrl  *string         `json:"prescription_url"`
+	EndedAt          *time.Time      `json:"ended_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	Chats            []ChatResponse  `json:"chats,omitempty"`
+	UnreadCount      int             `json:"unread_count"`
+	Status           string          `json:"status"`
+	QueuedAt         time.Time       `json:"queued_at"`
+	AcceptedAt       *time.Time      `json:"accepted_at"`
+	Fee              decimal.Decimal `json:"fee"`
+	PaymentDeadline  *time.Time      `json:"payment_deadline"`
+	PaidAt           *time.Time      `json:"paid_at"`
+	ScheduledAt      *time.Time      `json:"scheduled_at"`
+	DependentId      *int64          `json:"dependent_id"`
 }
 
 type ConsultationResponses struct {
@@ -52,6 +65,15 @@ func ConvertToConsultationResponse(consultation entities.Consultation) Consultat
 		EndedAt:          nil,
 		CreatedAt:        consultation.CreatedAt,
 		Chats:            ConvertToChatResponses(consultation.Chats),
+		UnreadCount:      consultation.UnreadCount,
+		Status:           consultation.Status,
+		QueuedAt:         consultation.QueuedAt,
+		AcceptedAt:       nil,
+		Fee:              consultation.Fee,
+		PaymentDeadline:  nil,
+		PaidAt:           nil,
+		ScheduledAt:      nil,
+		DependentId:      nil,
 	}
 
 	if consultation.CertificateUrl.Valid {
@@ -66,6 +88,26 @@ func ConvertToConsultationResponse(consultation entities.Consultation) Consultat
 		consultationResponse.EndedAt = &consultation.EndedAt.Time
 	}
 
+	if consultation.AcceptedAt.Valid {
+		consultationResponse.AcceptedAt = &consultation.AcceptedAt.Time
+	}
+
+	if consultation.PaymentDeadline.Valid {
+		consultationResponse.PaymentDeadline = &consultation.PaymentDeadline.Time
+	}
+
+	if consultation.PaidAt.Valid {
+		consultationResponse.PaidAt = &consultation.PaidAt.Time
+	}
+
+	if consultation.ScheduledAt.Valid {
+		consultationResponse.ScheduledAt = &consultation.ScheduledAt.Time
+	}
+
+	if consultation.DependentId.Valid {
+		consultationResponse.DependentId = &consultation.DependentId.Int64
+	}
+
 	return consultationResponse
 }
 
@@ -86,12 +128,141 @@ type CertificateUrlResponse struct {
 	CertificateUrl string `json:"certificate_url"`
 }
 
+type PrescriptionItemRequest struct {
+	ProductId int64   `json:"product_id" binding:"required"`
+	Quantity  int     `json:"quantity" binding:"required,min=1"`
+	Dosage    string  `json:"dosage" binding:"required"`
+	Frequency string  `json:"frequency" binding:"required"`
+	Duration  string  `json:"duration" binding:"required"`
+	Route     string  `json:"route" binding:"required"`
+	Notes     *string `json:"notes"`
+}
+
 type PrescriptionRequest struct {
-	Products   []int64 `json:"products"`
-	Quantities []int   `json:"quantities"`
-	PatientAge int     `json:"patient_age" binding:"required"`
+	Items      []PrescriptionItemRequest `json:"items" binding:"required,min=1,dive"`
+	ExpiresAt  string                    `json:"expires_at" binding:"required,datetime=2006-01-02"`
+	Refills    int                       `json:"refills" binding:"min=0"`
+	PatientAge int                       `json:"patient_age" binding:"required"`
 }
 
 type PrescriptionUrlResponse struct {
 	PrescriptionUrl string `json:"prescription_url"`
 }
+
+type PrescriptionItemResponse struct {
+	Id          int64   `json:"id"`
+	ProductId   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	SellingUnit string  `json:"selling_unit"`
+	Quantity    int     `json:"quantity"`
+	Dosage      string  `json:"dosage"`
+	Frequency   string  `json:"frequency"`
+	Duration    string  `json:"duration"`
+	Route       string  `json:"route"`
+	Notes       *string `json:"notes"`
+}
+
+type PrescriptionResponse struct {
+	Id             int64                      `json:"id"`
+	ConsultationId int64                      `json:"consultation_id"`
+	Items          []PrescriptionItemResponse `json:"items"`
+	ExpiresAt      string                     `json:"expires_at"`
+	Refills        int                        `json:"refills"`
+	TimesFilled    int                        `json:"times_filled"`
+	RemainingFills int                        `json:"remaining_fills"`
+	CreatedAt      time.Time                  `json:"created_at"`
+}
+
+func ConvertToPrescriptionItems(items []PrescriptionItemRequest) []entities.PrescriptionItem {
+	prescriptionItems := []entities.PrescriptionItem{}
+
+	for _, i := range items {
+		item := entities.PrescriptionItem{
+			Product:   entities.Product{Id: i.ProductId},
+			Quantity:  i.Quantity,
+			Dosage:    i.Dosage,
+			Frequency: i.Frequency,
+			Duration:  i.Duration,
+			Route:     i.Route,
+		}
+
+		if i.Notes != nil {
+			item.Notes = sql.NullString{String: *i.Notes, Valid: true}
+		}
+
+		prescriptionItems = append(prescriptionItems, item)
+	}
+
+	return prescriptionItems
+}
+
+func ConvertToPrescriptionItemResponses(prescriptionItems []entities.PrescriptionItem) []PrescriptionItemResponse {
+	items := []PrescriptionItemResponse{}
+
+	for _, i := range prescriptionItems {
+		item := PrescriptionItemResponse{
+			Id:          i.Id,
+			ProductId:   i.Product.Id,
+			ProductName: i.Product.Name,
+			SellingUnit: i.Product.SellingUnit,
+			Quantity:    i.Quantity,
+			Dosage:      i.Dosage,
+			Frequency:   i.Frequency,
+			Duration:    i.Duration,
+			Route:       i.Route,
+			Notes:       nil,
+		}
+
+		if i.Notes.Valid {
+			item.Notes = &i.Notes.String
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func ConvertToPrescriptionResponse(prescription entities.Prescription) PrescriptionResponse {
+	// the first fill is not a refill, so a prescription without refills can
+	// still be redeemed once
+	remaining := prescription.Refills + 1 - prescription.FillCount
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return PrescriptionResponse{
+		Id:             prescription.Id,
+		ConsultationId: prescription.ConsultationId,
+		Items:          ConvertToPrescriptionItemResponses(prescription.Items),
+		ExpiresAt:      prescription.ExpiresAt.Format("2006-01-02"),
+		Refills:        prescription.Refills,
+		TimesFilled:    prescription.FillCount,
+		RemainingFills: remaining,
+		CreatedAt:      prescription.CreatedAt,
+	}
+}
+
+type ConsultationQueueItemResponse struct {
+	Consultation         ConsultationResponse `json:"consultation"`
+	Position             int                  `json:"position"`
+	EstimatedWaitMinutes int                  `json:"estimated_wait_minutes"`
+}
+
+func ConvertToConsultationQueueItemResponse(item entities.ConsultationQueueItem) ConsultationQueueItemResponse {
+	return ConsultationQueueItemResponse{
+		Consultation:         ConvertToConsultationResponse(item.Consultation),
+		Position:             item.Position,
+		EstimatedWaitMinutes: int(math.Ceil(item.EstimatedWait.Minutes())),
+	}
+}
+
+func ConvertToConsultationQueueItemResponses(items []entities.ConsultationQueueItem) []ConsultationQueueItemResponse {
+	itemResponses := []ConsultationQueueItemResponse{}
+
+	for _, item := range items {
+		itemResponses = append(itemResponses, ConvertToConsultationQueueItemResponse(item))
+	}
+
+	return itemResponses
+}
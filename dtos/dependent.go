@@ -0,0 +1,57 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DependentRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Relationship string `json:"relationship" binding:"required,oneof=child parent spouse sibling other"`
+	BirthDate    string `json:"birth_date" binding:"required,datetime=2006-01-02"`
+	GenderId     int64  `json:"gender_id" binding:"required"`
+}
+
+type DependentResponse struct {
+	Id           int64          `json:"id"`
+	Name         string         `json:"name"`
+	Relationship string         `json:"relationship"`
+	BirthDate    string         `json:"birth_date"`
+	Gender       GenderResponse `json:"gender"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+func ConvertToDependent(userId int64, req DependentRequest) entities.Dependent {
+	birthDate, _ := time.ParseInLocation(constants.ScheduleDateLayout, req.BirthDate, time.Local)
+
+	return entities.Dependent{
+		UserId:       userId,
+		Name:         req.Name,
+		Relationship: req.Relationship,
+		BirthDate:    birthDate,
+		Gender:       entities.Gender{Id: req.GenderId},
+	}
+}
+
+func ConvertToDependentResponse(dependent entities.Dependent) DependentResponse {
+	return DependentResponse{
+		Id:           dependent.Id,
+		Name:         dependent.Name,
+		Relationship: dependent.Relationship,
+		BirthDate:    dependent.BirthDate.Format(constants.ScheduleDateLayout),
+		Gender:       *ConvertToGenderResponse(&dependent.Gender),
+		CreatedAt:    dependent.CreatedAt,
+	}
+}
+
+func ConvertToDependentResponses(dependents []entities.Dependent) []DependentResponse {
+	result := []DependentResponse{}
+
+	for _, dependent := range dependents {
+		result = append(result, ConvertToDependentResponse(dependent))
+	}
+
+	return result
+}
@@ -15,6 +15,10 @@ type DoctorUpdateRequest struct {
 	ProfilePicture *multipart.FileHeader `form:"profile_picture"`
 }
 
+type DoctorPresenceResponse struct {
+	IsOnline bool `json:"is_online"`
+}
+
 type DoctorResponse struct {
 	Id             int64               `json:"id"`
 	Name           string              `json:"name"`
@@ -27,6 +31,8 @@ type DoctorResponse struct {
 	WorkStartYear  *int                `json:"work_start_year"`
 	Specialist     *SpecialistResponse `json:"specialist"`
 	ProfilePicture *string             `json:"profile_picture"`
+	Rating         float64             `json:"rating"`
+	ReviewCount    int                 `json:"review_count"`
 }
 
 type DoctorResponses struct {
@@ -47,6 +53,8 @@ func ConvertToDoctorResponse(doctor entities.Doctor) DoctorResponse {
 		WorkStartYear:  nil,
 		Specialist:     nil,
 		ProfilePicture: nil,
+		Rating:         doctor.Rating,
+		ReviewCount:    doctor.ReviewCount,
 	}
 
 	if doctor.Specialist.Id.Valid {
@@ -0,0 +1,72 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DoctorReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+type HideDoctorReviewRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type DoctorReviewResponse struct {
+	Id             int64      `json:"id"`
+	ConsultationId int64      `json:"consultation_id"`
+	DoctorId       int64      `json:"doctor_id"`
+	UserId         int64      `json:"user_id"`
+	UserName       string     `json:"user_name"`
+	Rating         int        `json:"rating"`
+	Comment        *string    `json:"comment"`
+	IsHidden       bool       `json:"is_hidden"`
+	HiddenReason   *string    `json:"hidden_reason,omitempty"`
+	HiddenAt       *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type GetAllDoctorReviewsResponse struct {
+	PaginationInfo PaginationResponse     `json:"pagination_info"`
+	Data           []DoctorReviewResponse `json:"reviews"`
+}
+
+func ConvertToDoctorReviewResponse(review entities.DoctorReview) DoctorReviewResponse {
+	res := DoctorReviewResponse{
+		Id:             review.Id,
+		ConsultationId: review.ConsultationId,
+		DoctorId:       review.DoctorId,
+		UserId:         review.UserId,
+		UserName:       review.UserName,
+		Rating:         review.Rating,
+		IsHidden:       review.IsHidden,
+		CreatedAt:      review.CreatedAt,
+	}
+
+	if review.Comment.Valid {
+		res.Comment = &review.Comment.String
+	}
+
+	if review.HiddenReason.Valid {
+		res.HiddenReason = &review.HiddenReason.String
+	}
+
+	if review.HiddenAt.Valid {
+		res.HiddenAt = &review.HiddenAt.Time
+	}
+
+	return res
+}
+
+func ConvertToDoctorReviewResponses(reviews []entities.DoctorReview) []DoctorReviewResponse {
+	result := []DoctorReviewResponse{}
+
+	for _, review := range reviews {
+		result = append(result, ConvertToDoctorReviewResponse(review))
+	}
+
+	return result
+}
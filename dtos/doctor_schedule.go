@@ -0,0 +1,142 @@
+package dtos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DoctorScheduleRequest struct {
+	DayOfWeek *int   `json:"day_of_week" binding:"required,min=0,max=6"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+type UpdateDoctorSchedulesRequest struct {
+	Schedules []DoctorScheduleRequest `json:"schedules" binding:"dive"`
+}
+
+type DoctorScheduleExceptionRequest struct {
+	Date      string `json:"date" binding:"required,datetime=2006-01-02"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Reason    string `json:"reason"`
+}
+
+type DoctorScheduleResponse struct {
+	Id        int64  `json:"id"`
+	DayOfWeek int    `json:"day_of_week"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+type DoctorScheduleExceptionResponse struct {
+	Id        int64     `json:"id"`
+	Date      string    `json:"date"`
+	StartTime *string   `json:"start_time"`
+	EndTime   *string   `json:"end_time"`
+	Reason    *string   `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AppointmentSlotResponse struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+func ConvertToDoctorSchedules(doctorId int64, req UpdateDoctorSchedulesRequest) []entities.DoctorSchedule {
+	schedules := []entities.DoctorSchedule{}
+
+	for _, s := range req.Schedules {
+		schedules = append(schedules, entities.DoctorSchedule{
+			DoctorId:  doctorId,
+			DayOfWeek: *s.DayOfWeek,
+			StartTime: s.StartTime,
+			EndTime:   s.EndTime,
+		})
+	}
+
+	return schedules
+}
+
+func ConvertToDoctorScheduleException(doctorId int64, req DoctorScheduleExceptionRequest) entities.DoctorScheduleException {
+	exception := entities.DoctorScheduleException{
+		DoctorId: doctorId,
+		Date:     req.Date,
+	}
+
+	if req.StartTime != "" {
+		exception.StartTime = sql.NullString{String: req.StartTime, Valid: true}
+	}
+
+	if req.EndTime != "" {
+		exception.EndTime = sql.NullString{String: req.EndTime, Valid: true}
+	}
+
+	if req.Reason != "" {
+		exception.Reason = sql.NullString{String: req.Reason, Valid: true}
+	}
+
+	return exception
+}
+
+func ConvertToDoctorScheduleResponses(schedules []entities.DoctorSchedule) []DoctorScheduleResponse {
+	result := []DoctorScheduleResponse{}
+
+	for _, s := range schedules {
+		result = append(result, DoctorScheduleResponse{
+			Id:        s.Id,
+			DayOfWeek: s.DayOfWeek,
+			StartTime: s.StartTime,
+			EndTime:   s.EndTime,
+		})
+	}
+
+	return result
+}
+
+func ConvertToDoctorScheduleExceptionResponse(exception entities.DoctorScheduleException) DoctorScheduleExceptionResponse {
+	res := DoctorScheduleExceptionResponse{
+		Id:        exception.Id,
+		Date:      exception.Date,
+		CreatedAt: exception.CreatedAt,
+	}
+
+	if exception.StartTime.Valid {
+		res.StartTime = &exception.StartTime.String
+	}
+
+	if exception.EndTime.Valid {
+		res.EndTime = &exception.EndTime.String
+	}
+
+	if exception.Reason.Valid {
+		res.Reason = &exception.Reason.String
+	}
+
+	return res
+}
+
+func ConvertToDoctorScheduleExceptionResponses(exceptions []entities.DoctorScheduleException) []DoctorScheduleExceptionResponse {
+	result := []DoctorScheduleExceptionResponse{}
+
+	for _, exception := range exceptions {
+		result = append(result, ConvertToDoctorScheduleExceptionResponse(exception))
+	}
+
+	return result
+}
+
+func ConvertToAppointmentSlotResponses(slots []entities.AppointmentSlot) []AppointmentSlotResponse {
+	result := []AppointmentSlotResponse{}
+
+	for _, slot := range slots {
+		result = append(result, AppointmentSlotResponse{
+			StartAt: slot.StartAt,
+			EndAt:   slot.EndAt,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,56 @@
+package dtos
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DocumentResponse struct {
+	Number           string    `json:"number"`
+	Type             string    `json:"type"`
+	Status           string    `json:"status"`
+	DoctorName       string    `json:"doctor_name"`
+	DoctorSpecialist string    `json:"doctor_specialist"`
+	PatientInitials  string    `json:"patient_initials"`
+	ValidFrom        string    `json:"valid_from"`
+	ValidUntil       string    `json:"valid_until"`
+	IssuedAt         time.Time `json:"issued_at"`
+}
+
+// ConvertToDocumentResponse only shows the patient's initials, since anyone
+// holding the document can look it up.
+func ConvertToDocumentResponse(document entities.Document) DocumentResponse {
+	validUntil := document.ValidUntil.Format(constants.ScheduleDateLayout)
+
+	status := constants.DocumentStatusValid
+	if document.RevokedAt.Valid {
+		status = constants.DocumentStatusRevoked
+	} else if validUntil < time.Now().Format(constants.ScheduleDateLayout) {
+		status = constants.DocumentStatusExpired
+	}
+
+	initials := ""
+	for _, word := range strings.Fields(document.PatientName) {
+		initials += strings.ToUpper(word[:1])
+	}
+
+	res := DocumentResponse{
+		Number:          document.Number,
+		Type:            document.Type,
+		Status:          status,
+		DoctorName:      document.Doctor.Name,
+		PatientInitials: initials,
+		ValidFrom:       document.ValidFrom.Format(constants.ScheduleDateLayout),
+		ValidUntil:      validUntil,
+		IssuedAt:        document.IssuedAt,
+	}
+
+	if document.Doctor.Specialist != nil {
+		res.DoctorSpecialist = document.Doctor.Specialist.Name.String
+	}
+
+	return res
+}
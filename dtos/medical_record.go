@@ -0,0 +1,122 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type MedicalConditionRequest struct {
+	Type  string `json:"type" binding:"required,oneof=allergy chronic"`
+	Name  string `json:"name" binding:"required"`
+	Notes string `json:"notes"`
+}
+
+type MedicalConditionResponse struct {
+	Id        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	Notes     *string   `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MedicalRecordEntryResponse struct {
+	ConsultationId    int64                      `json:"consultation_id"`
+	DoctorName        string                     `json:"doctor_name"`
+	DoctorSpecialist  string                     `json:"doctor_specialist"`
+	PatientName       string                     `json:"patient_name"`
+	Diagnosis         *string                    `json:"diagnosis"`
+	PrescriptionItems []PrescriptionItemResponse `json:"prescription_items"`
+	ConsultedAt       time.Time                  `json:"consulted_at"`
+}
+
+type MedicalRecordResponse struct {
+	UserId        int64                        `json:"user_id"`
+	UserName      string                       `json:"user_name"`
+	Dependent     *DependentResponse           `json:"dependent"`
+	Allergies     []MedicalConditionResponse   `json:"allergies"`
+	Chronic       []MedicalConditionResponse   `json:"chronic_conditions"`
+	Consultations []MedicalRecordEntryResponse `json:"consultations"`
+}
+
+type MedicalRecordUrlResponse struct {
+	MedicalRecordUrl string `json:"medical_record_url"`
+}
+
+func ConvertToMedicalCondition(userId int64, dependentId int64, req MedicalConditionRequest) entities.MedicalCondition {
+	condition := entities.MedicalCondition{
+		UserId: userId,
+		Type:   req.Type,
+		Name:   req.Name,
+	}
+
+	if dependentId != 0 {
+		condition.DependentId.Int64 = dependentId
+		condition.DependentId.Valid = true
+	}
+
+	if req.Notes != "" {
+		condition.Notes.String = req.Notes
+		condition.Notes.Valid = true
+	}
+
+	return condition
+}
+
+func ConvertToMedicalConditionResponse(condition entities.MedicalCondition) MedicalConditionResponse {
+	res := MedicalConditionResponse{
+		Id:        condition.Id,
+		Type:      condition.Type,
+		Name:      condition.Name,
+		CreatedAt: condition.CreatedAt,
+	}
+
+	if condition.Notes.Valid {
+		res.Notes = &condition.Notes.String
+	}
+
+	return res
+}
+
+func ConvertToMedicalRecordResponse(record entities.MedicalRecord) MedicalRecordResponse {
+	res := MedicalRecordResponse{
+		UserId:        record.User.Id,
+		UserName:      record.User.Name,
+		Allergies:     []MedicalConditionResponse{},
+		Chronic:       []MedicalConditionResponse{},
+		Consultations: []MedicalRecordEntryResponse{},
+	}
+
+	if record.Dependent != nil {
+		dependent := ConvertToDependentResponse(*record.Dependent)
+		res.Dependent = &dependent
+	}
+
+	for _, condition := range record.Conditions {
+		if condition.Type == constants.MedicalConditionAllergy {
+			res.Allergies = append(res.Allergies, ConvertToMedicalConditionResponse(condition))
+		} else {
+			res.Chronic = append(res.Chronic, ConvertToMedicalConditionResponse(condition))
+		}
+	}
+
+	for _, entry := range record.Entries {
+		e := MedicalRecordEntryResponse{
+			ConsultationId:    entry.ConsultationId,
+			DoctorName:        entry.DoctorName,
+			DoctorSpecialist:  entry.DoctorSpecialist,
+			PatientName:       entry.PatientName,
+			PrescriptionItems: ConvertToPrescriptionItemResponses(entry.PrescriptionItems),
+			ConsultedAt:       entry.ConsultedAt,
+		}
+
+		if entry.Diagnosis.Valid {
+			e.Diagnosis = &entry.Diagnosis.String
+		}
+
+		res.Consultations = append(res.Consultations, e)
+	}
+
+	return res
+}
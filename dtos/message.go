@@ -3,4 +3,5 @@ package dtos
 type SentMessage struct {
 	Content string `json:"content"`
 	Type    string `json:"type"`
+	ChatId  int64  `json:"chat_id"`
 }
@@ -9,17 +9,32 @@ import (
 )
 
 type OrderRequest struct {
-	CartItemId     []int64         `json:"cart_item_id"`
-	UserAddressId  int64           `json:"user_address_id"`
-	TotalPrice     decimal.Decimal `json:"total_price"`
-	ShippingFee    decimal.Decimal `json:"shipping_fee"`
-	ShippingMethod string          `json:"shipping_method"`
+	CartItemId                  []int64                `json:"cart_item_id" binding:"required,min=1"`
+	UserAddressId               int64                  `json:"user_address_id" binding:"required"`
+	TotalPrice                  decimal.Decimal        `json:"total_price"`
+	ShippingFee                 decimal.Decimal        `json:"shipping_fee"`
+	ShippingMethod              string                 `json:"shipping_method"`
+	OfficialShippingMethodId    int64                  `json:"official_shipping_method_id"`
+	NonOfficialShippingMethodId int64                  `json:"non_official_shipping_method_id"`
+	Shippings                   []OrderShippingRequest `json:"shippings" binding:"dive"`
+	VoucherCode                 string                 `json:"voucher_code"`
+	Discount                    decimal.Decimal        `json:"discount"`
+	UserId                      int64                  `json:"-"`
+}
+
+type OrderShippingRequest struct {
+	PharmacyId                  int64           `json:"pharmacy_id" binding:"required"`
+	ShippingFee                 decimal.Decimal `json:"shipping_fee"`
+	ShippingMethod              string          `json:"shipping_method"`
+	OfficialShippingMethodId    int64           `json:"official_shipping_method_id"`
+	NonOfficialShippingMethodId int64           `json:"non_official_shipping_method_id"`
 }
 
 type OrderItem struct {
 	Name              string          `json:"name"`
 	SellingUnit       string          `json:"selling_unit"`
 	Price             decimal.Decimal `json:"price"`
+	Discount          decimal.Decimal `json:"discount"`
 	Quantity          int             `json:"quantity"`
 	ProductPicture    string          `json:"product_picture"`
 	PharmacyProductId int64           `json:"pharmacy_product_id"`
@@ -34,6 +49,7 @@ type OrderResponse struct {
 	PaymentProof    *string                 `json:"payment_proof"`
 	PaymentDeadline time.Time               `json:"payment_deadline"`
 	ShippingFee     decimal.Decimal         `json:"shipping_fee"`
+	Discount        decimal.Decimal         `json:"discount"`
 	ShippingMethod  string                  `json:"shipping_method"`
 	OrderStatus     string                  `json:"order_status"`
 	PharmacyName    string                  `json:"pharmacy_name"`
@@ -58,7 +74,30 @@ type UploadPaymentProofResponse struct {
 }
 
 type CreateOrderResponse struct {
-	PaymentDeadline time.Time `json:"payment_deadline"`
+	CheckoutId      int64           `json:"checkout_id"`
+	CheckoutNumber  string          `json:"checkout_number"`
+	OrderIds        []int64         `json:"order_ids"`
+	TotalPrice      decimal.Decimal `json:"total_price"`
+	ShippingFee     decimal.Decimal `json:"shipping_fee"`
+	Discount        decimal.Decimal `json:"discount"`
+	PaymentDeadline time.Time       `json:"payment_deadline"`
+}
+
+func ConvertToCreateOrderResponse(checkout entities.Checkout) CreateOrderResponse {
+	orderIds := []int64{}
+	for _, order := range checkout.Orders {
+		orderIds = append(orderIds, order.Id)
+	}
+
+	return CreateOrderResponse{
+		CheckoutId:      checkout.Id,
+		CheckoutNumber:  checkout.CheckoutNumber,
+		OrderIds:        orderIds,
+		TotalPrice:      checkout.TotalPrice,
+		ShippingFee:     checkout.ShippingFee,
+		Discount:        checkout.Discount,
+		PaymentDeadline: checkout.PaymentDeadline,
+	}
 }
 
 func ConvertToOrderResponse(req entities.Order) *OrderResponse {
@@ -73,6 +112,7 @@ func ConvertToOrderResponse(req entities.Order) *OrderResponse {
 		PaymentProof:    req.PaymentProof,
 		PaymentDeadline: req.PaymentDeadline,
 		ShippingFee:     req.ShippingFee,
+		Discount:        req.Discount,
 		ShippingMethod:  req.ShippingMethod,
 		OrderStatus:     req.OrderStatus,
 		PharmacyName:    req.PharmacyName,
@@ -91,3 +131,40 @@ func ConvertToOrderResponses(req []entities.Order) []OrderResponse {
 	}
 	return result
 }
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type OrderStatusHistoryResponse struct {
+	Id         int64     `json:"id"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	ActorId    *int64    `json:"actor_id"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ConvertToOrderStatusHistoryResponse(h entities.OrderStatusHistory) OrderStatusHistoryResponse {
+	res := OrderStatusHistoryResponse{
+		Id:        h.Id,
+		ToStatus:  h.ToStatus,
+		ActorRole: h.ActorRole,
+		CreatedAt: h.CreatedAt,
+	}
+
+	if h.FromStatus.Valid {
+		res.FromStatus = &h.FromStatus.String
+	}
+
+	if h.ActorId.Valid {
+		res.ActorId = &h.ActorId.Int64
+	}
+
+	if h.Reason.Valid {
+		res.Reason = &h.Reason.String
+	}
+
+	return res
+}
@@ -0,0 +1,65 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
+)
+
+type PaymentRequest struct {
+	Method  string `json:"method" binding:"required,oneof=manual_transfer virtual_account e_wallet"`
+	Channel string `json:"channel" binding:"required"`
+}
+
+type PaymentResponse struct {
+	Id             int64           `json:"id"`
+	CheckoutId     *int64          `json:"checkout_id"`
+	ConsultationId *int64          `json:"consultation_id"`
+	Provider       string          `json:"provider"`
+	Method         string          `json:"method"`
+	Channel        string          `json:"channel"`
+	AccountNumber  *string         `json:"account_number"`
+	CheckoutUrl    *string         `json:"checkout_url"`
+	Amount         decimal.Decimal `json:"amount"`
+	Status         string          `json:"status"`
+	ExpiredAt      time.Time       `json:"expired_at"`
+	PaidAt         *time.Time      `json:"paid_at"`
+}
+
+func ConvertToPaymentResponse(payment entities.Payment) PaymentResponse {
+	paymentResponse := PaymentResponse{
+		Id:            payment.Id,
+		Provider:      payment.Provider,
+		Method:        payment.Method,
+		Channel:       payment.Channel,
+		AccountNumber: nil,
+		CheckoutUrl:   nil,
+		Amount:        payment.Amount,
+		Status:        payment.Status,
+		ExpiredAt:     payment.ExpiredAt,
+		PaidAt:        nil,
+	}
+
+	if payment.CheckoutId.Valid {
+		paymentResponse.CheckoutId = &payment.CheckoutId.Int64
+	}
+
+	if payment.ConsultationId.Valid {
+		paymentResponse.ConsultationId = &payment.ConsultationId.Int64
+	}
+
+	if payment.AccountNumber.Valid {
+		paymentResponse.AccountNumber = &payment.AccountNumber.String
+	}
+
+	if payment.CheckoutUrl.Valid {
+		paymentResponse.CheckoutUrl = &payment.CheckoutUrl.String
+	}
+
+	if payment.PaidAt.Valid {
+		paymentResponse.PaidAt = &payment.PaidAt.Time
+	}
+
+	return paymentResponse
+}
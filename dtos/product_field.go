@@ -7,6 +7,16 @@ type ProductFieldResponse struct {
 	Name string `json:"name"`
 }
 
+type UpdateClassificationRequest struct {
+	RequiresPrescription *bool `json:"requires_prescription" binding:"required"`
+}
+
+type ProductClassificationResponse struct {
+	Id                   int64  `json:"id"`
+	Name                 string `json:"name"`
+	RequiresPrescription bool   `json:"requires_prescription"`
+}
+
 func ConvertToProductFieldResponse(form *entities.ProductForm, classification *entities.ProductClassification, manufacture *entities.Manufacture) *ProductFieldResponse {
 	if form != nil {
 		return &ProductFieldResponse{Id: form.Id, Name: form.Name}
@@ -45,3 +55,21 @@ func ConvertToProductFieldResponses(forms []entities.ProductForm, classification
 	}
 	return nil
 }
+
+func ConvertToProductClassificationResponse(classification entities.ProductClassification) ProductClassificationResponse {
+	return ProductClassificationResponse{
+		Id:                   classification.Id,
+		Name:                 classification.Name,
+		RequiresPrescription: classification.RequiresPrescription,
+	}
+}
+
+func ConvertToProductClassificationResponses(classifications []entities.ProductClassification) []ProductClassificationResponse {
+	responses := []ProductClassificationResponse{}
+
+	for _, classification := range classifications {
+		responses = append(responses, ConvertToProductClassificationResponse(classification))
+	}
+
+	return responses
+}
@@ -0,0 +1,105 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
+)
+
+type ReturnItemRequest struct {
+	PharmacyProductId int64 `json:"pharmacy_product_id" binding:"required"`
+	Quantity          int   `json:"quantity" binding:"required,min=1"`
+}
+
+type ReturnRequest struct {
+	Reason string              `json:"reason" binding:"required"`
+	Items  []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type RejectRefundRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+type RefundItemResponse struct {
+	PharmacyProductId int64           `json:"pharmacy_product_id"`
+	Quantity          int             `json:"quantity"`
+	Amount            decimal.Decimal `json:"amount"`
+}
+
+type RefundResponse struct {
+	Id             int64                `json:"id"`
+	OrderId        *int64               `json:"order_id"`
+	ConsultationId *int64               `json:"consultation_id"`
+	UserId         int64                `json:"user_id"`
+	Type           string               `json:"type"`
+	Status         string               `json:"status"`
+	Amount         decimal.Decimal      `json:"amount"`
+	Reason         *string              `json:"reason"`
+	AdminNote      *string              `json:"admin_note"`
+	Items          []RefundItemResponse `json:"items"`
+	ProcessedAt    *time.Time           `json:"processed_at"`
+	PaidOutAt      *time.Time           `json:"paid_out_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+type GetAllRefundsResponse struct {
+	PaginationInfo PaginationResponse `json:"pagination_info"`
+	Data           []RefundResponse   `json:"refunds"`
+}
+
+func ConvertToRefundResponse(refund entities.Refund) RefundResponse {
+	res := RefundResponse{
+		Id:        refund.Id,
+		UserId:    refund.UserId,
+		Type:      refund.Type,
+		Status:    refund.Status,
+		Amount:    refund.Amount,
+		Items:     []RefundItemResponse{},
+		CreatedAt: refund.CreatedAt,
+	}
+
+	if refund.OrderId.Valid {
+		res.OrderId = &refund.OrderId.Int64
+	}
+
+	if refund.ConsultationId.Valid {
+		res.ConsultationId = &refund.ConsultationId.Int64
+	}
+
+	if refund.Reason.Valid {
+		res.Reason = &refund.Reason.String
+	}
+
+	if refund.AdminNote.Valid {
+		res.AdminNote = &refund.AdminNote.String
+	}
+
+	if refund.ProcessedAt.Valid {
+		res.ProcessedAt = &refund.ProcessedAt.Time
+	}
+
+	if refund.PaidOutAt.Valid {
+		res.PaidOutAt = &refund.PaidOutAt.Time
+	}
+
+	for _, item := range refund.Items {
+		res.Items = append(res.Items, RefundItemResponse{
+			PharmacyProductId: item.PharmacyProductId,
+			Quantity:          item.Quantity,
+			Amount:            item.Amount,
+		})
+	}
+
+	return res
+}
+
+func ConvertToRefundResponses(refunds []entities.Refund) []RefundResponse {
+	result := []RefundResponse{}
+
+	for _, refund := range refunds {
+		result = append(result, ConvertToRefundResponse(refund))
+	}
+
+	return result
+}
@@ -6,13 +6,15 @@ import (
 )
 
 type SalesReportResponse struct {
-	Products          ProductCategoryResponse `json:"product_detail"`
-	PharmacyId        int64                   `json:"pharmacy_id"`
-	PharmacyName      string                  `json:"pharmacy_name"`
-	TotalSalesAmount  decimal.Decimal         `json:"total_sales_amount"`
-	TotalQuantitySold int                     `json:"total_quantity_sold"`
-	Month             string                  `json:"month"`
-	Year              int                     `json:"year"`
+	Products            ProductCategoryResponse `json:"product_detail"`
+	PharmacyId          int64                   `json:"pharmacy_id"`
+	PharmacyName        string                  `json:"pharmacy_name"`
+	TotalSalesAmount    decimal.Decimal         `json:"total_sales_amount"`
+	TotalDiscountAmount decimal.Decimal         `json:"total_discount_amount"`
+	NetSalesAmount      decimal.Decimal         `json:"net_sales_amount"`
+	TotalQuantitySold   int                     `json:"total_quantity_sold"`
+	Month               string                  `json:"month"`
+	Year                int                     `json:"year"`
 }
 
 type SalesReportResponses struct {
@@ -22,13 +24,15 @@ type SalesReportResponses struct {
 
 func ConvertToSalesResponse(sr *entities.SalesReport) *SalesReportResponse {
 	return &SalesReportResponse{
-		Products:          *ConvertToProductResponse(sr.PharmacyProduct.Product),
-		PharmacyId:        sr.PharmacyProduct.Pharmacy.Id,
-		PharmacyName:      sr.PharmacyProduct.Pharmacy.Name,
-		TotalSalesAmount:  sr.TotalSalesAmount,
-		TotalQuantitySold: sr.TotalQuantitySold,
-		Month:             sr.Month.Month().String(),
-		Year:              sr.Year.Year(),
+		Products:            *ConvertToProductResponse(sr.PharmacyProduct.Product),
+		PharmacyId:          sr.PharmacyProduct.Pharmacy.Id,
+		PharmacyName:        sr.PharmacyProduct.Pharmacy.Name,
+		TotalSalesAmount:    sr.TotalSalesAmount,
+		TotalDiscountAmount: sr.TotalDiscountAmount,
+		NetSalesAmount:      sr.TotalSalesAmount.Sub(sr.TotalDiscountAmount),
+		TotalQuantitySold:   sr.TotalQuantitySold,
+		Month:               sr.Month.Month().String(),
+		Year:                sr.Year.Year(),
 	}
 }
 
@@ -0,0 +1,121 @@
+package dtos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
+)
+
+type VoucherRequest struct {
+	Code          string           `json:"code" binding:"required"`
+	Name          string           `json:"name" binding:"required"`
+	DiscountType  string           `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue decimal.Decimal  `json:"discount_value" binding:"required"`
+	MaxDiscount   *decimal.Decimal `json:"max_discount"`
+	MinSpend      decimal.Decimal  `json:"min_spend"`
+	GlobalQuota   *int64           `json:"global_quota" binding:"omitempty,min=1"`
+	PerUserQuota  *int64           `json:"per_user_quota" binding:"omitempty,min=1"`
+	StartsAt      time.Time        `json:"starts_at" binding:"required"`
+	EndsAt        time.Time        `json:"ends_at" binding:"required"`
+	CategoryId    *int64           `json:"category_id"`
+	ProductId     *int64           `json:"product_id"`
+	PharmacyId    *int64           `json:"pharmacy_id"`
+}
+
+type VoucherResponse struct {
+	Id            int64            `json:"id"`
+	Code          string           `json:"code"`
+	Name          string           `json:"name"`
+	DiscountType  string           `json:"discount_type"`
+	DiscountValue decimal.Decimal  `json:"discount_value"`
+	MaxDiscount   *decimal.Decimal `json:"max_discount"`
+	MinSpend      decimal.Decimal  `json:"min_spend"`
+	GlobalQuota   *int64           `json:"global_quota"`
+	PerUserQuota  *int64           `json:"per_user_quota"`
+	StartsAt      time.Time        `json:"starts_at"`
+	EndsAt        time.Time        `json:"ends_at"`
+	CategoryId    *int64           `json:"category_id"`
+	ProductId     *int64           `json:"product_id"`
+	PharmacyId    *int64           `json:"pharmacy_id"`
+}
+
+type VoucherResponses struct {
+	Pagination PaginationResponse `json:"pagination_info"`
+	Vouchers   []VoucherResponse  `json:"vouchers"`
+}
+
+func ConvertToVoucherEntity(req VoucherRequest) entities.Voucher {
+	voucher := entities.Voucher{
+		Code:          req.Code,
+		Name:          req.Name,
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue,
+		MinSpend:      req.MinSpend,
+		GlobalQuota:   toNullInt64(req.GlobalQuota),
+		PerUserQuota:  toNullInt64(req.PerUserQuota),
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		CategoryId:    toNullInt64(req.CategoryId),
+		ProductId:     toNullInt64(req.ProductId),
+		PharmacyId:    toNullInt64(req.PharmacyId),
+	}
+
+	if req.MaxDiscount != nil {
+		voucher.MaxDiscount = decimal.NewNullDecimal(*req.MaxDiscount)
+	}
+
+	return voucher
+}
+
+func ConvertToVoucherResponse(voucher entities.Voucher) *VoucherResponse {
+	res := &VoucherResponse{
+		Id:            voucher.Id,
+		Code:          voucher.Code,
+		Name:          voucher.Name,
+		DiscountType:  voucher.DiscountType,
+		DiscountValue: voucher.DiscountValue,
+		MinSpend:      voucher.MinSpend,
+		GlobalQuota:   fromNullInt64(voucher.GlobalQuota),
+		PerUserQuota:  fromNullInt64(voucher.PerUserQuota),
+		StartsAt:      voucher.StartsAt,
+		EndsAt:        voucher.EndsAt,
+		CategoryId:    fromNullInt64(voucher.CategoryId),
+		ProductId:     fromNullInt64(voucher.ProductId),
+		PharmacyId:    fromNullInt64(voucher.PharmacyId),
+	}
+
+	if voucher.MaxDiscount.Valid {
+		res.MaxDiscount = &voucher.MaxDiscount.Decimal
+	}
+
+	return res
+}
+
+func ConvertToVoucherResponses(vouchers []entities.Voucher, pagination entities.PaginationInfo) *VoucherResponses {
+	voucherResponses := []VoucherResponse{}
+
+	for _, voucher := range vouchers {
+		voucherResponses = append(voucherResponses, *ConvertToVoucherResponse(voucher))
+	}
+
+	return &VoucherResponses{
+		Pagination: *ConvertToPaginationResponse(pagination),
+		Vouchers:   voucherResponses,
+	}
+}
+
+func toNullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
+
+func fromNullInt64(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Appointment struct {
+	Id                 int64
+	ConsultationId     int64
+	ConsultationStatus string
+	DoctorId           int64
+	DoctorName         string
+	DoctorEmail        string
+	UserId             int64
+	UserName           string
+	UserEmail          string
+	DependentId        sql.NullInt64
+	StartAt            time.Time
+	EndAt              time.Time
+	Status             string
+	ReminderSentAt     sql.NullTime
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          sql.NullTime
+	Total              int
+}
+
+type AppointmentParams struct {
+	Limit  int
+	Page   int
+	Status string
+}
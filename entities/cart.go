@@ -8,21 +8,27 @@ import (
 )
 
 type CartItem struct {
-	Id                int64
-	Quantity          int
-	UserId            int64
-	PharmacyProductId int64
-	ProductName       string
-	ProductPicture    string
-	SellingUnit       string
-	Price             decimal.Decimal
-	PharmacyId        int64
-	PharmacyName      string
-	SlugId            string
-	TotalStock        int
-	Weight            int
-	IsAvailable       bool
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
-	DeletedAt         sql.NullTime
+	Id                   int64
+	Quantity             int
+	UserId               int64
+	PharmacyProductId    int64
+	ProductId            int64
+	ProductName          string
+	ProductPicture       string
+	SellingUnit          string
+	Price                decimal.Decimal
+	Discount             decimal.Decimal
+	PharmacyId           int64
+	PharmacyName         string
+	SlugId               string
+	TotalStock           int
+	Weight               int
+	IsAvailable          bool
+	PrescriptionItemId   sql.NullInt64
+	RequiresPrescription bool
+	IsPrescribed         bool
+	PrescribedQuantity   sql.NullInt64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            sql.NullTime
 }
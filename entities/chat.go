@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"database/sql"
 	"mime/multipart"
 	"time"
 )
@@ -12,5 +13,7 @@ type Chat struct {
 	Type           string
 	ConsultationId int64
 	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+	ReadAt         sql.NullTime
 	File           *multipart.FileHeader
 }
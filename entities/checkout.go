@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Checkout struct {
+	Id              int64
+	CheckoutNumber  string
+	UserId          int64
+	TotalPrice      decimal.Decimal
+	ShippingFee     decimal.Decimal
+	Discount        decimal.Decimal
+	PaymentDeadline time.Time
+	Orders          []Order
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       sql.NullTime
+}
@@ -3,23 +3,34 @@ package entities
 import (
 	"database/sql"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Consultation struct {
-	Id                int64
-	Doctor            Doctor
-	User              User
-	PatientGender     Gender
-	PatientName       string
-	PatientBirthDate  string
-	CertificateUrl    sql.NullString
-	PrescriptionUrl   sql.NullString
-	PrescriptionItems []PrescriptionItem
-	EndedAt           sql.NullTime
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
-	DeletedAt         sql.NullTime
-	Chats             []Chat
+	Id               int64
+	Doctor           Doctor
+	User             User
+	PatientGender    Gender
+	PatientName      string
+	PatientBirthDate string
+	CertificateUrl   sql.NullString
+	PrescriptionUrl  sql.NullString
+	Prescription     *Prescription
+	EndedAt          sql.NullTime
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        sql.NullTime
+	Chats            []Chat
+	UnreadCount      int
+	Status           string
+	QueuedAt         time.Time
+	AcceptedAt       sql.NullTime
+	Fee              decimal.Decimal
+	PaymentDeadline  sql.NullTime
+	PaidAt           sql.NullTime
+	ScheduledAt      sql.NullTime
+	DependentId      sql.NullInt64
 }
 
 type ConsultationParams struct {
@@ -38,15 +49,37 @@ type CertificateData struct {
 	PatientGender    Gender
 	PatientAge       int
 	DoctorName       string
+	DocumentNumber   string
+	VerifyUrl        string
 }
 
 type PrescriptionData struct {
 	ConsultationId   int64
-	Products         []Product
-	Quantities       []int
+	Items            []PrescriptionItem
+	ExpiresAt        string
+	Refills          int
 	PatientName      string
 	PatientBirthDate string
 	PatientGender    Gender
 	PatientAge       int
 	DoctorName       string
+	DocumentNumber   string
+	VerifyUrl        string
+}
+
+type ConsultationQueueItem struct {
+	Consultation  Consultation
+	Position      int
+	EstimatedWait time.Duration
+}
+
+// ConsultationEvent tells the doctor and the consultation room what happened
+// to a request.
+type ConsultationEvent struct {
+	Type           string
+	ConsultationId int64
+	UserId         int64
+	DoctorId       int64
+	ChatId         int64
+	Content        string
 }
@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Dependent struct {
+	Id           int64
+	UserId       int64
+	Name         string
+	Relationship string
+	BirthDate    time.Time
+	Gender       Gender
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    sql.NullTime
+}
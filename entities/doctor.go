@@ -21,6 +21,8 @@ type Doctor struct {
 	UpdatedAt      time.Time
 	DeletedAt      sql.NullTime
 	ProfilePicture sql.NullString
+	Rating         float64
+	ReviewCount    int
 }
 
 type DoctorSpecialist struct {
@@ -39,4 +41,5 @@ type DoctorParams struct {
 	Limit        int
 	Page         int
 	Keyword      string
+	MinRating    float64
 }
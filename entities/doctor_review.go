@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type DoctorReview struct {
+	Id             int64
+	ConsultationId int64
+	DoctorId       int64
+	UserId         int64
+	UserName       string
+	Rating         int
+	Comment        sql.NullString
+	IsHidden       bool
+	HiddenBy       sql.NullInt64
+	HiddenReason   sql.NullString
+	HiddenAt       sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      sql.NullTime
+	Total          int
+}
+
+type DoctorReviewParams struct {
+	Limit    int
+	Page     int
+	IsHidden string
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DoctorSchedule is a weekly window, in server local time, that is split into
+// appointment slots. DayOfWeek follows time.Weekday.
+type DoctorSchedule struct {
+	Id        int64
+	DoctorId  int64
+	DayOfWeek int
+	StartTime string
+	EndTime   string
+}
+
+// DoctorScheduleException blocks part of a date, or all of it when StartTime
+// and EndTime are null.
+type DoctorScheduleException struct {
+	Id        int64
+	DoctorId  int64
+	Date      string
+	StartTime sql.NullString
+	EndTime   sql.NullString
+	Reason    sql.NullString
+	CreatedAt time.Time
+}
+
+type AppointmentSlot struct {
+	StartAt time.Time
+	EndAt   time.Time
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Document struct {
+	Id             int64
+	Number         string
+	Type           string
+	ConsultationId int64
+	Doctor         Doctor
+	PatientName    string
+	ValidFrom      time.Time
+	ValidUntil     time.Time
+	IssuedAt       time.Time
+	RevokedAt      sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      sql.NullTime
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type InvoiceData struct {
+	OrderId         int64
+	OrderNumber     string
+	OrderStatus     string
+	PaymentStatus   string
+	OrderedAt       time.Time
+	UserName        string
+	UserEmail       string
+	UserAddress     UserAddress
+	PharmacyName    string
+	PharmacyAddress PharmacyAddress
+	ShippingMethod  string
+	ShippingFee     decimal.Decimal
+	Discount        decimal.Decimal
+	TotalPrice      decimal.Decimal
+	Items           []InvoiceItem
+}
+
+type InvoiceItem struct {
+	Name        string
+	SellingUnit string
+	Price       decimal.Decimal
+	Discount    decimal.Decimal
+	Quantity    int
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type MedicalCondition struct {
+	Id          int64
+	UserId      int64
+	DependentId sql.NullInt64
+	Type        string
+	Name        string
+	Notes       sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   sql.NullTime
+}
+
+// MedicalRecordEntry is one ended consultation as it appears in the user's
+// medical record.
+type MedicalRecordEntry struct {
+	ConsultationId    int64
+	DoctorName        string
+	DoctorSpecialist  string
+	PatientName       string
+	Diagnosis         sql.NullString
+	PrescriptionItems []PrescriptionItem
+	ConsultedAt       time.Time
+}
+
+type MedicalRecord struct {
+	User       User
+	Dependent  *Dependent
+	Conditions []MedicalCondition
+	Entries    []MedicalRecordEntry
+}
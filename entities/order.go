@@ -8,26 +8,33 @@ import (
 )
 
 type Order struct {
-	Id              int64
-	OrderNumber     string
-	TotalPrice      decimal.Decimal
-	PaymentProof    *string
-	PaymentDeadline time.Time
-	ShippingFee     decimal.Decimal
-	ShippingMethod  string
-	UserAddressId   int64
-	UserAddress     UserAddress
-	PharmacyAddress PharmacyAddress
-	OrderStatusId   int64
-	OrderStatus     string
-	PharmacyId      int64
-	PharmacyName    string
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
-	DeletedAt       sql.NullTime
-	Total           int
-	UserName        string
-	UserEmail       string
+	Id                int64
+	OrderNumber       string
+	TotalPrice        decimal.Decimal
+	PaymentProof      *string
+	PaymentDeadline   time.Time
+	ShippingFee       decimal.Decimal
+	Discount          decimal.Decimal
+	ShippingMethod    string
+	UserAddressId     int64
+	UserId            int64
+	UserAddress       UserAddress
+	PharmacyAddress   PharmacyAddress
+	OrderStatusId     int64
+	OrderStatus       string
+	PharmacyId        int64
+	PharmacyName      string
+	PharmacyManagerId int64
+	CheckoutId        int64
+	InvoiceUrl        sql.NullString
+	InvoiceCacheKey   sql.NullString
+	CanceledAt        sql.NullTime
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         sql.NullTime
+	Total             int
+	UserName          string
+	UserEmail         string
 }
 
 type OrderParams struct {
@@ -42,6 +49,9 @@ type UpdateOrderStatus struct {
 	OrderStatus       string
 	PharmacyManagerId int64
 	UserId            int64
+	ActorRole         string
+	ActorId           int64
+	Reason            string
 }
 
 type UploadPaymentProof struct {
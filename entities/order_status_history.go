@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+)
+
+type OrderStatusHistory struct {
+	Id         int64
+	OrderId    int64
+	FromStatus sql.NullString
+	ToStatus   string
+	ActorRole  string
+	ActorId    sql.NullInt64
+	Reason     sql.NullString
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  sql.NullTime
+}
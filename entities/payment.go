@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Payment struct {
+	Id             int64
+	CheckoutId     sql.NullInt64
+	ConsultationId sql.NullInt64
+	Provider       string
+	Method         string
+	Channel        string
+	ExternalId     string
+	AccountNumber  sql.NullString
+	CheckoutUrl    sql.NullString
+	Amount         decimal.Decimal
+	Status         string
+	ExpiredAt      time.Time
+	PaidAt         sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      sql.NullTime
+}
+
+type PaymentCallback struct {
+	Id         int64
+	Provider   string
+	CallbackId string
+	PaymentId  int64
+	Payload    string
+	CreatedAt  time.Time
+}
@@ -9,7 +9,24 @@ type PrescriptionItem struct {
 	Id        int64
 	Product   Product
 	Quantity  int
+	Dosage    string
+	Frequency string
+	Duration  string
+	Route     string
+	Notes     sql.NullString
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt sql.NullTime
 }
+
+type Prescription struct {
+	Id             int64
+	ConsultationId int64
+	Items          []PrescriptionItem
+	ExpiresAt      time.Time
+	Refills        int
+	FillCount      int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      sql.NullTime
+}
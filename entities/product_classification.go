@@ -6,9 +6,10 @@ import (
 )
 
 type ProductClassification struct {
-	Id        int64
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt sql.NullTime
+	Id                   int64
+	Name                 string
+	RequiresPrescription bool
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            sql.NullTime
 }
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Refund struct {
+	Id             int64
+	OrderId        sql.NullInt64
+	ConsultationId sql.NullInt64
+	UserId         int64
+	Type           string
+	Status         string
+	Amount         decimal.Decimal
+	Reason         sql.NullString
+	AdminId        sql.NullInt64
+	AdminNote      sql.NullString
+	Items          []RefundItem
+	ProcessedAt    sql.NullTime
+	PaidOutAt      sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      sql.NullTime
+	Total          int
+}
+
+type RefundItem struct {
+	Id                int64
+	RefundId          int64
+	PharmacyProductId int64
+	Quantity          int
+	Amount            decimal.Decimal
+}
+
+type RefundParams struct {
+	Limit  int
+	Page   int
+	Status string
+	Type   string
+}
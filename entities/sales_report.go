@@ -7,11 +7,12 @@ import (
 )
 
 type SalesReport struct {
-	PharmacyProduct   PharmacyProduct
-	TotalSalesAmount  decimal.Decimal
-	TotalQuantitySold int
-	Month             time.Time
-	Year              time.Time
+	PharmacyProduct     PharmacyProduct
+	TotalSalesAmount    decimal.Decimal
+	TotalDiscountAmount decimal.Decimal
+	TotalQuantitySold   int
+	Month               time.Time
+	Year                time.Time
 }
 
 type SalesReportParams struct {
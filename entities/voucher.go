@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Voucher struct {
+	Id            int64
+	Code          string
+	Name          string
+	DiscountType  string
+	DiscountValue decimal.Decimal
+	MaxDiscount   decimal.NullDecimal
+	MinSpend      decimal.Decimal
+	GlobalQuota   sql.NullInt64
+	PerUserQuota  sql.NullInt64
+	StartsAt      time.Time
+	EndsAt        time.Time
+	CategoryId    sql.NullInt64
+	ProductId     sql.NullInt64
+	PharmacyId    sql.NullInt64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     sql.NullTime
+}
+
+type VoucherUsage struct {
+	Id         int64
+	VoucherId  int64
+	UserId     int64
+	CheckoutId int64
+	Discount   decimal.Decimal
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  sql.NullTime
+}
+
+type VoucherParams struct {
+	SortBy  string
+	Sort    string
+	Limit   int
+	Page    int
+	Keyword string
+}
@@ -0,0 +1,10 @@
+package entities
+
+import "time"
+
+type WsTicketRedemption struct {
+	Id        int64
+	Jti       string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
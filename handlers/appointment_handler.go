@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AppointmentHandlerOpts struct {
+	AppointmentUsecase usecases.AppointmentUsecase
+}
+
+type AppointmentHandler struct {
+	AppointmentUsecase usecases.AppointmentUsecase
+}
+
+func NewAppointmentHandler(ahOpts *AppointmentHandlerOpts) *AppointmentHandler {
+	return &AppointmentHandler{
+		AppointmentUsecase: ahOpts.AppointmentUsecase,
+	}
+}
+
+func (h *AppointmentHandler) BookAppointment(ctx *gin.Context) {
+	var payload dtos.AppointmentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	appointment, err := h.AppointmentUsecase.BookAppointment(ctx, int64(userId), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToAppointmentResponse(*appointment),
+	})
+}
+
+func (h *AppointmentHandler) GetAllAppointmentByUser(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	params := getAppointmentParams(ctx)
+
+	result, total, err := h.AppointmentUsecase.GetAllAppointmentByUser(ctx, int64(userId), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    convertToAppointmentsResponse(result, total, params),
+	})
+}
+
+func (h *AppointmentHandler) GetAllAppointmentByDoctor(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	params := getAppointmentParams(ctx)
+
+	result, total, err := h.AppointmentUsecase.GetAllAppointmentByDoctor(ctx, int64(doctorId), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    convertToAppointmentsResponse(result, total, params),
+	})
+}
+
+func (h *AppointmentHandler) CancelAppointment(ctx *gin.Context) {
+	appointmentId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.AppointmentUsecase.CancelAppointment(ctx, int64(appointmentId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func getAppointmentParams(ctx *gin.Context) entities.AppointmentParams {
+	var params entities.AppointmentParams
+
+	params.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	if ctx.Query("limit") == "" || params.Limit < 1 {
+		params.Limit = constants.DefaultLimit
+	}
+
+	params.Page, _ = strconv.Atoi(ctx.Query("page"))
+	if ctx.Query("page") == "" || params.Page < 1 {
+		params.Page = constants.DefaultPage
+	}
+
+	params.Status = ctx.Query("status")
+
+	return params
+}
+
+func convertToAppointmentsResponse(appointments []entities.Appointment, total int, params entities.AppointmentParams) dtos.GetAllAppointmentsResponse {
+	return dtos.GetAllAppointmentsResponse{
+		PaginationInfo: dtos.PaginationResponse{
+			Page:      params.Page,
+			Limit:     params.Limit,
+			TotalPage: int(math.Ceil(float64(total) / float64(params.Limit))),
+			TotalData: total,
+		},
+		Data: dtos.ConvertToAppointmentResponses(appointments),
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
@@ -45,6 +46,10 @@ func (h *CartHandler) CreateCartItem(ctx *gin.Context) {
 		PharmacyProductId: payload.PharmacyProductId,
 	}
 
+	if payload.PrescriptionItemId != nil {
+		cartEntity.PrescriptionItemId = sql.NullInt64{Int64: *payload.PrescriptionItemId, Valid: true}
+	}
+
 	err = h.CartUsecase.CreateCartItem(ctx, cartEntity)
 	if err != nil {
 		ctx.Error(err)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 
@@ -170,6 +171,7 @@ func (h *ConsultationHandler) CreateConsultation(ctx *gin.Context) {
 		PatientGender:    entities.Gender{Id: payload.PatientGenderId},
 		PatientName:      payload.PatientName,
 		PatientBirthDate: payload.PatientBirthDate,
+		DependentId:      sql.NullInt64{Int64: payload.DependentId, Valid: payload.DependentId != 0},
 	}
 
 	newC, err := h.ConsultationUsecase.CreateConsultation(ctx, consultation)
@@ -303,19 +305,12 @@ func (h *ConsultationHandler) CreatePrescription(ctx *gin.Context) {
 
 	prescriptionData := entities.PrescriptionData{
 		ConsultationId: int64(consultationId),
-		Quantities:     payload.Quantities,
-	}
-
-	products := []entities.Product{}
-
-	for i := 0; i < len(payload.Products); i++ {
-		products = append(products, entities.Product{
-			Id: payload.Products[i],
-		})
+		Items:          dtos.ConvertToPrescriptionItems(payload.Items),
+		ExpiresAt:      payload.ExpiresAt,
+		Refills:        payload.Refills,
+		PatientAge:     payload.PatientAge,
 	}
 
-	prescriptionData.Products = products
-
 	datas, err := utils.GetDataFromContext(ctx)
 	if err != nil {
 		ctx.Error(err)
@@ -378,6 +373,31 @@ func (h *ConsultationHandler) CreateCertificate(ctx *gin.Context) {
 	})
 }
 
+func (h *ConsultationHandler) GetPrescription(ctx *gin.Context) {
+	consultationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	datas, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	prescription, err := h.ConsultationUsecase.GetPrescription(ctx, int64(consultationId), datas.Id, datas.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToPrescriptionResponse(*prescription),
+	})
+}
+
 func (h *ConsultationHandler) AddPrescriptionToCart(ctx *gin.Context) {
 	consultationId, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
@@ -402,3 +422,97 @@ func (h *ConsultationHandler) AddPrescriptionToCart(ctx *gin.Context) {
 		Data:    nil,
 	})
 }
+
+func (h *ConsultationHandler) GetConsultationQueue(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	queue, err := h.ConsultationUsecase.GetConsultationQueue(ctx, int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToConsultationQueueItemResponses(queue),
+	})
+}
+
+func (h *ConsultationHandler) GetConsultationQueuePosition(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consultationId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+		return
+	}
+
+	item, err := h.ConsultationUsecase.GetConsultationQueuePosition(ctx, int64(consultationId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToConsultationQueueItemResponse(*item),
+	})
+}
+
+func (h *ConsultationHandler) AcceptConsultation(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consultationId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+		return
+	}
+
+	err = h.ConsultationUsecase.AcceptConsultation(ctx, int64(consultationId), int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func (h *ConsultationHandler) DeclineConsultation(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consultationId, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+		return
+	}
+
+	err = h.ConsultationUsecase.DeclineConsultation(ctx, int64(consultationId), int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
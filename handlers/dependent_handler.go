@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DependentHandlerOpts struct {
+	DependentUsecase usecases.DependentUsecase
+}
+
+type DependentHandler struct {
+	DependentUsecase usecases.DependentUsecase
+}
+
+func NewDependentHandler(dhOpts *DependentHandlerOpts) *DependentHandler {
+	return &DependentHandler{
+		DependentUsecase: dhOpts.DependentUsecase,
+	}
+}
+
+func (h *DependentHandler) GetAllDependents(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependents, err := h.DependentUsecase.GetAllDependents(ctx, int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToDependentResponses(dependents),
+	})
+}
+
+func (h *DependentHandler) GetDependentById(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := utils.GetIdParamOrContext(ctx, "dependentId")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependent, err := h.DependentUsecase.GetDependentById(ctx, int64(dependentId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToDependentResponse(*dependent),
+	})
+}
+
+func (h *DependentHandler) CreateDependent(ctx *gin.Context) {
+	var payload dtos.DependentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependent, err := h.DependentUsecase.CreateDependent(ctx, dtos.ConvertToDependent(int64(userId), payload))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToDependentResponse(*dependent),
+	})
+}
+
+func (h *DependentHandler) UpdateDependent(ctx *gin.Context) {
+	var payload dtos.DependentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := utils.GetIdParamOrContext(ctx, "dependentId")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependent := dtos.ConvertToDependent(int64(userId), payload)
+	dependent.Id = int64(dependentId)
+
+	updated, err := h.DependentUsecase.UpdateDependent(ctx, dependent)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    dtos.ConvertToDependentResponse(*updated),
+	})
+}
+
+func (h *DependentHandler) DeleteDependent(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := utils.GetIdParamOrContext(ctx, "dependentId")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.DependentUsecase.DeleteDependent(ctx, int64(dependentId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgDeleted,
+		Data:    nil,
+	})
+}
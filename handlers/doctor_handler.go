@@ -148,6 +148,16 @@ func (h *DoctorHandler) GetAllDoctor(ctx *gin.Context) {
 		}
 	}
 
+	var minRating float64
+	minRatingStr := ctx.Query("minRating")
+	if minRatingStr != "" {
+		minRating, err = strconv.ParseFloat(minRatingStr, 64)
+		if err != nil || minRating < 1 || minRating > 5 {
+			ctx.Error(custom_errors.BadRequest(err, constants.InvalidRatingInputErrMsg))
+			return
+		}
+	}
+
 	limitStr := ctx.Query("limit")
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
@@ -180,6 +190,7 @@ func (h *DoctorHandler) GetAllDoctor(ctx *gin.Context) {
 		Limit:        limit,
 		Page:         page,
 		Keyword:      keyword,
+		MinRating:    minRating,
 	}
 
 	var doctors []entities.Doctor
@@ -209,6 +220,8 @@ func (h *DoctorHandler) GetAllDoctor(ctx *gin.Context) {
 	})
 }
 
+// ToggleDoctorIsOnline is kept for older clients. Presence now follows the
+// doctor's WebSocket connections, so it only reports the current state.
 func (h *DoctorHandler) ToggleDoctorIsOnline(ctx *gin.Context) {
 	doctorId, err := utils.GetIdParamOrContext(ctx, constants.Id)
 	if err != nil {
@@ -216,14 +229,14 @@ func (h *DoctorHandler) ToggleDoctorIsOnline(ctx *gin.Context) {
 		return
 	}
 
-	err = h.DoctorUsecase.ToggleDoctorIsOnline(ctx, int64(doctorId))
+	doctor, err := h.DoctorUsecase.GetDoctorById(ctx, int64(doctorId))
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
-		Message: constants.ResponseMsgUpdated,
-		Data:    nil,
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.DoctorPresenceResponse{IsOnline: doctor.IsOnline},
 	})
 }
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DoctorReviewHandlerOpts struct {
+	DoctorReviewUsecase usecases.DoctorReviewUsecase
+}
+
+type DoctorReviewHandler struct {
+	DoctorReviewUsecase usecases.DoctorReviewUsecase
+}
+
+func NewDoctorReviewHandler(drhOpts *DoctorReviewHandlerOpts) *DoctorReviewHandler {
+	return &DoctorReviewHandler{
+		DoctorReviewUsecase: drhOpts.DoctorReviewUsecase,
+	}
+}
+
+func (h *DoctorReviewHandler) CreateReview(ctx *gin.Context) {
+	var payload dtos.DoctorReviewRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consultationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	review, err := h.DoctorReviewUsecase.CreateReview(ctx, int64(consultationId), int64(userId), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToDoctorReviewResponse(*review),
+	})
+}
+
+func (h *DoctorReviewHandler) GetDoctorReviews(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	params := getDoctorReviewParams(ctx)
+
+	result, total, err := h.DoctorReviewUsecase.GetDoctorReviews(ctx, int64(doctorId), params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    convertToDoctorReviewsResponse(result, total, params),
+	})
+}
+
+func (h *DoctorReviewHandler) GetAllReview(ctx *gin.Context) {
+	params := getDoctorReviewParams(ctx)
+	params.IsHidden = ctx.Query("isHidden")
+
+	result, total, err := h.DoctorReviewUsecase.GetAllReview(ctx, params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    convertToDoctorReviewsResponse(result, total, params),
+	})
+}
+
+func (h *DoctorReviewHandler) HideReview(ctx *gin.Context) {
+	var payload dtos.HideDoctorReviewRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	reviewId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.DoctorReviewUsecase.HideReview(ctx, int64(reviewId), data.Id, payload.Reason)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func (h *DoctorReviewHandler) UnhideReview(ctx *gin.Context) {
+	reviewId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.DoctorReviewUsecase.UnhideReview(ctx, int64(reviewId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func getDoctorReviewParams(ctx *gin.Context) entities.DoctorReviewParams {
+	var params entities.DoctorReviewParams
+
+	params.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	if ctx.Query("limit") == "" || params.Limit < 1 {
+		params.Limit = constants.DefaultLimit
+	}
+
+	params.Page, _ = strconv.Atoi(ctx.Query("page"))
+	if ctx.Query("page") == "" || params.Page < 1 {
+		params.Page = constants.DefaultPage
+	}
+
+	return params
+}
+
+func convertToDoctorReviewsResponse(reviews []entities.DoctorReview, total int, params entities.DoctorReviewParams) dtos.GetAllDoctorReviewsResponse {
+	return dtos.GetAllDoctorReviewsResponse{
+		PaginationInfo: dtos.PaginationResponse{
+			Page:      params.Page,
+			Limit:     params.Limit,
+			TotalPage: int(math.Ceil(float64(total) / float64(params.Limit))),
+			TotalData: total,
+		},
+		Data: dtos.ConvertToDoctorReviewResponses(reviews),
+	}
+}
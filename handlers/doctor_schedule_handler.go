@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DoctorScheduleHandlerOpts struct {
+	DoctorScheduleUsecase usecases.DoctorScheduleUsecase
+}
+
+type DoctorScheduleHandler struct {
+	DoctorScheduleUsecase usecases.DoctorScheduleUsecase
+}
+
+func NewDoctorScheduleHandler(dshOpts *DoctorScheduleHandlerOpts) *DoctorScheduleHandler {
+	return &DoctorScheduleHandler{
+		DoctorScheduleUsecase: dshOpts.DoctorScheduleUsecase,
+	}
+}
+
+func (h *DoctorScheduleHandler) GetSchedules(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	schedules, err := h.DoctorScheduleUsecase.GetSchedules(ctx, int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToDoctorScheduleResponses(schedules),
+	})
+}
+
+func (h *DoctorScheduleHandler) UpdateSchedules(ctx *gin.Context) {
+	var payload dtos.UpdateDoctorSchedulesRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	schedules, err := h.DoctorScheduleUsecase.UpdateSchedules(ctx, int64(doctorId), dtos.ConvertToDoctorSchedules(int64(doctorId), payload))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    dtos.ConvertToDoctorScheduleResponses(schedules),
+	})
+}
+
+func (h *DoctorScheduleHandler) GetExceptions(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	exceptions, err := h.DoctorScheduleUsecase.GetExceptions(ctx, int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToDoctorScheduleExceptionResponses(exceptions),
+	})
+}
+
+func (h *DoctorScheduleHandler) CreateException(ctx *gin.Context) {
+	var payload dtos.DoctorScheduleExceptionRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	exception, err := h.DoctorScheduleUsecase.CreateException(ctx, dtos.ConvertToDoctorScheduleException(int64(doctorId), payload))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToDoctorScheduleExceptionResponse(*exception),
+	})
+}
+
+func (h *DoctorScheduleHandler) DeleteException(ctx *gin.Context) {
+	exceptionId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	doctorId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.DoctorScheduleUsecase.DeleteException(ctx, int64(exceptionId), int64(doctorId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgDeleted,
+		Data:    nil,
+	})
+}
+
+func (h *DoctorScheduleHandler) GetAvailableSlots(ctx *gin.Context) {
+	doctorId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	slots, err := h.DoctorScheduleUsecase.GetAvailableSlots(ctx, int64(doctorId), ctx.Query("date"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToAppointmentSlotResponses(slots),
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentHandlerOpts struct {
+	DocumentUsecase usecases.DocumentUsecase
+}
+
+type DocumentHandler struct {
+	DocumentUsecase usecases.DocumentUsecase
+}
+
+func NewDocumentHandler(dhOpts *DocumentHandlerOpts) *DocumentHandler {
+	return &DocumentHandler{
+		DocumentUsecase: dhOpts.DocumentUsecase,
+	}
+}
+
+func (h *DocumentHandler) VerifyDocument(ctx *gin.Context) {
+	document, err := h.DocumentUsecase.VerifyDocument(ctx, ctx.Param(constants.DocumentToken))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToDocumentResponse(*document),
+	})
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MedicalRecordHandlerOpts struct {
+	MedicalRecordUsecase usecases.MedicalRecordUsecase
+}
+
+type MedicalRecordHandler struct {
+	MedicalRecordUsecase usecases.MedicalRecordUsecase
+}
+
+func NewMedicalRecordHandler(mrhOpts *MedicalRecordHandlerOpts) *MedicalRecordHandler {
+	return &MedicalRecordHandler{
+		MedicalRecordUsecase: mrhOpts.MedicalRecordUsecase,
+	}
+}
+
+func (h *MedicalRecordHandler) GetMedicalRecord(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := getDependentIdParam(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	record, err := h.MedicalRecordUsecase.GetMedicalRecord(ctx, int64(userId), int64(dependentId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToMedicalRecordResponse(*record),
+	})
+}
+
+func (h *MedicalRecordHandler) GetConsultationMedicalRecord(ctx *gin.Context) {
+	consultationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	datas, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	record, err := h.MedicalRecordUsecase.GetConsultationMedicalRecord(ctx, int64(consultationId), datas.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToMedicalRecordResponse(*record),
+	})
+}
+
+func (h *MedicalRecordHandler) ExportMedicalRecord(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := getDependentIdParam(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	fileUrl, err := h.MedicalRecordUsecase.ExportMedicalRecord(ctx, int64(userId), int64(dependentId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data: dtos.MedicalRecordUrlResponse{
+			MedicalRecordUrl: fileUrl,
+		},
+	})
+}
+
+func (h *MedicalRecordHandler) CreateCondition(ctx *gin.Context) {
+	var payload dtos.MedicalConditionRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	dependentId, err := getDependentIdParam(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	condition, err := h.MedicalRecordUsecase.CreateCondition(ctx, dtos.ConvertToMedicalCondition(int64(userId), int64(dependentId), payload))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToMedicalConditionResponse(*condition),
+	})
+}
+
+func (h *MedicalRecordHandler) DeleteCondition(ctx *gin.Context) {
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	conditionId, err := utils.GetIdParamOrContext(ctx, "conditionId")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.MedicalRecordUsecase.DeleteCondition(ctx, int64(conditionId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgDeleted,
+		Data:    nil,
+	})
+}
+
+// getDependentIdParam returns the dependent named in the route, or 0 when the
+// route is about the user themselves.
+func getDependentIdParam(ctx *gin.Context) (int, error) {
+	if _, exists := ctx.Params.Get("dependentId"); !exists {
+		return 0, nil
+	}
+
+	return utils.GetIdParamOrContext(ctx, "dependentId")
+}
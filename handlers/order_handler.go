@@ -36,7 +36,14 @@ func (h *OrderHandler) CreateOrder(ctx *gin.Context) {
 		return
 	}
 
-	order, err := h.OrderUsecase.CreateOrderWithTransaction(ctx, payload)
+	userId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	payload.UserId = int64(userId)
+
+	checkout, err := h.OrderUsecase.CreateOrderWithTransaction(ctx, payload)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -44,9 +51,7 @@ func (h *OrderHandler) CreateOrder(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
 		Message: constants.ResponseMsgCreateOrder,
-		Data: dtos.CreateOrderResponse{
-			PaymentDeadline: order.PaymentDeadline,
-		},
+		Data:    dtos.ConvertToCreateOrderResponse(*checkout),
 	})
 }
 
@@ -193,7 +198,13 @@ func (h *OrderHandler) UpdateOrderStatusToProcessing(ctx *gin.Context) {
 		return
 	}
 
-	err = h.OrderUsecase.UpdateOrderStatusToProcessing(ctx, int64(orderId))
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.OrderUsecase.UpdateOrderStatusToProcessing(ctx, int64(orderId), data.Id)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -230,6 +241,31 @@ func (h *OrderHandler) UpdateOrderStatusToShipped(ctx *gin.Context) {
 	})
 }
 
+func (h *OrderHandler) UpdateOrderStatusToDelivered(ctx *gin.Context) {
+	orderId, err := utils.GetIdParamOrContext(ctx, constants.OrderId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	pharmacyManagerId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.OrderUsecase.UpdateOrderStatusToDelivered(ctx, int64(orderId), int64(pharmacyManagerId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
 func (h *OrderHandler) UpdateOrderStatusToCompleted(ctx *gin.Context) {
 	orderId, err := utils.GetIdParamOrContext(ctx, constants.OrderId)
 	if err != nil {
@@ -301,7 +337,13 @@ func (h *OrderHandler) UpdateOrderStatusToCanceled(ctx *gin.Context) {
 		return
 	}
 
-	err = h.OrderUsecase.UpdateOrderStatusToCanceled(ctx, int64(orderId), int64(userId))
+	payload, err := bindCancelOrderRequest(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.OrderUsecase.UpdateOrderStatusToCanceled(ctx, int64(orderId), int64(userId), payload.Reason)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -320,7 +362,19 @@ func (h *OrderHandler) CancelOrderByAdmin(ctx *gin.Context) {
 		return
 	}
 
-	err = h.OrderUsecase.CancelOrderByAdmin(ctx, int64(orderId))
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payload, err := bindCancelOrderRequest(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.OrderUsecase.CancelOrderByAdmin(ctx, int64(orderId), data.Id, payload.Reason)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -345,7 +399,13 @@ func (h *OrderHandler) CancelOrderByPharmacyManager(ctx *gin.Context) {
 		return
 	}
 
-	err = h.OrderUsecase.CancelOrderByPharmacyManager(ctx, int64(orderId), int64(pharmacyManagerId))
+	payload, err := bindCancelOrderRequest(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.OrderUsecase.CancelOrderByPharmacyManager(ctx, int64(orderId), int64(pharmacyManagerId), payload.Reason)
 	if err != nil {
 		ctx.Error(err)
 		return
@@ -375,3 +435,71 @@ func (h *OrderHandler) GetOrderDetail(ctx *gin.Context) {
 		Data:    result,
 	})
 }
+
+func (h *OrderHandler) GetOrderStatusHistories(ctx *gin.Context) {
+	orderId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	histories, err := h.OrderUsecase.GetOrderStatusHistories(ctx, int64(orderId), data.Id, data.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	result := []dtos.OrderStatusHistoryResponse{}
+	for _, history := range histories {
+		result = append(result, dtos.ConvertToOrderStatusHistoryResponse(history))
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgGetOrder,
+		Data:    result,
+	})
+}
+
+// GetOrderInvoice sends the client to the cached invoice PDF, so the endpoint
+// can be used as a plain download link.
+func (h *OrderHandler) GetOrderInvoice(ctx *gin.Context) {
+	orderId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	fileUrl, err := h.OrderUsecase.GetOrderInvoice(ctx, int64(orderId), data.Id, data.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, fileUrl)
+}
+
+func bindCancelOrderRequest(ctx *gin.Context) (dtos.CancelOrderRequest, error) {
+	var payload dtos.CancelOrderRequest
+
+	if ctx.Request.ContentLength == 0 {
+		return payload, nil
+	}
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		return payload, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandlerOpts struct {
+	PaymentUsecase usecases.PaymentUsecase
+}
+
+type PaymentHandler struct {
+	PaymentUsecase usecases.PaymentUsecase
+}
+
+func NewPaymentHandler(pHandOpts *PaymentHandlerOpts) *PaymentHandler {
+	return &PaymentHandler{
+		PaymentUsecase: pHandOpts.PaymentUsecase,
+	}
+}
+
+func (h *PaymentHandler) CreatePayment(ctx *gin.Context) {
+	var payload dtos.PaymentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	checkoutId, err := utils.GetIdParamOrContext(ctx, constants.CheckoutId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payment, err := h.PaymentUsecase.CreatePayment(ctx, int64(checkoutId), int64(userId), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreatePayment,
+		Data:    dtos.ConvertToPaymentResponse(*payment),
+	})
+}
+
+func (h *PaymentHandler) GetPayment(ctx *gin.Context) {
+	checkoutId, err := utils.GetIdParamOrContext(ctx, constants.CheckoutId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payment, err := h.PaymentUsecase.GetPayment(ctx, int64(checkoutId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToPaymentResponse(*payment),
+	})
+}
+
+func (h *PaymentHandler) CreateConsultationPayment(ctx *gin.Context) {
+	var payload dtos.PaymentRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	consultationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payment, err := h.PaymentUsecase.CreateConsultationPayment(ctx, int64(consultationId), int64(userId), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreatePayment,
+		Data:    dtos.ConvertToPaymentResponse(*payment),
+	})
+}
+
+func (h *PaymentHandler) GetConsultationPayment(ctx *gin.Context) {
+	consultationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, "")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	payment, err := h.PaymentUsecase.GetConsultationPayment(ctx, int64(consultationId), int64(userId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToPaymentResponse(*payment),
+	})
+}
+
+func (h *PaymentHandler) HandleCallback(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		ctx.Error(custom_errors.BadRequest(err, constants.ResponseMsgErrorInvalidRequest))
+		return
+	}
+
+	provider := ctx.Param(constants.PaymentProvider)
+	signature := ctx.GetHeader(constants.PaymentSignatureHeader)
+
+	err = h.PaymentUsecase.HandleCallback(ctx, provider, body, signature)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    nil,
+	})
+}
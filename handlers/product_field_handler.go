@@ -5,7 +5,9 @@ import (
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,7 +45,36 @@ func (h *ProductFieldHandler) GetAllClassification(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
 		Message: constants.ResponseMsgOK,
-		Data:    dtos.ConvertToProductFieldResponses(nil, classifications, nil),
+		Data:    dtos.ConvertToProductClassificationResponses(classifications),
+	})
+}
+
+func (h *ProductFieldHandler) UpdateClassification(ctx *gin.Context) {
+	var payload dtos.UpdateClassificationRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	classificationId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	classification, err := h.ProductFieldUsecase.UpdateClassification(ctx, entities.ProductClassification{
+		Id:                   int64(classificationId),
+		RequiresPrescription: *payload.RequiresPrescription,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    dtos.ConvertToProductClassificationResponse(*classification),
 	})
 }
 
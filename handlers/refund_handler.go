@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandlerOpts struct {
+	RefundUsecase usecases.RefundUsecase
+}
+
+type RefundHandler struct {
+	RefundUsecase usecases.RefundUsecase
+}
+
+func NewRefundHandler(rhOpts *RefundHandlerOpts) *RefundHandler {
+	return &RefundHandler{
+		RefundUsecase: rhOpts.RefundUsecase,
+	}
+}
+
+func (h *RefundHandler) RequestReturn(ctx *gin.Context) {
+	var payload dtos.ReturnRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	orderId, err := utils.GetIdParamOrContext(ctx, constants.OrderId)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	userId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	refund, err := h.RefundUsecase.RequestReturn(ctx, int64(orderId), int64(userId), payload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    dtos.ConvertToRefundResponse(*refund),
+	})
+}
+
+func (h *RefundHandler) GetOrderRefunds(ctx *gin.Context) {
+	orderId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	refunds, err := h.RefundUsecase.GetOrderRefunds(ctx, int64(orderId), data.Id, data.Role)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToRefundResponses(refunds),
+	})
+}
+
+func (h *RefundHandler) GetAllRefund(ctx *gin.Context) {
+	var params entities.RefundParams
+
+	params.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	if ctx.Query("limit") == "" || params.Limit < 1 {
+		params.Limit = constants.DefaultLimit
+	}
+
+	params.Page, _ = strconv.Atoi(ctx.Query("page"))
+	if ctx.Query("page") == "" || params.Page < 1 {
+		params.Page = constants.DefaultPage
+	}
+
+	params.Status = ctx.Query("status")
+	params.Type = ctx.Query("type")
+
+	result, total, err := h.RefundUsecase.GetAllRefund(ctx, params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data: dtos.GetAllRefundsResponse{
+			PaginationInfo: dtos.PaginationResponse{
+				Page:      params.Page,
+				Limit:     params.Limit,
+				TotalPage: int(math.Ceil(float64(total) / float64(params.Limit))),
+				TotalData: total,
+			},
+			Data: dtos.ConvertToRefundResponses(result),
+		},
+	})
+}
+
+func (h *RefundHandler) GetRefundById(ctx *gin.Context) {
+	refundId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	refund, err := h.RefundUsecase.GetRefundById(ctx, int64(refundId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToRefundResponse(*refund),
+	})
+}
+
+func (h *RefundHandler) ApproveRefund(ctx *gin.Context) {
+	refundId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.RefundUsecase.ApproveRefund(ctx, int64(refundId), data.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func (h *RefundHandler) RejectRefund(ctx *gin.Context) {
+	var payload dtos.RejectRefundRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	refundId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.RefundUsecase.RejectRefund(ctx, int64(refundId), data.Id, payload.Note)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func (h *RefundHandler) PayOutRefund(ctx *gin.Context) {
+	refundId, err := utils.GetIdParamOrContext(ctx, constants.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	data, err := utils.GetDataFromContext(ctx)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	err = h.RefundUsecase.PayOutRefund(ctx, int64(refundId), data.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type VoucherHandlerOpts struct {
+	VoucherUsecase usecases.VoucherUsecase
+}
+
+type VoucherHandler struct {
+	VoucherUsecase usecases.VoucherUsecase
+}
+
+func NewVoucherHandler(vhOpts *VoucherHandlerOpts) *VoucherHandler {
+	return &VoucherHandler{
+		VoucherUsecase: vhOpts.VoucherUsecase,
+	}
+}
+
+func (h *VoucherHandler) GetAllVoucher(ctx *gin.Context) {
+	var err error
+	params := entities.VoucherParams{}
+
+	sortBy := ctx.Query("sortBy")
+	sort := ctx.Query("sort")
+	keyword := ctx.Query("keyword")
+
+	limit := constants.DefaultLimit
+	page := constants.DefaultPage
+
+	limitStr, limitExist := ctx.GetQuery("limit")
+	if limitExist && limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+			return
+		}
+		if limit == 0 {
+			ctx.Error(custom_errors.BadRequest(err, constants.ZeroLimitInputErrMsg))
+			return
+		}
+	}
+	if !limitExist || limitStr == "" {
+		limit = 0
+	}
+
+	pageStr, pageExist := ctx.GetQuery("page")
+	if pageExist && pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil {
+			ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+			return
+		}
+		if page == 0 {
+			ctx.Error(custom_errors.BadRequest(err, constants.ZeroPageInputErrMsg))
+			return
+		}
+	}
+	if !pageExist || pageStr == "" {
+		page = 0
+	}
+
+	params.Page = page
+	params.Limit = limit
+	params.SortBy = sortBy
+	params.Sort = sort
+	params.Keyword = keyword
+
+	vouchers, pagination, err := h.VoucherUsecase.GetAllVoucher(ctx, params)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToVoucherResponses(vouchers, *pagination),
+	})
+}
+
+func (h *VoucherHandler) GetVoucherById(ctx *gin.Context) {
+	voucherId, _ := strconv.Atoi(ctx.Param("id"))
+
+	voucher, err := h.VoucherUsecase.GetVoucherById(ctx, int64(voucherId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgOK,
+		Data:    dtos.ConvertToVoucherResponse(*voucher),
+	})
+}
+
+func (h *VoucherHandler) CreateVoucher(ctx *gin.Context) {
+	var payload dtos.VoucherRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	voucher := dtos.ConvertToVoucherEntity(payload)
+
+	err := h.VoucherUsecase.CreateVoucher(ctx, voucher)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data:    nil,
+	})
+}
+
+func (h *VoucherHandler) UpdateVoucher(ctx *gin.Context) {
+	var payload dtos.VoucherRequest
+
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	voucherId, _ := strconv.Atoi(ctx.Param("id"))
+
+	voucher := dtos.ConvertToVoucherEntity(payload)
+	voucher.Id = int64(voucherId)
+
+	err := h.VoucherUsecase.UpdateVoucher(ctx, voucher)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgUpdated,
+		Data:    nil,
+	})
+}
+
+func (h *VoucherHandler) DeleteVoucher(ctx *gin.Context) {
+	voucherId, _ := strconv.Atoi(ctx.Param("id"))
+
+	err := h.VoucherUsecase.DeleteVoucher(ctx, int64(voucherId))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgDeleted,
+		Data:    nil,
+	})
+}
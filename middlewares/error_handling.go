@@ -70,6 +70,8 @@ func getErrorMsg(fe validator.FieldError) string {
 		return "this field cannot contain uppercase character"
 	case "alphanum":
 		return "this field just receive alphanumeric"
+	case "oneof":
+		return "this field must be one of " + fe.Param()
 	default:
 		return "unknown error"
 	}
@@ -0,0 +1,288 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AppointmentRepoOpts struct {
+	Db *sql.DB
+}
+
+type AppointmentRepository interface {
+	CreateOne(ctx context.Context, appointment entities.Appointment) (*entities.Appointment, error)
+	FindAllByUserId(ctx context.Context, userId int64, params entities.AppointmentParams) ([]entities.Appointment, error)
+	FindAllByDoctorId(ctx context.Context, doctorId int64, params entities.AppointmentParams) ([]entities.Appointment, error)
+	LockById(ctx context.Context, appointmentId int64) (*entities.Appointment, error)
+	FindBookedStarts(ctx context.Context, doctorId int64, from time.Time, to time.Time) ([]time.Time, error)
+	CountOverlapping(ctx context.Context, doctorId int64, from time.Time, to time.Time) (int, error)
+	UpdateStatus(ctx context.Context, appointmentId int64, status string) error
+	CancelByConsultationId(ctx context.Context, consultationId int64) error
+	LockDue(ctx context.Context, limit int) ([]entities.Appointment, error)
+	LockUnreminded(ctx context.Context, lead time.Duration, limit int) ([]entities.Appointment, error)
+	UpdateReminderSent(ctx context.Context, appointmentId int64) error
+}
+
+type AppointmentRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewAppointmentRepositoryPostgres(aOpts *AppointmentRepoOpts) AppointmentRepository {
+	return &AppointmentRepositoryPostgres{
+		db: aOpts.Db,
+	}
+}
+
+func (r *AppointmentRepositoryPostgres) CreateOne(ctx context.Context, appointment entities.Appointment) (*entities.Appointment, error) {
+	values := []interface{}{}
+	values = append(values, appointment.ConsultationId)
+	values = append(values, appointment.DoctorId)
+	values = append(values, appointment.UserId)
+	values = append(values, appointment.DependentId)
+	values = append(values, appointment.StartAt)
+	values = append(values, appointment.EndAt)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneAppointment, values...).Scan(&appointment.Id, &appointment.Status, &appointment.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneAppointment, values...).Scan(&appointment.Id, &appointment.Status, &appointment.CreatedAt)
+	}
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == constants.ViolatesExclusionConstraintPgErrCode {
+			return nil, custom_errors.BadRequest(err, constants.SlotAlreadyBookedErrMsg)
+		}
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+func (r *AppointmentRepositoryPostgres) FindAllByUserId(ctx context.Context, userId int64, params entities.AppointmentParams) ([]entities.Appointment, error) {
+	return r.findAll(ctx, qFindAppointmentsByUserId, userId, params)
+}
+
+func (r *AppointmentRepositoryPostgres) FindAllByDoctorId(ctx context.Context, doctorId int64, params entities.AppointmentParams) ([]entities.Appointment, error) {
+	return r.findAll(ctx, qFindAppointmentsByDoctorId, doctorId, params)
+}
+
+func (r *AppointmentRepositoryPostgres) findAll(ctx context.Context, query string, ownerId int64, params entities.AppointmentParams) ([]entities.Appointment, error) {
+	appointments := []entities.Appointment{}
+
+	var err error
+	var rows *sql.Rows
+
+	page := params.Limit * (params.Page - 1)
+	values := []interface{}{ownerId, page, params.Limit}
+
+	filter := ""
+	if params.Status != "" {
+		values = append(values, params.Status)
+		filter = fmt.Sprintf("AND a.status = $%d ", len(values))
+	}
+	q := fmt.Sprintf(query, filter)
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, q, values...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, q, values...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := entities.Appointment{}
+		err := rows.Scan(&a.Id, &a.ConsultationId, &a.ConsultationStatus, &a.DoctorId, &a.DoctorName, &a.UserId, &a.UserName, &a.StartAt, &a.EndAt, &a.Status, &a.ReminderSentAt, &a.CreatedAt, &a.Total)
+		if err != nil {
+			return nil, err
+		}
+
+		appointments = append(appointments, a)
+	}
+
+	return appointments, nil
+}
+
+func (r *AppointmentRepositoryPostgres) LockById(ctx context.Context, appointmentId int64) (*entities.Appointment, error) {
+	var row *sql.Row
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, qLockAppointmentById, appointmentId)
+	} else {
+		row = r.db.QueryRowContext(ctx, qLockAppointmentById, appointmentId)
+	}
+
+	a, err := scanAppointment(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// FindBookedStarts lists the start of every appointment that still holds a
+// slot of the doctor between from and to.
+func (r *AppointmentRepositoryPostgres) FindBookedStarts(ctx context.Context, doctorId int64, from time.Time, to time.Time) ([]time.Time, error) {
+	starts := []time.Time{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindBookedAppointmentStarts, doctorId, constants.AppointmentStatusCanceled, from, to)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindBookedAppointmentStarts, doctorId, constants.AppointmentStatusCanceled, from, to)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var start time.Time
+		if err := rows.Scan(&start); err != nil {
+			return nil, err
+		}
+
+		starts = append(starts, start)
+	}
+
+	return starts, nil
+}
+
+func (r *AppointmentRepositoryPostgres) CountOverlapping(ctx context.Context, doctorId int64, from time.Time, to time.Time) (int, error) {
+	var count int
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCountOverlappingAppointments, doctorId, constants.AppointmentStatusCanceled, from, to).Scan(&count)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCountOverlappingAppointments, doctorId, constants.AppointmentStatusCanceled, from, to).Scan(&count)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *AppointmentRepositoryPostgres) UpdateStatus(ctx context.Context, appointmentId int64, status string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpdateAppointmentStatus, appointmentId, status)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpdateAppointmentStatus, appointmentId, status)
+	}
+
+	return err
+}
+
+// CancelByConsultationId frees the slot of a booked appointment whose
+// consultation was dropped. Consultations without an appointment are left
+// alone.
+func (r *AppointmentRepositoryPostgres) CancelByConsultationId(ctx context.Context, consultationId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qCancelAppointmentByConsultationId, consultationId, constants.AppointmentStatusCanceled, constants.AppointmentStatusBooked)
+	} else {
+		_, err = r.db.ExecContext(ctx, qCancelAppointmentByConsultationId, consultationId, constants.AppointmentStatusCanceled, constants.AppointmentStatusBooked)
+	}
+
+	return err
+}
+
+// LockDue claims paid appointments whose slot has started.
+func (r *AppointmentRepositoryPostgres) LockDue(ctx context.Context, limit int) ([]entities.Appointment, error) {
+	return r.lockAll(ctx, qLockDueAppointments, constants.AppointmentStatusBooked, constants.ConsultationStatusScheduled, limit)
+}
+
+// LockUnreminded claims paid appointments starting within lead that nobody
+// has been reminded of yet.
+func (r *AppointmentRepositoryPostgres) LockUnreminded(ctx context.Context, lead time.Duration, limit int) ([]entities.Appointment, error) {
+	return r.lockAll(ctx, qLockUnremindedAppointments, constants.AppointmentStatusBooked, constants.ConsultationStatusScheduled, lead.Seconds(), limit)
+}
+
+func (r *AppointmentRepositoryPostgres) lockAll(ctx context.Context, query string, values ...interface{}) ([]entities.Appointment, error) {
+	appointments := []entities.Appointment{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, values...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, values...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, *a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
+
+func (r *AppointmentRepositoryPostgres) UpdateReminderSent(ctx context.Context, appointmentId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpdateAppointmentReminderSent, appointmentId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpdateAppointmentReminderSent, appointmentId)
+	}
+
+	return err
+}
+
+func scanAppointment(row interface{ Scan(dest ...any) error }) (*entities.Appointment, error) {
+	a := entities.Appointment{}
+
+	err := row.Scan(&a.Id, &a.ConsultationId, &a.ConsultationStatus, &a.DoctorId, &a.DoctorName, &a.DoctorEmail, &a.UserId, &a.UserName, &a.UserEmail, &a.StartAt, &a.EndAt, &a.Status, &a.ReminderSentAt, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
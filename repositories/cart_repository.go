@@ -24,6 +24,9 @@ type CartRepository interface {
 	FindAllUserCartItem(ctx context.Context, userId int64) ([]entities.CartItem, error)
 	FindPharmacyIdByCartId(ctx context.Context, id int64) (*entities.CartItem, error)
 	CartBulkDelete(ctx context.Context, id []int64) error
+	FindCheckoutCartItems(ctx context.Context, userId int64, id []int64) ([]entities.CartItem, error)
+	FindPrescriptionRequirement(ctx context.Context, req entities.CartItem) (*entities.CartItem, error)
+	SumPrescriptionItemQuantity(ctx context.Context, userId int64, prescriptionItemId int64, excludedCartId int64) (int, error)
 }
 
 type CartRepositoryPostgres struct {
@@ -41,9 +44,9 @@ func (r *CartRepositoryPostgres) CreateOneCartItem(ctx context.Context, req enti
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		_, err = tx.ExecContext(ctx, qCreateCart, req.Quantity, req.UserId, req.PharmacyProductId)
+		_, err = tx.ExecContext(ctx, qCreateCart, req.Quantity, req.UserId, req.PharmacyProductId, req.PrescriptionItemId)
 	} else {
-		_, err = r.db.ExecContext(ctx, qCreateCart, req.Quantity, req.UserId, req.PharmacyProductId)
+		_, err = r.db.ExecContext(ctx, qCreateCart, req.Quantity, req.UserId, req.PharmacyProductId, req.PrescriptionItemId)
 	}
 
 	if err != nil {
@@ -58,9 +61,9 @@ func (r *CartRepositoryPostgres) IncreaseCartQuantity(ctx context.Context, req e
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		_, err = tx.ExecContext(ctx, qIncreaseCartQuantity, req.Quantity, req.Id)
+		_, err = tx.ExecContext(ctx, qIncreaseCartQuantity, req.Quantity, req.Id, req.PrescriptionItemId)
 	} else {
-		_, err = r.db.ExecContext(ctx, qIncreaseCartQuantity, req.Quantity, req.Id)
+		_, err = r.db.ExecContext(ctx, qIncreaseCartQuantity, req.Quantity, req.Id, req.PrescriptionItemId)
 	}
 
 	if err != nil {
@@ -168,9 +171,9 @@ func (r *CartRepositoryPostgres) FindPharmacyIdByCartId(ctx context.Context, id
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qFindPharmacyIdByCartId, id).Scan(&c.PharmacyId, &c.Quantity, &c.PharmacyProductId)
+		err = tx.QueryRowContext(ctx, qFindPharmacyIdByCartId, id).Scan(&c.PharmacyId, &c.Quantity, &c.PharmacyProductId, &c.UserId, &c.PrescriptionItemId)
 	} else {
-		err = r.db.QueryRowContext(ctx, qFindPharmacyIdByCartId, id).Scan(&c.PharmacyId, &c.Quantity, &c.PharmacyProductId)
+		err = r.db.QueryRowContext(ctx, qFindPharmacyIdByCartId, id).Scan(&c.PharmacyId, &c.Quantity, &c.PharmacyProductId, &c.UserId, &c.PrescriptionItemId)
 	}
 
 	if err != nil {
@@ -211,3 +214,88 @@ func (r *CartRepositoryPostgres) CartBulkDelete(ctx context.Context, id []int64)
 
 	return nil
 }
+
+func (r *CartRepositoryPostgres) FindCheckoutCartItems(ctx context.Context, userId int64, id []int64) ([]entities.CartItem, error) {
+	carts := []entities.CartItem{}
+
+	valueStrings := make([]string, 0, len(id))
+	valueArgs := make([]interface{}, 0, len(id)+1)
+	valueArgs = append(valueArgs, userId)
+
+	for i, cartId := range id {
+		valueStrings = append(valueStrings, fmt.Sprintf("$%d", i+2))
+		valueArgs = append(valueArgs, cartId)
+	}
+
+	stmt := fmt.Sprintf(qFindCheckoutCartItems, strings.Join(valueStrings, ","))
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, stmt, valueArgs...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, stmt, valueArgs...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cart := entities.CartItem{}
+		err := rows.Scan(&cart.Id, &cart.Quantity, &cart.PharmacyProductId, &cart.PharmacyId, &cart.Price, &cart.Weight, &cart.ProductId, &cart.RequiresPrescription, &cart.PrescriptionItemId, &cart.IsPrescribed, &cart.PrescribedQuantity)
+		if err != nil {
+			return nil, err
+		}
+
+		carts = append(carts, cart)
+	}
+
+	return carts, nil
+}
+
+// FindPrescriptionRequirement reports whether the product behind the cart
+// line needs a prescription, whether the line's prescription item covers it
+// and how much of the product it prescribes.
+func (r *CartRepositoryPostgres) FindPrescriptionRequirement(ctx context.Context, req entities.CartItem) (*entities.CartItem, error) {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindPrescriptionRequirement, req.PharmacyProductId, req.UserId, req.PrescriptionItemId).Scan(&req.RequiresPrescription, &req.IsPrescribed, &req.PrescribedQuantity)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindPrescriptionRequirement, req.PharmacyProductId, req.UserId, req.PrescriptionItemId).Scan(&req.RequiresPrescription, &req.IsPrescribed, &req.PrescribedQuantity)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// SumPrescriptionItemQuantity adds up the quantity the user's cart lines hold
+// against a prescription item, leaving out the line being changed.
+func (r *CartRepositoryPostgres) SumPrescriptionItemQuantity(ctx context.Context, userId int64, prescriptionItemId int64, excludedCartId int64) (int, error) {
+	var quantity int
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qSumPrescriptionItemCartQuantity, userId, prescriptionItemId, excludedCartId).Scan(&quantity)
+	} else {
+		err = r.db.QueryRowContext(ctx, qSumPrescriptionItemCartQuantity, userId, prescriptionItemId, excludedCartId).Scan(&quantity)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return quantity, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 )
@@ -14,7 +15,11 @@ type ChatRepoOpts struct {
 
 type ChatRepository interface {
 	FindAllConsultationChat(ctx context.Context, consultationId int64) ([]entities.Chat, error)
-	CreateOne(ctx context.Context, chat entities.Chat) error
+	CreateOne(ctx context.Context, chat entities.Chat) (*entities.Chat, error)
+	FindAllConsultationChatAfter(ctx context.Context, consultationId int64, lastSeenId int64) ([]entities.Chat, error)
+	UpdateDeliveredUpTo(ctx context.Context, consultationId int64, chatId int64, isFromUser bool) error
+	UpdateReadUpTo(ctx context.Context, consultationId int64, chatId int64, isFromUser bool) error
+	ExistsFromDoctor(ctx context.Context, consultationId int64) (bool, error)
 }
 
 type ChatRepositoryPostgres struct {
@@ -43,7 +48,7 @@ func (r *ChatRepositoryPostgres) FindAllConsultationChat(ctx context.Context, co
 
 	for rows.Next() {
 		chat := entities.Chat{}
-		rows.Scan(&chat.Id, &chat.IsFromUser, &chat.Content, &chat.Type, &chat.CreatedAt)
+		rows.Scan(&chat.Id, &chat.IsFromUser, &chat.Content, &chat.Type, &chat.CreatedAt, &chat.DeliveredAt, &chat.ReadAt)
 		chats = append(chats, chat)
 	}
 
@@ -57,7 +62,7 @@ func (r *ChatRepositoryPostgres) FindAllConsultationChat(ctx context.Context, co
 	return chats, nil
 }
 
-func (r *ChatRepositoryPostgres) CreateOne(ctx context.Context, chat entities.Chat) error {
+func (r *ChatRepositoryPostgres) CreateOne(ctx context.Context, chat entities.Chat) (*entities.Chat, error) {
 	var err error
 
 	values := []interface{}{}
@@ -74,8 +79,87 @@ func (r *ChatRepositoryPostgres) CreateOne(ctx context.Context, chat entities.Ch
 	}
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return &chat, nil
+}
+
+func (r *ChatRepositoryPostgres) FindAllConsultationChatAfter(ctx context.Context, consultationId int64, lastSeenId int64) ([]entities.Chat, error) {
+	chats := []entities.Chat{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindAllChatByConsultationIdAfter, consultationId, lastSeenId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindAllChatByConsultationIdAfter, consultationId, lastSeenId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		chat := entities.Chat{ConsultationId: consultationId}
+		if err := rows.Scan(&chat.Id, &chat.IsFromUser, &chat.Content, &chat.Type, &chat.CreatedAt, &chat.DeliveredAt, &chat.ReadAt); err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return chats, nil
+}
+
+func (r *ChatRepositoryPostgres) UpdateDeliveredUpTo(ctx context.Context, consultationId int64, chatId int64, isFromUser bool) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpdateChatsDeliveredUpTo, consultationId, chatId, isFromUser)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpdateChatsDeliveredUpTo, consultationId, chatId, isFromUser)
+	}
+
+	return err
+}
+
+func (r *ChatRepositoryPostgres) UpdateReadUpTo(ctx context.Context, consultationId int64, chatId int64, isFromUser bool) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpdateChatsReadUpTo, consultationId, chatId, isFromUser)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpdateChatsReadUpTo, consultationId, chatId, isFromUser)
+	}
+
+	return err
+}
+
+// ExistsFromDoctor tells whether the doctor wrote anything in the
+// consultation. System chats do not count.
+func (r *ChatRepositoryPostgres) ExistsFromDoctor(ctx context.Context, consultationId int64) (bool, error) {
+	var exists bool
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qExistsDoctorChat, consultationId, constants.SystemChatType).Scan(&exists)
+	} else {
+		err = r.db.QueryRowContext(ctx, qExistsDoctorChat, consultationId, constants.SystemChatType).Scan(&exists)
+	}
+
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	return exists, nil
 }
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type CheckoutRepoOpts struct {
+	Db *sql.DB
+}
+
+type CheckoutRepository interface {
+	CreateOne(ctx context.Context, checkout entities.Checkout) (*entities.Checkout, error)
+	FindById(ctx context.Context, checkoutId int64) (*entities.Checkout, error)
+	LockById(ctx context.Context, checkoutId int64) (*entities.Checkout, error)
+}
+
+type CheckoutRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewCheckoutRepositoryPostgres(cOpts *CheckoutRepoOpts) CheckoutRepository {
+	return &CheckoutRepositoryPostgres{
+		db: cOpts.Db,
+	}
+}
+
+func (r *CheckoutRepositoryPostgres) CreateOne(ctx context.Context, checkout entities.Checkout) (*entities.Checkout, error) {
+	values := []interface{}{}
+	values = append(values, checkout.CheckoutNumber)
+	values = append(values, checkout.UserId)
+	values = append(values, checkout.TotalPrice)
+	values = append(values, checkout.ShippingFee)
+	values = append(values, checkout.Discount)
+	values = append(values, checkout.PaymentDeadline)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneCheckout, values...).Scan(&checkout.Id, &checkout.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneCheckout, values...).Scan(&checkout.Id, &checkout.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkout, nil
+}
+
+func (r *CheckoutRepositoryPostgres) FindById(ctx context.Context, checkoutId int64) (*entities.Checkout, error) {
+	c := entities.Checkout{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindCheckoutById, checkoutId).Scan(&c.Id, &c.CheckoutNumber, &c.UserId, &c.TotalPrice, &c.ShippingFee, &c.Discount, &c.PaymentDeadline, &c.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindCheckoutById, checkoutId).Scan(&c.Id, &c.CheckoutNumber, &c.UserId, &c.TotalPrice, &c.ShippingFee, &c.Discount, &c.PaymentDeadline, &c.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *CheckoutRepositoryPostgres) LockById(ctx context.Context, checkoutId int64) (*entities.Checkout, error) {
+	c := entities.Checkout{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qLockCheckoutById, checkoutId).Scan(&c.Id, &c.CheckoutNumber, &c.UserId, &c.TotalPrice, &c.ShippingFee, &c.Discount, &c.PaymentDeadline, &c.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qLockCheckoutById, checkoutId).Scan(&c.Id, &c.CheckoutNumber, &c.UserId, &c.TotalPrice, &c.ShippingFee, &c.Discount, &c.PaymentDeadline, &c.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
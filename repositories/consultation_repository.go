@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 )
@@ -20,10 +22,22 @@ type ConsultationRepository interface {
 	FindAllByDoctorId(ctx context.Context, userId int64, params entities.ConsultationParams) ([]entities.Consultation, int, error)
 	CreateOne(ctx context.Context, consultation entities.Consultation) (*entities.Consultation, error)
 	UpdateEndedAt(ctx context.Context, consultationId int64) error
-	CreateCertificate(ctx context.Context, consultationId int64, certificateUrl string) error
-	CreatePrescriptionItems(ctx context.Context, prescriptionData entities.PrescriptionData) error
+	CreateCertificate(ctx context.Context, consultationId int64, certificateUrl string, diagnosis string) error
 	UpdatePrescription(ctx context.Context, consultationId int64, prescriptionUrl string) error
-	FindAllPrescribedProductsById(ctx context.Context, consultationId int64) ([]int64, []int, error)
+	FindAllActive(ctx context.Context) ([]entities.Consultation, error)
+	LockRequestById(ctx context.Context, id int64) (*entities.Consultation, error)
+	LockTimedOutRequests(ctx context.Context, timeout time.Duration, limit int) ([]entities.Consultation, error)
+	LockUnpaidRequests(ctx context.Context, limit int) ([]entities.Consultation, error)
+	LockOverdueConsultations(ctx context.Context, maxDuration time.Duration, limit int) ([]entities.Consultation, error)
+	LockIdleConsultations(ctx context.Context, idleTimeout time.Duration, limit int) ([]entities.Consultation, error)
+	FindWaitingByDoctorId(ctx context.Context, doctorId int64) ([]entities.Consultation, error)
+	FindDoctorLoad(ctx context.Context, doctorId int64) (int, time.Duration, error)
+	UpdateAccepted(ctx context.Context, consultationId int64) error
+	UpdatePaid(ctx context.Context, consultationId int64, status string, paidAt time.Time) error
+	UpdateDoctor(ctx context.Context, consultationId int64, doctorId int64) error
+	UpdateExpired(ctx context.Context, consultationId int64) error
+	CreateDecline(ctx context.Context, consultationId int64, doctorId int64, reason string) error
+	FindAvailableDoctorId(ctx context.Context, consultationId int64, specialistId int64) (int64, error)
 }
 
 type ConsultationRepositoryPostgres struct {
@@ -47,9 +61,9 @@ func (r *ConsultationRepositoryPostgres) FindById(ctx context.Context, id int64)
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qFindConsultationById, id).Scan(&c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt)
+		err = tx.QueryRowContext(ctx, qFindConsultationById, id).Scan(&c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt, &c.Status, &c.QueuedAt, &c.AcceptedAt, &c.Fee, &c.PaymentDeadline, &c.PaidAt, &c.ScheduledAt, &c.DependentId)
 	} else {
-		err = r.db.QueryRowContext(ctx, qFindConsultationById, id).Scan(&c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt)
+		err = r.db.QueryRowContext(ctx, qFindConsultationById, id).Scan(&c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt, &c.Status, &c.QueuedAt, &c.AcceptedAt, &c.Fee, &c.PaymentDeadline, &c.PaidAt, &c.ScheduledAt, &c.DependentId)
 	}
 
 	if err != nil {
@@ -69,6 +83,7 @@ func (r *ConsultationRepositoryPostgres) FindAllByUserId(ctx context.Context, us
 	var qb strings.Builder
 	qb.WriteString(qCountTotalRows)
 	qb.WriteString(qConsultationColl)
+	qb.WriteString(qConsultationUserUnreadColl)
 	qb.WriteString(qConsultationCommands)
 	qb.WriteString(qConsultationByUserIdCommands)
 
@@ -124,7 +139,7 @@ func (r *ConsultationRepositoryPostgres) FindAllByUserId(ctx context.Context, us
 			PatientGender: entities.Gender{},
 		}
 
-		err := rows.Scan(&total_rows, &c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt)
+		err := rows.Scan(&total_rows, &c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt, &c.Status, &c.QueuedAt, &c.AcceptedAt, &c.Fee, &c.PaymentDeadline, &c.PaidAt, &c.DependentId, &c.UnreadCount)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -154,6 +169,7 @@ func (r *ConsultationRepositoryPostgres) FindAllByDoctorId(ctx context.Context,
 	var qb strings.Builder
 	qb.WriteString(qCountTotalRows)
 	qb.WriteString(qConsultationColl)
+	qb.WriteString(qConsultationDoctorUnreadColl)
 	qb.WriteString(qConsultationCommands)
 	qb.WriteString(qConsultationByDoctorIdCommands)
 
@@ -209,7 +225,7 @@ func (r *ConsultationRepositoryPostgres) FindAllByDoctorId(ctx context.Context,
 			PatientGender: entities.Gender{},
 		}
 
-		err := rows.Scan(&total_rows, &c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt)
+		err := rows.Scan(&total_rows, &c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt, &c.Status, &c.QueuedAt, &c.AcceptedAt, &c.Fee, &c.PaymentDeadline, &c.PaidAt, &c.DependentId, &c.UnreadCount)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -237,9 +253,9 @@ func (r *ConsultationRepositoryPostgres) CreateOne(ctx context.Context, consulta
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qCreateOneConsultation, consultation.Doctor.Id, consultation.User.Id, consultation.PatientGender.Id, consultation.PatientName, consultation.PatientBirthDate).Scan(&consultation.Id)
+		err = tx.QueryRowContext(ctx, qCreateOneConsultation, consultation.Doctor.Id, consultation.User.Id, consultation.PatientGender.Id, consultation.PatientName, consultation.PatientBirthDate, consultation.Status, consultation.Fee, consultation.PaymentDeadline, consultation.ScheduledAt, consultation.DependentId).Scan(&consultation.Id, &consultation.QueuedAt)
 	} else {
-		err = r.db.QueryRowContext(ctx, qCreateOneConsultation, consultation.Doctor.Id, consultation.User.Id, consultation.PatientGender.Id, consultation.PatientName, consultation.PatientBirthDate).Scan(&consultation.Id)
+		err = r.db.QueryRowContext(ctx, qCreateOneConsultation, consultation.Doctor.Id, consultation.User.Id, consultation.PatientGender.Id, consultation.PatientName, consultation.PatientBirthDate, consultation.Status, consultation.Fee, consultation.PaymentDeadline, consultation.ScheduledAt, consultation.DependentId).Scan(&consultation.Id, &consultation.QueuedAt)
 	}
 
 	if err != nil {
@@ -280,7 +296,7 @@ func (r *ConsultationRepositoryPostgres) UpdateEndedAt(ctx context.Context, cons
 	return nil
 }
 
-func (r *ConsultationRepositoryPostgres) CreateCertificate(ctx context.Context, consultationId int64, certificateUrl string) error {
+func (r *ConsultationRepositoryPostgres) CreateCertificate(ctx context.Context, consultationId int64, certificateUrl string, diagnosis string) error {
 	var err error
 	var stmt *sql.Stmt
 
@@ -295,7 +311,7 @@ func (r *ConsultationRepositoryPostgres) CreateCertificate(ctx context.Context,
 		return err
 	}
 
-	res, err := stmt.ExecContext(ctx, consultationId, certificateUrl)
+	res, err := stmt.ExecContext(ctx, consultationId, certificateUrl, diagnosis)
 	if err != nil {
 		return err
 	}
@@ -311,99 +327,386 @@ func (r *ConsultationRepositoryPostgres) CreateCertificate(ctx context.Context,
 	return nil
 }
 
-func (r *ConsultationRepositoryPostgres) CreatePrescriptionItems(ctx context.Context, prescriptionData entities.PrescriptionData) error {
+func (r *ConsultationRepositoryPostgres) UpdatePrescription(ctx context.Context, consultationId int64, prescriptionUrl string) error {
 	var err error
+	var stmt *sql.Stmt
 
-	var qb strings.Builder
-	values := []interface{}{}
-	qb.WriteString(qCreatePrescriptionItems)
-	valCount := 0
-
-	for i := 0; i < len(prescriptionData.Products); i++ {
-		values = append(values, prescriptionData.ConsultationId)
-		values = append(values, prescriptionData.Products[i].Id)
-		values = append(values, prescriptionData.Quantities[i])
-		qb.WriteString(fmt.Sprintf("($%d, $%d, $%d)", valCount+1, valCount+2, valCount+3))
-		if i != len(prescriptionData.Products)-1 {
-			qb.WriteString(", ")
+	tx := extractTx(ctx)
+	if tx != nil {
+		stmt, err = tx.PrepareContext(ctx, qUpdatePrescriptionConsultation)
+	} else {
+		stmt, err = r.db.PrepareContext(ctx, qUpdatePrescriptionConsultation)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	res, err := stmt.ExecContext(ctx, consultationId, prescriptionUrl)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *ConsultationRepositoryPostgres) FindAllActive(ctx context.Context) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindAllActiveConsultation, constants.ConsultationStatusUnpaid, constants.ConsultationStatusScheduled)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindAllActiveConsultation, constants.ConsultationStatusUnpaid, constants.ConsultationStatusScheduled)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entities.Consultation
+		if err := rows.Scan(&c.Id, &c.User.Id, &c.Doctor.Id); err != nil {
+			return nil, err
 		}
-		valCount += 3
+		consultations = append(consultations, c)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
+}
+
+func scanConsultationRequest(row interface{ Scan(dest ...any) error }) (*entities.Consultation, error) {
+	c := entities.Consultation{Doctor: entities.Doctor{Specialist: &entities.DoctorSpecialist{}}}
+
+	err := row.Scan(&c.Id, &c.User.Id, &c.Doctor.Id, &c.Doctor.Specialist.Id, &c.Status, &c.QueuedAt, &c.Fee, &c.PaidAt, &c.ScheduledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *ConsultationRepositoryPostgres) LockRequestById(ctx context.Context, id int64) (*entities.Consultation, error) {
+	var row *sql.Row
+
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qb.String(), values...).Scan()
+		row = tx.QueryRowContext(ctx, qLockConsultationRequestById, id)
 	} else {
-		err = r.db.QueryRowContext(ctx, qb.String(), values...).Scan()
+		row = r.db.QueryRowContext(ctx, qLockConsultationRequestById, id)
 	}
 
-	if err != nil && err != sql.ErrNoRows {
-		return err
+	c, err := scanConsultationRequest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
 	}
 
-	return nil
+	return c, nil
 }
 
-func (r *ConsultationRepositoryPostgres) UpdatePrescription(ctx context.Context, consultationId int64, prescriptionUrl string) error {
+// LockTimedOutRequests claims waiting requests nobody answered in time.
+// Rows are skipped when locked, so several instances can sweep side by side.
+func (r *ConsultationRepositoryPostgres) LockTimedOutRequests(ctx context.Context, timeout time.Duration, limit int) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
+
 	var err error
-	var stmt *sql.Stmt
+	var rows *sql.Rows
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		stmt, err = tx.PrepareContext(ctx, qUpdatePrescriptionConsultation)
+		rows, err = tx.QueryContext(ctx, qLockTimedOutConsultationRequests, constants.ConsultationStatusWaiting, timeout.Seconds(), limit)
 	} else {
-		stmt, err = r.db.PrepareContext(ctx, qUpdatePrescriptionConsultation)
+		rows, err = r.db.QueryContext(ctx, qLockTimedOutConsultationRequests, constants.ConsultationStatusWaiting, timeout.Seconds(), limit)
 	}
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanConsultationRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		consultations = append(consultations, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
+}
+
+// LockUnpaidRequests claims requests whose payment deadline has passed.
+func (r *ConsultationRepositoryPostgres) LockUnpaidRequests(ctx context.Context, limit int) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qLockUnpaidConsultationRequests, constants.ConsultationStatusUnpaid, limit)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qLockUnpaidConsultationRequests, constants.ConsultationStatusUnpaid, limit)
 	}
 
-	res, err := stmt.ExecContext(ctx, consultationId, prescriptionUrl)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanConsultationRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		consultations = append(consultations, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
+}
+
+// LockOverdueConsultations claims accepted consultations running longer than
+// maxDuration.
+func (r *ConsultationRepositoryPostgres) LockOverdueConsultations(ctx context.Context, maxDuration time.Duration, limit int) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qLockOverdueConsultations, constants.ConsultationStatusAccepted, maxDuration.Seconds(), limit)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qLockOverdueConsultations, constants.ConsultationStatusAccepted, maxDuration.Seconds(), limit)
 	}
 
-	rows, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if rows == 0 {
-		return custom_errors.NotFound(sql.ErrNoRows)
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanConsultationRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		consultations = append(consultations, *c)
 	}
 
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
+}
+
+// LockIdleConsultations claims accepted consultations without chat activity
+// for longer than idleTimeout.
+func (r *ConsultationRepositoryPostgres) LockIdleConsultations(ctx context.Context, idleTimeout time.Duration, limit int) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qLockIdleConsultations, constants.ConsultationStatusAccepted, idleTimeout.Seconds(), limit)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qLockIdleConsultations, constants.ConsultationStatusAccepted, idleTimeout.Seconds(), limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanConsultationRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		consultations = append(consultations, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
 }
 
-func (r *ConsultationRepositoryPostgres) FindAllPrescribedProductsById(ctx context.Context, consultationId int64) ([]int64, []int, error) {
-	itemIds := []int64{}
-	itemQuantities := []int{}
+func (r *ConsultationRepositoryPostgres) FindWaitingByDoctorId(ctx context.Context, doctorId int64) ([]entities.Consultation, error) {
+	consultations := []entities.Consultation{}
 
 	var err error
 	var rows *sql.Rows
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		rows, err = tx.QueryContext(ctx, qPrescriptionProducts, consultationId)
+		rows, err = tx.QueryContext(ctx, qFindWaitingConsultationsByDoctorId, doctorId, constants.ConsultationStatusWaiting)
 	} else {
-		rows, err = r.db.QueryContext(ctx, qPrescriptionProducts, consultationId)
+		rows, err = r.db.QueryContext(ctx, qFindWaitingConsultationsByDoctorId, doctorId, constants.ConsultationStatusWaiting)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var productId int64
-		var quantity int
-		rows.Scan(&productId, &quantity)
-		itemIds = append(itemIds, productId)
-		itemQuantities = append(itemQuantities, quantity)
+		c := entities.Consultation{
+			Doctor:        entities.Doctor{Specialist: &entities.DoctorSpecialist{}},
+			User:          entities.User{Gender: &entities.Gender{}},
+			PatientGender: entities.Gender{},
+		}
+
+		err := rows.Scan(&c.Id, &c.Doctor.Id, &c.Doctor.Name, &c.Doctor.ProfilePicture, &c.Doctor.IsOnline, &c.Doctor.Specialist.Id, &c.Doctor.Specialist.Name, &c.User.Id, &c.User.Name, &c.User.ProfilePicture, &c.PatientGender.Id, &c.PatientGender.Name, &c.PatientName, &c.PatientBirthDate, &c.CertificateUrl, &c.PrescriptionUrl, &c.EndedAt, &c.CreatedAt, &c.Status, &c.QueuedAt, &c.AcceptedAt, &c.Fee, &c.PaymentDeadline, &c.PaidAt, &c.ScheduledAt, &c.DependentId)
+		if err != nil {
+			return nil, err
+		}
+
+		consultations = append(consultations, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return consultations, nil
+}
+
+// FindDoctorLoad returns how many accepted consultations the doctor still has
+// open and the average length of their recent ones, zero when there is none.
+func (r *ConsultationRepositoryPostgres) FindDoctorLoad(ctx context.Context, doctorId int64) (int, time.Duration, error) {
+	var active int
+	var avgSeconds sql.NullFloat64
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindDoctorConsultationLoad, doctorId, constants.ConsultationStatusAccepted, constants.ConsultationDurationSample).Scan(&active, &avgSeconds)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindDoctorConsultationLoad, doctorId, constants.ConsultationStatusAccepted, constants.ConsultationDurationSample).Scan(&active, &avgSeconds)
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return active, time.Duration(avgSeconds.Float64 * float64(time.Second)), nil
+}
+
+func (r *ConsultationRepositoryPostgres) UpdateAccepted(ctx context.Context, consultationId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qAcceptConsultation, consultationId, constants.ConsultationStatusAccepted)
+	} else {
+		_, err = r.db.ExecContext(ctx, qAcceptConsultation, consultationId, constants.ConsultationStatusAccepted)
+	}
+
+	return err
+}
+
+func (r *ConsultationRepositoryPostgres) UpdatePaid(ctx context.Context, consultationId int64, status string, paidAt time.Time) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qPayConsultation, consultationId, status, paidAt)
+	} else {
+		_, err = r.db.ExecContext(ctx, qPayConsultation, consultationId, status, paidAt)
+	}
+
+	return err
+}
+
+func (r *ConsultationRepositoryPostgres) UpdateDoctor(ctx context.Context, consultationId int64, doctorId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qReassignConsultation, consultationId, doctorId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qReassignConsultation, consultationId, doctorId)
+	}
+
+	return err
+}
+
+func (r *ConsultationRepositoryPostgres) UpdateExpired(ctx context.Context, consultationId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qExpireConsultation, consultationId, constants.ConsultationStatusExpired)
+	} else {
+		_, err = r.db.ExecContext(ctx, qExpireConsultation, consultationId, constants.ConsultationStatusExpired)
+	}
+
+	return err
+}
+
+func (r *ConsultationRepositoryPostgres) CreateDecline(ctx context.Context, consultationId int64, doctorId int64, reason string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qCreateConsultationDecline, consultationId, doctorId, reason)
+	} else {
+		_, err = r.db.ExecContext(ctx, qCreateConsultationDecline, consultationId, doctorId, reason)
+	}
+
+	return err
+}
+
+func (r *ConsultationRepositoryPostgres) FindAvailableDoctorId(ctx context.Context, consultationId int64, specialistId int64) (int64, error) {
+	var doctorId int64
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindAvailableDoctorForConsultation, consultationId, specialistId, constants.ConsultationStatusWaiting).Scan(&doctorId)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindAvailableDoctorForConsultation, consultationId, specialistId, constants.ConsultationStatusWaiting).Scan(&doctorId)
 	}
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil, custom_errors.NotFound(err)
+			return 0, custom_errors.NotFound(err)
 		}
-		return nil, nil, err
+		return 0, err
 	}
 
-	return itemIds, itemQuantities, nil
+	return doctorId, nil
 }
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DependentRepoOpts struct {
+	Db *sql.DB
+}
+
+type DependentRepository interface {
+	FindAllByUserId(ctx context.Context, userId int64) ([]entities.Dependent, error)
+	FindOneById(ctx context.Context, dependentId int64, userId int64) (*entities.Dependent, error)
+	CreateOne(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error)
+	UpdateOne(ctx context.Context, dependent entities.Dependent) error
+	DeleteOne(ctx context.Context, dependentId int64, userId int64) error
+}
+
+type DependentRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewDependentRepositoryPostgres(dOpts *DependentRepoOpts) DependentRepository {
+	return &DependentRepositoryPostgres{
+		db: dOpts.Db,
+	}
+}
+
+func (r *DependentRepositoryPostgres) FindAllByUserId(ctx context.Context, userId int64) ([]entities.Dependent, error) {
+	dependents := []entities.Dependent{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindDependentsByUserId, userId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindDependentsByUserId, userId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d, err := scanDependent(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		dependents = append(dependents, *d)
+	}
+
+	return dependents, nil
+}
+
+// FindOneById only finds dependents registered under userId, so it doubles
+// as the ownership check.
+func (r *DependentRepositoryPostgres) FindOneById(ctx context.Context, dependentId int64, userId int64) (*entities.Dependent, error) {
+	var row *sql.Row
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, qFindDependentById, dependentId, userId)
+	} else {
+		row = r.db.QueryRowContext(ctx, qFindDependentById, dependentId, userId)
+	}
+
+	d, err := scanDependent(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (r *DependentRepositoryPostgres) CreateOne(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error) {
+	values := []interface{}{}
+	values = append(values, dependent.UserId)
+	values = append(values, dependent.Name)
+	values = append(values, dependent.Relationship)
+	values = append(values, dependent.BirthDate)
+	values = append(values, dependent.Gender.Id)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneDependent, values...).Scan(&dependent.Id, &dependent.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneDependent, values...).Scan(&dependent.Id, &dependent.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dependent, nil
+}
+
+func (r *DependentRepositoryPostgres) UpdateOne(ctx context.Context, dependent entities.Dependent) error {
+	values := []interface{}{}
+	values = append(values, dependent.Id)
+	values = append(values, dependent.UserId)
+	values = append(values, dependent.Name)
+	values = append(values, dependent.Relationship)
+	values = append(values, dependent.BirthDate)
+	values = append(values, dependent.Gender.Id)
+
+	return r.execOne(ctx, qUpdateOneDependent, values...)
+}
+
+func (r *DependentRepositoryPostgres) DeleteOne(ctx context.Context, dependentId int64, userId int64) error {
+	return r.execOne(ctx, qDeleteOneDependent, dependentId, userId)
+}
+
+func (r *DependentRepositoryPostgres) execOne(ctx context.Context, query string, values ...interface{}) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, query, values...)
+	} else {
+		res, err = r.db.ExecContext(ctx, query, values...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func scanDependent(row interface{ Scan(dest ...any) error }) (*entities.Dependent, error) {
+	d := entities.Dependent{}
+
+	err := row.Scan(&d.Id, &d.UserId, &d.Name, &d.Relationship, &d.BirthDate, &d.Gender.Id, &d.Gender.Name, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -27,7 +27,12 @@ type DoctorRepository interface {
 	DoctorVerificationToken(ctx context.Context, doctorId int64, token string, exp time.Time) error
 	CreateOneDoctor(ctx context.Context, u entities.Doctor) (*entities.Doctor, error)
 	VerifyDoctor(ctx context.Context, email string) error
-	UpdateIsOnline(ctx context.Context, doctor entities.Doctor) error
+	UpsertPresence(ctx context.Context, doctorId int64, instanceId string) error
+	DeletePresence(ctx context.Context, doctorId int64, instanceId string) error
+	RefreshPresences(ctx context.Context, instanceId string) error
+	DeleteStalePresences(ctx context.Context, ttl time.Duration) error
+	SyncIsOnline(ctx context.Context, doctorId int64) error
+	SyncAllIsOnline(ctx context.Context) error
 	UpdatePassword(ctx context.Context, doctorId int64, newPassword string) error
 	FindPasswordById(ctx context.Context, doctorId int64) (*entities.Doctor, error)
 }
@@ -72,10 +77,10 @@ func (r *DoctorRepositoryPostgres) FindOneById(ctx context.Context, doctorId int
 	tx := extractTx(ctx)
 	if tx != nil {
 		err = tx.QueryRowContext(ctx, qFindDoctorById, doctorId).Scan(&d.Id, &d.Name, &d.Email, &d.Certificate, &d.IsOnline, &d.IsVerified, &d.IsGoogle, &d.Fee, &d.WorkStartYear,
-			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture)
+			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture, &d.Rating, &d.ReviewCount)
 	} else {
 		err = r.db.QueryRowContext(ctx, qFindDoctorById, doctorId).Scan(&d.Id, &d.Name, &d.Email, &d.Certificate, &d.IsOnline, &d.IsVerified, &d.IsGoogle, &d.Fee, &d.WorkStartYear,
-			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture)
+			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture, &d.Rating, &d.ReviewCount)
 	}
 
 	if err != nil {
@@ -225,12 +230,26 @@ func (r *DoctorRepositoryPostgres) FindAll(ctx context.Context, params entities.
 		sbTotalRows.WriteString(fmt.Sprintf(`AND d.is_online = %s `, params.IsOnline))
 	}
 
+	if params.MinRating > 0 {
+		sb.WriteString(`AND d.rating >= `)
+		sb.WriteString(fmt.Sprintf(`$%d `, numberOfArgs))
+		values = append(values, params.MinRating)
+
+		sbTotalRows.WriteString(`AND d.rating >= `)
+		sbTotalRows.WriteString(fmt.Sprintf(`$%d `, numberOfArgs))
+		valuesCountTotal = append(valuesCountTotal, params.MinRating)
+
+		numberOfArgs++
+	}
+
 	var sortBy string
 	switch params.SortBy {
 	case "experience":
 		sortBy = `d.work_start_year `
 	case "fee":
 		sortBy = `d.fee `
+	case "rating":
+		sortBy = `d.rating `
 	default:
 		sortBy = `d.is_online `
 	}
@@ -262,7 +281,7 @@ func (r *DoctorRepositoryPostgres) FindAll(ctx context.Context, params entities.
 	for rows.Next() {
 		d := entities.Doctor{Specialist: &entities.DoctorSpecialist{}}
 		err := rows.Scan(&totalRows, &d.Id, &d.Name, &d.Email, &d.Certificate, &d.IsOnline, &d.IsVerified, &d.IsGoogle, &d.Fee, &d.WorkStartYear,
-			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture)
+			&d.Specialist.Id, &d.Specialist.Name, &d.ProfilePicture, &d.Rating, &d.ReviewCount)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return nil, 0, nil
@@ -354,22 +373,22 @@ func (r *DoctorRepositoryPostgres) VerifyDoctor(ctx context.Context, email strin
 	return nil
 }
 
-func (r *DoctorRepositoryPostgres) UpdateIsOnline(ctx context.Context, doctor entities.Doctor) error {
+func (r *DoctorRepositoryPostgres) UpdatePassword(ctx context.Context, doctorId int64, newPassword string) error {
 	var err error
 	var stmt *sql.Stmt
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		stmt, err = tx.PrepareContext(ctx, qUpdateIsOnlineDoctor)
+		stmt, err = tx.PrepareContext(ctx, qUpadateDoctorPassword)
 	} else {
-		stmt, err = r.db.PrepareContext(ctx, qUpdateIsOnlineDoctor)
+		stmt, err = r.db.PrepareContext(ctx, qUpadateDoctorPassword)
 	}
 
 	if err != nil {
 		return err
 	}
 
-	res, err := stmt.ExecContext(ctx, doctor.Id, doctor.IsOnline)
+	res, err := stmt.ExecContext(ctx, doctorId, newPassword)
 	if err != nil {
 		return err
 	}
@@ -385,57 +404,104 @@ func (r *DoctorRepositoryPostgres) UpdateIsOnline(ctx context.Context, doctor en
 	return nil
 }
 
-func (r *DoctorRepositoryPostgres) UpdatePassword(ctx context.Context, doctorId int64, newPassword string) error {
+func (r *DoctorRepositoryPostgres) FindPasswordById(ctx context.Context, doctorId int64) (*entities.Doctor, error) {
+	d := entities.Doctor{}
+
 	var err error
-	var stmt *sql.Stmt
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		stmt, err = tx.PrepareContext(ctx, qUpadateDoctorPassword)
+		err = tx.QueryRowContext(ctx, qFindDoctorPasswordById, doctorId).Scan(
+			&d.Id, &d.Password)
 	} else {
-		stmt, err = r.db.PrepareContext(ctx, qUpadateDoctorPassword)
+		err = r.db.QueryRowContext(ctx, qFindDoctorPasswordById, doctorId).Scan(
+			&d.Id, &d.Password)
 	}
 
 	if err != nil {
-		return err
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
 	}
 
-	res, err := stmt.ExecContext(ctx, doctorId, newPassword)
-	if err != nil {
-		return err
+	return &d, nil
+}
+
+func (r *DoctorRepositoryPostgres) UpsertPresence(ctx context.Context, doctorId int64, instanceId string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpsertDoctorPresence, doctorId, instanceId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpsertDoctorPresence, doctorId, instanceId)
 	}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return err
+	return err
+}
+
+func (r *DoctorRepositoryPostgres) DeletePresence(ctx context.Context, doctorId int64, instanceId string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qDeleteDoctorPresence, doctorId, instanceId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qDeleteDoctorPresence, doctorId, instanceId)
 	}
-	if rows == 0 {
-		return custom_errors.NotFound(sql.ErrNoRows)
+
+	return err
+}
+
+func (r *DoctorRepositoryPostgres) RefreshPresences(ctx context.Context, instanceId string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qRefreshDoctorPresences, instanceId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qRefreshDoctorPresences, instanceId)
 	}
 
-	return nil
+	return err
 }
 
-func (r *DoctorRepositoryPostgres) FindPasswordById(ctx context.Context, doctorId int64) (*entities.Doctor, error) {
-	d := entities.Doctor{}
+func (r *DoctorRepositoryPostgres) DeleteStalePresences(ctx context.Context, ttl time.Duration) error {
+	var err error
 
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qDeleteStaleDoctorPresences, ttl.Seconds())
+	} else {
+		_, err = r.db.ExecContext(ctx, qDeleteStaleDoctorPresences, ttl.Seconds())
+	}
+
+	return err
+}
+
+func (r *DoctorRepositoryPostgres) SyncIsOnline(ctx context.Context, doctorId int64) error {
 	var err error
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qFindDoctorPasswordById, doctorId).Scan(
-			&d.Id, &d.Password)
+		_, err = tx.ExecContext(ctx, qSyncDoctorIsOnline, doctorId)
 	} else {
-		err = r.db.QueryRowContext(ctx, qFindDoctorPasswordById, doctorId).Scan(
-			&d.Id, &d.Password)
+		_, err = r.db.ExecContext(ctx, qSyncDoctorIsOnline, doctorId)
 	}
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, custom_errors.NotFound(err)
-		}
-		return nil, err
+	return err
+}
+
+func (r *DoctorRepositoryPostgres) SyncAllIsOnline(ctx context.Context) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qSyncAllDoctorIsOnline)
+	} else {
+		_, err = r.db.ExecContext(ctx, qSyncAllDoctorIsOnline)
 	}
 
-	return &d, nil
+	return err
 }
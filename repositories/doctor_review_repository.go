@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DoctorReviewRepoOpts struct {
+	Db *sql.DB
+}
+
+type DoctorReviewRepository interface {
+	CreateOne(ctx context.Context, review entities.DoctorReview) (*entities.DoctorReview, error)
+	FindAllByDoctorId(ctx context.Context, doctorId int64, params entities.DoctorReviewParams) ([]entities.DoctorReview, error)
+	FindAll(ctx context.Context, params entities.DoctorReviewParams) ([]entities.DoctorReview, error)
+	LockById(ctx context.Context, reviewId int64) (*entities.DoctorReview, error)
+	UpdateHidden(ctx context.Context, review entities.DoctorReview) error
+	RefreshDoctorRating(ctx context.Context, doctorId int64) error
+}
+
+type DoctorReviewRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewDoctorReviewRepositoryPostgres(drOpts *DoctorReviewRepoOpts) DoctorReviewRepository {
+	return &DoctorReviewRepositoryPostgres{
+		db: drOpts.Db,
+	}
+}
+
+func (r *DoctorReviewRepositoryPostgres) CreateOne(ctx context.Context, review entities.DoctorReview) (*entities.DoctorReview, error) {
+	values := []interface{}{}
+	values = append(values, review.ConsultationId)
+	values = append(values, review.DoctorId)
+	values = append(values, review.UserId)
+	values = append(values, review.Rating)
+	values = append(values, review.Comment)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneDoctorReview, values...).Scan(&review.Id, &review.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneDoctorReview, values...).Scan(&review.Id, &review.CreatedAt)
+	}
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == constants.ViolatesUniqueConstraintPgErrCode {
+			return nil, custom_errors.BadRequest(err, constants.ReviewAlreadyExistsErrMsg)
+		}
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (r *DoctorReviewRepositoryPostgres) FindAllByDoctorId(ctx context.Context, doctorId int64, params entities.DoctorReviewParams) ([]entities.DoctorReview, error) {
+	page := params.Limit * (params.Page - 1)
+	return r.findAll(ctx, qFindDoctorReviewsByDoctorId, doctorId, page, params.Limit)
+}
+
+func (r *DoctorReviewRepositoryPostgres) FindAll(ctx context.Context, params entities.DoctorReviewParams) ([]entities.DoctorReview, error) {
+	page := params.Limit * (params.Page - 1)
+	values := []interface{}{page, params.Limit}
+
+	filter := ""
+	if params.IsHidden != "" {
+		values = append(values, params.IsHidden == "true")
+		filter = fmt.Sprintf("AND r.is_hidden = $%d ", len(values))
+	}
+
+	return r.findAll(ctx, fmt.Sprintf(qFindAllDoctorReviews, filter), values...)
+}
+
+func (r *DoctorReviewRepositoryPostgres) findAll(ctx context.Context, query string, values ...interface{}) ([]entities.DoctorReview, error) {
+	reviews := []entities.DoctorReview{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, values...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, values...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rv := entities.DoctorReview{}
+		err := rows.Scan(&rv.Id, &rv.ConsultationId, &rv.DoctorId, &rv.UserId, &rv.UserName, &rv.Rating, &rv.Comment, &rv.IsHidden, &rv.HiddenBy, &rv.HiddenReason, &rv.HiddenAt, &rv.CreatedAt, &rv.Total)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, rv)
+	}
+
+	return reviews, nil
+}
+
+func (r *DoctorReviewRepositoryPostgres) LockById(ctx context.Context, reviewId int64) (*entities.DoctorReview, error) {
+	rv := entities.DoctorReview{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qLockDoctorReviewById, reviewId).Scan(&rv.Id, &rv.ConsultationId, &rv.DoctorId, &rv.UserId, &rv.UserName, &rv.Rating, &rv.Comment, &rv.IsHidden, &rv.HiddenBy, &rv.HiddenReason, &rv.HiddenAt, &rv.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qLockDoctorReviewById, reviewId).Scan(&rv.Id, &rv.ConsultationId, &rv.DoctorId, &rv.UserId, &rv.UserName, &rv.Rating, &rv.Comment, &rv.IsHidden, &rv.HiddenBy, &rv.HiddenReason, &rv.HiddenAt, &rv.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &rv, nil
+}
+
+func (r *DoctorReviewRepositoryPostgres) UpdateHidden(ctx context.Context, review entities.DoctorReview) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qUpdateDoctorReviewHidden, review.Id, review.IsHidden, review.HiddenBy, review.HiddenReason, review.HiddenAt)
+	} else {
+		res, err = r.db.ExecContext(ctx, qUpdateDoctorReviewHidden, review.Id, review.IsHidden, review.HiddenBy, review.HiddenReason, review.HiddenAt)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RefreshDoctorRating recomputes the doctor's average rating and review count
+// from the reviews that are currently visible.
+func (r *DoctorReviewRepositoryPostgres) RefreshDoctorRating(ctx context.Context, doctorId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qRefreshDoctorRating, doctorId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qRefreshDoctorRating, doctorId)
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DoctorScheduleRepoOpts struct {
+	Db *sql.DB
+}
+
+type DoctorScheduleRepository interface {
+	FindAllByDoctorId(ctx context.Context, doctorId int64) ([]entities.DoctorSchedule, error)
+	ReplaceAll(ctx context.Context, doctorId int64, schedules []entities.DoctorSchedule) error
+	FindExceptions(ctx context.Context, doctorId int64, from string, to string) ([]entities.DoctorScheduleException, error)
+	CreateException(ctx context.Context, exception entities.DoctorScheduleException) (*entities.DoctorScheduleException, error)
+	DeleteException(ctx context.Context, exceptionId int64, doctorId int64) error
+}
+
+type DoctorScheduleRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewDoctorScheduleRepositoryPostgres(dsOpts *DoctorScheduleRepoOpts) DoctorScheduleRepository {
+	return &DoctorScheduleRepositoryPostgres{
+		db: dsOpts.Db,
+	}
+}
+
+func (r *DoctorScheduleRepositoryPostgres) FindAllByDoctorId(ctx context.Context, doctorId int64) ([]entities.DoctorSchedule, error) {
+	schedules := []entities.DoctorSchedule{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindDoctorSchedulesByDoctorId, doctorId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindDoctorSchedulesByDoctorId, doctorId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := entities.DoctorSchedule{}
+		err := rows.Scan(&s.Id, &s.DoctorId, &s.DayOfWeek, &s.StartTime, &s.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
+
+// ReplaceAll swaps the doctor's weekly schedule for schedules. The caller runs
+// it in a transaction so readers never see an empty week.
+func (r *DoctorScheduleRepositoryPostgres) ReplaceAll(ctx context.Context, doctorId int64, schedules []entities.DoctorSchedule) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qDeleteDoctorSchedules, doctorId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qDeleteDoctorSchedules, doctorId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(schedules))
+	valueArgs := make([]interface{}, 0, len(schedules)*4)
+
+	for i, s := range schedules {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d::time, $%d::time)", i*4+1, i*4+2, i*4+3, i*4+4))
+		valueArgs = append(valueArgs, doctorId)
+		valueArgs = append(valueArgs, s.DayOfWeek)
+		valueArgs = append(valueArgs, s.StartTime)
+		valueArgs = append(valueArgs, s.EndTime)
+	}
+	stmt := fmt.Sprintf(qCreateDoctorSchedules, strings.Join(valueStrings, ","))
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, stmt, valueArgs...)
+	} else {
+		_, err = r.db.ExecContext(ctx, stmt, valueArgs...)
+	}
+
+	return err
+}
+
+// FindExceptions returns the doctor's exceptions dated from from to to,
+// inclusive. Both dates use constants.ScheduleDateLayout.
+func (r *DoctorScheduleRepositoryPostgres) FindExceptions(ctx context.Context, doctorId int64, from string, to string) ([]entities.DoctorScheduleException, error) {
+	exceptions := []entities.DoctorScheduleException{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindDoctorScheduleExceptions, doctorId, from, to)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindDoctorScheduleExceptions, doctorId, from, to)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := entities.DoctorScheduleException{}
+		err := rows.Scan(&e.Id, &e.DoctorId, &e.Date, &e.StartTime, &e.EndTime, &e.Reason, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		exceptions = append(exceptions, e)
+	}
+
+	return exceptions, nil
+}
+
+func (r *DoctorScheduleRepositoryPostgres) CreateException(ctx context.Context, exception entities.DoctorScheduleException) (*entities.DoctorScheduleException, error) {
+	values := []interface{}{}
+	values = append(values, exception.DoctorId)
+	values = append(values, exception.Date)
+	values = append(values, exception.StartTime)
+	values = append(values, exception.EndTime)
+	values = append(values, exception.Reason)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateDoctorScheduleException, values...).Scan(&exception.Id, &exception.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateDoctorScheduleException, values...).Scan(&exception.Id, &exception.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &exception, nil
+}
+
+func (r *DoctorScheduleRepositoryPostgres) DeleteException(ctx context.Context, exceptionId int64, doctorId int64) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qDeleteDoctorScheduleException, exceptionId, doctorId)
+	} else {
+		res, err = r.db.ExecContext(ctx, qDeleteDoctorScheduleException, exceptionId, doctorId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
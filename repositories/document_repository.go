@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type DocumentRepoOpts struct {
+	Db *sql.DB
+}
+
+type DocumentRepository interface {
+	CreateOne(ctx context.Context, document entities.Document) (*entities.Document, error)
+	RevokeByConsultationId(ctx context.Context, consultationId int64, documentType string) error
+	FindByNumber(ctx context.Context, number string) (*entities.Document, error)
+}
+
+type DocumentRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewDocumentRepositoryPostgres(dOpts *DocumentRepoOpts) DocumentRepository {
+	return &DocumentRepositoryPostgres{
+		db: dOpts.Db,
+	}
+}
+
+func (r *DocumentRepositoryPostgres) CreateOne(ctx context.Context, document entities.Document) (*entities.Document, error) {
+	values := []interface{}{}
+	values = append(values, document.Number)
+	values = append(values, document.Type)
+	values = append(values, document.ConsultationId)
+	values = append(values, document.Doctor.Id)
+	values = append(values, document.PatientName)
+	values = append(values, document.ValidFrom)
+	values = append(values, document.ValidUntil)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneDocument, values...).Scan(&document.Id, &document.IssuedAt, &document.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneDocument, values...).Scan(&document.Id, &document.IssuedAt, &document.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// RevokeByConsultationId revokes the documents of documentType already issued
+// for the consultation, so only the latest one verifies as valid.
+func (r *DocumentRepositoryPostgres) RevokeByConsultationId(ctx context.Context, consultationId int64, documentType string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qRevokeDocumentsByConsultationId, consultationId, documentType)
+	} else {
+		_, err = r.db.ExecContext(ctx, qRevokeDocumentsByConsultationId, consultationId, documentType)
+	}
+
+	return err
+}
+
+func (r *DocumentRepositoryPostgres) FindByNumber(ctx context.Context, number string) (*entities.Document, error) {
+	d := entities.Document{
+		Doctor: entities.Doctor{Specialist: &entities.DoctorSpecialist{}},
+	}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindDocumentByNumber, number).Scan(&d.Id, &d.Number, &d.Type, &d.ConsultationId, &d.Doctor.Id, &d.Doctor.Name, &d.Doctor.Specialist.Id, &d.Doctor.Specialist.Name, &d.PatientName, &d.ValidFrom, &d.ValidUntil, &d.IssuedAt, &d.RevokedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindDocumentByNumber, number).Scan(&d.Id, &d.Number, &d.Type, &d.ConsultationId, &d.Doctor.Id, &d.Doctor.Name, &d.Doctor.Specialist.Id, &d.Doctor.Specialist.Name, &d.PatientName, &d.ValidFrom, &d.ValidUntil, &d.IssuedAt, &d.RevokedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type MedicalRecordRepoOpts struct {
+	Db *sql.DB
+}
+
+type MedicalRecordRepository interface {
+	FindConditionsByUserId(ctx context.Context, userId int64, dependentId int64) ([]entities.MedicalCondition, error)
+	CreateCondition(ctx context.Context, condition entities.MedicalCondition) (*entities.MedicalCondition, error)
+	DeleteCondition(ctx context.Context, conditionId int64, userId int64) error
+	FindEntriesByUserId(ctx context.Context, userId int64, dependentId int64) ([]entities.MedicalRecordEntry, error)
+}
+
+type MedicalRecordRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewMedicalRecordRepositoryPostgres(mrOpts *MedicalRecordRepoOpts) MedicalRecordRepository {
+	return &MedicalRecordRepositoryPostgres{
+		db: mrOpts.Db,
+	}
+}
+
+func (r *MedicalRecordRepositoryPostgres) FindConditionsByUserId(ctx context.Context, userId int64, dependentId int64) ([]entities.MedicalCondition, error) {
+	conditions := []entities.MedicalCondition{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindMedicalConditionsByUserId, userId, dependentId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindMedicalConditionsByUserId, userId, dependentId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := entities.MedicalCondition{}
+		err := rows.Scan(&c.Id, &c.UserId, &c.Type, &c.Name, &c.Notes, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, c)
+	}
+
+	return conditions, nil
+}
+
+func (r *MedicalRecordRepositoryPostgres) CreateCondition(ctx context.Context, condition entities.MedicalCondition) (*entities.MedicalCondition, error) {
+	values := []interface{}{}
+	values = append(values, condition.UserId)
+	values = append(values, condition.DependentId)
+	values = append(values, condition.Type)
+	values = append(values, condition.Name)
+	values = append(values, condition.Notes)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneMedicalCondition, values...).Scan(&condition.Id, &condition.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneMedicalCondition, values...).Scan(&condition.Id, &condition.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &condition, nil
+}
+
+func (r *MedicalRecordRepositoryPostgres) DeleteCondition(ctx context.Context, conditionId int64, userId int64) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qDeleteMedicalCondition, conditionId, userId)
+	} else {
+		res, err = r.db.ExecContext(ctx, qDeleteMedicalCondition, conditionId, userId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// FindEntriesByUserId lists the ended consultations of the user, or of their
+// dependent when dependentId is not 0, newest first, each with the diagnosis
+// and the items prescribed in it.
+func (r *MedicalRecordRepositoryPostgres) FindEntriesByUserId(ctx context.Context, userId int64, dependentId int64) ([]entities.MedicalRecordEntry, error) {
+	entries := []entities.MedicalRecordEntry{}
+
+	// fetched first, so the rows below are the only ones open when this runs
+	// inside a transaction
+	items, err := r.findPrescriptionItems(ctx, userId, dependentId)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindMedicalRecordEntriesByUserId, userId, dependentId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindMedicalRecordEntriesByUserId, userId, dependentId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := entities.MedicalRecordEntry{PrescriptionItems: []entities.PrescriptionItem{}}
+		err := rows.Scan(&e.ConsultationId, &e.DoctorName, &e.DoctorSpecialist, &e.PatientName, &e.Diagnosis, &e.ConsultedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if consultationItems, ok := items[e.ConsultationId]; ok {
+			e.PrescriptionItems = consultationItems
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (r *MedicalRecordRepositoryPostgres) findPrescriptionItems(ctx context.Context, userId int64, dependentId int64) (map[int64][]entities.PrescriptionItem, error) {
+	items := map[int64][]entities.PrescriptionItem{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindMedicalRecordPrescriptionItemsByUserId, userId, dependentId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindMedicalRecordPrescriptionItemsByUserId, userId, dependentId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var consultationId int64
+		i := entities.PrescriptionItem{}
+		err := rows.Scan(&consultationId, &i.Id, &i.Product.Id, &i.Product.Name, &i.Product.SellingUnit, &i.Quantity, &i.Dosage, &i.Frequency, &i.Duration, &i.Route, &i.Notes)
+		if err != nil {
+			return nil, err
+		}
+
+		items[consultationId] = append(items[consultationId], i)
+	}
+
+	return items, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
@@ -17,7 +18,7 @@ type OrderRepoOpts struct {
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, req entities.Order) (*entities.Order, error)
-	CreateOrderItems(ctx context.Context, cartId []int64, orderId int64) error
+	CreateOrderItems(ctx context.Context, cartItems []entities.CartItem, orderId int64) error
 	FindAllOrdersByUserId(ctx context.Context, userId int64, params entities.OrderParams) ([]entities.Order, error)
 	GetOrderItems(ctx context.Context, orderId int64) ([]dtos.OrderItem, error)
 	FindAllOrdersByPharmacyManagerId(ctx context.Context, pharmacyManagerId int64, params entities.OrderParams) ([]entities.Order, error)
@@ -26,6 +27,11 @@ type OrderRepository interface {
 	GetOrder(ctx context.Context, orderId int64) (*entities.Order, error)
 	UploadPaymentProof(ctx context.Context, req entities.UploadPaymentProof) error
 	FindOrderDetail(ctx context.Context, orderId int64) (*entities.Order, error)
+	LockExpiredOrders(ctx context.Context, limit int) ([]entities.Order, error)
+	LockShippedOrders(ctx context.Context, days int, limit int) ([]entities.Order, error)
+	FindOrdersByCheckoutId(ctx context.Context, checkoutId int64) ([]entities.Order, error)
+	FindOrderInvoice(ctx context.Context, orderId int64) (*entities.Order, error)
+	UpdateOrderInvoice(ctx context.Context, orderId int64, invoiceUrl string, cacheKey string) error
 }
 
 type OrderRepositoryPostgres struct {
@@ -45,9 +51,9 @@ func (r *OrderRepositoryPostgres) CreateOrder(ctx context.Context, req entities.
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qCreateOrder, req.OrderNumber, req.TotalPrice, req.PaymentDeadline, req.ShippingFee, req.ShippingMethod, req.UserAddressId, req.OrderStatus, req.PharmacyId).Scan(&o.Id, &o.PaymentDeadline)
+		err = tx.QueryRowContext(ctx, qCreateOrder, req.OrderNumber, req.TotalPrice, req.PaymentDeadline, req.ShippingFee, req.ShippingMethod, req.UserAddressId, req.OrderStatus, req.PharmacyId, req.CheckoutId, req.Discount).Scan(&o.Id, &o.PaymentDeadline)
 	} else {
-		err = r.db.QueryRowContext(ctx, qCreateOrder, req.OrderNumber, req.TotalPrice, req.PaymentDeadline, req.ShippingFee, req.ShippingMethod, req.UserAddressId, req.OrderStatus, req.PharmacyId).Scan(&o.Id, &o.PaymentDeadline)
+		err = r.db.QueryRowContext(ctx, qCreateOrder, req.OrderNumber, req.TotalPrice, req.PaymentDeadline, req.ShippingFee, req.ShippingMethod, req.UserAddressId, req.OrderStatus, req.PharmacyId, req.CheckoutId, req.Discount).Scan(&o.Id, &o.PaymentDeadline)
 	}
 
 	if err != nil {
@@ -57,16 +63,19 @@ func (r *OrderRepositoryPostgres) CreateOrder(ctx context.Context, req entities.
 	return &o, nil
 }
 
-func (r *OrderRepositoryPostgres) CreateOrderItems(ctx context.Context, cartId []int64, orderId int64) error {
-	valueStrings := make([]string, 0, len(cartId))
-	valueArgs := make([]interface{}, 0, len(cartId)*3)
+func (r *OrderRepositoryPostgres) CreateOrderItems(ctx context.Context, cartItems []entities.CartItem, orderId int64) error {
+	valueStrings := make([]string, 0, len(cartItems))
+	valueArgs := make([]interface{}, 0, len(cartItems)*6)
 	i := 0
 
-	for _, id := range cartId {
-		valueStrings = append(valueStrings, fmt.Sprintf("((select quantity from cart_items where id = $%d), $%d, (select pharmacy_product_id from cart_items where id = $%d))", i*3+1, i*3+2, i*3+3))
-		valueArgs = append(valueArgs, id)
+	for _, item := range cartItems {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+		valueArgs = append(valueArgs, item.Quantity)
 		valueArgs = append(valueArgs, orderId)
-		valueArgs = append(valueArgs, id)
+		valueArgs = append(valueArgs, item.PharmacyProductId)
+		valueArgs = append(valueArgs, item.Price)
+		valueArgs = append(valueArgs, item.Discount)
+		valueArgs = append(valueArgs, item.PrescriptionItemId)
 		i++
 	}
 	stmt := fmt.Sprintf(qCreateOrderItem, strings.Join(valueStrings, ","))
@@ -109,7 +118,7 @@ func (r *OrderRepositoryPostgres) FindAllOrdersByUserId(ctx context.Context, use
 
 	for rows.Next() {
 		order := entities.Order{}
-		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.Total)
+		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.Discount, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.Total)
 		if err != nil {
 			return nil, err
 		}
@@ -140,7 +149,7 @@ func (r *OrderRepositoryPostgres) GetOrderItems(ctx context.Context, orderId int
 
 	for rows.Next() {
 		orderItem := dtos.OrderItem{}
-		err := rows.Scan(&orderItem.Name, &orderItem.SellingUnit, &orderItem.Price, &orderItem.Quantity, &orderItem.ProductPicture, &orderItem.PharmacyProductId)
+		err := rows.Scan(&orderItem.Name, &orderItem.SellingUnit, &orderItem.Price, &orderItem.Discount, &orderItem.Quantity, &orderItem.ProductPicture, &orderItem.PharmacyProductId)
 		if err != nil {
 			return nil, err
 		}
@@ -181,7 +190,7 @@ func (r *OrderRepositoryPostgres) FindAllOrdersByPharmacyManagerId(ctx context.C
 
 	for rows.Next() {
 		order := entities.Order{}
-		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.PharmacyAddress.City, &order.PharmacyAddress.Province, &order.PharmacyAddress.Address, &order.PharmacyAddress.District, &order.PharmacyAddress.SubDistrict, &order.PharmacyAddress.PostalCode, &order.Total, &order.UserName, &order.UserEmail)
+		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.Discount, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.PharmacyAddress.City, &order.PharmacyAddress.Province, &order.PharmacyAddress.Address, &order.PharmacyAddress.District, &order.PharmacyAddress.SubDistrict, &order.PharmacyAddress.PostalCode, &order.Total, &order.UserName, &order.UserEmail)
 		if err != nil {
 			return nil, err
 		}
@@ -222,7 +231,7 @@ func (r *OrderRepositoryPostgres) FindAllOrdersByAdmin(ctx context.Context, para
 
 	for rows.Next() {
 		order := entities.Order{}
-		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.PharmacyAddress.City, &order.PharmacyAddress.Province, &order.PharmacyAddress.Address, &order.PharmacyAddress.District, &order.PharmacyAddress.SubDistrict, &order.PharmacyAddress.PostalCode, &order.Total, &order.UserName, &order.UserEmail)
+		err := rows.Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.Discount, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode, &order.PharmacyAddress.City, &order.PharmacyAddress.Province, &order.PharmacyAddress.Address, &order.PharmacyAddress.District, &order.PharmacyAddress.SubDistrict, &order.PharmacyAddress.PostalCode, &order.Total, &order.UserName, &order.UserEmail)
 		if err != nil {
 			return nil, err
 		}
@@ -242,7 +251,7 @@ func (r *OrderRepositoryPostgres) UpdateOrderStatus(ctx context.Context, req ent
 		stmnt := fmt.Sprintf("AND pharmacies.id = orders.pharmacy_id AND pharmacies.pharmacy_manager_id = %d", req.PharmacyManagerId)
 		q = fmt.Sprintf(qUpdateOrderStatus, stmnt)
 	} else if req.UserId != 0 {
-		stmnt := fmt.Sprintf("AND user_addresses.user_id = orders.user_address_id AND user_addresses.user_id = %d", req.UserId)
+		stmnt := fmt.Sprintf("AND user_addresses.id = orders.user_address_id AND user_addresses.user_id = %d", req.UserId)
 		q = fmt.Sprintf(qUpdateOrderStatus, stmnt)
 	} else {
 		q = fmt.Sprintf(qUpdateOrderStatus, "")
@@ -277,9 +286,9 @@ func (r *OrderRepositoryPostgres) GetOrder(ctx context.Context, orderId int64) (
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qFindOrderStatus, orderId).Scan(&o.OrderStatus, &o.PaymentProof, &o.PharmacyId)
+		err = tx.QueryRowContext(ctx, qFindOrderStatus, orderId).Scan(&o.OrderStatus, &o.PaymentProof, &o.PharmacyId, &o.Id, &o.OrderNumber, &o.TotalPrice, &o.ShippingFee, &o.PaymentDeadline, &o.UserId, &o.PharmacyManagerId, &o.CheckoutId, &o.Discount)
 	} else {
-		err = r.db.QueryRowContext(ctx, qFindOrderStatus, orderId).Scan(&o.OrderStatus, &o.PaymentProof, &o.PharmacyId)
+		err = r.db.QueryRowContext(ctx, qFindOrderStatus, orderId).Scan(&o.OrderStatus, &o.PaymentProof, &o.PharmacyId, &o.Id, &o.OrderNumber, &o.TotalPrice, &o.ShippingFee, &o.PaymentDeadline, &o.UserId, &o.PharmacyManagerId, &o.CheckoutId, &o.Discount)
 	}
 
 	if err != nil {
@@ -325,9 +334,9 @@ func (r *OrderRepositoryPostgres) FindOrderDetail(ctx context.Context, orderId i
 
 	tx := extractTx(ctx)
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, qFindOrderDetail, orderId).Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode)
+		err = tx.QueryRowContext(ctx, qFindOrderDetail, orderId).Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.Discount, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode)
 	} else {
-		err = r.db.QueryRowContext(ctx, qFindOrderDetail, orderId).Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode)
+		err = r.db.QueryRowContext(ctx, qFindOrderDetail, orderId).Scan(&order.Id, &order.OrderNumber, &order.TotalPrice, &order.PaymentProof, &order.PaymentDeadline, &order.ShippingFee, &order.Discount, &order.ShippingMethod, &order.OrderStatus, &order.PharmacyName, &order.UserAddress.City, &order.UserAddress.Province, &order.UserAddress.Address, &order.UserAddress.District, &order.UserAddress.SubDistrict, &order.UserAddress.PostalCode)
 	}
 
 	if err != nil {
@@ -336,3 +345,135 @@ func (r *OrderRepositoryPostgres) FindOrderDetail(ctx context.Context, orderId i
 
 	return &order, nil
 }
+
+func (r *OrderRepositoryPostgres) LockExpiredOrders(ctx context.Context, limit int) ([]entities.Order, error) {
+	orders := []entities.Order{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qLockExpiredOrders, constants.Pending, limit)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qLockExpiredOrders, constants.Pending, limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order := entities.Order{}
+		err := rows.Scan(&order.Id, &order.PaymentDeadline, &order.CheckoutId)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepositoryPostgres) LockShippedOrders(ctx context.Context, days int, limit int) ([]entities.Order, error) {
+	orders := []entities.Order{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qLockShippedOrders, constants.Shipped, constants.Delivered, days, limit)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qLockShippedOrders, constants.Shipped, constants.Delivered, days, limit)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order := entities.Order{}
+		err := rows.Scan(&order.Id, &order.OrderStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepositoryPostgres) FindOrdersByCheckoutId(ctx context.Context, checkoutId int64) ([]entities.Order, error) {
+	orders := []entities.Order{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindOrdersByCheckoutId, checkoutId, constants.Canceled)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindOrdersByCheckoutId, checkoutId, constants.Canceled)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order := entities.Order{}
+		err := rows.Scan(&order.Id, &order.OrderStatus, &order.PharmacyId, &order.UserId, &order.TotalPrice, &order.ShippingFee, &order.Discount, &order.CanceledAt)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepositoryPostgres) FindOrderInvoice(ctx context.Context, orderId int64) (*entities.Order, error) {
+	o := entities.Order{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindOrderInvoice, orderId).Scan(&o.Id, &o.OrderNumber, &o.TotalPrice, &o.PaymentProof, &o.ShippingFee, &o.Discount, &o.ShippingMethod, &o.OrderStatus, &o.CreatedAt, &o.InvoiceUrl, &o.InvoiceCacheKey, &o.PharmacyName, &o.PharmacyManagerId, &o.PharmacyAddress.City, &o.PharmacyAddress.Province, &o.PharmacyAddress.Address, &o.PharmacyAddress.District, &o.PharmacyAddress.SubDistrict, &o.PharmacyAddress.PostalCode, &o.UserId, &o.UserAddress.City, &o.UserAddress.Province, &o.UserAddress.Address, &o.UserAddress.District, &o.UserAddress.SubDistrict, &o.UserAddress.PostalCode, &o.UserName, &o.UserEmail)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindOrderInvoice, orderId).Scan(&o.Id, &o.OrderNumber, &o.TotalPrice, &o.PaymentProof, &o.ShippingFee, &o.Discount, &o.ShippingMethod, &o.OrderStatus, &o.CreatedAt, &o.InvoiceUrl, &o.InvoiceCacheKey, &o.PharmacyName, &o.PharmacyManagerId, &o.PharmacyAddress.City, &o.PharmacyAddress.Province, &o.PharmacyAddress.Address, &o.PharmacyAddress.District, &o.PharmacyAddress.SubDistrict, &o.PharmacyAddress.PostalCode, &o.UserId, &o.UserAddress.City, &o.UserAddress.Province, &o.UserAddress.Address, &o.UserAddress.District, &o.UserAddress.SubDistrict, &o.UserAddress.PostalCode, &o.UserName, &o.UserEmail)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func (r *OrderRepositoryPostgres) UpdateOrderInvoice(ctx context.Context, orderId int64, invoiceUrl string, cacheKey string) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qUpdateOrderInvoice, orderId, invoiceUrl, cacheKey)
+	} else {
+		_, err = r.db.ExecContext(ctx, qUpdateOrderInvoice, orderId, invoiceUrl, cacheKey)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
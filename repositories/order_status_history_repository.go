@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type OrderStatusHistoryRepoOpts struct {
+	Db *sql.DB
+}
+
+type OrderStatusHistoryRepository interface {
+	CreateOne(ctx context.Context, history entities.OrderStatusHistory) error
+	FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.OrderStatusHistory, error)
+}
+
+type OrderStatusHistoryRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewOrderStatusHistoryRepositoryPostgres(oshOpts *OrderStatusHistoryRepoOpts) OrderStatusHistoryRepository {
+	return &OrderStatusHistoryRepositoryPostgres{
+		db: oshOpts.Db,
+	}
+}
+
+func (r *OrderStatusHistoryRepositoryPostgres) CreateOne(ctx context.Context, history entities.OrderStatusHistory) error {
+	values := []interface{}{}
+	values = append(values, history.OrderId)
+	values = append(values, history.FromStatus)
+	values = append(values, history.ToStatus)
+	values = append(values, history.ActorRole)
+	values = append(values, history.ActorId)
+	values = append(values, history.Reason)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qCreateOneOrderStatusHistory, values...)
+	} else {
+		_, err = r.db.ExecContext(ctx, qCreateOneOrderStatusHistory, values...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *OrderStatusHistoryRepositoryPostgres) FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.OrderStatusHistory, error) {
+	histories := []entities.OrderStatusHistory{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindOrderStatusHistoriesByOrderId, orderId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindOrderStatusHistoriesByOrderId, orderId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h := entities.OrderStatusHistory{}
+		err := rows.Scan(&h.Id, &h.OrderId, &h.FromStatus, &h.ToStatus, &h.ActorRole, &h.ActorId, &h.Reason, &h.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		histories = append(histories, h)
+	}
+
+	return histories, nil
+}
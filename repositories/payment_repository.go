@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type PaymentRepoOpts struct {
+	Db *sql.DB
+}
+
+type PaymentRepository interface {
+	CreateOne(ctx context.Context, payment entities.Payment) (*entities.Payment, error)
+	FindActiveByCheckoutId(ctx context.Context, checkoutId int64) (*entities.Payment, error)
+	FindActiveByConsultationId(ctx context.Context, consultationId int64) (*entities.Payment, error)
+	LockByExternalId(ctx context.Context, provider string, externalId string) (*entities.Payment, error)
+	UpdateStatus(ctx context.Context, paymentId int64, status string, paidAt sql.NullTime) error
+	ExpirePendingByCheckoutId(ctx context.Context, checkoutId int64) error
+	CreateCallback(ctx context.Context, callback entities.PaymentCallback) (bool, error)
+}
+
+type PaymentRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewPaymentRepositoryPostgres(pOpts *PaymentRepoOpts) PaymentRepository {
+	return &PaymentRepositoryPostgres{
+		db: pOpts.Db,
+	}
+}
+
+func (r *PaymentRepositoryPostgres) CreateOne(ctx context.Context, payment entities.Payment) (*entities.Payment, error) {
+	values := []interface{}{}
+	values = append(values, payment.CheckoutId)
+	values = append(values, payment.ConsultationId)
+	values = append(values, payment.Provider)
+	values = append(values, payment.Method)
+	values = append(values, payment.Channel)
+	values = append(values, payment.ExternalId)
+	values = append(values, payment.AccountNumber)
+	values = append(values, payment.CheckoutUrl)
+	values = append(values, payment.Amount)
+	values = append(values, payment.Status)
+	values = append(values, payment.ExpiredAt)
+	values = append(values, payment.CreatedAt)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOnePayment, values...).Scan(&payment.Id)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOnePayment, values...).Scan(&payment.Id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (r *PaymentRepositoryPostgres) FindActiveByCheckoutId(ctx context.Context, checkoutId int64) (*entities.Payment, error) {
+	p := entities.Payment{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindActivePaymentByCheckoutId, checkoutId, constants.PaymentStatusPending, constants.PaymentStatusPaid).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindActivePaymentByCheckoutId, checkoutId, constants.PaymentStatusPending, constants.PaymentStatusPaid).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *PaymentRepositoryPostgres) FindActiveByConsultationId(ctx context.Context, consultationId int64) (*entities.Payment, error) {
+	p := entities.Payment{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qFindActivePaymentByConsultationId, consultationId, constants.PaymentStatusPending, constants.PaymentStatusPaid).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qFindActivePaymentByConsultationId, consultationId, constants.PaymentStatusPending, constants.PaymentStatusPaid).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *PaymentRepositoryPostgres) LockByExternalId(ctx context.Context, provider string, externalId string) (*entities.Payment, error) {
+	p := entities.Payment{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qLockPaymentByExternalId, provider, externalId).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qLockPaymentByExternalId, provider, externalId).Scan(&p.Id, &p.CheckoutId, &p.ConsultationId, &p.Provider, &p.Method, &p.Channel, &p.ExternalId, &p.AccountNumber, &p.CheckoutUrl, &p.Amount, &p.Status, &p.ExpiredAt, &p.PaidAt, &p.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (r *PaymentRepositoryPostgres) UpdateStatus(ctx context.Context, paymentId int64, status string, paidAt sql.NullTime) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qUpdatePaymentStatus, paymentId, status, paidAt)
+	} else {
+		res, err = r.db.ExecContext(ctx, qUpdatePaymentStatus, paymentId, status, paidAt)
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (r *PaymentRepositoryPostgres) ExpirePendingByCheckoutId(ctx context.Context, checkoutId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qExpirePendingPaymentsByCheckoutId, checkoutId, constants.PaymentStatusExpired, constants.PaymentStatusPending)
+	} else {
+		_, err = r.db.ExecContext(ctx, qExpirePendingPaymentsByCheckoutId, checkoutId, constants.PaymentStatusExpired, constants.PaymentStatusPending)
+	}
+
+	return err
+}
+
+// CreateCallback records a gateway callback and reports whether it was seen
+// for the first time. Replays of the same callback id return false.
+func (r *PaymentRepositoryPostgres) CreateCallback(ctx context.Context, callback entities.PaymentCallback) (bool, error) {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreatePaymentCallback, callback.Provider, callback.CallbackId, callback.PaymentId, callback.Payload).Scan(&callback.Id)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreatePaymentCallback, callback.Provider, callback.CallbackId, callback.PaymentId, callback.Payload).Scan(&callback.Id)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
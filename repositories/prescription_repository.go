@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type PrescriptionRepoOpts struct {
+	Db *sql.DB
+}
+
+type PrescriptionRepository interface {
+	UpsertOne(ctx context.Context, prescription entities.Prescription) (*entities.Prescription, error)
+	ReplaceItems(ctx context.Context, consultationId int64, items []entities.PrescriptionItem) error
+	FindByConsultationId(ctx context.Context, consultationId int64) (*entities.Prescription, error)
+	UpdateFillCountByItemIds(ctx context.Context, itemIds []int64) error
+	RestoreFillCountByOrderId(ctx context.Context, orderId int64) error
+}
+
+type PrescriptionRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewPrescriptionRepositoryPostgres(pOpts *PrescriptionRepoOpts) PrescriptionRepository {
+	return &PrescriptionRepositoryPostgres{
+		db: pOpts.Db,
+	}
+}
+
+// UpsertOne issues the consultation's prescription, or reissues it when the
+// doctor writes a new one. Fills already made still count against a reissue.
+func (r *PrescriptionRepositoryPostgres) UpsertOne(ctx context.Context, prescription entities.Prescription) (*entities.Prescription, error) {
+	values := []interface{}{}
+	values = append(values, prescription.ConsultationId)
+	values = append(values, prescription.ExpiresAt)
+	values = append(values, prescription.Refills)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qUpsertPrescription, values...).Scan(&prescription.Id, &prescription.FillCount, &prescription.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qUpsertPrescription, values...).Scan(&prescription.Id, &prescription.FillCount, &prescription.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &prescription, nil
+}
+
+// ReplaceItems swaps the consultation's prescribed items for items. The caller
+// runs it in a transaction together with UpsertOne.
+func (r *PrescriptionRepositoryPostgres) ReplaceItems(ctx context.Context, consultationId int64, items []entities.PrescriptionItem) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qDeletePrescriptionItems, consultationId)
+	} else {
+		_, err = r.db.ExecContext(ctx, qDeletePrescriptionItems, consultationId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	valueStrings := make([]string, 0, len(items))
+	valueArgs := make([]interface{}, 0, len(items)*8)
+
+	for i, item := range items {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*8+1, i*8+2, i*8+3, i*8+4, i*8+5, i*8+6, i*8+7, i*8+8))
+		valueArgs = append(valueArgs, consultationId)
+		valueArgs = append(valueArgs, item.Product.Id)
+		valueArgs = append(valueArgs, item.Quantity)
+		valueArgs = append(valueArgs, item.Dosage)
+		valueArgs = append(valueArgs, item.Frequency)
+		valueArgs = append(valueArgs, item.Duration)
+		valueArgs = append(valueArgs, item.Route)
+		valueArgs = append(valueArgs, item.Notes)
+	}
+	stmt := fmt.Sprintf(qCreatePrescriptionItems, strings.Join(valueStrings, ","))
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, stmt, valueArgs...)
+	} else {
+		_, err = r.db.ExecContext(ctx, stmt, valueArgs...)
+	}
+
+	return err
+}
+
+func (r *PrescriptionRepositoryPostgres) FindByConsultationId(ctx context.Context, consultationId int64) (*entities.Prescription, error) {
+	return r.findOne(ctx, qFindPrescriptionByConsultationId, consultationId)
+}
+
+func (r *PrescriptionRepositoryPostgres) findOne(ctx context.Context, query string, consultationId int64) (*entities.Prescription, error) {
+	p := entities.Prescription{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, consultationId).Scan(&p.Id, &p.ConsultationId, &p.ExpiresAt, &p.Refills, &p.FillCount, &p.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, query, consultationId).Scan(&p.Id, &p.ConsultationId, &p.ExpiresAt, &p.Refills, &p.FillCount, &p.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	items, err := r.findItems(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+	p.Items = items
+
+	return &p, nil
+}
+
+func (r *PrescriptionRepositoryPostgres) findItems(ctx context.Context, consultationId int64) ([]entities.PrescriptionItem, error) {
+	items := []entities.PrescriptionItem{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindPrescriptionItems, consultationId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindPrescriptionItems, consultationId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		i := entities.PrescriptionItem{}
+		err := rows.Scan(&i.Id, &i.Product.Id, &i.Product.Name, &i.Product.SellingUnit, &i.Quantity, &i.Dosage, &i.Frequency, &i.Duration, &i.Route, &i.Notes)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, i)
+	}
+
+	return items, nil
+}
+
+// UpdateFillCountByItemIds spends one fill of every prescription the items
+// belong to. It fails with not found when one of them has no fill left or has
+// expired, so the caller's transaction can be rolled back.
+func (r *PrescriptionRepositoryPostgres) UpdateFillCountByItemIds(ctx context.Context, itemIds []int64) error {
+	valueStrings := make([]string, 0, len(itemIds))
+	valueArgs := make([]interface{}, 0, len(itemIds))
+
+	for i, itemId := range itemIds {
+		valueStrings = append(valueStrings, fmt.Sprintf("$%d", i+1))
+		valueArgs = append(valueArgs, itemId)
+	}
+	stmt := fmt.Sprintf(qUpdatePrescriptionFillCountByItemIds, strings.Join(valueStrings, ","))
+
+	var targets, filled int
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, stmt, valueArgs...).Scan(&targets, &filled)
+	} else {
+		err = r.db.QueryRowContext(ctx, stmt, valueArgs...).Scan(&targets, &filled)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if filled != targets {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// RestoreFillCountByOrderId gives back the fills spent by a canceled order.
+func (r *PrescriptionRepositoryPostgres) RestoreFillCountByOrderId(ctx context.Context, orderId int64) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qRestorePrescriptionFillCountByOrderId, orderId, constants.Canceled)
+	} else {
+		_, err = r.db.ExecContext(ctx, qRestorePrescriptionFillCountByOrderId, orderId, constants.Canceled)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ type ProductFieldRepository interface {
 	FindAllForm(ctx context.Context) ([]entities.ProductForm, error)
 	FindAllClassification(ctx context.Context) ([]entities.ProductClassification, error)
 	FindAllManufacture(ctx context.Context) ([]entities.Manufacture, error)
+	UpdateClassification(ctx context.Context, classification entities.ProductClassification) (*entities.ProductClassification, error)
 }
 
 type ProductFieldRepositoryPostgres struct {
@@ -72,7 +73,7 @@ func (r *ProductFieldRepositoryPostgres) FindAllClassification(ctx context.Conte
 
 	for rows.Next() {
 		pc := entities.ProductClassification{}
-		rows.Scan(&pc.Id, &pc.Name)
+		rows.Scan(&pc.Id, &pc.Name, &pc.RequiresPrescription)
 		pcs = append(pcs, pc)
 	}
 
@@ -115,3 +116,25 @@ func (r *ProductFieldRepositoryPostgres) FindAllManufacture(ctx context.Context)
 
 	return ms, nil
 }
+
+func (r *ProductFieldRepositoryPostgres) UpdateClassification(ctx context.Context, classification entities.ProductClassification) (*entities.ProductClassification, error) {
+	pc := entities.ProductClassification{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qUpdateClassificationRequirement, classification.Id, classification.RequiresPrescription).Scan(&pc.Id, &pc.Name, &pc.RequiresPrescription)
+	} else {
+		err = r.db.QueryRowContext(ctx, qUpdateClassificationRequirement, classification.Id, classification.RequiresPrescription).Scan(&pc.Id, &pc.Name, &pc.RequiresPrescription)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &pc, nil
+}
@@ -73,7 +73,7 @@ const (
 		WHERE email = $1 AND deleted_at IS NULL;
 	`
 	qFindDoctorById = `
-		SELECT d.id, d.name, d.email, d.certificate, d.is_online, d.is_verified, d.is_google, d.fee, d.work_start_year, ds.id, ds.name, d.profile_picture FROM doctors d
+		SELECT d.id, d.name, d.email, d.certificate, d.is_online, d.is_verified, d.is_google, d.fee, d.work_start_year, ds.id, ds.name, d.profile_picture, d.rating, d.review_count FROM doctors d
 		LEFT JOIN doctor_specialists ds ON d.doctor_specialists_id = ds.id
 		WHERE d.id = $1 AND d.deleted_at IS NULL
 		LIMIT 1;
@@ -102,12 +102,6 @@ const (
 		doctor_specialists_id = $5,
 	`
 
-	qUpdateIsOnlineDoctor = `
-		UPDATE doctors SET 
-		is_online = $2,
-		updated_at = NOW()
-		WHERE id = $1 AND deleted_at IS NULL;
-	`
 	qUpdateDoctorCommand = `
 		updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
@@ -123,7 +117,7 @@ const (
 	`
 
 	qDoctorColl = `
-		, d.id, d.name, d.email ,d.certificate, d.is_online, d.is_verified, d.is_google, d.fee, d.work_start_year, d.doctor_specialists_id, ds.name, d.profile_picture 
+		, d.id, d.name, d.email ,d.certificate, d.is_online, d.is_verified, d.is_google, d.fee, d.work_start_year, d.doctor_specialists_id, ds.name, d.profile_picture, d.rating, d.review_count 
 	`
 
 	qDoctorCommands = `
@@ -738,7 +732,7 @@ const (
 	`
 
 	qFindAllClassification = `
-		SELECT id, name FROM product_classifications WHERE deleted_at IS NULL
+		SELECT id, name, requires_prescription FROM product_classifications WHERE deleted_at IS NULL
 	`
 
 	qFindAllManufacture = `
@@ -748,12 +742,13 @@ const (
 
 const (
 	qCreateCart = `
-		INSERT INTO cart_items (quantity, user_id, pharmacy_product_id) VALUES
-		($1, $2, $3);
+		INSERT INTO cart_items (quantity, user_id, pharmacy_product_id, prescription_item_id) VALUES
+		($1, $2, $3, $4);
 	`
 	qIncreaseCartQuantity = `
 		UPDATE cart_items SET
 		quantity = quantity + $1,
+		prescription_item_id = COALESCE($3, prescription_item_id),
 		updated_at = NOW()
 		WHERE id = $2 AND deleted_at IS NULL;
 	`
@@ -789,12 +784,27 @@ const (
 		WHERE ci.user_id = $1 AND ci.deleted_at IS NULL) AS tab
 		ORDER BY MAX(tab.updated_at) OVER (partition BY tab.pharmacy_id) DESC, tab.updated_at DESC;
 	`
+	qSumPrescriptionItemCartQuantity = `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM cart_items
+		WHERE user_id = $1 AND prescription_item_id = $2 AND id <> $3 AND deleted_at IS NULL;
+	`
 	qFindPharmacyIdByCartId = `
-		SELECT pp.pharmacy_id, ci.quantity, pp.id
+		SELECT pp.pharmacy_id, ci.quantity, pp.id, ci.user_id, ci.prescription_item_id
 		FROM cart_items ci 
 		JOIN pharmacy_products pp ON pp.id = ci.pharmacy_product_id 
 		WHERE ci.id = $1 AND ci.deleted_at IS NULL;
 	`
+	qFindCheckoutCartItems = `
+		SELECT ci.id, ci.quantity, pp.id, pp.pharmacy_id, pp.price, p.weight, p.id, pc.requires_prescription, ci.prescription_item_id,
+		EXISTS (` + qValidPrescriptionItem + `) AS is_prescribed, (` + qPrescribedQuantity + `) AS prescribed_quantity
+		FROM cart_items ci 
+		JOIN pharmacy_products pp ON pp.id = ci.pharmacy_product_id 
+		JOIN products p ON p.id = pp.product_id 
+		JOIN product_classifications pc ON pc.id = p.product_classification_id
+		WHERE ci.user_id = $1 AND ci.deleted_at IS NULL AND ci.id IN (%s)
+		ORDER BY ci.id;
+	`
 
 	// location
 	qFindProvinces       = `SELECT id, name FROM provinces;`
@@ -894,12 +904,12 @@ const (
 
 	// order
 	qCreateOrder = `
-		INSERT INTO orders (order_number, total_price, payment_deadline, shipping_fee, shipping_method, user_address_id, order_status_id, pharmacy_id)
-		VALUES ($1, $2, $3, $4, $5, $6, (select id from order_statuses where name = $7), $8)
+		INSERT INTO orders (order_number, total_price, payment_deadline, shipping_fee, shipping_method, user_address_id, order_status_id, pharmacy_id, checkout_id, discount)
+		VALUES ($1, $2, $3, $4, $5, $6, (select id from order_statuses where name = $7), $8, $9, $10)
 		RETURNING id, payment_deadline
 	`
 	qCreateOrderItem = `
-		INSERT INTO order_items (quantity, order_id, pharmacy_product_id)
+		INSERT INTO order_items (quantity, order_id, pharmacy_product_id, price, discount, prescription_item_id)
 		VALUES %s
 	`
 	qCartItemsBulkDelete = `
@@ -912,7 +922,7 @@ const (
 		WHERE cart_items.id = CAST (nv.id AS BIGINT);
 	`
 	qFindUserOrder = `
-		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, COUNT(*) OVER () as total
+		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.discount, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, COUNT(*) OVER () as total
 		FROM orders o 
 		JOIN user_addresses ua ON ua.id = o.user_address_id 
 		JOIN order_statuses os ON os.id = o.order_status_id 
@@ -923,7 +933,7 @@ const (
 		LIMIT $4;
 	`
 	qFindOrderDetail = `
-		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code
+		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.discount, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code
 		FROM orders o 
 		JOIN user_addresses ua ON ua.id = o.user_address_id 
 		JOIN order_statuses os ON os.id = o.order_status_id 
@@ -931,14 +941,14 @@ const (
 		WHERE o.id = $1 AND o.deleted_at IS NULL;
 	`
 	qFindOrderItems = `
-		SELECT p.name, p.selling_unit, pp.price, oi.quantity, p.product_picture, pp.id
+		SELECT p.name, p.selling_unit, oi.price, oi.discount, oi.quantity, p.product_picture, pp.id
 		FROM order_items oi 
 		JOIN pharmacy_products pp ON pp.id = oi.pharmacy_product_id 
 		JOIN products p ON p.id = pp.product_id 
 		WHERE oi.order_id = $1 AND oi.deleted_at IS NULL;
 	`
 	qFindUserOrderByPharmacyManager = `
-		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, pa.city, pa.province, pa.address, pa.district, pa.sub_district, pa.postal_code, COUNT(*) OVER () as total, u.name AS user_name, u.email AS user_email
+		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.discount, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, pa.city, pa.province, pa.address, pa.district, pa.sub_district, pa.postal_code, COUNT(*) OVER () as total, u.name AS user_name, u.email AS user_email
 		FROM orders o 
 		JOIN user_addresses ua ON ua.id = o.user_address_id 
 		JOIN order_statuses os ON os.id = o.order_status_id 
@@ -951,7 +961,7 @@ const (
 		LIMIT $4;
 	`
 	qFindUserOrderByAdmin = `
-		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, pa.city, pa.province, pa.address, pa.district, pa.sub_district, pa.postal_code, COUNT(*) OVER () as total, u.name AS user_name, u.email AS user_email 
+		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.payment_deadline, o.shipping_fee, o.discount, o.shipping_method, os.name AS status, p.name AS pharmacy_name, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, pa.city, pa.province, pa.address, pa.district, pa.sub_district, pa.postal_code, COUNT(*) OVER () as total, u.name AS user_name, u.email AS user_email 
 		FROM orders o 
 		JOIN user_addresses ua ON ua.id = o.user_address_id 
 		JOIN order_statuses os ON os.id = o.order_status_id 
@@ -971,17 +981,50 @@ const (
 		WHERE orders.id = $2 AND orders.deleted_at IS NULL %s
 	`
 	qFindOrderStatus = `
-		SELECT os.name, o.payment_proof, o.pharmacy_id
+		SELECT os.name, o.payment_proof, o.pharmacy_id, o.id, o.order_number, o.total_price, o.shipping_fee, o.payment_deadline, ua.user_id, p.pharmacy_manager_id, o.checkout_id, o.discount
 		FROM orders o 
 		JOIN order_statuses os ON os.id = o.order_status_id 
+		JOIN user_addresses ua ON ua.id = o.user_address_id 
+		JOIN pharmacies p ON p.id = o.pharmacy_id 
 		WHERE o.id = $1 AND o.deleted_at IS NULL;
 	`
+	qLockExpiredOrders = `
+		SELECT o.id, o.payment_deadline, o.checkout_id
+		FROM orders o 
+		JOIN order_statuses os ON os.id = o.order_status_id 
+		WHERE os.name = $1 AND o.payment_proof IS NULL AND o.payment_deadline < NOW() AND o.deleted_at IS NULL
+		ORDER BY o.payment_deadline
+		LIMIT $2
+		FOR UPDATE OF o SKIP LOCKED;
+	`
+	qLockShippedOrders = `
+		SELECT o.id, os.name
+		FROM orders o 
+		JOIN order_statuses os ON os.id = o.order_status_id 
+		WHERE os.name IN ($1, $2) AND o.deleted_at IS NULL
+		AND COALESCE(
+			(SELECT MAX(h.created_at) FROM order_status_histories h WHERE h.order_id = o.id AND h.to_status = $1),
+			o.updated_at
+		) < NOW() - make_interval(days => $3)
+		ORDER BY o.id
+		LIMIT $4
+		FOR UPDATE OF o SKIP LOCKED;
+	`
 	qUploadPaymentProof = `
 		UPDATE orders SET 
 		payment_proof = $1,
 		updated_at = NOW()
 		FROM user_addresses
-		WHERE orders.id = $2 AND orders.deleted_at IS NULL AND user_addresses.user_id = orders.user_address_id AND user_addresses.user_id = $3;
+		WHERE orders.checkout_id = (SELECT checkout_id FROM orders WHERE id = $2) AND orders.deleted_at IS NULL AND user_addresses.id = orders.user_address_id AND user_addresses.user_id = $3;
+	`
+	qFindOrdersByCheckoutId = `
+		SELECT o.id, os.name, o.pharmacy_id, ua.user_id, o.total_price, o.shipping_fee, o.discount,
+		(SELECT MAX(osh.created_at) FROM order_status_histories osh WHERE osh.order_id = o.id AND osh.to_status = $2)
+		FROM orders o 
+		JOIN order_statuses os ON os.id = o.order_status_id 
+		JOIN user_addresses ua ON ua.id = o.user_address_id
+		WHERE o.checkout_id = $1 AND o.deleted_at IS NULL
+		ORDER BY o.id;
 	`
 
 	// stock
@@ -1002,7 +1045,7 @@ const (
 
 const (
 	qFindConsultationById = `
-		SELECT c.id, d.id, d.name, d.profile_picture, d.is_online, s.id, s.name, u.id, u.name, u.profile_picture, g.id, g.name, c.patient_name, c.patient_birth_date, c.certificate_url, c.prescription_url, c.ended_at, c.created_at FROM consultations c
+		SELECT c.id, d.id, d.name, d.profile_picture, d.is_online, s.id, s.name, u.id, u.name, u.profile_picture, g.id, g.name, c.patient_name, c.patient_birth_date, c.certificate_url, c.prescription_url, c.ended_at, c.created_at, c.status, c.queued_at, c.accepted_at, c.fee, c.payment_deadline, c.paid_at, c.scheduled_at, c.dependent_id FROM consultations c
 		JOIN doctors d ON c.doctor_id = d.id 
 		JOIN doctor_specialists s ON d.doctor_specialists_id = s.id
 		JOIN users u ON c.user_id = u.id
@@ -1011,7 +1054,7 @@ const (
 	`
 
 	qConsultationColl = `
-		, c.id, d.id, d.name, d.profile_picture, d.is_online, s.id, s.name, u.id, u.name, u.profile_picture, g.id, g.name, c.patient_name, c.patient_birth_date, c.certificate_url, c.prescription_url, c.ended_at, c.created_at
+		, c.id, d.id, d.name, d.profile_picture, d.is_online, s.id, s.name, u.id, u.name, u.profile_picture, g.id, g.name, c.patient_name, c.patient_birth_date, c.certificate_url, c.prescription_url, c.ended_at, c.created_at, c.status, c.queued_at, c.accepted_at, c.fee, c.payment_deadline, c.paid_at, c.dependent_id
 	`
 
 	qConsultationCommands = `
@@ -1030,8 +1073,8 @@ const (
 	`
 
 	qCreateOneConsultation = `
-		INSERT INTO consultations(doctor_id, user_id, patient_gender_id, patient_name, patient_birth_date) VALUES
-		($1, $2, $3, $4, $5) RETURNING id;
+		INSERT INTO consultations(doctor_id, user_id, patient_gender_id, patient_name, patient_birth_date, status, fee, payment_deadline, scheduled_at, dependent_id) VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, queued_at;
 	`
 
 	qUpdateEndedAtConsultation = `
@@ -1042,27 +1085,18 @@ const (
 
 	qUpdateCertificateConsultation = `
 		UPDATE consultations SET
-		certificate_url = $2, updated_at = NOW()
+		certificate_url = $2, diagnosis = $3, updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL;
 	`
 
-	qCreatePrescriptionItems = `
-		INSERT INTO prescription_items (consultation_id, product_id, quantity) VALUES  
-	`
-
 	qUpdatePrescriptionConsultation = `
 		UPDATE consultations SET
 		prescription_url = $2, updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL;
 	`
 
-	qPrescriptionProducts = `
-		SELECT product_id, quantity FROM prescription_items 
-		WHERE consultation_id = $1 AND deleted_at IS NULL;
-	`
-
 	qFindAllChatByConsultationId = `
-		SELECT id, is_from_user, content, type, created_at FROM chats 
+		SELECT id, is_from_user, content, type, created_at, delivered_at, read_at FROM chats 
 		WHERE consultation_id = $1 AND deleted_at IS NULL;
 	`
 
@@ -1189,7 +1223,8 @@ const (
 		pd.id, pd.name, pd.content, pd.description, pd.unit_in_pack, pd.selling_unit,
 		pd.weight, pd.height, pd.length, pd.width, pd.product_picture, pd.slug_id,
 		pc.name, pf.name, m.name,
-		SUM(oi.quantity * pp.price) AS total_sales_amount,
+		SUM(oi.quantity * oi.price) AS total_sales_amount,
+		SUM(oi.discount) AS total_discount_amount,
 		SUM(oi.quantity) AS total_quantity_sold,
 		DATE_TRUNC('month', oi.created_at) AS month,
 		DATE_TRUNC('year', oi.created_at) AS year
@@ -1266,3 +1301,826 @@ const (
 				LIMIT $3
 			`
 )
+
+const (
+	qCreateOnePayment = `
+		INSERT INTO payments (checkout_id, consultation_id, provider, method, channel, external_id, account_number, checkout_url, amount, status, expired_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id;
+	`
+
+	qFindActivePaymentByCheckoutId = `
+		SELECT id, checkout_id, consultation_id, provider, method, channel, external_id, account_number, checkout_url, amount, status, expired_at, paid_at, created_at
+		FROM payments
+		WHERE checkout_id = $1 AND status IN ($2, $3) AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1;
+	`
+
+	qFindActivePaymentByConsultationId = `
+		SELECT id, checkout_id, consultation_id, provider, method, channel, external_id, account_number, checkout_url, amount, status, expired_at, paid_at, created_at
+		FROM payments
+		WHERE consultation_id = $1 AND status IN ($2, $3) AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1;
+	`
+
+	qLockPaymentByExternalId = `
+		SELECT id, checkout_id, consultation_id, provider, method, channel, external_id, account_number, checkout_url, amount, status, expired_at, paid_at, created_at
+		FROM payments
+		WHERE provider = $1 AND external_id = $2 AND deleted_at IS NULL
+		FOR UPDATE;
+	`
+
+	qUpdatePaymentStatus = `
+		UPDATE payments SET
+		status = $2, paid_at = $3, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qExpirePendingPaymentsByCheckoutId = `
+		UPDATE payments SET
+		status = $2, updated_at = NOW()
+		WHERE checkout_id = $1 AND status = $3 AND deleted_at IS NULL;
+	`
+
+	qCreatePaymentCallback = `
+		INSERT INTO payment_callbacks (provider, callback_id, payment_id, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, callback_id) DO NOTHING
+		RETURNING id;
+	`
+)
+
+const (
+	qRedeemWsTicket = `
+		INSERT INTO ws_ticket_redemptions (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+		RETURNING id, created_at;
+	`
+
+	qDeleteExpiredWsTicketRedemptions = `
+		DELETE FROM ws_ticket_redemptions
+		WHERE expires_at < $1;
+	`
+)
+
+const (
+	qCreateOneOrderStatusHistory = `
+		INSERT INTO order_status_histories (order_id, from_status, to_status, actor_role, actor_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6);
+	`
+
+	qFindOrderStatusHistoriesByOrderId = `
+		SELECT id, order_id, from_status, to_status, actor_role, actor_id, reason, created_at
+		FROM order_status_histories
+		WHERE order_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at, id;
+	`
+)
+
+const (
+	qCreateOneCheckout = `
+		INSERT INTO checkouts (checkout_number, user_id, total_price, shipping_fee, discount, payment_deadline)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at;
+	`
+
+	qFindCheckoutById = `
+		SELECT id, checkout_number, user_id, total_price, shipping_fee, discount, payment_deadline, created_at
+		FROM checkouts
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qLockCheckoutById = `
+		SELECT id, checkout_number, user_id, total_price, shipping_fee, discount, payment_deadline, created_at
+		FROM checkouts
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE;
+	`
+)
+
+const (
+	qVoucherColl = `
+		, id, code, name, discount_type, discount_value, max_discount, min_spend, global_quota, per_user_quota, starts_at, ends_at, category_id, product_id, pharmacy_id
+	`
+
+	qVoucherCommands = `
+		FROM vouchers
+		WHERE deleted_at IS NULL 
+	`
+
+	qFindOneVoucherById = `
+		SELECT id, code, name, discount_type, discount_value, max_discount, min_spend, global_quota, per_user_quota, starts_at, ends_at, category_id, product_id, pharmacy_id
+		FROM vouchers
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qLockOneVoucherByCode = `
+		SELECT id, code, name, discount_type, discount_value, max_discount, min_spend, global_quota, per_user_quota, starts_at, ends_at, category_id, product_id, pharmacy_id
+		FROM vouchers
+		WHERE UPPER(code) = UPPER($1) AND deleted_at IS NULL
+		FOR UPDATE;
+	`
+
+	qCreateOneVoucher = `
+		INSERT INTO vouchers (code, name, discount_type, discount_value, max_discount, min_spend, global_quota, per_user_quota, starts_at, ends_at, category_id, product_id, pharmacy_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id;
+	`
+
+	qUpdateOneVoucher = `
+		UPDATE vouchers SET
+		code = $2, name = $3, discount_type = $4, discount_value = $5, max_discount = $6, min_spend = $7, global_quota = $8, per_user_quota = $9,
+		starts_at = $10, ends_at = $11, category_id = $12, product_id = $13, pharmacy_id = $14, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qDeleteOneVoucher = `
+		UPDATE vouchers SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	// a usage stops counting once every order of its checkout is canceled
+	qCountVoucherUsages = `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE vu.user_id = $2)
+		FROM voucher_usages vu
+		WHERE vu.voucher_id = $1 AND vu.deleted_at IS NULL
+		AND EXISTS (
+			SELECT 1 FROM orders o
+			JOIN order_statuses os ON os.id = o.order_status_id
+			WHERE o.checkout_id = vu.checkout_id AND os.name <> $3 AND o.deleted_at IS NULL
+		);
+	`
+
+	qCreateOneVoucherUsage = `
+		INSERT INTO voucher_usages (voucher_id, user_id, checkout_id, discount)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	qFindProductIdsInCategory = `
+		SELECT DISTINCT product_id
+		FROM product_categories
+		WHERE category_id = $1 AND deleted_at IS NULL AND product_id IN (%s);
+	`
+)
+
+const (
+	qCreateOneRefund = `
+		INSERT INTO refunds (order_id, consultation_id, user_id, type, status, amount, reason, admin_id, processed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at;
+	`
+
+	qCreateRefundItems = `
+		INSERT INTO refund_items (refund_id, pharmacy_product_id, quantity, amount)
+		VALUES %s
+	`
+
+	qFindRefundById = `
+		SELECT id, order_id, consultation_id, user_id, type, status, amount, reason, admin_id, admin_note, processed_at, paid_out_at, created_at
+		FROM refunds
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qLockRefundById = `
+		SELECT id, order_id, consultation_id, user_id, type, status, amount, reason, admin_id, admin_note, processed_at, paid_out_at, created_at
+		FROM refunds
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE;
+	`
+
+	qFindRefundsByOrderId = `
+		SELECT id, order_id, consultation_id, user_id, type, status, amount, reason, admin_id, admin_note, processed_at, paid_out_at, created_at
+		FROM refunds
+		WHERE order_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at, id;
+	`
+
+	qFindAllRefunds = `
+		SELECT id, order_id, consultation_id, user_id, type, status, amount, reason, admin_id, admin_note, processed_at, paid_out_at, created_at, COUNT(*) OVER () AS total
+		FROM refunds
+		WHERE deleted_at IS NULL %s
+		ORDER BY created_at DESC
+		OFFSET $1
+		LIMIT $2;
+	`
+
+	qFindRefundItems = `
+		SELECT id, refund_id, pharmacy_product_id, quantity, amount
+		FROM refund_items
+		WHERE refund_id = $1 AND deleted_at IS NULL
+		ORDER BY id;
+	`
+
+	qUpdateRefundStatus = `
+		UPDATE refunds SET
+		status = $2, admin_id = $3, admin_note = $4, processed_at = $5, paid_out_at = $6, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	// quantities already returned or waiting for a decision
+	qSumReturnedQuantities = `
+		SELECT ri.pharmacy_product_id, SUM(ri.quantity)
+		FROM refund_items ri
+		JOIN refunds r ON r.id = ri.refund_id
+		WHERE r.order_id = $1 AND r.type = $2 AND r.status <> $3 AND r.deleted_at IS NULL AND ri.deleted_at IS NULL
+		GROUP BY ri.pharmacy_product_id;
+	`
+)
+
+const (
+	qFindOrderInvoice = `
+		SELECT o.id, o.order_number, o.total_price, o.payment_proof, o.shipping_fee, o.discount, o.shipping_method, os.name, o.created_at, o.invoice_url, o.invoice_cache_key,
+		p.name, p.pharmacy_manager_id, pa.city, pa.province, pa.address, pa.district, pa.sub_district, pa.postal_code,
+		ua.user_id, ua.city, ua.province, ua.address, ua.district, ua.sub_district, ua.postal_code, u.name, u.email
+		FROM orders o 
+		JOIN order_statuses os ON os.id = o.order_status_id 
+		JOIN user_addresses ua ON ua.id = o.user_address_id 
+		JOIN users u ON u.id = ua.user_id
+		JOIN pharmacies p ON p.id = o.pharmacy_id 
+		JOIN pharmacy_addresses pa ON pa.pharmacy_id = p.id
+		WHERE o.id = $1 AND o.deleted_at IS NULL;
+	`
+
+	qUpdateOrderInvoice = `
+		UPDATE orders SET
+		invoice_url = $2, invoice_cache_key = $3
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+)
+
+const (
+	qFindAllActiveConsultation = `
+		SELECT id, user_id, doctor_id FROM consultations
+		WHERE status NOT IN ($1, $2) AND ended_at IS NULL AND deleted_at IS NULL;
+	`
+)
+
+const (
+	qFindAllChatByConsultationIdAfter = `
+		SELECT id, is_from_user, content, type, created_at, delivered_at, read_at FROM chats 
+		WHERE consultation_id = $1 AND id > $2 AND deleted_at IS NULL
+		ORDER BY id;
+	`
+)
+
+const (
+	// a participant reads the chats sent by the other side, so $3 is the
+	// sender flag opposite to the reader
+	qUpdateChatsDeliveredUpTo = `
+		UPDATE chats SET
+		delivered_at = NOW()
+		WHERE consultation_id = $1 AND id <= $2 AND is_from_user = $3 AND delivered_at IS NULL AND deleted_at IS NULL;
+	`
+
+	qUpdateChatsReadUpTo = `
+		UPDATE chats SET
+		delivered_at = COALESCE(delivered_at, NOW()), read_at = NOW()
+		WHERE consultation_id = $1 AND id <= $2 AND is_from_user = $3 AND read_at IS NULL AND deleted_at IS NULL;
+	`
+
+	qExistsDoctorChat = `
+		SELECT EXISTS (SELECT 1 FROM chats WHERE consultation_id = $1 AND is_from_user = false AND type <> $2 AND deleted_at IS NULL);
+	`
+
+	qConsultationUserUnreadColl = `
+		, (SELECT COUNT(*) FROM chats ch WHERE ch.consultation_id = c.id AND ch.is_from_user = false AND ch.read_at IS NULL AND ch.deleted_at IS NULL)
+	`
+
+	qConsultationDoctorUnreadColl = `
+		, (SELECT COUNT(*) FROM chats ch WHERE ch.consultation_id = c.id AND ch.is_from_user = true AND ch.read_at IS NULL AND ch.deleted_at IS NULL)
+	`
+)
+
+const (
+	qLockConsultationRequestById = `
+		SELECT c.id, c.user_id, c.doctor_id, d.doctor_specialists_id, c.status, c.queued_at, c.fee, c.paid_at, c.scheduled_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+		FOR UPDATE OF c;
+	`
+
+	qLockTimedOutConsultationRequests = `
+		SELECT c.id, c.user_id, c.doctor_id, d.doctor_specialists_id, c.status, c.queued_at, c.fee, c.paid_at, c.scheduled_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		WHERE c.status = $1 AND c.queued_at < NOW() - make_interval(secs => $2) AND c.ended_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY c.queued_at
+		LIMIT $3
+		FOR UPDATE OF c SKIP LOCKED;
+	`
+
+	qLockUnpaidConsultationRequests = `
+		SELECT c.id, c.user_id, c.doctor_id, d.doctor_specialists_id, c.status, c.queued_at, c.fee, c.paid_at, c.scheduled_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		WHERE c.status = $1 AND c.payment_deadline < NOW() AND c.deleted_at IS NULL
+		ORDER BY c.payment_deadline
+		LIMIT $2
+		FOR UPDATE OF c SKIP LOCKED;
+	`
+
+	qFindWaitingConsultationsByDoctorId = `
+		SELECT c.id, d.id, d.name, d.profile_picture, d.is_online, s.id, s.name, u.id, u.name, u.profile_picture, g.id, g.name, c.patient_name, c.patient_birth_date, c.certificate_url, c.prescription_url, c.ended_at, c.created_at, c.status, c.queued_at, c.accepted_at, c.fee, c.payment_deadline, c.paid_at, c.scheduled_at, c.dependent_id FROM consultations c
+		JOIN doctors d ON c.doctor_id = d.id 
+		JOIN doctor_specialists s ON d.doctor_specialists_id = s.id
+		JOIN users u ON c.user_id = u.id
+		JOIN genders g ON c.patient_gender_id = g.id
+		WHERE c.doctor_id = $1 AND c.status = $2 AND c.ended_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY c.queued_at, c.id;
+	`
+
+	// open accepted consultations and the average length of the recent ones,
+	// used to estimate how long a waiting patient still has to wait
+	qFindDoctorConsultationLoad = `
+		SELECT
+		(SELECT COUNT(*) FROM consultations WHERE doctor_id = $1 AND status = $2 AND ended_at IS NULL AND deleted_at IS NULL),
+		(SELECT EXTRACT(EPOCH FROM AVG(recent.ended_at - recent.accepted_at))::FLOAT FROM (
+			SELECT ended_at, accepted_at FROM consultations
+			WHERE doctor_id = $1 AND status = $2 AND ended_at IS NOT NULL AND accepted_at IS NOT NULL AND deleted_at IS NULL
+			ORDER BY ended_at DESC
+			LIMIT $3
+		) recent);
+	`
+
+	qAcceptConsultation = `
+		UPDATE consultations SET
+		status = $2, accepted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qLockOverdueConsultations = `
+		SELECT c.id, c.user_id, c.doctor_id, d.doctor_specialists_id, c.status, c.queued_at, c.fee, c.paid_at, c.scheduled_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		WHERE c.status = $1 AND c.accepted_at < NOW() - make_interval(secs => $2) AND c.ended_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY c.accepted_at
+		LIMIT $3
+		FOR UPDATE OF c SKIP LOCKED;
+	`
+
+	// consultations nobody has written in since the idle timeout, counting
+	// from acceptance when the chat is still empty
+	qLockIdleConsultations = `
+		SELECT c.id, c.user_id, c.doctor_id, d.doctor_specialists_id, c.status, c.queued_at, c.fee, c.paid_at, c.scheduled_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		WHERE c.status = $1 AND c.ended_at IS NULL AND c.deleted_at IS NULL
+		AND COALESCE((SELECT MAX(ch.created_at) FROM chats ch WHERE ch.consultation_id = c.id AND ch.deleted_at IS NULL), c.accepted_at) < NOW() - make_interval(secs => $2)
+		ORDER BY c.accepted_at
+		LIMIT $3
+		FOR UPDATE OF c SKIP LOCKED;
+	`
+
+	// the queue timeout starts once the fee is paid
+	qPayConsultation = `
+		UPDATE consultations SET
+		status = $2, paid_at = $3, queued_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qReassignConsultation = `
+		UPDATE consultations SET
+		doctor_id = $2, queued_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qExpireConsultation = `
+		UPDATE consultations SET
+		status = $2, ended_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qCreateConsultationDecline = `
+		INSERT INTO consultation_declines (consultation_id, doctor_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (consultation_id, doctor_id) DO NOTHING;
+	`
+
+	// the least busy online doctor of the specialist who has not been
+	// offered this request yet
+	qFindAvailableDoctorForConsultation = `
+		SELECT d.id FROM doctors d
+		WHERE d.doctor_specialists_id = $2 AND d.is_online = true AND d.is_verified = true AND d.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM consultation_declines cd WHERE cd.consultation_id = $1 AND cd.doctor_id = d.id)
+		AND COALESCE(d.fee, 0) = (SELECT c.fee FROM consultations c WHERE c.id = $1)
+		ORDER BY (SELECT COUNT(*) FROM consultations w WHERE w.doctor_id = d.id AND w.status = $3 AND w.ended_at IS NULL AND w.deleted_at IS NULL), d.id
+		LIMIT 1;
+	`
+)
+
+const (
+	qUpsertDoctorPresence = `
+		INSERT INTO doctor_presences (doctor_id, instance_id) VALUES ($1, $2)
+		ON CONFLICT (doctor_id, instance_id) DO UPDATE SET seen_at = NOW();
+	`
+
+	qDeleteDoctorPresence = `
+		DELETE FROM doctor_presences WHERE doctor_id = $1 AND instance_id = $2;
+	`
+
+	qRefreshDoctorPresences = `
+		UPDATE doctor_presences SET seen_at = NOW() WHERE instance_id = $1;
+	`
+
+	qDeleteStaleDoctorPresences = `
+		DELETE FROM doctor_presences WHERE seen_at < NOW() - make_interval(secs => $1);
+	`
+
+	qSyncDoctorIsOnline = `
+		UPDATE doctors d SET
+		is_online = EXISTS (SELECT 1 FROM doctor_presences p WHERE p.doctor_id = d.id)
+		WHERE d.id = $1;
+	`
+
+	qSyncAllDoctorIsOnline = `
+		UPDATE doctors d SET
+		is_online = EXISTS (SELECT 1 FROM doctor_presences p WHERE p.doctor_id = d.id)
+		WHERE d.is_online <> EXISTS (SELECT 1 FROM doctor_presences p WHERE p.doctor_id = d.id);
+	`
+)
+
+const (
+	qCreateOneDoctorReview = `
+		INSERT INTO doctor_reviews (consultation_id, doctor_id, user_id, rating, comment)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	qFindDoctorReviewsByDoctorId = `
+		SELECT r.id, r.consultation_id, r.doctor_id, r.user_id, u.name, r.rating, r.comment, r.is_hidden, r.hidden_by, r.hidden_reason, r.hidden_at, r.created_at, COUNT(*) OVER () AS total
+		FROM doctor_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.doctor_id = $1 AND r.is_hidden = false AND r.deleted_at IS NULL
+		ORDER BY r.created_at DESC, r.id DESC
+		OFFSET $2
+		LIMIT $3;
+	`
+
+	qFindAllDoctorReviews = `
+		SELECT r.id, r.consultation_id, r.doctor_id, r.user_id, u.name, r.rating, r.comment, r.is_hidden, r.hidden_by, r.hidden_reason, r.hidden_at, r.created_at, COUNT(*) OVER () AS total
+		FROM doctor_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.deleted_at IS NULL %s
+		ORDER BY r.created_at DESC, r.id DESC
+		OFFSET $1
+		LIMIT $2;
+	`
+
+	qLockDoctorReviewById = `
+		SELECT r.id, r.consultation_id, r.doctor_id, r.user_id, u.name, r.rating, r.comment, r.is_hidden, r.hidden_by, r.hidden_reason, r.hidden_at, r.created_at
+		FROM doctor_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1 AND r.deleted_at IS NULL
+		FOR UPDATE OF r;
+	`
+
+	qUpdateDoctorReviewHidden = `
+		UPDATE doctor_reviews SET
+		is_hidden = $2, hidden_by = $3, hidden_reason = $4, hidden_at = $5, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qRefreshDoctorRating = `
+		UPDATE doctors SET
+		rating = COALESCE((SELECT ROUND(AVG(rating), 2) FROM doctor_reviews WHERE doctor_id = $1 AND is_hidden = false AND deleted_at IS NULL), 0),
+		review_count = (SELECT COUNT(*) FROM doctor_reviews WHERE doctor_id = $1 AND is_hidden = false AND deleted_at IS NULL),
+		updated_at = NOW()
+		WHERE id = $1;
+	`
+)
+
+const (
+	qFindDoctorSchedulesByDoctorId = `
+		SELECT id, doctor_id, day_of_week, to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI')
+		FROM doctor_schedules
+		WHERE doctor_id = $1 AND deleted_at IS NULL
+		ORDER BY day_of_week, start_time;
+	`
+
+	qDeleteDoctorSchedules = `
+		UPDATE doctor_schedules SET
+		deleted_at = NOW(), updated_at = NOW()
+		WHERE doctor_id = $1 AND deleted_at IS NULL;
+	`
+
+	qCreateDoctorSchedules = `
+		INSERT INTO doctor_schedules (doctor_id, day_of_week, start_time, end_time)
+		VALUES %s;
+	`
+
+	qFindDoctorScheduleExceptions = `
+		SELECT id, doctor_id, to_char(date, 'YYYY-MM-DD'), to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'), reason, created_at
+		FROM doctor_schedule_exceptions
+		WHERE doctor_id = $1 AND date BETWEEN $2::date AND $3::date AND deleted_at IS NULL
+		ORDER BY date, start_time NULLS FIRST;
+	`
+
+	qCreateDoctorScheduleException = `
+		INSERT INTO doctor_schedule_exceptions (doctor_id, date, start_time, end_time, reason)
+		VALUES ($1, $2::date, $3::time, $4::time, $5)
+		RETURNING id, created_at;
+	`
+
+	qDeleteDoctorScheduleException = `
+		UPDATE doctor_schedule_exceptions SET
+		deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND doctor_id = $2 AND deleted_at IS NULL;
+	`
+)
+
+const (
+	qCreateOneAppointment = `
+		INSERT INTO appointments (consultation_id, doctor_id, user_id, dependent_id, start_at, end_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at;
+	`
+
+	qFindAppointmentsByUserId = `
+		SELECT a.id, a.consultation_id, c.status, a.doctor_id, d.name, a.user_id, u.name, a.start_at, a.end_at, a.status, a.reminder_sent_at, a.created_at, COUNT(*) OVER () AS total
+		FROM appointments a
+		JOIN consultations c ON c.id = a.consultation_id
+		JOIN doctors d ON d.id = a.doctor_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = $1 AND a.deleted_at IS NULL %s
+		ORDER BY a.start_at DESC
+		OFFSET $2
+		LIMIT $3;
+	`
+
+	qFindAppointmentsByDoctorId = `
+		SELECT a.id, a.consultation_id, c.status, a.doctor_id, d.name, a.user_id, u.name, a.start_at, a.end_at, a.status, a.reminder_sent_at, a.created_at, COUNT(*) OVER () AS total
+		FROM appointments a
+		JOIN consultations c ON c.id = a.consultation_id
+		JOIN doctors d ON d.id = a.doctor_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.doctor_id = $1 AND a.deleted_at IS NULL %s
+		ORDER BY a.start_at
+		OFFSET $2
+		LIMIT $3;
+	`
+
+	qLockAppointmentById = `
+		SELECT a.id, a.consultation_id, c.status, a.doctor_id, d.name, d.email, a.user_id, u.name, u.email, a.start_at, a.end_at, a.status, a.reminder_sent_at, a.created_at
+		FROM appointments a
+		JOIN consultations c ON c.id = a.consultation_id
+		JOIN doctors d ON d.id = a.doctor_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1 AND a.deleted_at IS NULL
+		FOR UPDATE OF a;
+	`
+
+	qFindBookedAppointmentStarts = `
+		SELECT start_at FROM appointments
+		WHERE doctor_id = $1 AND status <> $2 AND start_at >= $3 AND start_at < $4 AND deleted_at IS NULL;
+	`
+
+	qCountOverlappingAppointments = `
+		SELECT COUNT(*) FROM appointments
+		WHERE doctor_id = $1 AND status <> $2 AND start_at < $4 AND end_at > $3 AND deleted_at IS NULL;
+	`
+
+	qUpdateAppointmentStatus = `
+		UPDATE appointments SET
+		status = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+
+	qCancelAppointmentByConsultationId = `
+		UPDATE appointments SET
+		status = $2, updated_at = NOW()
+		WHERE consultation_id = $1 AND status = $3 AND deleted_at IS NULL;
+	`
+
+	// booked appointments whose slot has started and whose fee is settled
+	qLockDueAppointments = `
+		SELECT a.id, a.consultation_id, c.status, a.doctor_id, d.name, d.email, a.user_id, u.name, u.email, a.start_at, a.end_at, a.status, a.reminder_sent_at, a.created_at
+		FROM appointments a
+		JOIN consultations c ON c.id = a.consultation_id
+		JOIN doctors d ON d.id = a.doctor_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.status = $1 AND c.status = $2 AND a.start_at <= NOW() AND a.deleted_at IS NULL
+		ORDER BY a.start_at
+		LIMIT $3
+		FOR UPDATE OF a, c SKIP LOCKED;
+	`
+
+	qLockUnremindedAppointments = `
+		SELECT a.id, a.consultation_id, c.status, a.doctor_id, d.name, d.email, a.user_id, u.name, u.email, a.start_at, a.end_at, a.status, a.reminder_sent_at, a.created_at
+		FROM appointments a
+		JOIN consultations c ON c.id = a.consultation_id
+		JOIN doctors d ON d.id = a.doctor_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.status = $1 AND c.status = $2 AND a.reminder_sent_at IS NULL
+		AND a.start_at > NOW() AND a.start_at <= NOW() + make_interval(secs => $3) AND a.deleted_at IS NULL
+		ORDER BY a.start_at
+		LIMIT $4
+		FOR UPDATE OF a SKIP LOCKED;
+	`
+
+	qUpdateAppointmentReminderSent = `
+		UPDATE appointments SET
+		reminder_sent_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL;
+	`
+)
+
+const (
+	qUpsertPrescription = `
+		INSERT INTO prescriptions (consultation_id, expires_at, refills) VALUES ($1, $2, $3)
+		ON CONFLICT (consultation_id) DO UPDATE SET
+		expires_at = EXCLUDED.expires_at, refills = EXCLUDED.refills, updated_at = NOW(), deleted_at = NULL
+		RETURNING id, fill_count, created_at;
+	`
+
+	qDeletePrescriptionItems = `
+		UPDATE prescription_items SET
+		deleted_at = NOW()
+		WHERE consultation_id = $1 AND deleted_at IS NULL;
+	`
+
+	qCreatePrescriptionItems = `
+		INSERT INTO prescription_items (consultation_id, product_id, quantity, dosage, frequency, duration, route, notes) VALUES %s;
+	`
+
+	qFindPrescriptionByConsultationId = `
+		SELECT id, consultation_id, expires_at, refills, fill_count, created_at FROM prescriptions
+		WHERE consultation_id = $1 AND deleted_at IS NULL;
+	`
+
+	qFindPrescriptionItems = `
+		SELECT pi.id, p.id, p.name, p.selling_unit, pi.quantity, pi.dosage, pi.frequency, pi.duration, pi.route, pi.notes
+		FROM prescription_items pi
+		JOIN products p ON p.id = pi.product_id
+		WHERE pi.consultation_id = $1 AND pi.deleted_at IS NULL
+		ORDER BY pi.id;
+	`
+
+	qUpdatePrescriptionFillCountByItemIds = `
+		WITH targets AS (
+			SELECT DISTINCT consultation_id FROM prescription_items
+			WHERE id IN (%s) AND deleted_at IS NULL
+		), filled AS (
+			UPDATE prescriptions pr SET
+			fill_count = pr.fill_count + 1, updated_at = NOW()
+			FROM targets t
+			WHERE pr.consultation_id = t.consultation_id AND pr.fill_count <= pr.refills
+			AND pr.expires_at >= CURRENT_DATE AND pr.deleted_at IS NULL
+			RETURNING pr.id
+		)
+		SELECT (SELECT COUNT(*) FROM targets), (SELECT COUNT(*) FROM filled);
+	`
+
+	// a fill is spent once per checkout, so it only comes back when no other
+	// live order of the checkout holds an item of the same prescription
+	qRestorePrescriptionFillCountByOrderId = `
+		UPDATE prescriptions pr SET
+		fill_count = pr.fill_count - 1, updated_at = NOW()
+		WHERE pr.fill_count > 0 AND pr.consultation_id IN (
+			SELECT pi.consultation_id FROM order_items oi
+			JOIN prescription_items pi ON pi.id = oi.prescription_item_id
+			WHERE oi.order_id = $1
+		) AND NOT EXISTS (
+			SELECT 1 FROM orders o
+			JOIN order_statuses os ON os.id = o.order_status_id
+			JOIN order_items oi ON oi.order_id = o.id
+			JOIN prescription_items pi ON pi.id = oi.prescription_item_id
+			WHERE o.checkout_id = (SELECT checkout_id FROM orders WHERE id = $1)
+			AND o.id <> $1 AND os.name <> $2 AND o.deleted_at IS NULL
+			AND pi.consultation_id = pr.consultation_id
+		);
+	`
+)
+
+const (
+	// qValidPrescriptionItem matches a cart line's prescription item when it
+	// prescribes the line's product to the line's user, has not expired and
+	// has a fill left. It expects ci and p to be in scope.
+	qValidPrescriptionItem = `
+		SELECT 1 FROM prescription_items pi
+		JOIN consultations co ON co.id = pi.consultation_id
+		JOIN prescriptions pr ON pr.consultation_id = pi.consultation_id
+		WHERE pi.id = ci.prescription_item_id AND pi.product_id = p.id AND co.user_id = ci.user_id
+		AND pr.expires_at >= CURRENT_DATE AND pr.fill_count <= pr.refills AND pi.deleted_at IS NULL AND pr.deleted_at IS NULL
+	`
+
+	qPrescribedQuantity = `
+		SELECT pi.quantity FROM prescription_items pi
+		WHERE pi.id = ci.prescription_item_id AND pi.deleted_at IS NULL
+	`
+
+	qFindPrescriptionRequirement = `
+		SELECT pc.requires_prescription, EXISTS (` + qValidPrescriptionItem + `), (` + qPrescribedQuantity + `)
+		FROM (SELECT $2::bigint AS user_id, $3::bigint AS prescription_item_id) ci
+		JOIN pharmacy_products pp ON pp.id = $1
+		JOIN products p ON p.id = pp.product_id
+		JOIN product_classifications pc ON pc.id = p.product_classification_id
+		WHERE pp.deleted_at IS NULL;
+	`
+
+	qUpdateClassificationRequirement = `
+		UPDATE product_classifications SET
+		requires_prescription = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, name, requires_prescription;
+	`
+)
+
+const (
+	qCreateOneDocument = `
+		INSERT INTO documents (number, type, consultation_id, doctor_id, patient_name, valid_from, valid_until) VALUES
+		($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, issued_at, created_at;
+	`
+
+	qRevokeDocumentsByConsultationId = `
+		UPDATE documents SET
+		revoked_at = NOW(), updated_at = NOW()
+		WHERE consultation_id = $1 AND type = $2 AND revoked_at IS NULL AND deleted_at IS NULL;
+	`
+
+	qFindDocumentByNumber = `
+		SELECT dc.id, dc.number, dc.type, dc.consultation_id, d.id, d.name, s.id, s.name, dc.patient_name, dc.valid_from, dc.valid_until, dc.issued_at, dc.revoked_at
+		FROM documents dc
+		JOIN doctors d ON d.id = dc.doctor_id
+		JOIN doctor_specialists s ON s.id = d.doctor_specialists_id
+		WHERE dc.number = $1 AND dc.deleted_at IS NULL;
+	`
+)
+
+const (
+	qFindMedicalConditionsByUserId = `
+		SELECT id, user_id, type, name, notes, created_at
+		FROM medical_conditions
+		WHERE user_id = $1 AND COALESCE(dependent_id, 0) = $2 AND deleted_at IS NULL
+		ORDER BY type, name;
+	`
+
+	qCreateOneMedicalCondition = `
+		INSERT INTO medical_conditions (user_id, dependent_id, type, name, notes) VALUES
+		($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	qDeleteMedicalCondition = `
+		UPDATE medical_conditions SET
+		deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+	`
+
+	qFindMedicalRecordEntriesByUserId = `
+		SELECT c.id, d.name, s.name, c.patient_name, c.diagnosis, c.created_at
+		FROM consultations c
+		JOIN doctors d ON d.id = c.doctor_id
+		JOIN doctor_specialists s ON s.id = d.doctor_specialists_id
+		WHERE c.user_id = $1 AND COALESCE(c.dependent_id, 0) = $2 AND c.ended_at IS NOT NULL AND c.deleted_at IS NULL
+		ORDER BY c.created_at DESC;
+	`
+
+	qFindMedicalRecordPrescriptionItemsByUserId = `
+		SELECT pi.consultation_id, pi.id, p.id, p.name, p.selling_unit, pi.quantity, pi.dosage, pi.frequency, pi.duration, pi.route, pi.notes
+		FROM prescription_items pi
+		JOIN consultations c ON c.id = pi.consultation_id
+		JOIN products p ON p.id = pi.product_id
+		WHERE c.user_id = $1 AND COALESCE(c.dependent_id, 0) = $2 AND c.ended_at IS NOT NULL AND c.deleted_at IS NULL AND pi.deleted_at IS NULL
+		ORDER BY pi.consultation_id, pi.id;
+	`
+)
+
+const (
+	qFindDependentsByUserId = `
+		SELECT dp.id, dp.user_id, dp.name, dp.relationship, dp.birth_date, g.id, g.name, dp.created_at
+		FROM dependents dp
+		JOIN genders g ON g.id = dp.gender_id
+		WHERE dp.user_id = $1 AND dp.deleted_at IS NULL
+		ORDER BY dp.id;
+	`
+
+	qFindDependentById = `
+		SELECT dp.id, dp.user_id, dp.name, dp.relationship, dp.birth_date, g.id, g.name, dp.created_at
+		FROM dependents dp
+		JOIN genders g ON g.id = dp.gender_id
+		WHERE dp.id = $1 AND dp.user_id = $2 AND dp.deleted_at IS NULL;
+	`
+
+	qCreateOneDependent = `
+		INSERT INTO dependents (user_id, name, relationship, birth_date, gender_id) VALUES
+		($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	qUpdateOneDependent = `
+		UPDATE dependents SET
+		name = $3, relationship = $4, birth_date = $5, gender_id = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+	`
+
+	qDeleteOneDependent = `
+		UPDATE dependents SET
+		deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+	`
+)
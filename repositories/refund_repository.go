@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type RefundRepoOpts struct {
+	Db *sql.DB
+}
+
+type RefundRepository interface {
+	CreateOne(ctx context.Context, refund entities.Refund) (*entities.Refund, error)
+	FindById(ctx context.Context, refundId int64) (*entities.Refund, error)
+	LockById(ctx context.Context, refundId int64) (*entities.Refund, error)
+	FindAll(ctx context.Context, params entities.RefundParams) ([]entities.Refund, error)
+	FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.Refund, error)
+	FindItems(ctx context.Context, refundId int64) ([]entities.RefundItem, error)
+	UpdateStatus(ctx context.Context, refund entities.Refund) error
+	SumReturnedQuantities(ctx context.Context, orderId int64) (map[int64]int, error)
+}
+
+type RefundRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewRefundRepositoryPostgres(rOpts *RefundRepoOpts) RefundRepository {
+	return &RefundRepositoryPostgres{
+		db: rOpts.Db,
+	}
+}
+
+func (r *RefundRepositoryPostgres) CreateOne(ctx context.Context, refund entities.Refund) (*entities.Refund, error) {
+	values := []interface{}{}
+	values = append(values, refund.OrderId)
+	values = append(values, refund.ConsultationId)
+	values = append(values, refund.UserId)
+	values = append(values, refund.Type)
+	values = append(values, refund.Status)
+	values = append(values, refund.Amount)
+	values = append(values, refund.Reason)
+	values = append(values, refund.AdminId)
+	values = append(values, refund.ProcessedAt)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneRefund, values...).Scan(&refund.Id, &refund.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneRefund, values...).Scan(&refund.Id, &refund.CreatedAt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(refund.Items) == 0 {
+		return &refund, nil
+	}
+
+	valueStrings := make([]string, 0, len(refund.Items))
+	valueArgs := make([]interface{}, 0, len(refund.Items)*4)
+
+	for i, item := range refund.Items {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d)", i*4+1, i*4+2, i*4+3, i*4+4))
+		valueArgs = append(valueArgs, refund.Id)
+		valueArgs = append(valueArgs, item.PharmacyProductId)
+		valueArgs = append(valueArgs, item.Quantity)
+		valueArgs = append(valueArgs, item.Amount)
+		refund.Items[i].RefundId = refund.Id
+	}
+	stmt := fmt.Sprintf(qCreateRefundItems, strings.Join(valueStrings, ","))
+
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, stmt, valueArgs...)
+	} else {
+		_, err = r.db.ExecContext(ctx, stmt, valueArgs...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (r *RefundRepositoryPostgres) FindById(ctx context.Context, refundId int64) (*entities.Refund, error) {
+	return r.findOne(ctx, qFindRefundById, refundId)
+}
+
+func (r *RefundRepositoryPostgres) LockById(ctx context.Context, refundId int64) (*entities.Refund, error) {
+	return r.findOne(ctx, qLockRefundById, refundId)
+}
+
+func (r *RefundRepositoryPostgres) findOne(ctx context.Context, query string, refundId int64) (*entities.Refund, error) {
+	rf := entities.Refund{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, refundId).Scan(&rf.Id, &rf.OrderId, &rf.ConsultationId, &rf.UserId, &rf.Type, &rf.Status, &rf.Amount, &rf.Reason, &rf.AdminId, &rf.AdminNote, &rf.ProcessedAt, &rf.PaidOutAt, &rf.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, query, refundId).Scan(&rf.Id, &rf.OrderId, &rf.ConsultationId, &rf.UserId, &rf.Type, &rf.Status, &rf.Amount, &rf.Reason, &rf.AdminId, &rf.AdminNote, &rf.ProcessedAt, &rf.PaidOutAt, &rf.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &rf, nil
+}
+
+func (r *RefundRepositoryPostgres) FindAll(ctx context.Context, params entities.RefundParams) ([]entities.Refund, error) {
+	refunds := []entities.Refund{}
+
+	var err error
+	var rows *sql.Rows
+
+	page := params.Limit * (params.Page - 1)
+	values := []interface{}{page, params.Limit}
+
+	var sb strings.Builder
+	if params.Status != "" {
+		values = append(values, params.Status)
+		sb.WriteString(fmt.Sprintf("AND status = $%d ", len(values)))
+	}
+	if params.Type != "" {
+		values = append(values, params.Type)
+		sb.WriteString(fmt.Sprintf("AND type = $%d ", len(values)))
+	}
+	q := fmt.Sprintf(qFindAllRefunds, sb.String())
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, q, values...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, q, values...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rf := entities.Refund{}
+		err := rows.Scan(&rf.Id, &rf.OrderId, &rf.ConsultationId, &rf.UserId, &rf.Type, &rf.Status, &rf.Amount, &rf.Reason, &rf.AdminId, &rf.AdminNote, &rf.ProcessedAt, &rf.PaidOutAt, &rf.CreatedAt, &rf.Total)
+		if err != nil {
+			return nil, err
+		}
+
+		refunds = append(refunds, rf)
+	}
+
+	return refunds, nil
+}
+
+func (r *RefundRepositoryPostgres) FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.Refund, error) {
+	refunds := []entities.Refund{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindRefundsByOrderId, orderId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindRefundsByOrderId, orderId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rf := entities.Refund{}
+		err := rows.Scan(&rf.Id, &rf.OrderId, &rf.ConsultationId, &rf.UserId, &rf.Type, &rf.Status, &rf.Amount, &rf.Reason, &rf.AdminId, &rf.AdminNote, &rf.ProcessedAt, &rf.PaidOutAt, &rf.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		refunds = append(refunds, rf)
+	}
+
+	return refunds, nil
+}
+
+func (r *RefundRepositoryPostgres) FindItems(ctx context.Context, refundId int64) ([]entities.RefundItem, error) {
+	items := []entities.RefundItem{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qFindRefundItems, refundId)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qFindRefundItems, refundId)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := entities.RefundItem{}
+		err := rows.Scan(&item.Id, &item.RefundId, &item.PharmacyProductId, &item.Quantity, &item.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *RefundRepositoryPostgres) UpdateStatus(ctx context.Context, refund entities.Refund) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qUpdateRefundStatus, refund.Id, refund.Status, refund.AdminId, refund.AdminNote, refund.ProcessedAt, refund.PaidOutAt)
+	} else {
+		res, err = r.db.ExecContext(ctx, qUpdateRefundStatus, refund.Id, refund.Status, refund.AdminId, refund.AdminNote, refund.ProcessedAt, refund.PaidOutAt)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// SumReturnedQuantities returns, per pharmacy product, how much of the order
+// is already covered by return requests that weren't rejected.
+func (r *RefundRepositoryPostgres) SumReturnedQuantities(ctx context.Context, orderId int64) (map[int64]int, error) {
+	quantities := map[int64]int{}
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, qSumReturnedQuantities, orderId, constants.RefundTypeReturn, constants.RefundStatusRejected)
+	} else {
+		rows, err = r.db.QueryContext(ctx, qSumReturnedQuantities, orderId, constants.RefundTypeReturn, constants.RefundStatusRejected)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pharmacyProductId int64
+		var quantity int
+		err := rows.Scan(&pharmacyProductId, &quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		quantities[pharmacyProductId] = quantity
+	}
+
+	return quantities, nil
+}
@@ -129,7 +129,7 @@ func (r *SalesReportRepositoryPostgres) FindAll(ctx context.Context, params enti
 			&sr.PharmacyProduct.Product.Id, &sr.PharmacyProduct.Product.Name, &sr.PharmacyProduct.Product.Content, &sr.PharmacyProduct.Product.Description, &sr.PharmacyProduct.Product.UnitInPack, &sr.PharmacyProduct.Product.SellingUnit,
 			&sr.PharmacyProduct.Product.Weight, &sr.PharmacyProduct.Product.Height, &sr.PharmacyProduct.Product.Length, &sr.PharmacyProduct.Product.Width, &sr.PharmacyProduct.Product.ProductPicture, &sr.PharmacyProduct.Product.SlugId, &sr.PharmacyProduct.Product.ProductClassification.Name,
 			&sr.PharmacyProduct.Product.ProductForm.Name, &sr.PharmacyProduct.Product.Manufacture.Name,
-			&sr.TotalSalesAmount, &sr.TotalQuantitySold, &sr.Month, &sr.Year,
+			&sr.TotalSalesAmount, &sr.TotalDiscountAmount, &sr.TotalQuantitySold, &sr.Month, &sr.Year,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -3,6 +3,11 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type txKey struct{}
@@ -32,7 +37,38 @@ func NewTransactor(db *sql.DB) *transactor {
 	}
 }
 
+// WithinTransaction runs tFunc in a serializable transaction. Serialization
+// failures and deadlocks are retried from the start, so tFunc must be safe to
+// run more than once. A call made with a context that already carries a
+// transaction joins it instead of opening a new one.
 func (t *transactor) WithinTransaction(ctx context.Context, tFunc func(txCtx context.Context) (interface{}, error)) (interface{}, error) {
+	if extractTx(ctx) != nil {
+		return tFunc(ctx)
+	}
+
+	var res interface{}
+	var err error
+
+	for attempt := 1; attempt <= constants.MaxTransactionRetries; attempt++ {
+		res, err = t.runTransaction(ctx, tFunc)
+		if err == nil || !isRetryableTxError(err) {
+			return res, err
+		}
+		if attempt == constants.MaxTransactionRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(time.Duration(attempt) * constants.TransactionRetryBackoff):
+		}
+	}
+
+	return nil, err
+}
+
+func (t *transactor) runTransaction(ctx context.Context, tFunc func(txCtx context.Context) (interface{}, error)) (interface{}, error) {
 	tx, err := t.Db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return nil, err
@@ -50,10 +86,19 @@ func (t *transactor) WithinTransaction(ctx context.Context, tFunc func(txCtx con
 	errCommit := tx.Commit()
 	if errCommit != nil {
 		errRollback := tx.Rollback()
-		if errRollback != nil {
+		if errRollback != nil && errRollback != sql.ErrTxDone {
 			return nil, errRollback
 		}
 		return nil, errCommit
 	}
 	return res, nil
 }
+
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == constants.SerializationFailurePgErrCode || pgErr.Code == constants.DeadlockDetectedPgErrCode
+}
@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeTxDriver is just enough of a database/sql driver to open, commit and
+// roll back transactions, failing commits with the queued errors.
+type fakeTxDriver struct {
+	commitErrs []error
+	isolations []driver.IsolationLevel
+	commits    int
+	rollbacks  int
+}
+
+func (d *fakeTxDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeTxConn{d: d}, nil
+}
+
+func (d *fakeTxDriver) Driver() driver.Driver {
+	return nil
+}
+
+type fakeTxConn struct {
+	d *fakeTxDriver
+}
+
+func (c *fakeTxConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeTxConn) Close() error {
+	return nil
+}
+
+func (c *fakeTxConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeTxConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.d.isolations = append(c.d.isolations, opts.Isolation)
+	return c, nil
+}
+
+func (c *fakeTxConn) Commit() error {
+	c.d.commits++
+	if len(c.d.commitErrs) > 0 {
+		err := c.d.commitErrs[0]
+		c.d.commitErrs = c.d.commitErrs[1:]
+		return err
+	}
+	return nil
+}
+
+func (c *fakeTxConn) Rollback() error {
+	c.d.rollbacks++
+	return nil
+}
+
+func TestWithinTransaction(t *testing.T) {
+	serialization := &pgconn.PgError{Code: constants.SerializationFailurePgErrCode}
+	deadlock := &pgconn.PgError{Code: constants.DeadlockDetectedPgErrCode}
+	unique := &pgconn.PgError{Code: constants.ViolatesUniqueConstraintPgErrCode}
+
+	tests := []struct {
+		name         string
+		funcErrs     []error
+		commitErrs   []error
+		wantErr      error
+		wantAttempts int
+		wantCommits  int
+	}{
+		{
+			name:         "commits on the first attempt",
+			wantAttempts: 1,
+			wantCommits:  1,
+		},
+		{
+			name:         "retries a serialization failure",
+			funcErrs:     []error{serialization},
+			wantAttempts: 2,
+			wantCommits:  1,
+		},
+		{
+			name:         "retries a deadlock",
+			funcErrs:     []error{deadlock, deadlock},
+			wantAttempts: 3,
+			wantCommits:  1,
+		},
+		{
+			name:         "retries a wrapped serialization failure",
+			funcErrs:     []error{fmt.Errorf("update stock: %w", serialization)},
+			wantAttempts: 2,
+			wantCommits:  1,
+		},
+		{
+			name:         "retries a serialization failure on commit",
+			commitErrs:   []error{serialization},
+			wantAttempts: 2,
+			wantCommits:  2,
+		},
+		{
+			name:         "gives up after the last attempt",
+			funcErrs:     []error{serialization, serialization, serialization, serialization},
+			wantErr:      serialization,
+			wantAttempts: constants.MaxTransactionRetries,
+		},
+		{
+			name:         "does not retry other database errors",
+			funcErrs:     []error{unique},
+			wantErr:      unique,
+			wantAttempts: 1,
+		},
+		{
+			name:         "does not retry application errors",
+			funcErrs:     []error{sql.ErrNoRows},
+			wantErr:      sql.ErrNoRows,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTxDriver{commitErrs: tt.commitErrs}
+			db := sql.OpenDB(fake)
+			defer db.Close()
+
+			attempts := 0
+			res, err := NewTransactor(db).WithinTransaction(context.Background(), func(txCtx context.Context) (interface{}, error) {
+				if extractTx(txCtx) == nil {
+					t.Fatal("tFunc ran without a transaction")
+				}
+				attempts++
+				if attempts <= len(tt.funcErrs) {
+					return nil, tt.funcErrs[attempts-1]
+				}
+				return attempts, nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && res != attempts {
+				t.Fatalf("got result %v, want %d", res, attempts)
+			}
+			if attempts != tt.wantAttempts {
+				t.Fatalf("ran %d times, want %d", attempts, tt.wantAttempts)
+			}
+			if fake.commits != tt.wantCommits {
+				t.Fatalf("committed %d times, want %d", fake.commits, tt.wantCommits)
+			}
+			for _, isolation := range fake.isolations {
+				if sql.IsolationLevel(isolation) != sql.LevelSerializable {
+					t.Fatalf("got isolation %v, want serializable", sql.IsolationLevel(isolation))
+				}
+			}
+		})
+	}
+}
+
+func TestWithinTransactionStopsRetryingWhenCanceled(t *testing.T) {
+	db := sql.OpenDB(&fakeTxDriver{})
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serialization := &pgconn.PgError{Code: constants.SerializationFailurePgErrCode}
+
+	attempts := 0
+	_, err := NewTransactor(db).WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		attempts++
+		cancel()
+		return nil, serialization
+	})
+
+	if !errors.Is(err, serialization) || attempts != 1 {
+		t.Fatalf("got %v after %d attempts, want the serialization failure after 1", err, attempts)
+	}
+}
+
+func TestWithinTransactionJoinsOuterTransaction(t *testing.T) {
+	fake := &fakeTxDriver{}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+
+	transactor := NewTransactor(db)
+	_, err := transactor.WithinTransaction(context.Background(), func(txCtx context.Context) (interface{}, error) {
+		outer := extractTx(txCtx)
+		return transactor.WithinTransaction(txCtx, func(innerCtx context.Context) (interface{}, error) {
+			if extractTx(innerCtx) != outer {
+				t.Fatal("inner call opened its own transaction")
+			}
+			return nil, nil
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.isolations) != 1 || fake.commits != 1 {
+		t.Fatalf("began %d and committed %d transactions, want 1 each", len(fake.isolations), fake.commits)
+	}
+}
@@ -0,0 +1,356 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type VoucherRepoOpts struct {
+	Db *sql.DB
+}
+
+type VoucherRepository interface {
+	FindAll(ctx context.Context, params entities.VoucherParams) ([]entities.Voucher, int, error)
+	FindById(ctx context.Context, voucherId int64) (*entities.Voucher, error)
+	LockByCode(ctx context.Context, code string) (*entities.Voucher, error)
+	CreateOne(ctx context.Context, voucher entities.Voucher) error
+	UpdateOne(ctx context.Context, voucher entities.Voucher) error
+	DeleteOne(ctx context.Context, voucherId int64) error
+	CountUsages(ctx context.Context, voucherId int64, userId int64) (int, int, error)
+	CreateUsage(ctx context.Context, usage entities.VoucherUsage) error
+	FindProductIdsInCategory(ctx context.Context, categoryId int64, productIds []int64) ([]int64, error)
+}
+
+type VoucherRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewVoucherRepositoryPostgres(vOpts *VoucherRepoOpts) VoucherRepository {
+	return &VoucherRepositoryPostgres{
+		db: vOpts.Db,
+	}
+}
+
+func (r *VoucherRepositoryPostgres) FindAll(ctx context.Context, params entities.VoucherParams) ([]entities.Voucher, int, error) {
+	vouchers := []entities.Voucher{}
+
+	var totalRows int
+
+	var sb strings.Builder
+	sb.WriteString(qCountTotalRows)
+	sb.WriteString(qVoucherColl)
+	sb.WriteString(qVoucherCommands)
+
+	var sbTotalRows strings.Builder
+	sbTotalRows.WriteString(qCountTotalRows)
+	sbTotalRows.WriteString(qVoucherCommands)
+
+	values := []interface{}{}
+	valuesCountTotal := []interface{}{}
+
+	numberOfArgs := 1
+
+	if params.Keyword != "" {
+		sb.WriteString(fmt.Sprintf(`AND (code ILIKE $%d OR name ILIKE $%d) `, numberOfArgs, numberOfArgs))
+		values = append(values, "%"+params.Keyword+"%")
+
+		sbTotalRows.WriteString(fmt.Sprintf(`AND (code ILIKE $%d OR name ILIKE $%d) `, numberOfArgs, numberOfArgs))
+		valuesCountTotal = append(valuesCountTotal, "%"+params.Keyword+"%")
+
+		numberOfArgs++
+	}
+
+	var sortBy string
+	switch params.SortBy {
+	case "code":
+		sortBy = `code `
+	case "starts_at":
+		sortBy = `starts_at `
+	case "ends_at":
+		sortBy = `ends_at `
+	default:
+		sortBy = `id `
+	}
+	sb.WriteString(fmt.Sprintf(`ORDER BY %s `, sortBy))
+
+	sort := `ASC`
+	if strings.EqualFold(params.Sort, "desc") {
+		sort = `DESC`
+	}
+	sb.WriteString(fmt.Sprintf(`%s `, sort))
+
+	if params.Limit != 0 {
+		sb.WriteString(`LIMIT `)
+		sb.WriteString(fmt.Sprintf(`$%d `, numberOfArgs))
+		values = append(values, params.Limit)
+		numberOfArgs++
+	}
+
+	if params.Page != 0 {
+		sb.WriteString(`OFFSET `)
+		sb.WriteString(fmt.Sprintf(`$%d`, numberOfArgs))
+		values = append(values, params.Limit*(params.Page-1))
+		numberOfArgs++
+	}
+
+	rows, err := r.db.QueryContext(ctx, sb.String(), values...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := entities.Voucher{}
+
+		err := rows.Scan(&totalRows, &v.Id, &v.Code, &v.Name, &v.DiscountType, &v.DiscountValue, &v.MaxDiscount, &v.MinSpend, &v.GlobalQuota, &v.PerUserQuota, &v.StartsAt, &v.EndsAt, &v.CategoryId, &v.ProductId, &v.PharmacyId)
+		if err != nil {
+			return nil, 0, err
+		}
+		vouchers = append(vouchers, v)
+	}
+
+	if totalRows == 0 {
+		err := r.db.QueryRowContext(ctx, sbTotalRows.String(), valuesCountTotal...).Scan(&totalRows)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, 0, custom_errors.NotFound(err)
+			}
+			return nil, 0, err
+		}
+	}
+
+	return vouchers, totalRows, nil
+}
+
+func (r *VoucherRepositoryPostgres) FindById(ctx context.Context, voucherId int64) (*entities.Voucher, error) {
+	return r.findOne(ctx, qFindOneVoucherById, voucherId)
+}
+
+// LockByCode must run inside a transaction; the lock serializes checkouts that
+// use the same voucher so its usage caps can't be overrun.
+func (r *VoucherRepositoryPostgres) LockByCode(ctx context.Context, code string) (*entities.Voucher, error) {
+	return r.findOne(ctx, qLockOneVoucherByCode, code)
+}
+
+func (r *VoucherRepositoryPostgres) findOne(ctx context.Context, query string, arg interface{}) (*entities.Voucher, error) {
+	v := entities.Voucher{}
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, query, arg).Scan(&v.Id, &v.Code, &v.Name, &v.DiscountType, &v.DiscountValue, &v.MaxDiscount, &v.MinSpend, &v.GlobalQuota, &v.PerUserQuota, &v.StartsAt, &v.EndsAt, &v.CategoryId, &v.ProductId, &v.PharmacyId)
+	} else {
+		err = r.db.QueryRowContext(ctx, query, arg).Scan(&v.Id, &v.Code, &v.Name, &v.DiscountType, &v.DiscountValue, &v.MaxDiscount, &v.MinSpend, &v.GlobalQuota, &v.PerUserQuota, &v.StartsAt, &v.EndsAt, &v.CategoryId, &v.ProductId, &v.PharmacyId)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, custom_errors.NotFound(err)
+		}
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+func (r *VoucherRepositoryPostgres) CreateOne(ctx context.Context, voucher entities.Voucher) error {
+	values := []interface{}{}
+	values = append(values, voucher.Code)
+	values = append(values, voucher.Name)
+	values = append(values, voucher.DiscountType)
+	values = append(values, voucher.DiscountValue)
+	values = append(values, voucher.MaxDiscount)
+	values = append(values, voucher.MinSpend)
+	values = append(values, voucher.GlobalQuota)
+	values = append(values, voucher.PerUserQuota)
+	values = append(values, voucher.StartsAt)
+	values = append(values, voucher.EndsAt)
+	values = append(values, voucher.CategoryId)
+	values = append(values, voucher.ProductId)
+	values = append(values, voucher.PharmacyId)
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCreateOneVoucher, values...).Scan(&voucher.Id)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCreateOneVoucher, values...).Scan(&voucher.Id)
+	}
+
+	if err != nil {
+		return voucherWriteError(err)
+	}
+
+	return nil
+}
+
+func (r *VoucherRepositoryPostgres) UpdateOne(ctx context.Context, voucher entities.Voucher) error {
+	values := []interface{}{}
+	values = append(values, voucher.Id)
+	values = append(values, voucher.Code)
+	values = append(values, voucher.Name)
+	values = append(values, voucher.DiscountType)
+	values = append(values, voucher.DiscountValue)
+	values = append(values, voucher.MaxDiscount)
+	values = append(values, voucher.MinSpend)
+	values = append(values, voucher.GlobalQuota)
+	values = append(values, voucher.PerUserQuota)
+	values = append(values, voucher.StartsAt)
+	values = append(values, voucher.EndsAt)
+	values = append(values, voucher.CategoryId)
+	values = append(values, voucher.ProductId)
+	values = append(values, voucher.PharmacyId)
+
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qUpdateOneVoucher, values...)
+	} else {
+		res, err = r.db.ExecContext(ctx, qUpdateOneVoucher, values...)
+	}
+
+	if err != nil {
+		return voucherWriteError(err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func voucherWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case constants.ViolatesUniqueConstraintPgErrCode:
+			return custom_errors.BadRequest(err, constants.VoucherCodeNotUniqueErrMsg)
+		case constants.VioletesForeignKeyConstraintPgErrCode:
+			return custom_errors.BadRequest(err, constants.VoucherScopeNotFoundErrMsg)
+		}
+	}
+	return err
+}
+
+func (r *VoucherRepositoryPostgres) DeleteOne(ctx context.Context, voucherId int64) error {
+	var err error
+	var res sql.Result
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qDeleteOneVoucher, voucherId)
+	} else {
+		res, err = r.db.ExecContext(ctx, qDeleteOneVoucher, voucherId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return custom_errors.NotFound(sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// CountUsages returns how many times the voucher is in use overall and by the
+// given user.
+func (r *VoucherRepositoryPostgres) CountUsages(ctx context.Context, voucherId int64, userId int64) (int, int, error) {
+	var total, byUser int
+
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qCountVoucherUsages, voucherId, userId, constants.Canceled).Scan(&total, &byUser)
+	} else {
+		err = r.db.QueryRowContext(ctx, qCountVoucherUsages, voucherId, userId, constants.Canceled).Scan(&total, &byUser)
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return total, byUser, nil
+}
+
+func (r *VoucherRepositoryPostgres) CreateUsage(ctx context.Context, usage entities.VoucherUsage) error {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, qCreateOneVoucherUsage, usage.VoucherId, usage.UserId, usage.CheckoutId, usage.Discount)
+	} else {
+		_, err = r.db.ExecContext(ctx, qCreateOneVoucherUsage, usage.VoucherId, usage.UserId, usage.CheckoutId, usage.Discount)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *VoucherRepositoryPostgres) FindProductIdsInCategory(ctx context.Context, categoryId int64, productIds []int64) ([]int64, error) {
+	ids := []int64{}
+
+	valueStrings := make([]string, 0, len(productIds))
+	valueArgs := make([]interface{}, 0, len(productIds)+1)
+	valueArgs = append(valueArgs, categoryId)
+
+	for i, productId := range productIds {
+		valueStrings = append(valueStrings, fmt.Sprintf("$%d", i+2))
+		valueArgs = append(valueArgs, productId)
+	}
+
+	stmt := fmt.Sprintf(qFindProductIdsInCategory, strings.Join(valueStrings, ","))
+
+	var err error
+	var rows *sql.Rows
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, stmt, valueArgs...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, stmt, valueArgs...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type WsTicketRepoOpts struct {
+	Db *sql.DB
+}
+
+type WsTicketRepository interface {
+	Redeem(ctx context.Context, redemption entities.WsTicketRedemption) (bool, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type WsTicketRepositoryPostgres struct {
+	db *sql.DB
+}
+
+func NewWsTicketRepositoryPostgres(wtOpts *WsTicketRepoOpts) WsTicketRepository {
+	return &WsTicketRepositoryPostgres{
+		db: wtOpts.Db,
+	}
+}
+
+// Redeem records a ticket id and reports whether it was redeemed for the first
+// time. A replay of the same ticket returns false.
+func (r *WsTicketRepositoryPostgres) Redeem(ctx context.Context, redemption entities.WsTicketRedemption) (bool, error) {
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		err = tx.QueryRowContext(ctx, qRedeemWsTicket, redemption.Jti, redemption.ExpiresAt).Scan(&redemption.Id, &redemption.CreatedAt)
+	} else {
+		err = r.db.QueryRowContext(ctx, qRedeemWsTicket, redemption.Jti, redemption.ExpiresAt).Scan(&redemption.Id, &redemption.CreatedAt)
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *WsTicketRepositoryPostgres) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var res sql.Result
+	var err error
+
+	tx := extractTx(ctx)
+	if tx != nil {
+		res, err = tx.ExecContext(ctx, qDeleteExpiredWsTicketRedemptions, now)
+	} else {
+		res, err = r.db.ExecContext(ctx, qDeleteExpiredWsTicketRedemptions, now)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
 	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
 	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/tsanaativa/sehatin-backend-v0.1/workers"
 	"github.com/tsanaativa/sehatin-backend-v0.1/ws"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -50,9 +51,18 @@ type RouterOpts struct {
 	SalesReport         *handlers.SalesReportHandler
 	SalesReportCategory *handlers.SalesReportCategoryHandler
 	MostBoughtUser      *handlers.MostBoughtUserHandler
+	Payment             *handlers.PaymentHandler
+	Voucher             *handlers.VoucherHandler
+	Refund              *handlers.RefundHandler
+	DoctorReview        *handlers.DoctorReviewHandler
+	DoctorSchedule      *handlers.DoctorScheduleHandler
+	Appointment         *handlers.AppointmentHandler
+	Document            *handlers.DocumentHandler
+	MedicalRecord       *handlers.MedicalRecordHandler
+	Dependent           *handlers.DependentHandler
 }
 
-func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
+func createRouter(config utils.Config) (*gin.Engine, []workers.Worker) {
 	db, err := database.ConnectDB(config)
 	if err != nil {
 		log.Fatalf("error connecting to DB: %s", err.Error())
@@ -88,6 +98,19 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 	salesReportRepo := repositories.NewSalesReportRepositoryPostgres(&repositories.SalesReportRepoOpts{Db: db})
 	salesReportCategoryRepo := repositories.NewSalesReportCategoryRepositoryPostgres(&repositories.SalesReportCatgoryRepoOpts{Db: db})
 	mostBoughtUserRepo := repositories.NewMostBoughtUserRepositoryPostgres(&repositories.MostBoughtUserRepoOpts{Db: db})
+	paymentRepo := repositories.NewPaymentRepositoryPostgres(&repositories.PaymentRepoOpts{Db: db})
+	orderStatusHistoryRepo := repositories.NewOrderStatusHistoryRepositoryPostgres(&repositories.OrderStatusHistoryRepoOpts{Db: db})
+	checkoutRepo := repositories.NewCheckoutRepositoryPostgres(&repositories.CheckoutRepoOpts{Db: db})
+	voucherRepo := repositories.NewVoucherRepositoryPostgres(&repositories.VoucherRepoOpts{Db: db})
+	refundRepo := repositories.NewRefundRepositoryPostgres(&repositories.RefundRepoOpts{Db: db})
+	doctorReviewRepo := repositories.NewDoctorReviewRepositoryPostgres(&repositories.DoctorReviewRepoOpts{Db: db})
+	doctorScheduleRepo := repositories.NewDoctorScheduleRepositoryPostgres(&repositories.DoctorScheduleRepoOpts{Db: db})
+	appointmentRepo := repositories.NewAppointmentRepositoryPostgres(&repositories.AppointmentRepoOpts{Db: db})
+	prescriptionRepo := repositories.NewPrescriptionRepositoryPostgres(&repositories.PrescriptionRepoOpts{Db: db})
+	documentRepo := repositories.NewDocumentRepositoryPostgres(&repositories.DocumentRepoOpts{Db: db})
+	medicalRecordRepo := repositories.NewMedicalRecordRepositoryPostgres(&repositories.MedicalRecordRepoOpts{Db: db})
+	dependentRepo := repositories.NewDependentRepositoryPostgres(&repositories.DependentRepoOpts{Db: db})
+	wsTicketRepo := repositories.NewWsTicketRepositoryPostgres(&repositories.WsTicketRepoOpts{Db: db})
 
 	userUsecase := usecases.NewUserUsecaseImpl(&usecases.UserUsecaseOpts{
 		UserRepo:        userRepo,
@@ -123,6 +146,7 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 	pharmacyManagerUsecase := usecases.NewPharmacyManagerUsecaseImpl(&usecases.PharmacyManagerOpts{PharmacyManagerRepo: pharmacyManagerRepo})
 	userAddresUsecase := usecases.NewUserAddressUsecaseImpl(&usecases.UserAddressUsecaseOpts{UserAddressRepo: userAddressRepo, UserRepo: userRepo})
 	categoryUsecase := usecases.NewCategoryUsecaseImpl(&usecases.CategoryUsecaseOpts{CategoryRepo: categoryRepo})
+	voucherUsecase := usecases.NewVoucherUsecaseImpl(&usecases.VoucherUsecaseOpts{VoucherRepo: voucherRepo})
 
 	registerUsecase := usecases.NewRegisterUsecaseImpl(&usecases.RegisterUsecaseOpts{
 		HashAlgorithm:       utils.NewBCryptHasher(),
@@ -167,6 +191,24 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 	productCategoryUsecase := usecases.NewProductCategoryUsecaseImpl(&usecases.ProductCategoryUsecaseOpts{
 		ProductCategoryRepo: productCategoryRepo,
 	})
+	var pubSub ws.PubSub = ws.NewPostgresPubSub(&ws.PostgresPubSubOpts{
+		Db:      db,
+		DbUrl:   config.DbUrl,
+		Channel: constants.WsPubSubChannel,
+	})
+	if config.WsPubSub == constants.MemoryPubSub {
+		pubSub = ws.NewMemoryPubSub()
+	}
+
+	consultationEventPublisher := ws.NewConsultationEventPublisher(&ws.ConsultationEventPublisherOpts{PubSub: pubSub})
+
+	documentUsecase := usecases.NewDocumentUsecaseImpl(&usecases.DocumentUsecaseOpts{
+		DocumentRepository: documentRepo,
+		AuthTokenProvider:  utils.NewJwtProvider(config),
+		Transactor:         repositories.NewTransactor(db),
+		FrontendUrl:        config.FrontendUrl,
+	})
+
 	consultationUsecase := usecases.NewConsultationUsecaseImpl(&usecases.ConsultationUsecaseOpts{
 		ConsultationRepo: consultationRepo,
 		DoctorRepo:       doctorRepo,
@@ -175,8 +217,25 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 		PharmacyRepo:     pharmacyRepo,
 		UserAddressRepo:  userAddressRepo,
 		CartRepo:         cartRepo,
+		RefundRepo:       refundRepo,
+		AppointmentRepo:  appointmentRepo,
+		PrescriptionRepo: prescriptionRepo,
+		DependentRepo:    dependentRepo,
+		DocumentUsecase:  documentUsecase,
 		UploadFile:       utils.NewCloudinaryUploadFile(),
-	})
+		Transactor:       repositories.NewTransactor(db),
+		EventPublisher:   consultationEventPublisher,
+		MaxMinutes:       config.ConsultationMaxMins,
+		IdleMinutes:      config.ConsultationIdleMins,
+	})
+	medicalRecordUsecase := usecases.NewMedicalRecordUsecaseImpl(&usecases.MedicalRecordUsecaseOpts{
+		MedicalRecordRepository: medicalRecordRepo,
+		ConsultationRepository:  consultationRepo,
+		DependentRepository:     dependentRepo,
+		UserRepository:          userRepo,
+		UploadFile:              utils.NewCloudinaryUploadFile(),
+	})
+	dependentUsecase := usecases.NewDependentUsecaseImpl(&usecases.DependentUsecaseOpts{DependentRepository: dependentRepo})
 	productFieldUsecase := usecases.NewProductFieldUsecaseImpl(&usecases.ProductFieldUsecaseOpts{ProductFieldRepo: productFieldRepo})
 	cartUsecase := usecases.NewCartUsecaseImpl(&usecases.CartUsecaseOpts{
 		CartRepo:                  cartRepo,
@@ -203,12 +262,51 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 	})
 
 	orderUsecase := usecases.NewOrderUsecaseImpl(&usecases.OrderUsecaseOpts{
-		OrderRepository:           orderRepo,
-		CartRepository:            cartRepo,
-		PharmacyProductRepository: pharmacyProductRepo,
-		StockHistoryRepository:    stockHistoryRepo,
-		Transactor:                repositories.NewTransactor(db),
-		UploadFile:                utils.NewCloudinaryUploadFile(),
+		OrderRepository:              orderRepo,
+		CartRepository:               cartRepo,
+		CheckoutRepository:           checkoutRepo,
+		PharmacyProductRepository:    pharmacyProductRepo,
+		StockHistoryRepository:       stockHistoryRepo,
+		OrderStatusHistoryRepository: orderStatusHistoryRepo,
+		VoucherRepository:            voucherRepo,
+		RefundRepository:             refundRepo,
+		PaymentRepository:            paymentRepo,
+		PrescriptionRepository:       prescriptionRepo,
+		ShippingMethodUsecase:        shippingMethodUsecase,
+		Transactor:                   repositories.NewTransactor(db),
+		UploadFile:                   utils.NewCloudinaryUploadFile(),
+		AutoCompleteDays:             config.AutoCompleteDays,
+	})
+	refundUsecase := usecases.NewRefundUsecaseImpl(&usecases.RefundUsecaseOpts{
+		RefundRepository:             refundRepo,
+		OrderRepository:              orderRepo,
+		OrderStatusHistoryRepository: orderStatusHistoryRepo,
+		PharmacyProductRepository:    pharmacyProductRepo,
+		StockHistoryRepository:       stockHistoryRepo,
+		Transactor:                   repositories.NewTransactor(db),
+	})
+	doctorReviewUsecase := usecases.NewDoctorReviewUsecaseImpl(&usecases.DoctorReviewUsecaseOpts{
+		DoctorReviewRepository: doctorReviewRepo,
+		ConsultationRepository: consultationRepo,
+		DoctorRepository:       doctorRepo,
+		Transactor:             repositories.NewTransactor(db),
+	})
+	doctorScheduleUsecase := usecases.NewDoctorScheduleUsecaseImpl(&usecases.DoctorScheduleUsecaseOpts{
+		DoctorScheduleRepository: doctorScheduleRepo,
+		AppointmentRepository:    appointmentRepo,
+		DoctorRepository:         doctorRepo,
+		Transactor:               repositories.NewTransactor(db),
+	})
+	appointmentUsecase := usecases.NewAppointmentUsecaseImpl(&usecases.AppointmentUsecaseOpts{
+		AppointmentRepository:  appointmentRepo,
+		ConsultationRepository: consultationRepo,
+		DoctorRepository:       doctorRepo,
+		RefundRepository:       refundRepo,
+		DependentRepository:    dependentRepo,
+		DoctorScheduleUsecase:  doctorScheduleUsecase,
+		EmailSender:            utils.NewGoogleEmailSender(),
+		Transactor:             repositories.NewTransactor(db),
+		EventPublisher:         consultationEventPublisher,
 	})
 	adminUsecase := usecases.NewAdminUsecaseImpl(&usecases.AdminUsecaseOpts{
 		AdminRepository: adminRepo,
@@ -235,6 +333,18 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 		PharmacyProductRepo: pharmacyProductRepo,
 		PharmacyRepo:        pharmacyRepo,
 	})
+	paymentUsecase := usecases.NewPaymentUsecaseImpl(&usecases.PaymentUsecaseOpts{
+		PaymentRepository:            paymentRepo,
+		OrderRepository:              orderRepo,
+		CheckoutRepository:           checkoutRepo,
+		OrderStatusHistoryRepository: orderStatusHistoryRepo,
+		ConsultationRepository:       consultationRepo,
+		RefundRepository:             refundRepo,
+		Transactor:                   repositories.NewTransactor(db),
+		PaymentProviders:             utils.NewPaymentProviders(config),
+		PaymentGateway:               config.PaymentGateway,
+		EventPublisher:               consultationEventPublisher,
+	})
 
 	userHandler := handlers.NewUserHandler(&handlers.UserHandlerOpts{
 		UserUsecase: userUsecase,
@@ -271,9 +381,26 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 		ConsultationUsecase: consultationUsecase,
 	})
 
+	hub := ws.NewHub(&ws.HubOpts{
+		PubSub:              pubSub,
+		ConsultationUsecase: consultationUsecase,
+		DoctorUsecase:       doctorUsecase,
+	})
+	if err := hub.RebuildRooms(context.Background()); err != nil {
+		log.Printf("error rebuilding chat rooms: %s", err.Error())
+	}
+
+	wsTicketUsecase := usecases.NewWsTicketUsecaseImpl(&usecases.WsTicketUsecaseOpts{
+		WsTicketRepository: wsTicketRepo,
+		AuthTokenProvider:  utils.NewJwtProvider(config),
+	})
+
 	wsHandler := ws.NewWebSocketHandler(&ws.WebSocketHandlerOpts{
 		Hub:                 hub,
 		ConsultationUsecase: consultationUsecase,
+		WsTicketUsecase:     wsTicketUsecase,
+		AuthTokenProvider:   utils.NewJwtProvider(config),
+		AllowedOrigin:       config.FrontendUrl,
 	})
 
 	productFieldHandler := handlers.NewProductFieldHandler(&handlers.ProductFieldHandlerOpts{ProductFieldUsecase: productFieldUsecase})
@@ -296,8 +423,42 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 	salesReportHandler := handlers.NewSalesReportHandler(&handlers.SalesReportHandlerOpts{SalesReportUsecase: salesReportUsecase})
 	salesReportCategoryHandler := handlers.NewSalesReportCategoryHandler(&handlers.SalesReportCategoryHandlerOpts{SalesReportCategoryUsecase: salesReporctCategoryUsecase})
 	mostBoughtUserHandler := handlers.NewMostBoughtUserHandler(&handlers.MostBoughtUserHandlerOpts{MostBoughtUserUsecase: mostBoughtUserUsecase})
+	paymentHandler := handlers.NewPaymentHandler(&handlers.PaymentHandlerOpts{PaymentUsecase: paymentUsecase})
+	voucherHandler := handlers.NewVoucherHandler(&handlers.VoucherHandlerOpts{VoucherUsecase: voucherUsecase})
+	refundHandler := handlers.NewRefundHandler(&handlers.RefundHandlerOpts{RefundUsecase: refundUsecase})
+	doctorReviewHandler := handlers.NewDoctorReviewHandler(&handlers.DoctorReviewHandlerOpts{DoctorReviewUsecase: doctorReviewUsecase})
+	doctorScheduleHandler := handlers.NewDoctorScheduleHandler(&handlers.DoctorScheduleHandlerOpts{DoctorScheduleUsecase: doctorScheduleUsecase})
+	appointmentHandler := handlers.NewAppointmentHandler(&handlers.AppointmentHandlerOpts{AppointmentUsecase: appointmentUsecase})
+	documentHandler := handlers.NewDocumentHandler(&handlers.DocumentHandlerOpts{DocumentUsecase: documentUsecase})
+	medicalRecordHandler := handlers.NewMedicalRecordHandler(&handlers.MedicalRecordHandlerOpts{MedicalRecordUsecase: medicalRecordUsecase})
+	dependentHandler := handlers.NewDependentHandler(&handlers.DependentHandlerOpts{DependentUsecase: dependentUsecase})
+
+	orderExpiryWorker := workers.NewOrderExpiryWorker(&workers.OrderExpiryWorkerOpts{
+		OrderUsecase: orderUsecase,
+		Interval:     constants.OrderExpiryInterval,
+	})
+	orderAutoCompleteWorker := workers.NewOrderAutoCompleteWorker(&workers.OrderAutoCompleteWorkerOpts{
+		OrderUsecase: orderUsecase,
+		Interval:     constants.OrderAutoCompleteInterval,
+	})
+	consultationQueueWorker := workers.NewConsultationQueueWorker(&workers.ConsultationQueueWorkerOpts{
+		ConsultationUsecase: consultationUsecase,
+		Interval:            constants.ConsultationQueueInterval,
+	})
+	consultationCloseWorker := workers.NewConsultationCloseWorker(&workers.ConsultationCloseWorkerOpts{
+		ConsultationUsecase: consultationUsecase,
+		Interval:            constants.ConsultationCloseInterval,
+	})
+	appointmentWorker := workers.NewAppointmentWorker(&workers.AppointmentWorkerOpts{
+		AppointmentUsecase: appointmentUsecase,
+		Interval:           constants.AppointmentInterval,
+	})
+	wsTicketPruneWorker := workers.NewWsTicketPruneWorker(&workers.WsTicketPruneWorkerOpts{
+		WsTicketUsecase: wsTicketUsecase,
+		Interval:        constants.WsTicketPruneInterval,
+	})
 
-	return NewRouter(config, &RouterOpts{
+	router := NewRouter(config, &RouterOpts{
 		User:                userHandler,
 		Auth:                authHandler,
 		Pharmacy:            pharmacyHandler,
@@ -324,7 +485,18 @@ func createRouter(config utils.Config, hub *ws.Hub) *gin.Engine {
 		SalesReport:         salesReportHandler,
 		SalesReportCategory: salesReportCategoryHandler,
 		MostBoughtUser:      mostBoughtUserHandler,
-	})
+		Payment:             paymentHandler,
+		Voucher:             voucherHandler,
+		Refund:              refundHandler,
+		DoctorReview:        doctorReviewHandler,
+		DoctorSchedule:      doctorScheduleHandler,
+		Appointment:         appointmentHandler,
+		Document:            documentHandler,
+		MedicalRecord:       medicalRecordHandler,
+		Dependent:           dependentHandler,
+	})
+
+	return router, []workers.Worker{hub, orderExpiryWorker, orderAutoCompleteWorker, consultationQueueWorker, consultationCloseWorker, appointmentWorker, wsTicketPruneWorker}
 }
 
 func Init() {
@@ -333,10 +505,12 @@ func Init() {
 		log.Fatalf("error getting env: %s", err.Error())
 	}
 
-	hub := ws.NewHub()
-	go hub.Run()
+	router, jobs := createRouter(config)
 
-	router := createRouter(config, hub)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	for _, job := range jobs {
+		go job.Run(workerCtx)
+	}
 
 	srv := http.Server{
 		Handler: router,
@@ -353,6 +527,7 @@ func Init() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutdown server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	go func() {
@@ -408,6 +583,8 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 		{
 			doctorRouter.GET("/verified", handlers.Doctor.GetAllDoctor)
 			doctorRouter.GET("/:id", handlers.Doctor.GetDoctorById)
+			doctorRouter.GET("/:id/reviews", handlers.DoctorReview.GetDoctorReviews)
+			doctorRouter.GET("/:id/slots", handlers.DoctorSchedule.GetAvailableSlots)
 			doctorRouter.GET("/subscribe", handlers.WebSocket.JoinDoctorRoom)
 			doctorRouter.GET("/:id/consultations/:consultationId/rooms", handlers.WebSocket.JoinRoomAsDoctor)
 		}
@@ -450,6 +627,16 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 		{
 			mostBoughtUserRouter.GET("/", handlers.MostBoughtUser.GetMostBought)
 		}
+
+		paymentRouter := publicRouter.Group("/payments")
+		{
+			paymentRouter.POST("/callbacks/:provider", handlers.Payment.HandleCallback)
+		}
+
+		documentRouter := publicRouter.Group("/documents")
+		{
+			documentRouter.GET("/verify/:token", handlers.Document.VerifyDocument)
+		}
 	}
 
 	privateRouter := router.Group("/")
@@ -468,6 +655,18 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 			adminOrderRouter.GET("/", handlers.Order.GetAllOrderByAdmin)
 			adminOrderRouter.PATCH("/:id/approve", handlers.Order.UpdateOrderStatusToProcessing)
 			adminOrderRouter.PATCH("/:id/cancel", handlers.Order.CancelOrderByAdmin)
+
+			adminRefundRouter := adminPrivate.Group("/refunds")
+			adminRefundRouter.GET("", handlers.Refund.GetAllRefund)
+			adminRefundRouter.GET("/:id", handlers.Refund.GetRefundById)
+			adminRefundRouter.PATCH("/:id/approve", handlers.Refund.ApproveRefund)
+			adminRefundRouter.PATCH("/:id/reject", handlers.Refund.RejectRefund)
+			adminRefundRouter.PATCH("/:id/pay-out", handlers.Refund.PayOutRefund)
+
+			adminReviewRouter := adminPrivate.Group("/reviews")
+			adminReviewRouter.GET("", handlers.DoctorReview.GetAllReview)
+			adminReviewRouter.PATCH("/:id/hide", handlers.DoctorReview.HideReview)
+			adminReviewRouter.PATCH("/:id/unhide", handlers.DoctorReview.UnhideReview)
 		}
 
 		authPrivateRouter := privateRouter.Group("/auth")
@@ -499,6 +698,18 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 				userRouter.GET("/profile/addresses/:addressId", handlers.UserAddress.GetAddressById)
 				userRouter.PUT("/profile/addresses/:addressId", handlers.UserAddress.UpdateUserAddress)
 				userRouter.DELETE("/profile/addresses/:addressId", handlers.UserAddress.DeleteUserAddress)
+				userRouter.GET("/profile/medical-record", handlers.MedicalRecord.GetMedicalRecord)
+				userRouter.POST("/profile/medical-record/export", handlers.MedicalRecord.ExportMedicalRecord)
+				userRouter.POST("/profile/medical-record/conditions", handlers.MedicalRecord.CreateCondition)
+				userRouter.DELETE("/profile/medical-record/conditions/:conditionId", handlers.MedicalRecord.DeleteCondition)
+				userRouter.GET("/profile/dependents", handlers.Dependent.GetAllDependents)
+				userRouter.POST("/profile/dependents", handlers.Dependent.CreateDependent)
+				userRouter.GET("/profile/dependents/:dependentId", handlers.Dependent.GetDependentById)
+				userRouter.PUT("/profile/dependents/:dependentId", handlers.Dependent.UpdateDependent)
+				userRouter.DELETE("/profile/dependents/:dependentId", handlers.Dependent.DeleteDependent)
+				userRouter.GET("/profile/dependents/:dependentId/medical-record", handlers.MedicalRecord.GetMedicalRecord)
+				userRouter.POST("/profile/dependents/:dependentId/medical-record/export", handlers.MedicalRecord.ExportMedicalRecord)
+				userRouter.POST("/profile/dependents/:dependentId/medical-record/conditions", handlers.MedicalRecord.CreateCondition)
 
 				userConsultRouter := userRouter.Group("/consultations")
 				userConsultRouter.GET("", handlers.Consultation.GetAllConsultationByUser)
@@ -507,15 +718,30 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 				userConsultRouter.POST("/:id/chats", handlers.Consultation.CreateChat)
 				userConsultRouter.POST("/:id/chats/file", handlers.Consultation.CreateChatFile)
 				userConsultRouter.POST("/:id/end", handlers.Consultation.EndConsultation)
+				userConsultRouter.GET("/:id/queue", handlers.Consultation.GetConsultationQueuePosition)
+				userConsultRouter.POST("/:id/payments", handlers.Payment.CreateConsultationPayment)
+				userConsultRouter.GET("/:id/payments", handlers.Payment.GetConsultationPayment)
+				userConsultRouter.POST("/:id/review", handlers.DoctorReview.CreateReview)
+				userConsultRouter.GET("/:id/prescription", handlers.Consultation.GetPrescription)
 				userConsultRouter.POST("/:id/prescription/add", handlers.Consultation.AddPrescriptionToCart)
 				userConsultRouter.POST("/rooms", handlers.WebSocket.CreateRoom)
 
+				userAppointmentRouter := userRouter.Group("/appointments")
+				userAppointmentRouter.GET("", handlers.Appointment.GetAllAppointmentByUser)
+				userAppointmentRouter.POST("", handlers.Appointment.BookAppointment)
+				userAppointmentRouter.PATCH("/:id/cancel", handlers.Appointment.CancelAppointment)
+
 				userOrderRouter := userRouter.Group("/orders")
 				userOrderRouter.POST("/", handlers.Order.CreateOrder)
 				userOrderRouter.GET("/", handlers.Order.GetAllOrderByUser)
 				userOrderRouter.PATCH("/:orderId/complete", handlers.Order.UpdateOrderStatusToCompleted)
 				userOrderRouter.POST("/payment-proof", handlers.Order.UploadPaymentProof)
 				userOrderRouter.PATCH("/:orderId/cancel", handlers.Order.UpdateOrderStatusToCanceled)
+				userOrderRouter.POST("/:orderId/returns", handlers.Refund.RequestReturn)
+
+				userCheckoutRouter := userRouter.Group("/checkouts")
+				userCheckoutRouter.POST("/:checkoutId/payments", handlers.Payment.CreatePayment)
+				userCheckoutRouter.GET("/:checkoutId/payments", handlers.Payment.GetPayment)
 			}
 		}
 
@@ -571,6 +797,7 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 				pharmacyManagerOrderRouter := pharmacyManagerRouter.Group("/orders")
 				pharmacyManagerOrderRouter.GET("/", handlers.Order.GetAllOrderByPharmacyManager)
 				pharmacyManagerOrderRouter.PATCH("/:orderId/ship", handlers.Order.UpdateOrderStatusToShipped)
+				pharmacyManagerOrderRouter.PATCH("/:orderId/deliver", handlers.Order.UpdateOrderStatusToDelivered)
 				pharmacyManagerOrderRouter.PATCH("/:orderId/cancel", handlers.Order.CancelOrderByPharmacyManager)
 			}
 		}
@@ -595,10 +822,24 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 				doctorConsultRouter := doctorRouter.Group("/consultations")
 				doctorConsultRouter.GET("/:id", handlers.Consultation.GetConsultationById)
 				doctorConsultRouter.POST("/:id/certificate", handlers.Consultation.CreateCertificate)
+				doctorConsultRouter.GET("/:id/prescription", handlers.Consultation.GetPrescription)
+				doctorConsultRouter.GET("/:id/medical-record", handlers.MedicalRecord.GetConsultationMedicalRecord)
 				doctorConsultRouter.POST("/:id/prescription", handlers.Consultation.CreatePrescription)
 				doctorConsultRouter.GET("", handlers.Consultation.GetAllConsultationByDoctor)
 				doctorConsultRouter.POST("/:id/chats", handlers.Consultation.CreateChat)
 				doctorConsultRouter.POST("/:id/end", handlers.Consultation.EndConsultation)
+				doctorConsultRouter.GET("/queue", handlers.Consultation.GetConsultationQueue)
+				doctorConsultRouter.POST("/:id/accept", handlers.Consultation.AcceptConsultation)
+				doctorConsultRouter.POST("/:id/decline", handlers.Consultation.DeclineConsultation)
+
+				doctorScheduleRouter := doctorRouter.Group("/schedules")
+				doctorScheduleRouter.GET("", handlers.DoctorSchedule.GetSchedules)
+				doctorScheduleRouter.PUT("", handlers.DoctorSchedule.UpdateSchedules)
+				doctorScheduleRouter.GET("/exceptions", handlers.DoctorSchedule.GetExceptions)
+				doctorScheduleRouter.POST("/exceptions", handlers.DoctorSchedule.CreateException)
+				doctorScheduleRouter.DELETE("/exceptions/:id", handlers.DoctorSchedule.DeleteException)
+
+				doctorRouter.GET("/appointments", handlers.Appointment.GetAllAppointmentByDoctor)
 			}
 		}
 
@@ -610,6 +851,16 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 			privateCategoryRouter.DELETE("/:id", handlers.Category.DeleteCategory)
 		}
 
+		privateVoucherRouter := privateRouter.Group("/vouchers")
+		{
+			privateVoucherRouter.Use(middlewares.JwtAdminAuthMiddleware(config))
+			privateVoucherRouter.GET("", handlers.Voucher.GetAllVoucher)
+			privateVoucherRouter.GET("/:id", handlers.Voucher.GetVoucherById)
+			privateVoucherRouter.POST("", handlers.Voucher.CreateVoucher)
+			privateVoucherRouter.PUT("/:id", handlers.Voucher.UpdateVoucher)
+			privateVoucherRouter.DELETE("/:id", handlers.Voucher.DeleteVoucher)
+		}
+
 		privateProductRouter := privateRouter.Group("/products")
 		{
 			adminPrivateProductRouter := privateProductRouter.Group("")
@@ -619,6 +870,7 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 				adminPrivateProductRouter.PUT("/:id", handlers.Product.UpdateProduct)
 				adminPrivateProductRouter.GET("/forms", handlers.ProductField.GetAllForm)
 				adminPrivateProductRouter.GET("/classifications", handlers.ProductField.GetAllClassification)
+				adminPrivateProductRouter.PATCH("/classifications/:id", handlers.ProductField.UpdateClassification)
 				adminPrivateProductRouter.GET("/manufactures", handlers.ProductField.GetAllManufacture)
 				adminPrivateProductRouter.DELETE("/:id", handlers.Product.DeleteProduct)
 			}
@@ -672,10 +924,19 @@ func NewRouter(config utils.Config, handlers *RouterOpts) *gin.Engine {
 			privateSalesReportCategory.GET("/", handlers.SalesReportCategory.GetSalesReportCategories)
 		}
 
+		privateWsRouter := privateRouter.Group("/ws")
+		{
+			privateWsRouter.Use(middlewares.JwtMultiRoleMiddleware(config, []string{constants.UserRole, constants.DoctorRole}))
+			privateWsRouter.POST("/tickets", handlers.WebSocket.CreateTicket)
+		}
+
 		privateOrder := privateRouter.Group("/orders")
 		{
 			privateOrder.Use(middlewares.JwtMultiRoleMiddleware(config, []string{constants.UserRole, constants.AdminRole, constants.PharmacyManagerRole}))
 			privateOrder.GET("/:id", handlers.Order.GetOrderDetail)
+			privateOrder.GET("/:id/history", handlers.Order.GetOrderStatusHistories)
+			privateOrder.GET("/:id/refunds", handlers.Refund.GetOrderRefunds)
+			privateOrder.GET("/:id/invoice", handlers.Order.GetOrderInvoice)
 		}
 	}
 
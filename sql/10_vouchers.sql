@@ -0,0 +1,42 @@
+CREATE TABLE vouchers (
+	id BIGSERIAL PRIMARY KEY,
+	code VARCHAR NOT NULL,
+	name VARCHAR NOT NULL,
+	discount_type VARCHAR NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+	discount_value DECIMAL NOT NULL CHECK (discount_value > 0),
+	max_discount DECIMAL CHECK (max_discount > 0),
+	min_spend DECIMAL NOT NULL DEFAULT 0 CHECK (min_spend >= 0),
+	global_quota INT CHECK (global_quota > 0),
+	per_user_quota INT CHECK (per_user_quota > 0),
+	starts_at TIMESTAMP NOT NULL,
+	ends_at TIMESTAMP NOT NULL,
+	category_id BIGINT REFERENCES categories(id),
+	product_id BIGINT REFERENCES products(id),
+	pharmacy_id BIGINT REFERENCES pharmacies(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP,
+	CHECK (ends_at > starts_at),
+	CHECK (discount_type <> 'percentage' OR discount_value <= 100)
+);
+
+CREATE UNIQUE INDEX vouchers_code_idx ON vouchers(UPPER(code)) WHERE deleted_at IS NULL;
+
+CREATE TABLE voucher_usages (
+	id BIGSERIAL PRIMARY KEY,
+	voucher_id BIGINT NOT NULL REFERENCES vouchers(id),
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	checkout_id BIGINT NOT NULL UNIQUE REFERENCES checkouts(id),
+	discount DECIMAL NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX voucher_usages_voucher_id_user_id_idx ON voucher_usages(voucher_id, user_id);
+
+-- the discount is stored next to the prices it was taken from, so reports and
+-- payments don't depend on the voucher staying unchanged
+ALTER TABLE checkouts ADD COLUMN discount DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN discount DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN discount DECIMAL NOT NULL DEFAULT 0;
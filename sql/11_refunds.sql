@@ -0,0 +1,35 @@
+CREATE TABLE refunds (
+	id BIGSERIAL PRIMARY KEY,
+	order_id BIGINT NOT NULL REFERENCES orders(id),
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	type VARCHAR NOT NULL CHECK (type IN ('cancellation', 'return')),
+	status VARCHAR NOT NULL CHECK (status IN ('requested', 'approved', 'rejected', 'paid_out')),
+	amount DECIMAL NOT NULL CHECK (amount >= 0),
+	reason VARCHAR,
+	admin_id BIGINT REFERENCES admins(id),
+	admin_note VARCHAR,
+	processed_at TIMESTAMP,
+	paid_out_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX refunds_order_id_idx ON refunds(order_id);
+CREATE INDEX refunds_status_idx ON refunds(status);
+
+-- a canceled order is refunded at most once
+CREATE UNIQUE INDEX refunds_cancellation_order_id_idx ON refunds(order_id) WHERE type = 'cancellation' AND deleted_at IS NULL;
+
+CREATE TABLE refund_items (
+	id BIGSERIAL PRIMARY KEY,
+	refund_id BIGINT NOT NULL REFERENCES refunds(id),
+	pharmacy_product_id BIGINT NOT NULL REFERENCES pharmacy_products(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	amount DECIMAL NOT NULL CHECK (amount >= 0),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX refund_items_refund_id_idx ON refund_items(refund_id);
@@ -0,0 +1,3 @@
+-- the generated invoice is reused until the order or its payment changes state
+ALTER TABLE orders ADD COLUMN invoice_url VARCHAR;
+ALTER TABLE orders ADD COLUMN invoice_cache_key VARCHAR;
@@ -0,0 +1,6 @@
+-- each chat is read by the participant who did not send it, so one pair of
+-- timestamps per row is that participant's delivery and read state
+ALTER TABLE chats ADD COLUMN delivered_at TIMESTAMP;
+ALTER TABLE chats ADD COLUMN read_at TIMESTAMP;
+
+CREATE INDEX chats_unread_idx ON chats (consultation_id, is_from_user) WHERE read_at IS NULL AND deleted_at IS NULL;
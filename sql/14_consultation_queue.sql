@@ -0,0 +1,30 @@
+-- consultations used to start right away, so existing rows count as accepted
+ALTER TABLE consultations ADD COLUMN status VARCHAR NOT NULL DEFAULT 'accepted' CHECK (status IN ('waiting', 'accepted', 'expired'));
+ALTER TABLE consultations ADD COLUMN queued_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE consultations ADD COLUMN accepted_at TIMESTAMP;
+
+UPDATE consultations SET queued_at = created_at, accepted_at = created_at;
+
+CREATE INDEX consultations_waiting_idx ON consultations(doctor_id, queued_at) WHERE status = 'waiting' AND deleted_at IS NULL;
+
+-- doctors a request was already offered to, so it is never routed back to them
+CREATE TABLE consultation_declines (
+	id BIGSERIAL PRIMARY KEY,
+	consultation_id BIGINT NOT NULL REFERENCES consultations(id),
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	reason VARCHAR NOT NULL CHECK (reason IN ('declined', 'timed_out')),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE (consultation_id, doctor_id)
+);
+
+-- one row per doctor per API instance holding a live WebSocket, refreshed by
+-- a heartbeat so rows left by a crashed instance expire
+CREATE TABLE doctor_presences (
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	instance_id VARCHAR NOT NULL,
+	connected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (doctor_id, instance_id)
+);
+
+UPDATE doctors SET is_online = false;
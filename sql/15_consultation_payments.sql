@@ -0,0 +1,24 @@
+-- a consultation request is only put in front of the doctor once its fee is paid
+ALTER TABLE consultations DROP CONSTRAINT consultations_status_check;
+ALTER TABLE consultations ADD CONSTRAINT consultations_status_check CHECK (status IN ('unpaid', 'waiting', 'accepted', 'expired'));
+ALTER TABLE consultations ADD COLUMN fee DECIMAL NOT NULL DEFAULT 0 CHECK (fee >= 0);
+ALTER TABLE consultations ADD COLUMN payment_deadline TIMESTAMP;
+ALTER TABLE consultations ADD COLUMN paid_at TIMESTAMP;
+
+CREATE INDEX consultations_unpaid_idx ON consultations(payment_deadline) WHERE status = 'unpaid' AND deleted_at IS NULL;
+
+-- a payment settles either a checkout or a consultation
+ALTER TABLE payments ALTER COLUMN checkout_id DROP NOT NULL;
+ALTER TABLE payments ADD COLUMN consultation_id BIGINT REFERENCES consultations(id);
+ALTER TABLE payments ADD CONSTRAINT payments_target_check CHECK ((checkout_id IS NULL) <> (consultation_id IS NULL));
+
+CREATE INDEX payments_consultation_id_idx ON payments(consultation_id);
+
+-- consultation fees are refunded when no doctor ever accepted the request
+ALTER TABLE refunds ALTER COLUMN order_id DROP NOT NULL;
+ALTER TABLE refunds ADD COLUMN consultation_id BIGINT REFERENCES consultations(id);
+ALTER TABLE refunds DROP CONSTRAINT refunds_type_check;
+ALTER TABLE refunds ADD CONSTRAINT refunds_type_check CHECK (type IN ('cancellation', 'return', 'consultation'));
+ALTER TABLE refunds ADD CONSTRAINT refunds_target_check CHECK ((order_id IS NULL) <> (consultation_id IS NULL));
+
+CREATE INDEX refunds_consultation_id_idx ON refunds(consultation_id);
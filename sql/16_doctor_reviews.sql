@@ -0,0 +1,22 @@
+CREATE TABLE doctor_reviews (
+	id BIGSERIAL PRIMARY KEY,
+	consultation_id BIGINT NOT NULL UNIQUE REFERENCES consultations(id),
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+	comment VARCHAR,
+	is_hidden BOOLEAN NOT NULL DEFAULT false,
+	hidden_by BIGINT REFERENCES admins(id),
+	hidden_reason VARCHAR,
+	hidden_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX doctor_reviews_doctor_id_idx ON doctor_reviews(doctor_id, created_at) WHERE deleted_at IS NULL;
+
+-- kept on the doctor so the doctor list can sort and filter by it; hidden
+-- reviews do not count
+ALTER TABLE doctors ADD COLUMN rating DECIMAL(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE doctors ADD COLUMN review_count INT NOT NULL DEFAULT 0;
@@ -0,0 +1,59 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- weekly windows a doctor takes appointments in, split into fixed-length slots
+CREATE TABLE doctor_schedules (
+	id BIGSERIAL PRIMARY KEY,
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+	start_time TIME NOT NULL,
+	end_time TIME NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP,
+	CHECK (start_time < end_time)
+);
+
+CREATE INDEX doctor_schedules_doctor_id_idx ON doctor_schedules(doctor_id, day_of_week) WHERE deleted_at IS NULL;
+
+-- days or hours the doctor is away; no times means the whole day
+CREATE TABLE doctor_schedule_exceptions (
+	id BIGSERIAL PRIMARY KEY,
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	date DATE NOT NULL,
+	start_time TIME,
+	end_time TIME,
+	reason VARCHAR,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP,
+	CHECK ((start_time IS NULL) = (end_time IS NULL)),
+	CHECK (start_time IS NULL OR start_time < end_time)
+);
+
+CREATE INDEX doctor_schedule_exceptions_doctor_id_idx ON doctor_schedule_exceptions(doctor_id, date) WHERE deleted_at IS NULL;
+
+-- a booked consultation waits in 'scheduled' until its slot starts
+ALTER TABLE consultations DROP CONSTRAINT consultations_status_check;
+ALTER TABLE consultations ADD CONSTRAINT consultations_status_check CHECK (status IN ('unpaid', 'scheduled', 'waiting', 'accepted', 'expired'));
+ALTER TABLE consultations ADD COLUMN scheduled_at TIMESTAMP;
+
+CREATE TABLE appointments (
+	id BIGSERIAL PRIMARY KEY,
+	consultation_id BIGINT NOT NULL UNIQUE REFERENCES consultations(id),
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	start_at TIMESTAMP NOT NULL,
+	end_at TIMESTAMP NOT NULL,
+	status VARCHAR NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'started', 'canceled')),
+	reminder_sent_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP,
+	CHECK (start_at < end_at),
+	-- neither the doctor nor the patient can hold two overlapping appointments
+	EXCLUDE USING gist (doctor_id WITH =, tsrange(start_at, end_at) WITH &&) WHERE (status <> 'canceled' AND deleted_at IS NULL),
+	EXCLUDE USING gist (user_id WITH =, tsrange(start_at, end_at) WITH &&) WHERE (status <> 'canceled' AND deleted_at IS NULL)
+);
+
+CREATE INDEX appointments_user_id_idx ON appointments(user_id, start_at) WHERE deleted_at IS NULL;
+CREATE INDEX appointments_due_idx ON appointments(start_at) WHERE status = 'booked' AND deleted_at IS NULL;
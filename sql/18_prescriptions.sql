@@ -0,0 +1,23 @@
+ALTER TABLE prescription_items ADD COLUMN dosage VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE prescription_items ADD COLUMN frequency VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE prescription_items ADD COLUMN duration VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE prescription_items ADD COLUMN route VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE prescription_items ADD COLUMN notes VARCHAR;
+
+-- one prescription per consultation; the patient can redeem it once plus
+-- refills times before it expires
+CREATE TABLE prescriptions (
+	id BIGSERIAL PRIMARY KEY,
+	consultation_id BIGINT NOT NULL UNIQUE REFERENCES consultations(id),
+	expires_at DATE NOT NULL,
+	refills INT NOT NULL DEFAULT 0 CHECK (refills >= 0),
+	fill_count INT NOT NULL DEFAULT 0 CHECK (fill_count <= refills + 1),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+INSERT INTO prescriptions (consultation_id, expires_at)
+SELECT consultation_id, MIN(created_at)::date + 30 FROM prescription_items
+WHERE deleted_at IS NULL
+GROUP BY consultation_id;
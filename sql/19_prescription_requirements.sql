@@ -0,0 +1,8 @@
+-- admins decide which classifications can only be bought against a
+-- prescription; obat keras needs one out of the box
+ALTER TABLE product_classifications ADD COLUMN requires_prescription BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE product_classifications SET requires_prescription = true WHERE name ILIKE 'obat keras';
+
+-- the prescription item a cart line was added for, if any
+ALTER TABLE cart_items ADD COLUMN prescription_item_id BIGINT REFERENCES prescription_items(id);
@@ -0,0 +1,19 @@
+-- every prescription and sick-leave certificate PDF gets a row here; the QR
+-- code on the PDF carries a signed token naming the document number
+CREATE TABLE documents (
+	id BIGSERIAL PRIMARY KEY,
+	number VARCHAR NOT NULL UNIQUE,
+	type VARCHAR NOT NULL CHECK (type IN ('prescription', 'certificate')),
+	consultation_id BIGINT NOT NULL REFERENCES consultations(id),
+	doctor_id BIGINT NOT NULL REFERENCES doctors(id),
+	patient_name VARCHAR NOT NULL,
+	valid_from DATE NOT NULL,
+	valid_until DATE NOT NULL,
+	issued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX documents_consultation_id_idx ON documents(consultation_id, type) WHERE revoked_at IS NULL AND deleted_at IS NULL;
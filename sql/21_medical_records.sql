@@ -0,0 +1,17 @@
+-- the diagnosis a doctor writes on the sick-leave certificate, kept so it can
+-- show up in the patient's medical record
+ALTER TABLE consultations ADD COLUMN diagnosis VARCHAR;
+
+-- allergies and chronic conditions the user reports about themselves
+CREATE TABLE medical_conditions (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	type VARCHAR NOT NULL CHECK (type IN ('allergy', 'chronic')),
+	name VARCHAR NOT NULL,
+	notes VARCHAR,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX medical_conditions_user_id_idx ON medical_conditions(user_id) WHERE deleted_at IS NULL;
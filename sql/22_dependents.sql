@@ -0,0 +1,18 @@
+-- family members a user books consultations for; consultations and medical
+-- conditions point at one when they are not about the user themselves
+CREATE TABLE dependents (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	name VARCHAR NOT NULL,
+	relationship VARCHAR NOT NULL CHECK (relationship IN ('child', 'parent', 'spouse', 'sibling', 'other')),
+	birth_date DATE NOT NULL,
+	gender_id BIGINT NOT NULL REFERENCES genders(id),
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX dependents_user_id_idx ON dependents(user_id) WHERE deleted_at IS NULL;
+
+ALTER TABLE consultations ADD COLUMN dependent_id BIGINT REFERENCES dependents(id);
+ALTER TABLE medical_conditions ADD COLUMN dependent_id BIGINT REFERENCES dependents(id);
@@ -0,0 +1,3 @@
+-- the prescription item an order line spent a fill of, so canceling the
+-- order can give the fill back
+ALTER TABLE order_items ADD COLUMN prescription_item_id BIGINT REFERENCES prescription_items(id);
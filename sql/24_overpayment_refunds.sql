@@ -0,0 +1,4 @@
+-- a charge that settles after the order was already paid by another charge
+-- is given back as an overpayment
+ALTER TABLE refunds DROP CONSTRAINT refunds_type_check;
+ALTER TABLE refunds ADD CONSTRAINT refunds_type_check CHECK (type IN ('cancellation', 'return', 'consultation', 'overpayment'));
@@ -0,0 +1,10 @@
+-- a ws ticket is single use; its id is kept until the ticket expires so every
+-- API instance rejects a replay
+CREATE TABLE ws_ticket_redemptions (
+	id BIGSERIAL PRIMARY KEY,
+	jti VARCHAR NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ws_ticket_redemptions_expires_at_idx ON ws_ticket_redemptions(expires_at);
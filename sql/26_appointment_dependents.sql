@@ -0,0 +1,12 @@
+-- a user and each of their dependents are separate patients, so only the
+-- same patient cannot hold two overlapping appointments
+ALTER TABLE appointments ADD COLUMN dependent_id BIGINT REFERENCES dependents(id);
+
+UPDATE appointments a SET
+dependent_id = c.dependent_id
+FROM consultations c
+WHERE c.id = a.consultation_id;
+
+ALTER TABLE appointments DROP CONSTRAINT appointments_user_id_tsrange_excl;
+ALTER TABLE appointments ADD CONSTRAINT appointments_patient_tsrange_excl
+	EXCLUDE USING gist (user_id WITH =, (COALESCE(dependent_id, 0)) WITH =, tsrange(start_at, end_at) WITH &&) WHERE (status <> 'canceled' AND deleted_at IS NULL);
@@ -0,0 +1,30 @@
+CREATE TABLE payments (
+	id BIGSERIAL PRIMARY KEY,
+	order_id BIGINT NOT NULL REFERENCES orders(id),
+	provider VARCHAR NOT NULL,
+	method VARCHAR NOT NULL,
+	channel VARCHAR NOT NULL,
+	external_id VARCHAR NOT NULL,
+	account_number VARCHAR,
+	checkout_url VARCHAR,
+	amount DECIMAL NOT NULL,
+	status VARCHAR NOT NULL,
+	expired_at TIMESTAMP NOT NULL,
+	paid_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP,
+	UNIQUE (provider, external_id)
+);
+
+CREATE INDEX payments_order_id_idx ON payments(order_id);
+
+CREATE TABLE payment_callbacks (
+	id BIGSERIAL PRIMARY KEY,
+	provider VARCHAR NOT NULL,
+	callback_id VARCHAR NOT NULL,
+	payment_id BIGINT NOT NULL REFERENCES payments(id),
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	UNIQUE (provider, callback_id)
+);
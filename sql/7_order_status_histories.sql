@@ -0,0 +1,14 @@
+CREATE TABLE order_status_histories (
+	id BIGSERIAL PRIMARY KEY,
+	order_id BIGINT NOT NULL REFERENCES orders(id),
+	from_status VARCHAR,
+	to_status VARCHAR NOT NULL,
+	actor_role VARCHAR NOT NULL,
+	actor_id BIGINT,
+	reason VARCHAR,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+CREATE INDEX order_status_histories_order_id_idx ON order_status_histories(order_id);
@@ -0,0 +1,37 @@
+CREATE TABLE checkouts (
+	id BIGSERIAL PRIMARY KEY,
+	checkout_number VARCHAR NOT NULL UNIQUE,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	total_price DECIMAL NOT NULL,
+	shipping_fee DECIMAL NOT NULL,
+	payment_deadline TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	deleted_at TIMESTAMP
+);
+
+ALTER TABLE orders ADD COLUMN checkout_id BIGINT REFERENCES checkouts(id);
+
+-- every order placed before checkouts existed becomes its own single-order checkout
+INSERT INTO checkouts (checkout_number, user_id, total_price, shipping_fee, payment_deadline, created_at)
+SELECT o.order_number, ua.user_id, o.total_price, o.shipping_fee, o.payment_deadline, o.created_at
+FROM orders o
+JOIN user_addresses ua ON ua.id = o.user_address_id;
+
+UPDATE orders o SET checkout_id = c.id FROM checkouts c WHERE c.checkout_number = o.order_number;
+
+ALTER TABLE orders ALTER COLUMN checkout_id SET NOT NULL;
+
+CREATE INDEX orders_checkout_id_idx ON orders(checkout_id);
+
+ALTER TABLE payments ADD COLUMN checkout_id BIGINT REFERENCES checkouts(id);
+
+UPDATE payments p SET checkout_id = o.checkout_id FROM orders o WHERE o.id = p.order_id;
+
+ALTER TABLE payments ALTER COLUMN checkout_id SET NOT NULL;
+
+DROP INDEX payments_order_id_idx;
+
+ALTER TABLE payments DROP COLUMN order_id;
+
+CREATE INDEX payments_checkout_id_idx ON payments(checkout_id);
@@ -0,0 +1,6 @@
+ALTER TABLE order_items ADD COLUMN price DECIMAL;
+
+-- best effort for orders placed before prices were snapshotted
+UPDATE order_items oi SET price = pp.price FROM pharmacy_products pp WHERE pp.id = oi.pharmacy_product_id;
+
+ALTER TABLE order_items ALTER COLUMN price SET NOT NULL;
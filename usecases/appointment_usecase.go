@@ -0,0 +1,308 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/shopspring/decimal"
+)
+
+type AppointmentUsecaseOpts struct {
+	AppointmentRepository  repositories.AppointmentRepository
+	ConsultationRepository repositories.ConsultationRepository
+	DoctorRepository       repositories.DoctorRepository
+	RefundRepository       repositories.RefundRepository
+	DependentRepository    repositories.DependentRepository
+	DoctorScheduleUsecase  DoctorScheduleUsecase
+	EmailSender            utils.EmailSender
+	Transactor             repositories.Transactor
+	EventPublisher         ConsultationEventPublisher
+}
+
+type AppointmentUsecase interface {
+	BookAppointment(ctx context.Context, userId int64, req dtos.AppointmentRequest) (*entities.Appointment, error)
+	GetAllAppointmentByUser(ctx context.Context, userId int64, params entities.AppointmentParams) ([]entities.Appointment, int, error)
+	GetAllAppointmentByDoctor(ctx context.Context, doctorId int64, params entities.AppointmentParams) ([]entities.Appointment, int, error)
+	CancelAppointment(ctx context.Context, appointmentId int64, userId int64) error
+	StartDueAppointments(ctx context.Context) (int, error)
+	SendAppointmentReminders(ctx context.Context) (int, error)
+}
+
+type AppointmentUsecaseImpl struct {
+	AppointmentRepository  repositories.AppointmentRepository
+	ConsultationRepository repositories.ConsultationRepository
+	DoctorRepository       repositories.DoctorRepository
+	RefundRepository       repositories.RefundRepository
+	DependentRepository    repositories.DependentRepository
+	DoctorScheduleUsecase  DoctorScheduleUsecase
+	EmailSender            utils.EmailSender
+	Transactor             repositories.Transactor
+	EventPublisher         ConsultationEventPublisher
+}
+
+func NewAppointmentUsecaseImpl(aOpts *AppointmentUsecaseOpts) AppointmentUsecase {
+	return &AppointmentUsecaseImpl{
+		AppointmentRepository:  aOpts.AppointmentRepository,
+		ConsultationRepository: aOpts.ConsultationRepository,
+		DoctorRepository:       aOpts.DoctorRepository,
+		RefundRepository:       aOpts.RefundRepository,
+		DependentRepository:    aOpts.DependentRepository,
+		DoctorScheduleUsecase:  aOpts.DoctorScheduleUsecase,
+		EmailSender:            aOpts.EmailSender,
+		Transactor:             aOpts.Transactor,
+		EventPublisher:         aOpts.EventPublisher,
+	}
+}
+
+// BookAppointment reserves one of the doctor's free slots. The consultation
+// behind it is charged like an immediate one, but must be paid before the
+// slot starts, and then waits as scheduled until the slot opens.
+func (u *AppointmentUsecaseImpl) BookAppointment(ctx context.Context, userId int64, req dtos.AppointmentRequest) (*entities.Appointment, error) {
+	startAt := req.StartAt.In(time.Local)
+
+	slots, err := u.DoctorScheduleUsecase.GetAvailableSlots(ctx, req.DoctorId, startAt.Format(constants.ScheduleDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	var slot *entities.AppointmentSlot
+	for i := range slots {
+		if slots[i].StartAt.Equal(startAt) {
+			slot = &slots[i]
+			break
+		}
+	}
+
+	if slot == nil {
+		return nil, custom_errors.BadRequest(nil, constants.SlotNotAvailableErrMsg)
+	}
+
+	doctor, err := u.DoctorRepository.FindOneById(ctx, req.DoctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	consultation := entities.Consultation{
+		User:             entities.User{Id: userId},
+		Doctor:           entities.Doctor{Id: doctor.Id},
+		PatientGender:    entities.Gender{Id: req.PatientGenderId},
+		PatientName:      req.PatientName,
+		PatientBirthDate: req.PatientBirthDate,
+		Status:           constants.ConsultationStatusScheduled,
+		Fee:              decimal.NewFromInt(doctor.Fee.Int64),
+		ScheduledAt:      sql.NullTime{Time: slot.StartAt, Valid: true},
+		DependentId:      sql.NullInt64{Int64: req.DependentId, Valid: req.DependentId != 0},
+	}
+
+	err = applyDependent(ctx, u.DependentRepository, &consultation)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.Fee.IsPositive() {
+		deadline := time.Now().Add(constants.ConsultationPaymentTimeout)
+		if deadline.After(slot.StartAt) {
+			deadline = slot.StartAt
+		}
+
+		consultation.Status = constants.ConsultationStatusUnpaid
+		consultation.PaymentDeadline = sql.NullTime{Time: deadline, Valid: true}
+	}
+
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		newC, err := u.ConsultationRepository.CreateOne(txCtx, consultation)
+		if err != nil {
+			return nil, err
+		}
+
+		// the exclusion constraints on appointments reject a slot someone
+		// else took since the availability check, or one the same patient
+		// already holds
+		appointment, err := u.AppointmentRepository.CreateOne(txCtx, entities.Appointment{
+			ConsultationId: newC.Id,
+			DoctorId:       doctor.Id,
+			UserId:         userId,
+			DependentId:    consultation.DependentId,
+			StartAt:        slot.StartAt,
+			EndAt:          slot.EndAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		appointment.ConsultationStatus = newC.Status
+		appointment.DoctorName = doctor.Name
+
+		return appointment, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*entities.Appointment), nil
+}
+
+func (u *AppointmentUsecaseImpl) GetAllAppointmentByUser(ctx context.Context, userId int64, params entities.AppointmentParams) ([]entities.Appointment, int, error) {
+	var total int
+
+	appointments, err := u.AppointmentRepository.FindAllByUserId(ctx, userId, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(appointments) > 0 {
+		total = appointments[0].Total
+	}
+
+	return appointments, total, nil
+}
+
+func (u *AppointmentUsecaseImpl) GetAllAppointmentByDoctor(ctx context.Context, doctorId int64, params entities.AppointmentParams) ([]entities.Appointment, int, error) {
+	var total int
+
+	appointments, err := u.AppointmentRepository.FindAllByDoctorId(ctx, doctorId, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(appointments) > 0 {
+		total = appointments[0].Total
+	}
+
+	return appointments, total, nil
+}
+
+// CancelAppointment frees the slot and drops the consultation behind it. A
+// fee that was already paid is refunded.
+func (u *AppointmentUsecaseImpl) CancelAppointment(ctx context.Context, appointmentId int64, userId int64) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		appointment, err := u.AppointmentRepository.LockById(txCtx, appointmentId)
+		if err != nil {
+			return nil, err
+		}
+
+		if appointment.UserId != userId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		consultation, err := u.ConsultationRepository.LockRequestById(txCtx, appointment.ConsultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		if appointment.Status != constants.AppointmentStatusBooked || (consultation.Status != constants.ConsultationStatusUnpaid && consultation.Status != constants.ConsultationStatusScheduled) {
+			return nil, custom_errors.BadRequest(nil, constants.AppointmentNotBookedErrMsg)
+		}
+
+		err = u.AppointmentRepository.UpdateStatus(txCtx, appointment.Id, constants.AppointmentStatusCanceled)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.ConsultationRepository.UpdateExpired(txCtx, consultation.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if consultation.PaidAt.Valid {
+			return nil, createConsultationRefund(txCtx, u.RefundRepository, *consultation, consultation.Fee, constants.AppointmentCanceledReason)
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StartDueAppointments opens the consultation of every paid appointment whose
+// slot has started. It is accepted on the doctor's behalf, so both sides can
+// join the room and chat right away.
+func (u *AppointmentUsecaseImpl) StartDueAppointments(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		appointments, err := u.AppointmentRepository.LockDue(txCtx, constants.AppointmentBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		events := []entities.ConsultationEvent{}
+		for _, a := range appointments {
+			err := u.ConsultationRepository.UpdateAccepted(txCtx, a.ConsultationId)
+			if err != nil {
+				return nil, err
+			}
+
+			err = u.AppointmentRepository.UpdateStatus(txCtx, a.Id, constants.AppointmentStatusStarted)
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, entities.ConsultationEvent{
+				Type:           constants.ConsultationEventAccepted,
+				ConsultationId: a.ConsultationId,
+				UserId:         a.UserId,
+				DoctorId:       a.DoctorId,
+			})
+		}
+
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	events := res.([]entities.ConsultationEvent)
+	publishConsultationEvents(ctx, u.EventPublisher, events)
+
+	return len(events), nil
+}
+
+// SendAppointmentReminders emails the patient and the doctor of every paid
+// appointment starting soon. An appointment is marked as reminded before the
+// emails go out, so a failed email is logged and not retried.
+func (u *AppointmentUsecaseImpl) SendAppointmentReminders(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		appointments, err := u.AppointmentRepository.LockUnreminded(txCtx, constants.AppointmentReminderLead, constants.AppointmentBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range appointments {
+			err := u.AppointmentRepository.UpdateReminderSent(txCtx, a.Id)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return appointments, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	appointments := res.([]entities.Appointment)
+	for _, a := range appointments {
+		startAt := a.StartAt.Format(constants.AppointmentReminderLayout)
+
+		err := u.EmailSender.SendEmail(a.UserEmail, fmt.Sprintf(constants.AppointmentUserReminderMsg, a.DoctorName, startAt), constants.AppointmentEmailSubject)
+		if err != nil {
+			log.Printf("appointment %d reminder: %s", a.Id, err.Error())
+		}
+
+		err = u.EmailSender.SendEmail(a.DoctorEmail, fmt.Sprintf(constants.AppointmentDoctorReminderMsg, a.UserName, startAt), constants.AppointmentEmailSubject)
+		if err != nil {
+			log.Printf("appointment %d reminder: %s", a.Id, err.Error())
+		}
+	}
+
+	return len(appointments), nil
+}
@@ -45,6 +45,11 @@ func (u *CartUsecaseImpl) CreateCartItem(ctx context.Context, req entities.CartI
 	}
 
 	if err != nil && err.Error() == constants.ResponseMsgErrorNotFound {
+		err = u.checkPrescription(ctx, req, req.Quantity)
+		if err != nil {
+			return err
+		}
+
 		isAvailable, err := u.checkStockAvailability(ctx, req.PharmacyProductId, 0, req.Quantity)
 		if err != nil {
 			return err
@@ -77,6 +82,18 @@ func (u *CartUsecaseImpl) IncreaseCartItemQuantity(ctx context.Context, req enti
 		return err
 	}
 
+	cart.Id = req.Id
+
+	// a line added again for a new prescription item moves over to it
+	if req.PrescriptionItemId.Valid {
+		cart.PrescriptionItemId = req.PrescriptionItemId
+	}
+
+	err = u.checkPrescription(ctx, *cart, cart.Quantity+req.Quantity)
+	if err != nil {
+		return err
+	}
+
 	isAvailable, err := u.checkStockAvailability(ctx, cart.PharmacyProductId, cart.Quantity, req.Quantity)
 	if err != nil {
 		return err
@@ -154,3 +171,33 @@ func (u *CartUsecaseImpl) checkStockAvailability(ctx context.Context, PharmacyPr
 
 	return &result, nil
 }
+
+// checkPrescription refuses a cart line for a prescription-only product unless
+// it is tied to a valid prescription item of the user. A prescription item
+// given for any other product has to be valid as well, and the lines tied to
+// one cannot hold more than the quantity it prescribes between them.
+func (u *CartUsecaseImpl) checkPrescription(ctx context.Context, req entities.CartItem, quantity int) error {
+	requirement, err := u.CartRepository.FindPrescriptionRequirement(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if (requirement.RequiresPrescription || req.PrescriptionItemId.Valid) && !requirement.IsPrescribed {
+		return custom_errors.BadRequest(nil, constants.PrescriptionRequiredErrMsg)
+	}
+
+	if !req.PrescriptionItemId.Valid {
+		return nil
+	}
+
+	inCart, err := u.CartRepository.SumPrescriptionItemQuantity(ctx, req.UserId, req.PrescriptionItemId.Int64, req.Id)
+	if err != nil {
+		return err
+	}
+
+	if inCart+quantity > int(requirement.PrescribedQuantity.Int64) {
+		return custom_errors.BadRequest(nil, constants.PrescribedQuantityErrMsg)
+	}
+
+	return nil
+}
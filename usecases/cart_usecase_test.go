@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+)
+
+// stubCartRepository keeps the user's cart lines in memory. Every product
+// needs a prescription and every prescription item prescribes 5.
+type stubCartRepository struct {
+	repositories.CartRepository
+	lines []entities.CartItem
+}
+
+func (r *stubCartRepository) FindCartItem(ctx context.Context, req entities.CartItem) (*entities.CartItem, error) {
+	for _, line := range r.lines {
+		if line.UserId == req.UserId && line.PharmacyProductId == req.PharmacyProductId {
+			return &entities.CartItem{Id: line.Id, Quantity: line.Quantity}, nil
+		}
+	}
+	return nil, custom_errors.NotFound(sql.ErrNoRows)
+}
+
+func (r *stubCartRepository) FindPharmacyIdByCartId(ctx context.Context, id int64) (*entities.CartItem, error) {
+	for _, line := range r.lines {
+		if line.Id == id {
+			return &entities.CartItem{Quantity: line.Quantity, PharmacyProductId: line.PharmacyProductId, UserId: line.UserId, PrescriptionItemId: line.PrescriptionItemId}, nil
+		}
+	}
+	return nil, custom_errors.NotFound(sql.ErrNoRows)
+}
+
+func (r *stubCartRepository) CreateOneCartItem(ctx context.Context, req entities.CartItem) error {
+	req.Id = int64(len(r.lines)) + 1
+	r.lines = append(r.lines, req)
+	return nil
+}
+
+func (r *stubCartRepository) IncreaseCartQuantity(ctx context.Context, req entities.CartItem) error {
+	for i, line := range r.lines {
+		if line.Id == req.Id {
+			r.lines[i].Quantity += req.Quantity
+			if req.PrescriptionItemId.Valid {
+				r.lines[i].PrescriptionItemId = req.PrescriptionItemId
+			}
+		}
+	}
+	return nil
+}
+
+func (r *stubCartRepository) FindPrescriptionRequirement(ctx context.Context, req entities.CartItem) (*entities.CartItem, error) {
+	return &entities.CartItem{RequiresPrescription: true, IsPrescribed: req.PrescriptionItemId.Valid, PrescribedQuantity: sql.NullInt64{Int64: 5, Valid: req.PrescriptionItemId.Valid}}, nil
+}
+
+func (r *stubCartRepository) SumPrescriptionItemQuantity(ctx context.Context, userId int64, prescriptionItemId int64, excludedCartId int64) (int, error) {
+	quantity := 0
+	for _, line := range r.lines {
+		if line.UserId == userId && line.PrescriptionItemId.Int64 == prescriptionItemId && line.Id != excludedCartId {
+			quantity += line.Quantity
+		}
+	}
+	return quantity, nil
+}
+
+func (r *stubPharmacyProductRepository) FindOnePharmacyProduct(ctx context.Context, id int64) (*entities.PharmacyProduct, error) {
+	return &entities.PharmacyProduct{Id: id, TotalStock: 100}, nil
+}
+
+func testCartLine(id int64, pharmacyProductId int64, quantity int, prescriptionItemId int64) entities.CartItem {
+	return entities.CartItem{
+		Id:                 id,
+		UserId:             7,
+		PharmacyProductId:  pharmacyProductId,
+		Quantity:           quantity,
+		PrescriptionItemId: sql.NullInt64{Int64: prescriptionItemId, Valid: prescriptionItemId != 0},
+	}
+}
+
+func TestCartPrescribedQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []entities.CartItem
+		add     func(u CartUsecase) error
+		wantErr string
+	}{
+		{
+			name: "a new line within the prescribed quantity",
+			add: func(u CartUsecase) error {
+				return u.CreateCartItem(context.Background(), testCartLine(0, 1, 5, 9))
+			},
+		},
+		{
+			name: "a new line over the prescribed quantity",
+			add: func(u CartUsecase) error {
+				return u.CreateCartItem(context.Background(), testCartLine(0, 1, 6, 9))
+			},
+			wantErr: constants.PrescribedQuantityErrMsg,
+		},
+		{
+			name:  "a line at a second pharmacy shares the prescribed quantity",
+			lines: []entities.CartItem{testCartLine(1, 1, 3, 9)},
+			add: func(u CartUsecase) error {
+				return u.CreateCartItem(context.Background(), testCartLine(0, 2, 3, 9))
+			},
+			wantErr: constants.PrescribedQuantityErrMsg,
+		},
+		{
+			name:  "a line at a second pharmacy takes what is left",
+			lines: []entities.CartItem{testCartLine(1, 1, 3, 9)},
+			add: func(u CartUsecase) error {
+				return u.CreateCartItem(context.Background(), testCartLine(0, 2, 2, 9))
+			},
+		},
+		{
+			name:  "lines of another prescription item do not count",
+			lines: []entities.CartItem{testCartLine(1, 1, 5, 8)},
+			add: func(u CartUsecase) error {
+				return u.CreateCartItem(context.Background(), testCartLine(0, 2, 5, 9))
+			},
+		},
+		{
+			name:  "topping up a line counts the other lines",
+			lines: []entities.CartItem{testCartLine(1, 1, 3, 9), testCartLine(2, 2, 1, 9)},
+			add: func(u CartUsecase) error {
+				return u.IncreaseCartItemQuantity(context.Background(), entities.CartItem{Id: 2, Quantity: 2})
+			},
+			wantErr: constants.PrescribedQuantityErrMsg,
+		},
+		{
+			name:  "topping up a line does not count it twice",
+			lines: []entities.CartItem{testCartLine(1, 1, 3, 9), testCartLine(2, 2, 1, 9)},
+			add: func(u CartUsecase) error {
+				return u.IncreaseCartItemQuantity(context.Background(), entities.CartItem{Id: 2, Quantity: 1})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := NewCartUsecaseImpl(&CartUsecaseOpts{
+				CartRepo:                  &stubCartRepository{lines: tt.lines},
+				PharmacyProductRepository: &stubPharmacyProductRepository{},
+			})
+
+			err := tt.add(u)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func (r *stubCartRepository) FindCheckoutCartItems(ctx context.Context, userId int64, id []int64) ([]entities.CartItem, error) {
+	lines := []entities.CartItem{}
+	for _, line := range r.lines {
+		for _, cartId := range id {
+			if line.Id == cartId {
+				line.RequiresPrescription = true
+				line.IsPrescribed = line.PrescriptionItemId.Valid
+				line.PrescribedQuantity = sql.NullInt64{Int64: 5, Valid: line.PrescriptionItemId.Valid}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
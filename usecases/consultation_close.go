@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+// CloseInactiveConsultations ends consultations that ran past the maximum
+// session length or went quiet for longer than the idle timeout. Each one gets
+// a system chat explaining why, and its room is torn down on every instance.
+func (u *ConsultationUsecaseImpl) CloseInactiveConsultations(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		events := []entities.ConsultationEvent{}
+
+		overdue, err := u.ConsultationRepository.LockOverdueConsultations(txCtx, time.Duration(u.MaxMinutes)*time.Minute, constants.ConsultationCloseBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range overdue {
+			event, err := u.closeConsultation(txCtx, c, constants.ConsultationMaxReachedMsg)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, *event)
+		}
+
+		// consultations closed above are no longer open, so they drop out here
+		idle, err := u.ConsultationRepository.LockIdleConsultations(txCtx, time.Duration(u.IdleMinutes)*time.Minute, constants.ConsultationCloseBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range idle {
+			event, err := u.closeConsultation(txCtx, c, constants.ConsultationIdleEndedMsg)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, *event)
+		}
+
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	events := res.([]entities.ConsultationEvent)
+	publishConsultationEvents(ctx, u.EventPublisher, events)
+
+	return len(events), nil
+}
+
+// closeConsultation ends a locked consultation. A started appointment the
+// doctor never wrote in is a no-show, so it expires and its paid fee is
+// refunded the way a withdrawn one is.
+func (u *ConsultationUsecaseImpl) closeConsultation(ctx context.Context, c entities.Consultation, message string) (*entities.ConsultationEvent, error) {
+	noShow := false
+	if c.ScheduledAt.Valid {
+		doctorWrote, err := u.ChatRepository.ExistsFromDoctor(ctx, c.Id)
+		if err != nil {
+			return nil, err
+		}
+		noShow = !doctorWrote
+	}
+
+	var err error
+	if noShow {
+		message = constants.ConsultationNoShowMsg
+		err = u.ConsultationRepository.UpdateExpired(ctx, c.Id)
+	} else {
+		err = u.ConsultationRepository.UpdateEndedAt(ctx, c.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if noShow && c.PaidAt.Valid {
+		err = createConsultationRefund(ctx, u.RefundRepository, c, c.Fee, constants.ConsultationNoShowReason)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chat, err := u.ChatRepository.CreateOne(ctx, entities.Chat{
+		ConsultationId: c.Id,
+		IsFromUser:     false,
+		Content:        message,
+		Type:           constants.SystemChatType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.ConsultationEvent{
+		Type:           constants.ConsultationEventEnded,
+		ConsultationId: c.Id,
+		UserId:         c.User.Id,
+		DoctorId:       c.Doctor.Id,
+		ChatId:         chat.Id,
+		Content:        chat.Content,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+// createConsultationRefund returns a consultation fee to the patient. Nothing
+// has to be checked by hand, so the refund is approved right away and only
+// waits for an admin to pay it out.
+func createConsultationRefund(ctx context.Context, refundRepository repositories.RefundRepository, c entities.Consultation, amount decimal.Decimal, reason string) error {
+	_, err := refundRepository.CreateOne(ctx, entities.Refund{
+		ConsultationId: sql.NullInt64{Int64: c.Id, Valid: true},
+		UserId:         c.User.Id,
+		Type:           constants.RefundTypeConsultation,
+		Status:         constants.RefundStatusApproved,
+		Amount:         amount,
+		Reason:         sql.NullString{String: reason, Valid: true},
+		ProcessedAt:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+
+	return err
+}
+
+// ExpireUnpaidConsultations drops requests whose fee was not paid before the
+// payment deadline, freeing the slot of a booked appointment. Their doctor
+// never saw them, so nobody is notified.
+func (u *ConsultationUsecaseImpl) ExpireUnpaidConsultations(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		consultations, err := u.ConsultationRepository.LockUnpaidRequests(txCtx, constants.ConsultationQueueBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range consultations {
+			err := u.ConsultationRepository.UpdateExpired(txCtx, c.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = u.AppointmentRepository.CancelByConsultationId(txCtx, c.Id)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return len(consultations), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int), nil
+}
@@ -0,0 +1,243 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+// ConsultationEventPublisher pushes request updates to the connected doctor
+// and patient. Delivery is best effort; the database stays the source of truth.
+type ConsultationEventPublisher interface {
+	PublishConsultationEvent(ctx context.Context, event entities.ConsultationEvent) error
+}
+
+func publishConsultationEvents(ctx context.Context, publisher ConsultationEventPublisher, events []entities.ConsultationEvent) {
+	if publisher == nil {
+		return
+	}
+
+	for _, event := range events {
+		if err := publisher.PublishConsultationEvent(ctx, event); err != nil {
+			log.Printf("consultation event %s: %s", event.Type, err.Error())
+		}
+	}
+}
+
+func (u *ConsultationUsecaseImpl) GetConsultationQueue(ctx context.Context, doctorId int64) ([]entities.ConsultationQueueItem, error) {
+	waiting, err := u.ConsultationRepository.FindWaitingByDoctorId(ctx, doctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	active, avgDuration, err := u.ConsultationRepository.FindDoctorLoad(ctx, doctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	if avgDuration <= 0 {
+		avgDuration = constants.ConsultationDefaultDuration
+	}
+
+	queue := []entities.ConsultationQueueItem{}
+	for i, c := range waiting {
+		queue = append(queue, entities.ConsultationQueueItem{
+			Consultation:  c,
+			Position:      i + 1,
+			EstimatedWait: time.Duration(active+i) * avgDuration,
+		})
+	}
+
+	return queue, nil
+}
+
+func (u *ConsultationUsecaseImpl) GetConsultationQueuePosition(ctx context.Context, consultationId int64, userId int64) (*entities.ConsultationQueueItem, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.User.Id != userId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if consultation.Status != constants.ConsultationStatusWaiting {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationNotWaitingErrMsg)
+	}
+
+	queue, err := u.GetConsultationQueue(ctx, consultation.Doctor.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range queue {
+		if item.Consultation.Id == consultationId {
+			return &item, nil
+		}
+	}
+
+	return nil, custom_errors.BadRequest(nil, constants.ConsultationNotWaitingErrMsg)
+}
+
+func (u *ConsultationUsecaseImpl) AcceptConsultation(ctx context.Context, consultationId int64, doctorId int64) error {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		consultation, err := u.ConsultationRepository.LockRequestById(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		if consultation.Doctor.Id != doctorId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		if consultation.Status != constants.ConsultationStatusWaiting {
+			return nil, custom_errors.BadRequest(nil, constants.ConsultationNotWaitingErrMsg)
+		}
+
+		err = u.ConsultationRepository.UpdateAccepted(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		return []entities.ConsultationEvent{{
+			Type:           constants.ConsultationEventAccepted,
+			ConsultationId: consultation.Id,
+			UserId:         consultation.User.Id,
+			DoctorId:       doctorId,
+		}}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	publishConsultationEvents(ctx, u.EventPublisher, res.([]entities.ConsultationEvent))
+
+	return nil
+}
+
+func (u *ConsultationUsecaseImpl) DeclineConsultation(ctx context.Context, consultationId int64, doctorId int64) error {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		consultation, err := u.ConsultationRepository.LockRequestById(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		if consultation.Doctor.Id != doctorId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		if consultation.Status != constants.ConsultationStatusWaiting {
+			return nil, custom_errors.BadRequest(nil, constants.ConsultationNotWaitingErrMsg)
+		}
+
+		return u.reassignConsultation(txCtx, *consultation, constants.ConsultationDeclined)
+	})
+	if err != nil {
+		return err
+	}
+
+	publishConsultationEvents(ctx, u.EventPublisher, res.([]entities.ConsultationEvent))
+
+	return nil
+}
+
+// ReassignTimedOutConsultations hands every request its doctor left waiting
+// too long to another online doctor of the same specialist and fee.
+func (u *ConsultationUsecaseImpl) ReassignTimedOutConsultations(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		consultations, err := u.ConsultationRepository.LockTimedOutRequests(txCtx, constants.ConsultationRequestTimeout, constants.ConsultationQueueBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		events := []entities.ConsultationEvent{}
+		for _, c := range consultations {
+			reassigned, err := u.reassignConsultation(txCtx, c, constants.ConsultationTimedOut)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, reassigned...)
+		}
+
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	events := res.([]entities.ConsultationEvent)
+	publishConsultationEvents(ctx, u.EventPublisher, events)
+
+	reassigned := 0
+	for _, event := range events {
+		if event.Type == constants.ConsultationEventReassigned || event.Type == constants.ConsultationEventExpired {
+			reassigned++
+		}
+	}
+
+	return reassigned, nil
+}
+
+// reassignConsultation moves a locked request off its current doctor. Only
+// doctors charging the fee the user already paid are considered, so the price
+// never changes behind the user's back. When no other doctor is available the
+// request expires and a paid fee is refunded.
+func (u *ConsultationUsecaseImpl) reassignConsultation(ctx context.Context, c entities.Consultation, reason string) ([]entities.ConsultationEvent, error) {
+	err := u.ConsultationRepository.CreateDecline(ctx, c.Id, c.Doctor.Id, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	nextDoctorId, err := u.ConsultationRepository.FindAvailableDoctorId(ctx, c.Id, c.Doctor.Specialist.Id.Int64)
+	if err != nil {
+		var appErr *custom_errors.AppError
+		if !errors.As(err, &appErr) || appErr.Code != http.StatusNotFound {
+			return nil, err
+		}
+
+		err = u.ConsultationRepository.UpdateExpired(ctx, c.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.PaidAt.Valid {
+			err = createConsultationRefund(ctx, u.RefundRepository, c, c.Fee, constants.ConsultationRefundReason)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return []entities.ConsultationEvent{{
+			Type:           constants.ConsultationEventExpired,
+			ConsultationId: c.Id,
+			UserId:         c.User.Id,
+			DoctorId:       c.Doctor.Id,
+		}}, nil
+	}
+
+	err = u.ConsultationRepository.UpdateDoctor(ctx, c.Id, nextDoctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return []entities.ConsultationEvent{
+		{
+			Type:           constants.ConsultationEventReassigned,
+			ConsultationId: c.Id,
+			UserId:         c.User.Id,
+			DoctorId:       c.Doctor.Id,
+		},
+		{
+			Type:           constants.ConsultationEventRequested,
+			ConsultationId: c.Id,
+			UserId:         c.User.Id,
+			DoctorId:       nextDoctorId,
+		},
+	}, nil
+}
@@ -2,15 +2,18 @@ package usecases
 
 import (
 	"context"
+	"database/sql"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
 	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/shopspring/decimal"
 )
 
 type ConsultationUsecaseOpts struct {
@@ -21,7 +24,16 @@ type ConsultationUsecaseOpts struct {
 	PharmacyRepo     repositories.PharmacyRepository
 	UserAddressRepo  repositories.UserAddressRepository
 	CartRepo         repositories.CartRepository
+	RefundRepo       repositories.RefundRepository
+	AppointmentRepo  repositories.AppointmentRepository
+	PrescriptionRepo repositories.PrescriptionRepository
+	DependentRepo    repositories.DependentRepository
+	DocumentUsecase  DocumentUsecase
 	UploadFile       utils.FileUploader
+	Transactor       repositories.Transactor
+	EventPublisher   ConsultationEventPublisher
+	MaxMinutes       int
+	IdleMinutes      int
 }
 
 type ConsultationUsecase interface {
@@ -33,7 +45,19 @@ type ConsultationUsecase interface {
 	CreateChat(ctx context.Context, chat entities.Chat, userId int64) error
 	CreateCertificate(ctx context.Context, certificateData entities.CertificateData, doctorId int64) (string, error)
 	CreatePrescription(ctx context.Context, prescriptionData entities.PrescriptionData, doctorId int64) (string, error)
+	GetPrescription(ctx context.Context, consultationId int64, actorId int64, actorRole string) (*entities.Prescription, error)
 	AddPrescriptionToCart(ctx context.Context, consultationId int64, userId int64) error
+	GetAllActiveConsultation(ctx context.Context) ([]entities.Consultation, error)
+	GetConsultationChatsAfter(ctx context.Context, consultationId int64, lastSeenId int64) ([]entities.Chat, error)
+	MarkChatsDelivered(ctx context.Context, consultationId int64, chatId int64, readerRole string) error
+	MarkChatsRead(ctx context.Context, consultationId int64, chatId int64, readerRole string) error
+	GetConsultationQueue(ctx context.Context, doctorId int64) ([]entities.ConsultationQueueItem, error)
+	GetConsultationQueuePosition(ctx context.Context, consultationId int64, userId int64) (*entities.ConsultationQueueItem, error)
+	AcceptConsultation(ctx context.Context, consultationId int64, doctorId int64) error
+	DeclineConsultation(ctx context.Context, consultationId int64, doctorId int64) error
+	ReassignTimedOutConsultations(ctx context.Context) (int, error)
+	ExpireUnpaidConsultations(ctx context.Context) (int, error)
+	CloseInactiveConsultations(ctx context.Context) (int, error)
 }
 
 type ConsultationUsecaseImpl struct {
@@ -44,7 +68,16 @@ type ConsultationUsecaseImpl struct {
 	PharmacyRepository     repositories.PharmacyRepository
 	UserAddressRepository  repositories.UserAddressRepository
 	CartRepository         repositories.CartRepository
+	RefundRepository       repositories.RefundRepository
+	AppointmentRepository  repositories.AppointmentRepository
+	PrescriptionRepository repositories.PrescriptionRepository
+	DependentRepository    repositories.DependentRepository
+	DocumentUsecase        DocumentUsecase
 	UploadFile             utils.FileUploader
+	Transactor             repositories.Transactor
+	EventPublisher         ConsultationEventPublisher
+	MaxMinutes             int
+	IdleMinutes            int
 }
 
 func NewConsultationUsecaseImpl(cuOpts *ConsultationUsecaseOpts) ConsultationUsecase {
@@ -56,7 +89,16 @@ func NewConsultationUsecaseImpl(cuOpts *ConsultationUsecaseOpts) ConsultationUse
 		PharmacyRepository:     cuOpts.PharmacyRepo,
 		UserAddressRepository:  cuOpts.UserAddressRepo,
 		CartRepository:         cuOpts.CartRepo,
+		RefundRepository:       cuOpts.RefundRepo,
+		AppointmentRepository:  cuOpts.AppointmentRepo,
+		PrescriptionRepository: cuOpts.PrescriptionRepo,
+		DependentRepository:    cuOpts.DependentRepo,
+		DocumentUsecase:        cuOpts.DocumentUsecase,
 		UploadFile:             cuOpts.UploadFile,
+		Transactor:             cuOpts.Transactor,
+		EventPublisher:         cuOpts.EventPublisher,
+		MaxMinutes:             cuOpts.MaxMinutes,
+		IdleMinutes:            cuOpts.IdleMinutes,
 	}
 }
 
@@ -125,6 +167,11 @@ func (u *ConsultationUsecaseImpl) GetConsultationById(ctx context.Context, consu
 }
 
 func (u *ConsultationUsecaseImpl) CreateConsultation(ctx context.Context, consultation entities.Consultation) (*entities.Consultation, error) {
+	err := applyDependent(ctx, u.DependentRepository, &consultation)
+	if err != nil {
+		return nil, err
+	}
+
 	doctor, err := u.DoctorRepository.FindOneById(ctx, consultation.Doctor.Id)
 	if err != nil {
 		return nil, err
@@ -134,29 +181,100 @@ func (u *ConsultationUsecaseImpl) CreateConsultation(ctx context.Context, consul
 		return nil, custom_errors.DoctorIsNotVerified()
 	}
 
+	if !doctor.IsOnline {
+		return nil, custom_errors.BadRequest(nil, constants.DoctorOfflineErrMsg)
+	}
+
+	// the request only reaches the doctor once the fee is paid
+	consultation.Status = constants.ConsultationStatusWaiting
+	consultation.Fee = decimal.NewFromInt(doctor.Fee.Int64)
+	if consultation.Fee.IsPositive() {
+		consultation.Status = constants.ConsultationStatusUnpaid
+		consultation.PaymentDeadline = sql.NullTime{Time: time.Now().Add(constants.ConsultationPaymentTimeout), Valid: true}
+	}
+
 	newC, err := u.ConsultationRepository.CreateOne(ctx, consultation)
 	if err != nil {
 		return nil, err
 	}
 
+	if newC.Status != constants.ConsultationStatusWaiting {
+		return newC, nil
+	}
+
+	publishConsultationEvents(ctx, u.EventPublisher, []entities.ConsultationEvent{{
+		Type:           constants.ConsultationEventRequested,
+		ConsultationId: newC.Id,
+		UserId:         newC.User.Id,
+		DoctorId:       newC.Doctor.Id,
+	}})
+
 	return newC, nil
 }
 
+// EndConsultation closes a consultation. A request no doctor has accepted yet,
+// or an appointment that has not started, is withdrawn instead, and its fee
+// is refunded when it was already paid.
 func (u *ConsultationUsecaseImpl) EndConsultation(ctx context.Context, consultationId int64, userId int64) error {
-	existingC, err := u.ConsultationRepository.FindById(ctx, consultationId)
-	if err != nil {
-		return err
-	}
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		existingC, err := u.ConsultationRepository.LockRequestById(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
 
-	if existingC.User.Id != userId {
-		return custom_errors.Forbidden()
-	}
+		if existingC.User.Id != userId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		if existingC.Status != constants.ConsultationStatusUnpaid && existingC.Status != constants.ConsultationStatusWaiting && existingC.Status != constants.ConsultationStatusScheduled {
+			err = u.ConsultationRepository.UpdateEndedAt(txCtx, consultationId)
+			if err != nil {
+				return nil, err
+			}
+
+			return []entities.ConsultationEvent{{
+				Type:           constants.ConsultationEventEnded,
+				ConsultationId: existingC.Id,
+				UserId:         existingC.User.Id,
+				DoctorId:       existingC.Doctor.Id,
+			}}, nil
+		}
+
+		err = u.ConsultationRepository.UpdateExpired(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.AppointmentRepository.CancelByConsultationId(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		if existingC.PaidAt.Valid {
+			err = createConsultationRefund(txCtx, u.RefundRepository, *existingC, existingC.Fee, constants.ConsultationCanceledReason)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// only a queued request was ever shown to the doctor
+		if existingC.Status != constants.ConsultationStatusWaiting {
+			return []entities.ConsultationEvent{}, nil
+		}
 
-	err = u.ConsultationRepository.UpdateEndedAt(ctx, consultationId)
+		return []entities.ConsultationEvent{{
+			Type:           constants.ConsultationEventExpired,
+			ConsultationId: existingC.Id,
+			UserId:         existingC.User.Id,
+			DoctorId:       existingC.Doctor.Id,
+		}}, nil
+	})
 	if err != nil {
 		return err
 	}
 
+	publishConsultationEvents(ctx, u.EventPublisher, res.([]entities.ConsultationEvent))
+
 	return nil
 }
 
@@ -170,6 +288,14 @@ func (u *ConsultationUsecaseImpl) CreateChat(ctx context.Context, chat entities.
 		return custom_errors.Forbidden()
 	}
 
+	if consultation.Status != constants.ConsultationStatusAccepted {
+		return custom_errors.BadRequest(nil, constants.ConsultationNotAcceptedErrMsg)
+	}
+
+	if consultation.EndedAt.Valid {
+		return custom_errors.BadRequest(nil, constants.ConsultationEndedErrMsg)
+	}
+
 	if chat.Type == "file" {
 		file, _ := chat.File.Open()
 		if chat.File.Size > 1000000 {
@@ -189,7 +315,7 @@ func (u *ConsultationUsecaseImpl) CreateChat(ctx context.Context, chat entities.
 		chat.Content = fileUrl
 	}
 
-	err = u.ChatRepository.CreateOne(ctx, chat)
+	_, err = u.ChatRepository.CreateOne(ctx, chat)
 	if err != nil {
 		return err
 	}
@@ -207,37 +333,88 @@ func (u *ConsultationUsecaseImpl) CreatePrescription(ctx context.Context, prescr
 		return "", custom_errors.Forbidden()
 	}
 
-	err = u.ConsultationRepository.CreatePrescriptionItems(ctx, prescriptionData)
-	if err != nil {
-		return "", err
+	// a prescription is valid through its expiry date, so only past dates are refused
+	expiresAt, err := time.ParseInLocation(constants.ScheduleDateLayout, prescriptionData.ExpiresAt, time.Local)
+	if err != nil || prescriptionData.ExpiresAt < time.Now().Format(constants.ScheduleDateLayout) {
+		return "", custom_errors.BadRequest(err, constants.PrescriptionExpiryErrMsg)
 	}
 
-	for i := 0; i < len(prescriptionData.Products); i++ {
-		product, err := u.ProductRepository.FindOneById(ctx, prescriptionData.Products[i].Id)
+	for i := range prescriptionData.Items {
+		product, err := u.ProductRepository.FindOneById(ctx, prescriptionData.Items[i].Product.Id)
 		if err != nil {
 			return "", err
 		}
-		prescriptionData.Products[i] = *product
+		prescriptionData.Items[i].Product = *product
+	}
+
+	// a refused reissue is caught before anything is rendered
+	err = u.checkPrescriptionRefills(ctx, prescriptionData.ConsultationId, prescriptionData.Refills)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	document, verifyUrl, err := u.DocumentUsecase.NewDocument(entities.Document{
+		Type:           constants.DocumentTypePrescription,
+		ConsultationId: consultation.Id,
+		Doctor:         consultation.Doctor,
+		PatientName:    consultation.PatientName,
+		ValidFrom:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local),
+		ValidUntil:     expiresAt,
+	})
+	if err != nil {
+		return "", err
 	}
 
 	prescriptionData.PatientName = consultation.PatientName
 	prescriptionData.PatientGender = consultation.PatientGender
 	prescriptionData.PatientBirthDate = consultation.PatientBirthDate
 	prescriptionData.DoctorName = consultation.Doctor.Name
+	prescriptionData.DocumentNumber = document.Number
+	prescriptionData.VerifyUrl = verifyUrl
 
 	pdfName, err := utils.GeneratePrescriptionPdf(prescriptionData)
 	if err != nil {
 		return "", err
 	}
 
+	defer os.Remove(pdfName)
+
 	fileUrl, err := u.UploadFile.UploadFile(ctx, pdfName)
 	if err != nil {
 		return "", custom_errors.UploadFile()
 	}
 
-	_ = os.Remove(pdfName)
+	// writing a new prescription replaces the previous one and revokes its
+	// document only once the new file is uploaded; fills already made against
+	// it still count
+	_, err = u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		err := u.checkPrescriptionRefills(txCtx, prescriptionData.ConsultationId, prescriptionData.Refills)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = u.PrescriptionRepository.UpsertOne(txCtx, entities.Prescription{
+			ConsultationId: prescriptionData.ConsultationId,
+			ExpiresAt:      expiresAt,
+			Refills:        prescriptionData.Refills,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.PrescriptionRepository.ReplaceItems(txCtx, prescriptionData.ConsultationId, prescriptionData.Items)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = u.DocumentUsecase.IssueDocument(txCtx, *document)
+		if err != nil {
+			return nil, err
+		}
 
-	err = u.ConsultationRepository.UpdatePrescription(ctx, prescriptionData.ConsultationId, fileUrl)
+		return nil, u.ConsultationRepository.UpdatePrescription(txCtx, prescriptionData.ConsultationId, fileUrl)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -245,6 +422,21 @@ func (u *ConsultationUsecaseImpl) CreatePrescription(ctx context.Context, prescr
 	return fileUrl, nil
 }
 
+// checkPrescriptionRefills refuses a reissue that allows fewer refills than
+// were already used. The first fill is not a refill.
+func (u *ConsultationUsecaseImpl) checkPrescriptionRefills(ctx context.Context, consultationId int64, refills int) error {
+	previous, err := u.PrescriptionRepository.FindByConsultationId(ctx, consultationId)
+	if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+		return err
+	}
+
+	if err == nil && refills < previous.FillCount-1 {
+		return custom_errors.BadRequest(nil, constants.PrescriptionRefillsUsedErrMsg)
+	}
+
+	return nil
+}
+
 func (u *ConsultationUsecaseImpl) CreateCertificate(ctx context.Context, certificateData entities.CertificateData, doctorId int64) (string, error) {
 	consultation, err := u.ConsultationRepository.FindById(ctx, certificateData.ConsultationId)
 	if err != nil {
@@ -255,24 +447,57 @@ func (u *ConsultationUsecaseImpl) CreateCertificate(ctx context.Context, certifi
 		return "", custom_errors.Forbidden()
 	}
 
+	startDate, err := time.ParseInLocation(constants.ScheduleDateLayout, certificateData.StartDate, time.Local)
+	if err != nil {
+		return "", custom_errors.BadRequest(err, constants.InvalidDateInputErrMsg)
+	}
+
+	endDate, err := time.ParseInLocation(constants.ScheduleDateLayout, certificateData.EndDate, time.Local)
+	if err != nil || endDate.Before(startDate) {
+		return "", custom_errors.BadRequest(err, constants.InvalidDateInputErrMsg)
+	}
+
+	document, verifyUrl, err := u.DocumentUsecase.NewDocument(entities.Document{
+		Type:           constants.DocumentTypeCertificate,
+		ConsultationId: consultation.Id,
+		Doctor:         consultation.Doctor,
+		PatientName:    consultation.PatientName,
+		ValidFrom:      startDate,
+		ValidUntil:     endDate,
+	})
+	if err != nil {
+		return "", err
+	}
+
 	certificateData.PatientName = consultation.PatientName
 	certificateData.PatientGender = consultation.PatientGender
 	certificateData.PatientBirthDate = consultation.PatientBirthDate
 	certificateData.DoctorName = consultation.Doctor.Name
+	certificateData.DocumentNumber = document.Number
+	certificateData.VerifyUrl = verifyUrl
 
 	pdfName, err := utils.GenerateCertificatePdf(certificateData)
 	if err != nil {
 		return "", err
 	}
 
+	defer os.Remove(pdfName)
+
 	fileUrl, err := u.UploadFile.UploadFile(ctx, pdfName)
 	if err != nil {
 		return "", custom_errors.UploadFile()
 	}
 
-	_ = os.Remove(pdfName)
+	// the previous certificate is revoked only together with the new one
+	// being issued
+	_, err = u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		_, err := u.DocumentUsecase.IssueDocument(txCtx, *document)
+		if err != nil {
+			return nil, err
+		}
 
-	err = u.ConsultationRepository.CreateCertificate(ctx, certificateData.ConsultationId, fileUrl)
+		return nil, u.ConsultationRepository.CreateCertificate(txCtx, certificateData.ConsultationId, fileUrl, certificateData.Diagnosis)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -280,6 +505,25 @@ func (u *ConsultationUsecaseImpl) CreateCertificate(ctx context.Context, certifi
 	return fileUrl, nil
 }
 
+// GetPrescription returns the consultation's prescription to the patient or
+// the doctor of that consultation.
+func (u *ConsultationUsecaseImpl) GetPrescription(ctx context.Context, consultationId int64, actorId int64, actorRole string) (*entities.Prescription, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorRole == constants.UserRole && consultation.User.Id != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if actorRole == constants.DoctorRole && consultation.Doctor.Id != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	return u.PrescriptionRepository.FindByConsultationId(ctx, consultationId)
+}
+
 func (u *ConsultationUsecaseImpl) AddPrescriptionToCart(ctx context.Context, consultationId int64, userId int64) error {
 	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
 	if err != nil {
@@ -301,58 +545,117 @@ func (u *ConsultationUsecaseImpl) AddPrescriptionToCart(ctx context.Context, con
 	longitude, _ := strconv.ParseFloat(longitudeStr, 64)
 	latitude, _ := strconv.ParseFloat(latitudeStr, 64)
 
-	productIds, quantities, err := u.ConsultationRepository.FindAllPrescribedProductsById(ctx, consultationId)
-	if err != nil {
-		return err
-	}
-
-	pharmacyProductIds := []int64{}
+	// a fill is only spent when the items are ordered, see CreateNewOrder
+	_, err = u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		prescription, err := u.PrescriptionRepository.FindByConsultationId(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
 
-	for i := 0; i < len(productIds); i++ {
-		params := entities.PharmacyByProductParams{
-			Longitude: longitude,
-			Latitude:  latitude,
-			Radius:    constants.DefaultRadius,
-			ProductId: productIds[i],
+		// dates come back without a zone, so compare them on the calendar
+		if prescription.ExpiresAt.Format(constants.ScheduleDateLayout) < time.Now().Format(constants.ScheduleDateLayout) {
+			return nil, custom_errors.BadRequest(nil, constants.PrescriptionExpiredErrMsg)
 		}
 
-		pharmacyProductId, err := u.PharmacyRepository.FindNearestPharmacyProductByProductId(ctx, params)
-		if err != nil {
-			return err
+		if prescription.FillCount > prescription.Refills {
+			return nil, custom_errors.BadRequest(nil, constants.PrescriptionNoRefillsErrMsg)
 		}
 
-		pharmacyProductIds = append(pharmacyProductIds, pharmacyProductId)
-	}
+		pharmacyProductIds := []int64{}
 
-	for i := 0; i < len(pharmacyProductIds); i++ {
-		cartEntity := entities.CartItem{
-			UserId:            int64(userId),
-			Quantity:          quantities[i],
-			PharmacyProductId: pharmacyProductIds[i],
-		}
+		for _, item := range prescription.Items {
+			params := entities.PharmacyByProductParams{
+				Longitude: longitude,
+				Latitude:  latitude,
+				Radius:    constants.DefaultRadius,
+				ProductId: item.Product.Id,
+			}
 
-		cart, err := u.CartRepository.FindCartItem(ctx, cartEntity)
-		if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
-			return err
+			pharmacyProductId, err := u.PharmacyRepository.FindNearestPharmacyProductByProductId(txCtx, params)
+			if err != nil {
+				return nil, err
+			}
+
+			pharmacyProductIds = append(pharmacyProductIds, pharmacyProductId)
 		}
 
-		if err != nil && err.Error() == constants.ResponseMsgErrorNotFound {
-			err = u.CartRepository.CreateOneCartItem(ctx, cartEntity)
+		for i := 0; i < len(pharmacyProductIds); i++ {
+			cartEntity := entities.CartItem{
+				UserId:             int64(userId),
+				PharmacyProductId:  pharmacyProductIds[i],
+				PrescriptionItemId: sql.NullInt64{Int64: prescription.Items[i].Id, Valid: true},
+			}
+
+			cart, err := u.CartRepository.FindCartItem(txCtx, cartEntity)
+			if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+				return nil, err
+			}
+
+			if cart != nil {
+				cartEntity.Id = cart.Id
+			}
+
+			// lines at other pharmacies may already hold part of the
+			// prescribed quantity
+			inCart, err := u.CartRepository.SumPrescriptionItemQuantity(txCtx, cartEntity.UserId, cartEntity.PrescriptionItemId.Int64, cartEntity.Id)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			continue
-		}
+			missing := prescription.Items[i].Quantity - inCart
 
-		cartEntity.Id = cart.Id
+			if cart == nil {
+				if missing <= 0 {
+					continue
+				}
 
-		err = u.CartRepository.IncreaseCartQuantity(ctx, cartEntity)
-		if err != nil {
-			return err
+				cartEntity.Quantity = missing
+				err = u.CartRepository.CreateOneCartItem(txCtx, cartEntity)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+
+			// the line is topped up to what is left of the prescribed quantity,
+			// and one already holding more cannot be tied to the prescription
+			if cart.Quantity > missing {
+				return nil, custom_errors.BadRequest(nil, constants.PrescribedQuantityErrMsg)
+			}
+
+			cartEntity.Quantity = missing - cart.Quantity
+			err = u.CartRepository.IncreaseCartQuantity(txCtx, cartEntity)
+			if err != nil {
+				return nil, err
+			}
 		}
 
+		return nil, nil
+	})
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
+
+func (u *ConsultationUsecaseImpl) GetAllActiveConsultation(ctx context.Context) ([]entities.Consultation, error) {
+	return u.ConsultationRepository.FindAllActive(ctx)
+}
+
+func (u *ConsultationUsecaseImpl) GetConsultationChatsAfter(ctx context.Context, consultationId int64, lastSeenId int64) ([]entities.Chat, error) {
+	return u.ChatRepository.FindAllConsultationChatAfter(ctx, consultationId, lastSeenId)
+}
+
+// MarkChatsDelivered records that the reader received every chat the other
+// participant sent up to chatId.
+func (u *ConsultationUsecaseImpl) MarkChatsDelivered(ctx context.Context, consultationId int64, chatId int64, readerRole string) error {
+	return u.ChatRepository.UpdateDeliveredUpTo(ctx, consultationId, chatId, readerRole != constants.UserRole)
+}
+
+// MarkChatsRead records that the reader has seen every chat the other
+// participant sent up to chatId.
+func (u *ConsultationUsecaseImpl) MarkChatsRead(ctx context.Context, consultationId int64, chatId int64, readerRole string) error {
+	return u.ChatRepository.UpdateReadUpTo(ctx, consultationId, chatId, readerRole != constants.UserRole)
+}
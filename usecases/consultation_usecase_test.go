@@ -0,0 +1,450 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type stubConsultationRepository struct {
+	repositories.ConsultationRepository
+	consultation   entities.Consultation
+	certificateUrl string
+}
+
+func (r *stubConsultationRepository) FindById(ctx context.Context, id int64) (*entities.Consultation, error) {
+	consultation := r.consultation
+	return &consultation, nil
+}
+
+type stubProductRepository struct {
+	repositories.ProductRepository
+}
+
+func (r *stubProductRepository) FindOneById(ctx context.Context, productId int64) (*entities.Product, error) {
+	return &entities.Product{Id: productId}, nil
+}
+
+func (r *stubPrescriptionRepository) FindByConsultationId(ctx context.Context, consultationId int64) (*entities.Prescription, error) {
+	if r.prescription == nil {
+		return nil, custom_errors.NotFound(sql.ErrNoRows)
+	}
+	prescription := *r.prescription
+	return &prescription, nil
+}
+
+type stubDocumentUsecase struct {
+	DocumentUsecase
+	issued []entities.Document
+}
+
+func (u *stubDocumentUsecase) NewDocument(document entities.Document) (*entities.Document, string, error) {
+	document.Number = "DOC-1"
+	return &document, "https://sehatin.test/verify/DOC-1", nil
+}
+
+func (u *stubDocumentUsecase) IssueDocument(ctx context.Context, document entities.Document) (*entities.Document, error) {
+	u.issued = append(u.issued, document)
+	return &document, nil
+}
+
+type stubFileUploader struct {
+	err      error
+	uploaded []string
+}
+
+func (f *stubFileUploader) UploadFile(ctx context.Context, file interface{}) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.uploaded = append(f.uploaded, file.(string))
+	return "https://files.test/document.pdf", nil
+}
+
+func (r *stubConsultationRepository) CreateCertificate(ctx context.Context, consultationId int64, certificateUrl string, diagnosis string) error {
+	r.certificateUrl = certificateUrl
+	return nil
+}
+
+// chdirToRoot runs the test from the repository root, where the PDF header
+// logo is looked up.
+func chdirToRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// errStopAfterUpsert ends CreatePrescription once the prescription is written,
+// after the PDF is uploaded and before the steps these tests do not cover.
+var errStopAfterUpsert = errors.New("stop after upsert")
+
+func (r *stubPrescriptionRepository) UpsertOne(ctx context.Context, prescription entities.Prescription) (*entities.Prescription, error) {
+	r.upserted = append(r.upserted, prescription)
+	return nil, errStopAfterUpsert
+}
+
+func TestCreatePrescriptionRefills(t *testing.T) {
+	chdirToRoot(t)
+
+	tests := []struct {
+		name         string
+		previous     *entities.Prescription
+		refills      int
+		wantErr      string
+		wantUpserted bool
+	}{
+		{
+			name:         "first prescription",
+			refills:      0,
+			wantUpserted: true,
+		},
+		{
+			name:         "reissue before any fill",
+			previous:     &entities.Prescription{Refills: 2, FillCount: 0},
+			refills:      0,
+			wantUpserted: true,
+		},
+		{
+			name:         "reissue with as many refills as were used",
+			previous:     &entities.Prescription{Refills: 2, FillCount: 3},
+			refills:      2,
+			wantUpserted: true,
+		},
+		{
+			name:         "reissue after the first fill only",
+			previous:     &entities.Prescription{Refills: 2, FillCount: 1},
+			refills:      0,
+			wantUpserted: true,
+		},
+		{
+			name:     "reissue with fewer refills than were used",
+			previous: &entities.Prescription{Refills: 2, FillCount: 3},
+			refills:  1,
+			wantErr:  constants.PrescriptionRefillsUsedErrMsg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prescriptionRepo := &stubPrescriptionRepository{prescription: tt.previous}
+			u := NewConsultationUsecaseImpl(&ConsultationUsecaseOpts{
+				ConsultationRepo: &stubConsultationRepository{consultation: entities.Consultation{Id: 1, Doctor: entities.Doctor{Id: 2}, PatientBirthDate: "2000-01-01"}},
+				ProductRepo:      &stubProductRepository{},
+				PrescriptionRepo: prescriptionRepo,
+				DocumentUsecase:  &stubDocumentUsecase{},
+				UploadFile:       &stubFileUploader{},
+				Transactor:       stubTransactor{},
+			})
+
+			_, err := u.CreatePrescription(context.Background(), entities.PrescriptionData{
+				ConsultationId: 1,
+				Items:          []entities.PrescriptionItem{{Product: entities.Product{Id: 3}, Quantity: 1}},
+				ExpiresAt:      time.Now().AddDate(0, 1, 0).Format(constants.ScheduleDateLayout),
+				Refills:        tt.refills,
+			}, 2)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if !errors.Is(err, errStopAfterUpsert) {
+				t.Fatalf("got error %v, want the prescription to be written", err)
+			}
+
+			if got := len(prescriptionRepo.upserted) != 0; got != tt.wantUpserted {
+				t.Fatalf("upserted = %v, want %v", got, tt.wantUpserted)
+			}
+		})
+	}
+}
+
+func TestCreateCertificateIssuesAfterUpload(t *testing.T) {
+	chdirToRoot(t)
+
+	tests := []struct {
+		name       string
+		uploadErr  error
+		wantErr    bool
+		wantIssued bool
+	}{
+		{
+			name:       "issued once the file is uploaded",
+			wantIssued: true,
+		},
+		{
+			name:      "a failed upload leaves the previous certificate alone",
+			uploadErr: errors.New("upload failed"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consultationRepo := &stubConsultationRepository{consultation: entities.Consultation{Id: 1, Doctor: entities.Doctor{Id: 2}, PatientBirthDate: "2000-01-01"}}
+			documentUsecase := &stubDocumentUsecase{}
+			u := NewConsultationUsecaseImpl(&ConsultationUsecaseOpts{
+				ConsultationRepo: consultationRepo,
+				DocumentUsecase:  documentUsecase,
+				UploadFile:       &stubFileUploader{err: tt.uploadErr},
+				Transactor:       stubTransactor{},
+			})
+
+			today := time.Now().Format(constants.ScheduleDateLayout)
+			_, err := u.CreateCertificate(context.Background(), entities.CertificateData{ConsultationId: 1, Diagnosis: "flu", StartDate: today, EndDate: today}, 2)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if got := len(documentUsecase.issued) != 0; got != tt.wantIssued {
+				t.Fatalf("issued = %v, want %v", got, tt.wantIssued)
+			}
+			if tt.wantIssued && (documentUsecase.issued[0].Number != "DOC-1" || consultationRepo.certificateUrl == "") {
+				t.Fatalf("got document %+v and certificate %q, want the uploaded one", documentUsecase.issued[0], consultationRepo.certificateUrl)
+			}
+		})
+	}
+}
+
+type stubUserAddressRepository struct {
+	repositories.UserAddressRepository
+}
+
+func (r *stubUserAddressRepository) FindMainByUserId(ctx context.Context, userId int64) (*entities.UserAddress, error) {
+	return &entities.UserAddress{UserId: userId, Coordinate: "SRID=4326;POINT(106.8 -6.2)"}, nil
+}
+
+type stubPharmacyRepository struct {
+	repositories.PharmacyRepository
+	nearest int64
+}
+
+func (r *stubPharmacyRepository) FindNearestPharmacyProductByProductId(ctx context.Context, req entities.PharmacyByProductParams) (int64, error) {
+	return r.nearest, nil
+}
+
+func TestAddPrescriptionToCart(t *testing.T) {
+	// the prescription item 9 prescribes 5, and pharmacy product 1 is nearest
+	prescription := &entities.Prescription{
+		ConsultationId: 1,
+		ExpiresAt:      time.Now().Add(24 * time.Hour),
+		Refills:        1,
+		Items:          []entities.PrescriptionItem{{Id: 9, Product: entities.Product{Id: 100}, Quantity: 5}},
+	}
+
+	tests := []struct {
+		name      string
+		lines     []entities.CartItem
+		wantErr   string
+		wantLines map[int64]int
+	}{
+		{
+			name:      "an empty cart gets the prescribed quantity",
+			wantLines: map[int64]int{1: 5},
+		},
+		{
+			name:      "a line at another pharmacy leaves the rest",
+			lines:     []entities.CartItem{testCartLine(1, 2, 3, 9)},
+			wantLines: map[int64]int{1: 2, 2: 3},
+		},
+		{
+			name:      "a line at another pharmacy holding everything adds nothing",
+			lines:     []entities.CartItem{testCartLine(1, 2, 5, 9)},
+			wantLines: map[int64]int{2: 5},
+		},
+		{
+			name:      "a line at the nearest pharmacy is topped up to what is left",
+			lines:     []entities.CartItem{testCartLine(1, 1, 2, 9), testCartLine(2, 2, 1, 9)},
+			wantLines: map[int64]int{1: 4, 2: 1},
+		},
+		{
+			name:      "a line without a prescription moves over to it",
+			lines:     []entities.CartItem{testCartLine(1, 1, 2, 0)},
+			wantLines: map[int64]int{1: 5},
+		},
+		{
+			name:      "a line holding more than prescribed is not tied to it",
+			lines:     []entities.CartItem{testCartLine(1, 1, 6, 0)},
+			wantErr:   constants.PrescribedQuantityErrMsg,
+			wantLines: map[int64]int{1: 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartRepo := &stubCartRepository{lines: tt.lines}
+			u := NewConsultationUsecaseImpl(&ConsultationUsecaseOpts{
+				ConsultationRepo: &stubConsultationRepository{consultation: entities.Consultation{Id: 1, User: entities.User{Id: 7}}},
+				PharmacyRepo:     &stubPharmacyRepository{nearest: 1},
+				UserAddressRepo:  &stubUserAddressRepository{},
+				CartRepo:         cartRepo,
+				PrescriptionRepo: &stubPrescriptionRepository{prescription: prescription},
+				Transactor:       stubTransactor{},
+			})
+
+			err := u.AddPrescriptionToCart(context.Background(), 1, 7)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(cartRepo.lines) != len(tt.wantLines) {
+				t.Fatalf("got cart lines %+v, want quantities %v", cartRepo.lines, tt.wantLines)
+			}
+
+			prescribed := 0
+			for _, line := range cartRepo.lines {
+				if line.Quantity != tt.wantLines[line.PharmacyProductId] {
+					t.Fatalf("got cart lines %+v, want quantities %v", cartRepo.lines, tt.wantLines)
+				}
+				if line.PrescriptionItemId.Int64 == 9 {
+					prescribed += line.Quantity
+				}
+			}
+			if prescribed > 5 {
+				t.Fatalf("cart holds %d of the 5 prescribed", prescribed)
+			}
+		})
+	}
+}
+
+type stubCloseConsultationRepository struct {
+	repositories.ConsultationRepository
+	idle    []entities.Consultation
+	expired []int64
+	ended   []int64
+}
+
+func (r *stubCloseConsultationRepository) LockOverdueConsultations(ctx context.Context, maxDuration time.Duration, limit int) ([]entities.Consultation, error) {
+	return []entities.Consultation{}, nil
+}
+
+func (r *stubCloseConsultationRepository) LockIdleConsultations(ctx context.Context, idleTimeout time.Duration, limit int) ([]entities.Consultation, error) {
+	return r.idle, nil
+}
+
+func (r *stubCloseConsultationRepository) UpdateExpired(ctx context.Context, consultationId int64) error {
+	r.expired = append(r.expired, consultationId)
+	return nil
+}
+
+func (r *stubCloseConsultationRepository) UpdateEndedAt(ctx context.Context, consultationId int64) error {
+	r.ended = append(r.ended, consultationId)
+	return nil
+}
+
+type stubChatRepository struct {
+	repositories.ChatRepository
+	doctorWrote bool
+	created     []entities.Chat
+}
+
+func (r *stubChatRepository) ExistsFromDoctor(ctx context.Context, consultationId int64) (bool, error) {
+	return r.doctorWrote, nil
+}
+
+func (r *stubChatRepository) CreateOne(ctx context.Context, chat entities.Chat) (*entities.Chat, error) {
+	r.created = append(r.created, chat)
+	chat.Id = int64(len(r.created))
+	return &chat, nil
+}
+
+func TestCloseInactiveConsultations(t *testing.T) {
+	paid := sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	scheduled := sql.NullTime{Time: time.Now().Add(-20 * time.Minute), Valid: true}
+
+	tests := []struct {
+		name         string
+		consultation entities.Consultation
+		doctorWrote  bool
+		wantExpired  bool
+		wantRefund   bool
+		wantMessage  string
+	}{
+		{
+			name:         "a paid appointment the doctor never joined is refunded",
+			consultation: entities.Consultation{Id: 1, User: entities.User{Id: 7}, Fee: decimal.NewFromInt(50000), PaidAt: paid, ScheduledAt: scheduled},
+			wantExpired:  true,
+			wantRefund:   true,
+			wantMessage:  constants.ConsultationNoShowMsg,
+		},
+		{
+			name:         "a free appointment the doctor never joined expires",
+			consultation: entities.Consultation{Id: 1, User: entities.User{Id: 7}, ScheduledAt: scheduled},
+			wantExpired:  true,
+			wantMessage:  constants.ConsultationNoShowMsg,
+		},
+		{
+			name:         "an appointment that went quiet after the doctor wrote ends",
+			consultation: entities.Consultation{Id: 1, User: entities.User{Id: 7}, Fee: decimal.NewFromInt(50000), PaidAt: paid, ScheduledAt: scheduled},
+			doctorWrote:  true,
+			wantMessage:  constants.ConsultationIdleEndedMsg,
+		},
+		{
+			name:         "a request the doctor accepted ends",
+			consultation: entities.Consultation{Id: 1, User: entities.User{Id: 7}, Fee: decimal.NewFromInt(50000), PaidAt: paid},
+			wantMessage:  constants.ConsultationIdleEndedMsg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consultationRepo := &stubCloseConsultationRepository{idle: []entities.Consultation{tt.consultation}}
+			chatRepo := &stubChatRepository{doctorWrote: tt.doctorWrote}
+			refundRepo := &stubRefundRepository{}
+			u := NewConsultationUsecaseImpl(&ConsultationUsecaseOpts{
+				ConsultationRepo: consultationRepo,
+				ChatRepo:         chatRepo,
+				RefundRepo:       refundRepo,
+				Transactor:       stubTransactor{},
+			})
+
+			closed, err := u.CloseInactiveConsultations(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if closed != 1 {
+				t.Fatalf("closed %d consultations, want 1", closed)
+			}
+
+			if got := len(consultationRepo.expired) != 0; got != tt.wantExpired {
+				t.Fatalf("expired = %v, want %v", got, tt.wantExpired)
+			}
+			if len(consultationRepo.expired)+len(consultationRepo.ended) != 1 {
+				t.Fatalf("expired %v and ended %v, want the consultation closed once", consultationRepo.expired, consultationRepo.ended)
+			}
+
+			if got := len(refundRepo.refunds) != 0; got != tt.wantRefund {
+				t.Fatalf("refunded = %v, want %v", got, tt.wantRefund)
+			}
+			if tt.wantRefund {
+				refund := refundRepo.refunds[0]
+				if refund.Type != constants.RefundTypeConsultation || !refund.Amount.Equal(tt.consultation.Fee) || refund.Reason.String != constants.ConsultationNoShowReason {
+					t.Fatalf("unexpected refund %+v", refund)
+				}
+			}
+
+			if len(chatRepo.created) != 1 || chatRepo.created[0].Content != tt.wantMessage {
+				t.Fatalf("got chats %+v, want %q", chatRepo.created, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+)
+
+type DependentUsecaseOpts struct {
+	DependentRepository repositories.DependentRepository
+}
+
+type DependentUsecase interface {
+	GetAllDependents(ctx context.Context, userId int64) ([]entities.Dependent, error)
+	GetDependentById(ctx context.Context, dependentId int64, userId int64) (*entities.Dependent, error)
+	CreateDependent(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error)
+	UpdateDependent(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error)
+	DeleteDependent(ctx context.Context, dependentId int64, userId int64) error
+}
+
+type DependentUsecaseImpl struct {
+	DependentRepository repositories.DependentRepository
+}
+
+func NewDependentUsecaseImpl(dOpts *DependentUsecaseOpts) DependentUsecase {
+	return &DependentUsecaseImpl{
+		DependentRepository: dOpts.DependentRepository,
+	}
+}
+
+func (u *DependentUsecaseImpl) GetAllDependents(ctx context.Context, userId int64) ([]entities.Dependent, error) {
+	return u.DependentRepository.FindAllByUserId(ctx, userId)
+}
+
+func (u *DependentUsecaseImpl) GetDependentById(ctx context.Context, dependentId int64, userId int64) (*entities.Dependent, error) {
+	return u.DependentRepository.FindOneById(ctx, dependentId, userId)
+}
+
+func (u *DependentUsecaseImpl) CreateDependent(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error) {
+	if dependent.BirthDate.After(time.Now()) {
+		return nil, custom_errors.BadRequest(nil, constants.InvalidDateInputErrMsg)
+	}
+
+	newD, err := u.DependentRepository.CreateOne(ctx, dependent)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.DependentRepository.FindOneById(ctx, newD.Id, newD.UserId)
+}
+
+func (u *DependentUsecaseImpl) UpdateDependent(ctx context.Context, dependent entities.Dependent) (*entities.Dependent, error) {
+	if dependent.BirthDate.After(time.Now()) {
+		return nil, custom_errors.BadRequest(nil, constants.InvalidDateInputErrMsg)
+	}
+
+	err := u.DependentRepository.UpdateOne(ctx, dependent)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.DependentRepository.FindOneById(ctx, dependent.Id, dependent.UserId)
+}
+
+// DeleteDependent removes the profile from the user's list. Consultations
+// and conditions already recorded for the dependent keep pointing at it.
+func (u *DependentUsecaseImpl) DeleteDependent(ctx context.Context, dependentId int64, userId int64) error {
+	return u.DependentRepository.DeleteOne(ctx, dependentId, userId)
+}
+
+// applyDependent fills the patient details of consultation from the user's
+// dependent, when one was picked instead of typing them in.
+func applyDependent(ctx context.Context, dependentRepo repositories.DependentRepository, consultation *entities.Consultation) error {
+	if !consultation.DependentId.Valid {
+		return nil
+	}
+
+	dependent, err := dependentRepo.FindOneById(ctx, consultation.DependentId.Int64, consultation.User.Id)
+	if err != nil {
+		return err
+	}
+
+	consultation.PatientName = dependent.Name
+	consultation.PatientBirthDate = dependent.BirthDate.Format(constants.ScheduleDateLayout)
+	consultation.PatientGender = dependent.Gender
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+)
+
+type DoctorReviewUsecaseOpts struct {
+	DoctorReviewRepository repositories.DoctorReviewRepository
+	ConsultationRepository repositories.ConsultationRepository
+	DoctorRepository       repositories.DoctorRepository
+	Transactor             repositories.Transactor
+}
+
+type DoctorReviewUsecase interface {
+	CreateReview(ctx context.Context, consultationId int64, userId int64, req dtos.DoctorReviewRequest) (*entities.DoctorReview, error)
+	GetDoctorReviews(ctx context.Context, doctorId int64, params entities.DoctorReviewParams) ([]entities.DoctorReview, int, error)
+	GetAllReview(ctx context.Context, params entities.DoctorReviewParams) ([]entities.DoctorReview, int, error)
+	HideReview(ctx context.Context, reviewId int64, adminId int64, reason string) error
+	UnhideReview(ctx context.Context, reviewId int64) error
+}
+
+type DoctorReviewUsecaseImpl struct {
+	DoctorReviewRepository repositories.DoctorReviewRepository
+	ConsultationRepository repositories.ConsultationRepository
+	DoctorRepository       repositories.DoctorRepository
+	Transactor             repositories.Transactor
+}
+
+func NewDoctorReviewUsecaseImpl(drOpts *DoctorReviewUsecaseOpts) DoctorReviewUsecase {
+	return &DoctorReviewUsecaseImpl{
+		DoctorReviewRepository: drOpts.DoctorReviewRepository,
+		ConsultationRepository: drOpts.ConsultationRepository,
+		DoctorRepository:       drOpts.DoctorRepository,
+		Transactor:             drOpts.Transactor,
+	}
+}
+
+// CreateReview lets the patient rate a consultation once the doctor has
+// actually served it and it has ended.
+func (u *DoctorReviewUsecaseImpl) CreateReview(ctx context.Context, consultationId int64, userId int64, req dtos.DoctorReviewRequest) (*entities.DoctorReview, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.User.Id != userId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if consultation.Status != constants.ConsultationStatusAccepted || !consultation.EndedAt.Valid {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationNotEndedErrMsg)
+	}
+
+	review := entities.DoctorReview{
+		ConsultationId: consultationId,
+		DoctorId:       consultation.Doctor.Id,
+		UserId:         userId,
+		UserName:       consultation.User.Name,
+		Rating:         req.Rating,
+	}
+
+	comment := strings.TrimSpace(req.Comment)
+	if comment != "" {
+		review.Comment = sql.NullString{String: comment, Valid: true}
+	}
+
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		newReview, err := u.DoctorReviewRepository.CreateOne(txCtx, review)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.DoctorReviewRepository.RefreshDoctorRating(txCtx, newReview.DoctorId)
+		if err != nil {
+			return nil, err
+		}
+
+		return newReview, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*entities.DoctorReview), nil
+}
+
+func (u *DoctorReviewUsecaseImpl) GetDoctorReviews(ctx context.Context, doctorId int64, params entities.DoctorReviewParams) ([]entities.DoctorReview, int, error) {
+	var total int
+
+	_, err := u.DoctorRepository.FindOneById(ctx, doctorId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reviews, err := u.DoctorReviewRepository.FindAllByDoctorId(ctx, doctorId, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(reviews) > 0 {
+		total = reviews[0].Total
+	}
+
+	return reviews, total, nil
+}
+
+func (u *DoctorReviewUsecaseImpl) GetAllReview(ctx context.Context, params entities.DoctorReviewParams) ([]entities.DoctorReview, int, error) {
+	var total int
+
+	reviews, err := u.DoctorReviewRepository.FindAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(reviews) > 0 {
+		total = reviews[0].Total
+	}
+
+	return reviews, total, nil
+}
+
+// HideReview takes an abusive review off the doctor's public page and out of
+// their rating.
+func (u *DoctorReviewUsecaseImpl) HideReview(ctx context.Context, reviewId int64, adminId int64, reason string) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		review, err := u.DoctorReviewRepository.LockById(txCtx, reviewId)
+		if err != nil {
+			return nil, err
+		}
+
+		if review.IsHidden {
+			return nil, custom_errors.BadRequest(nil, constants.ReviewAlreadyHiddenErrMsg)
+		}
+
+		review.IsHidden = true
+		review.HiddenBy = sql.NullInt64{Int64: adminId, Valid: true}
+		review.HiddenReason = sql.NullString{String: reason, Valid: true}
+		review.HiddenAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		err = u.DoctorReviewRepository.UpdateHidden(txCtx, *review)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, u.DoctorReviewRepository.RefreshDoctorRating(txCtx, review.DoctorId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *DoctorReviewUsecaseImpl) UnhideReview(ctx context.Context, reviewId int64) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		review, err := u.DoctorReviewRepository.LockById(txCtx, reviewId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !review.IsHidden {
+			return nil, custom_errors.BadRequest(nil, constants.ReviewNotHiddenErrMsg)
+		}
+
+		review.IsHidden = false
+		review.HiddenBy = sql.NullInt64{}
+		review.HiddenReason = sql.NullString{}
+		review.HiddenAt = sql.NullTime{}
+
+		err = u.DoctorReviewRepository.UpdateHidden(txCtx, *review)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, u.DoctorReviewRepository.RefreshDoctorRating(txCtx, review.DoctorId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
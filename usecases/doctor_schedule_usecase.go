@@ -0,0 +1,262 @@
+package usecases
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+)
+
+type DoctorScheduleUsecaseOpts struct {
+	DoctorScheduleRepository repositories.DoctorScheduleRepository
+	AppointmentRepository    repositories.AppointmentRepository
+	DoctorRepository         repositories.DoctorRepository
+	Transactor               repositories.Transactor
+}
+
+type DoctorScheduleUsecase interface {
+	GetSchedules(ctx context.Context, doctorId int64) ([]entities.DoctorSchedule, error)
+	UpdateSchedules(ctx context.Context, doctorId int64, schedules []entities.DoctorSchedule) ([]entities.DoctorSchedule, error)
+	GetExceptions(ctx context.Context, doctorId int64) ([]entities.DoctorScheduleException, error)
+	CreateException(ctx context.Context, exception entities.DoctorScheduleException) (*entities.DoctorScheduleException, error)
+	DeleteException(ctx context.Context, exceptionId int64, doctorId int64) error
+	GetAvailableSlots(ctx context.Context, doctorId int64, date string) ([]entities.AppointmentSlot, error)
+}
+
+type DoctorScheduleUsecaseImpl struct {
+	DoctorScheduleRepository repositories.DoctorScheduleRepository
+	AppointmentRepository    repositories.AppointmentRepository
+	DoctorRepository         repositories.DoctorRepository
+	Transactor               repositories.Transactor
+}
+
+func NewDoctorScheduleUsecaseImpl(dsOpts *DoctorScheduleUsecaseOpts) DoctorScheduleUsecase {
+	return &DoctorScheduleUsecaseImpl{
+		DoctorScheduleRepository: dsOpts.DoctorScheduleRepository,
+		AppointmentRepository:    dsOpts.AppointmentRepository,
+		DoctorRepository:         dsOpts.DoctorRepository,
+		Transactor:               dsOpts.Transactor,
+	}
+}
+
+func (u *DoctorScheduleUsecaseImpl) GetSchedules(ctx context.Context, doctorId int64) ([]entities.DoctorSchedule, error) {
+	return u.DoctorScheduleRepository.FindAllByDoctorId(ctx, doctorId)
+}
+
+// UpdateSchedules replaces the doctor's whole week. Appointments that are
+// already booked keep their slot even if it falls outside the new schedule.
+func (u *DoctorScheduleUsecaseImpl) UpdateSchedules(ctx context.Context, doctorId int64, schedules []entities.DoctorSchedule) ([]entities.DoctorSchedule, error) {
+	for i := range schedules {
+		start, end, ok := parseTimeRange(schedules[i].StartTime, schedules[i].EndTime)
+		if !ok {
+			return nil, custom_errors.BadRequest(nil, constants.InvalidScheduleErrMsg)
+		}
+
+		schedules[i].StartTime = start
+		schedules[i].EndTime = end
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].DayOfWeek != schedules[j].DayOfWeek {
+			return schedules[i].DayOfWeek < schedules[j].DayOfWeek
+		}
+		return schedules[i].StartTime < schedules[j].StartTime
+	})
+
+	// times are zero-padded now, so comparing them as strings orders them
+	for i, s := range schedules {
+		if i > 0 && schedules[i-1].DayOfWeek == s.DayOfWeek && schedules[i-1].EndTime > s.StartTime {
+			return nil, custom_errors.BadRequest(nil, constants.InvalidScheduleErrMsg)
+		}
+	}
+
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		return nil, u.DoctorScheduleRepository.ReplaceAll(txCtx, doctorId, schedules)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.DoctorScheduleRepository.FindAllByDoctorId(ctx, doctorId)
+}
+
+// GetExceptions lists the exceptions from today to the end of the booking
+// window.
+func (u *DoctorScheduleUsecaseImpl) GetExceptions(ctx context.Context, doctorId int64) ([]entities.DoctorScheduleException, error) {
+	today := time.Now()
+	lastDay := today.AddDate(0, 0, constants.AppointmentBookingDays)
+
+	return u.DoctorScheduleRepository.FindExceptions(ctx, doctorId, today.Format(constants.ScheduleDateLayout), lastDay.Format(constants.ScheduleDateLayout))
+}
+
+// CreateException blocks time off inside the booking window. It is refused
+// when a patient already holds an appointment in that time; the doctor has to
+// sort those out with the patient first.
+func (u *DoctorScheduleUsecaseImpl) CreateException(ctx context.Context, exception entities.DoctorScheduleException) (*entities.DoctorScheduleException, error) {
+	day, ok := parseBookableDate(exception.Date)
+	if !ok {
+		return nil, custom_errors.BadRequest(nil, constants.InvalidDateInputErrMsg)
+	}
+
+	if exception.StartTime.Valid != exception.EndTime.Valid {
+		return nil, custom_errors.BadRequest(nil, constants.InvalidScheduleErrMsg)
+	}
+
+	from := day
+	to := day.AddDate(0, 0, 1)
+	if exception.StartTime.Valid {
+		start, end, ok := parseTimeRange(exception.StartTime.String, exception.EndTime.String)
+		if !ok {
+			return nil, custom_errors.BadRequest(nil, constants.InvalidScheduleErrMsg)
+		}
+
+		exception.StartTime.String = start
+		exception.EndTime.String = end
+		from = atTimeOfDay(day, exception.StartTime.String)
+		to = atTimeOfDay(day, exception.EndTime.String)
+	}
+
+	booked, err := u.AppointmentRepository.CountOverlapping(ctx, exception.DoctorId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if booked > 0 {
+		return nil, custom_errors.BadRequest(nil, constants.ScheduleExceptionClashErrMsg)
+	}
+
+	return u.DoctorScheduleRepository.CreateException(ctx, exception)
+}
+
+func (u *DoctorScheduleUsecaseImpl) DeleteException(ctx context.Context, exceptionId int64, doctorId int64) error {
+	return u.DoctorScheduleRepository.DeleteException(ctx, exceptionId, doctorId)
+}
+
+// GetAvailableSlots splits the doctor's schedule for date into slots and
+// leaves out the ones that already started, fall in an exception or are
+// booked. Dates outside the booking window have no slots.
+func (u *DoctorScheduleUsecaseImpl) GetAvailableSlots(ctx context.Context, doctorId int64, date string) ([]entities.AppointmentSlot, error) {
+	slots := []entities.AppointmentSlot{}
+
+	if _, err := time.ParseInLocation(constants.ScheduleDateLayout, date, time.Local); err != nil {
+		return nil, custom_errors.BadRequest(err, constants.InvalidDateInputErrMsg)
+	}
+
+	doctor, err := u.DoctorRepository.FindOneById(ctx, doctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !doctor.IsVerified {
+		return nil, custom_errors.DoctorIsNotVerified()
+	}
+
+	day, ok := parseBookableDate(date)
+	if !ok {
+		return slots, nil
+	}
+
+	schedules, err := u.DoctorScheduleRepository.FindAllByDoctorId(ctx, doctorId)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions, err := u.DoctorScheduleRepository.FindExceptions(ctx, doctorId, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	bookedStarts, err := u.AppointmentRepository.FindBookedStarts(ctx, doctorId, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	// timestamps come back without a zone, so match them on the wall clock
+	booked := map[string]bool{}
+	for _, start := range bookedStarts {
+		booked[start.Format(time.DateTime)] = true
+	}
+
+	now := time.Now()
+	for _, s := range schedules {
+		if s.DayOfWeek != int(day.Weekday()) {
+			continue
+		}
+
+		end := atTimeOfDay(day, s.EndTime)
+		for start := atTimeOfDay(day, s.StartTime); !start.Add(constants.AppointmentSlotDuration).After(end); start = start.Add(constants.AppointmentSlotDuration) {
+			slot := entities.AppointmentSlot{StartAt: start, EndAt: start.Add(constants.AppointmentSlotDuration)}
+
+			if !slot.StartAt.After(now) || booked[slot.StartAt.Format(time.DateTime)] || isBlocked(day, slot, exceptions) {
+				continue
+			}
+
+			slots = append(slots, slot)
+		}
+	}
+
+	return slots, nil
+}
+
+// parseBookableDate reads date in server local time and reports whether it
+// lies between today and the last day patients can book.
+func parseBookableDate(date string) (time.Time, bool) {
+	day, err := time.ParseInLocation(constants.ScheduleDateLayout, date, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	if day.Before(today) || day.After(today.AddDate(0, 0, constants.AppointmentBookingDays)) {
+		return time.Time{}, false
+	}
+
+	return day, true
+}
+
+// parseTimeRange checks that startTime comes before endTime and returns both
+// as zero-padded HH:MM.
+func parseTimeRange(startTime string, endTime string) (string, string, bool) {
+	start, err := time.Parse(constants.ScheduleTimeLayout, startTime)
+	if err != nil {
+		return "", "", false
+	}
+
+	end, err := time.Parse(constants.ScheduleTimeLayout, endTime)
+	if err != nil {
+		return "", "", false
+	}
+
+	if !start.Before(end) {
+		return "", "", false
+	}
+
+	return start.Format(constants.ScheduleTimeLayout), end.Format(constants.ScheduleTimeLayout), true
+}
+
+// atTimeOfDay puts a validated HH:MM clock time on day.
+func atTimeOfDay(day time.Time, clock string) time.Time {
+	t, _ := time.Parse(constants.ScheduleTimeLayout, clock)
+
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+}
+
+func isBlocked(day time.Time, slot entities.AppointmentSlot, exceptions []entities.DoctorScheduleException) bool {
+	for _, e := range exceptions {
+		if !e.StartTime.Valid {
+			return true
+		}
+
+		if slot.StartAt.Before(atTimeOfDay(day, e.EndTime.String)) && slot.EndAt.After(atTimeOfDay(day, e.StartTime.String)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
 )
@@ -14,9 +15,11 @@ type DoctorUsecaseOpts struct {
 type DoctorUsecase interface {
 	GetDoctorById(ctx context.Context, doctorId int64) (*entities.Doctor, error)
 	UpdateDoctor(ctx context.Context, doctor entities.Doctor) error
-	ToggleDoctorIsOnline(ctx context.Context, doctorId int64) error
 	DeleteDoctor(ctx context.Context, doctorId int64) error
 	GetAllDoctor(ctx context.Context, params entities.DoctorParams, isPublic bool) ([]entities.Doctor, *entities.PaginationInfo, error)
+	ConnectDoctor(ctx context.Context, doctorId int64, instanceId string) error
+	DisconnectDoctor(ctx context.Context, doctorId int64, instanceId string) error
+	RefreshDoctorPresence(ctx context.Context, instanceId string) error
 }
 
 type DoctorUsecaseImpl struct {
@@ -52,22 +55,6 @@ func (u *DoctorUsecaseImpl) UpdateDoctor(ctx context.Context, doctor entities.Do
 	return nil
 }
 
-func (u *DoctorUsecaseImpl) ToggleDoctorIsOnline(ctx context.Context, doctorId int64) error {
-	doctor, err := u.DoctorRepository.FindOneById(ctx, doctorId)
-	if err != nil {
-		return err
-	}
-
-	doctor.IsOnline = !doctor.IsOnline
-
-	err = u.DoctorRepository.UpdateIsOnline(ctx, *doctor)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (u *DoctorUsecaseImpl) DeleteDoctor(ctx context.Context, doctorId int64) error {
 	err := u.DoctorRepository.Delete(ctx, doctorId)
 	if err != nil {
@@ -108,3 +95,41 @@ func (u *DoctorUsecaseImpl) GetAllDoctor(ctx context.Context, params entities.Do
 
 	return doctors, &pagination, nil
 }
+
+// ConnectDoctor marks the doctor online while this instance holds one of their
+// WebSocket connections.
+func (u *DoctorUsecaseImpl) ConnectDoctor(ctx context.Context, doctorId int64, instanceId string) error {
+	err := u.DoctorRepository.UpsertPresence(ctx, doctorId, instanceId)
+	if err != nil {
+		return err
+	}
+
+	return u.DoctorRepository.SyncIsOnline(ctx, doctorId)
+}
+
+// DisconnectDoctor drops this instance's presence, leaving the doctor online
+// only if another instance still holds a connection.
+func (u *DoctorUsecaseImpl) DisconnectDoctor(ctx context.Context, doctorId int64, instanceId string) error {
+	err := u.DoctorRepository.DeletePresence(ctx, doctorId, instanceId)
+	if err != nil {
+		return err
+	}
+
+	return u.DoctorRepository.SyncIsOnline(ctx, doctorId)
+}
+
+// RefreshDoctorPresence keeps this instance's rows alive and clears the ones
+// left behind by instances that stopped refreshing.
+func (u *DoctorUsecaseImpl) RefreshDoctorPresence(ctx context.Context, instanceId string) error {
+	err := u.DoctorRepository.RefreshPresences(ctx, instanceId)
+	if err != nil {
+		return err
+	}
+
+	err = u.DoctorRepository.DeleteStalePresences(ctx, constants.DoctorPresenceTtl)
+	if err != nil {
+		return err
+	}
+
+	return u.DoctorRepository.SyncAllIsOnline(ctx)
+}
@@ -0,0 +1,88 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/google/uuid"
+)
+
+type DocumentUsecaseOpts struct {
+	DocumentRepository repositories.DocumentRepository
+	AuthTokenProvider  utils.AuthTokenProvider
+	Transactor         repositories.Transactor
+	FrontendUrl        string
+}
+
+type DocumentUsecase interface {
+	NewDocument(document entities.Document) (*entities.Document, string, error)
+	IssueDocument(ctx context.Context, document entities.Document) (*entities.Document, error)
+	VerifyDocument(ctx context.Context, token string) (*entities.Document, error)
+}
+
+type DocumentUsecaseImpl struct {
+	DocumentRepository repositories.DocumentRepository
+	AuthTokenProvider  utils.AuthTokenProvider
+	Transactor         repositories.Transactor
+	FrontendUrl        string
+}
+
+func NewDocumentUsecaseImpl(dOpts *DocumentUsecaseOpts) DocumentUsecase {
+	return &DocumentUsecaseImpl{
+		DocumentRepository: dOpts.DocumentRepository,
+		AuthTokenProvider:  dOpts.AuthTokenProvider,
+		Transactor:         dOpts.Transactor,
+		FrontendUrl:        dOpts.FrontendUrl,
+	}
+}
+
+// NewDocument numbers a document and returns it with the URL its QR code
+// should point to. Nothing is stored, so the file can be rendered and uploaded
+// before the document is issued.
+func (u *DocumentUsecaseImpl) NewDocument(document entities.Document) (*entities.Document, string, error) {
+	document.Number = fmt.Sprintf("%s-%s-%s", constants.DocumentNumberPrefixes[document.Type], time.Now().Format(constants.DocumentNumberDateLayout), strings.ToUpper(uuid.NewString()[:8]))
+
+	token, err := u.AuthTokenProvider.CreateDocumentToken(document.Number)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &document, fmt.Sprintf("%s%s%s", u.FrontendUrl, constants.DocumentVerifyPath, token), nil
+}
+
+// IssueDocument stores a document made by NewDocument, revoking the one of the
+// same type issued earlier for the consultation. Called within the caller's
+// transaction, the old document stays valid unless the whole change commits.
+func (u *DocumentUsecaseImpl) IssueDocument(ctx context.Context, document entities.Document) (*entities.Document, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		err := u.DocumentRepository.RevokeByConsultationId(txCtx, document.ConsultationId, document.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		return u.DocumentRepository.CreateOne(txCtx, document)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*entities.Document), nil
+}
+
+// VerifyDocument checks the token from a document's QR code and looks the
+// document up. A forged or tampered token is rejected before any lookup.
+func (u *DocumentUsecaseImpl) VerifyDocument(ctx context.Context, token string) (*entities.Document, error) {
+	number, err := u.AuthTokenProvider.ParseDocumentToken(token)
+	if err != nil {
+		return nil, custom_errors.BadRequest(err, constants.InvalidDocumentErrMsg)
+	}
+
+	return u.DocumentRepository.FindByNumber(ctx, number)
+}
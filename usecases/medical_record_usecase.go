@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"context"
+	"os"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+)
+
+type MedicalRecordUsecaseOpts struct {
+	MedicalRecordRepository repositories.MedicalRecordRepository
+	ConsultationRepository  repositories.ConsultationRepository
+	DependentRepository     repositories.DependentRepository
+	UserRepository          repositories.UserRepository
+	UploadFile              utils.FileUploader
+}
+
+type MedicalRecordUsecase interface {
+	GetMedicalRecord(ctx context.Context, userId int64, dependentId int64) (*entities.MedicalRecord, error)
+	GetConsultationMedicalRecord(ctx context.Context, consultationId int64, doctorId int64) (*entities.MedicalRecord, error)
+	ExportMedicalRecord(ctx context.Context, userId int64, dependentId int64) (string, error)
+	CreateCondition(ctx context.Context, condition entities.MedicalCondition) (*entities.MedicalCondition, error)
+	DeleteCondition(ctx context.Context, conditionId int64, userId int64) error
+}
+
+type MedicalRecordUsecaseImpl struct {
+	MedicalRecordRepository repositories.MedicalRecordRepository
+	ConsultationRepository  repositories.ConsultationRepository
+	DependentRepository     repositories.DependentRepository
+	UserRepository          repositories.UserRepository
+	UploadFile              utils.FileUploader
+}
+
+func NewMedicalRecordUsecaseImpl(mrOpts *MedicalRecordUsecaseOpts) MedicalRecordUsecase {
+	return &MedicalRecordUsecaseImpl{
+		MedicalRecordRepository: mrOpts.MedicalRecordRepository,
+		ConsultationRepository:  mrOpts.ConsultationRepository,
+		DependentRepository:     mrOpts.DependentRepository,
+		UserRepository:          mrOpts.UserRepository,
+		UploadFile:              mrOpts.UploadFile,
+	}
+}
+
+// GetMedicalRecord gathers the conditions and every ended consultation, with
+// its diagnosis and prescribed items, of the user or, when dependentId is not
+// 0, of one of their dependents.
+func (u *MedicalRecordUsecaseImpl) GetMedicalRecord(ctx context.Context, userId int64, dependentId int64) (*entities.MedicalRecord, error) {
+	user, err := u.UserRepository.FindOneById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	record := entities.MedicalRecord{User: *user}
+
+	if dependentId != 0 {
+		record.Dependent, err = u.DependentRepository.FindOneById(ctx, dependentId, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	record.Conditions, err = u.MedicalRecordRepository.FindConditionsByUserId(ctx, userId, dependentId)
+	if err != nil {
+		return nil, err
+	}
+
+	record.Entries, err = u.MedicalRecordRepository.FindEntriesByUserId(ctx, userId, dependentId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// GetConsultationMedicalRecord lets the doctor of a consultation read the
+// record of its patient, who is either the user or one of their dependents,
+// but only while the consultation is going on.
+func (u *MedicalRecordUsecaseImpl) GetConsultationMedicalRecord(ctx context.Context, consultationId int64, doctorId int64) (*entities.MedicalRecord, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.Doctor.Id != doctorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if consultation.Status != constants.ConsultationStatusAccepted {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationNotAcceptedErrMsg)
+	}
+
+	if consultation.EndedAt.Valid {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationEndedErrMsg)
+	}
+
+	return u.GetMedicalRecord(ctx, consultation.User.Id, consultation.DependentId.Int64)
+}
+
+func (u *MedicalRecordUsecaseImpl) ExportMedicalRecord(ctx context.Context, userId int64, dependentId int64) (string, error) {
+	record, err := u.GetMedicalRecord(ctx, userId, dependentId)
+	if err != nil {
+		return "", err
+	}
+
+	pdfName, err := utils.GenerateMedicalRecordPdf(*record)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(pdfName)
+
+	fileUrl, err := u.UploadFile.UploadFile(ctx, pdfName)
+	if err != nil {
+		return "", custom_errors.UploadFile()
+	}
+
+	return fileUrl, nil
+}
+
+func (u *MedicalRecordUsecaseImpl) CreateCondition(ctx context.Context, condition entities.MedicalCondition) (*entities.MedicalCondition, error) {
+	if condition.DependentId.Valid {
+		_, err := u.DependentRepository.FindOneById(ctx, condition.DependentId.Int64, condition.UserId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.MedicalRecordRepository.CreateCondition(ctx, condition)
+}
+
+func (u *MedicalRecordUsecaseImpl) DeleteCondition(ctx context.Context, conditionId int64, userId int64) error {
+	return u.MedicalRecordRepository.DeleteCondition(ctx, conditionId, userId)
+}
@@ -0,0 +1,89 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+)
+
+type orderTransition struct {
+	From string
+	To   string
+}
+
+// orderTransitions lists every order status change the system allows and the
+// roles that may perform it. Anything not in this table is rejected.
+var orderTransitions = map[orderTransition][]string{
+	{constants.Pending, constants.Processing}:  {constants.AdminRole, constants.SystemRole},
+	{constants.Pending, constants.Canceled}:    {constants.UserRole, constants.AdminRole, constants.SystemRole},
+	{constants.Processing, constants.Shipped}:  {constants.PharmacyManagerRole},
+	{constants.Processing, constants.Canceled}: {constants.PharmacyManagerRole, constants.AdminRole},
+	{constants.Shipped, constants.Delivered}:   {constants.PharmacyManagerRole},
+	{constants.Shipped, constants.Completed}:   {constants.UserRole, constants.SystemRole},
+	{constants.Delivered, constants.Completed}: {constants.UserRole, constants.SystemRole},
+}
+
+var orderTransitionErrMsgs = map[string]string{
+	constants.Processing: constants.OrderStatusNotPendingErrMsg,
+	constants.Shipped:    constants.OrderStatusNotProcessingErrMsg,
+	constants.Delivered:  constants.OrderNotDeliverableErrMsg,
+	constants.Completed:  constants.OrderStatusNotShippedErrMsg,
+	constants.Canceled:   constants.CannotCancelOrderErrMsg,
+}
+
+type orderStateMachine struct {
+	orderRepository              repositories.OrderRepository
+	orderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+}
+
+func newOrderStateMachine(orderRepo repositories.OrderRepository, orderStatusHistoryRepo repositories.OrderStatusHistoryRepository) *orderStateMachine {
+	return &orderStateMachine{
+		orderRepository:              orderRepo,
+		orderStatusHistoryRepository: orderStatusHistoryRepo,
+	}
+}
+
+func (m *orderStateMachine) Validate(from string, to string, role string) error {
+	for _, allowed := range orderTransitions[orderTransition{From: from, To: to}] {
+		if allowed == role {
+			return nil
+		}
+	}
+
+	return custom_errors.BadRequest(nil, orderTransitionErrMsgs[to])
+}
+
+// Transition moves the order from its current status to req.OrderStatus and
+// records the change in order_status_histories. It should run inside a
+// transaction so the status and its history entry are written together.
+func (m *orderStateMachine) Transition(ctx context.Context, from string, req entities.UpdateOrderStatus) error {
+	err := m.Validate(from, req.OrderStatus, req.ActorRole)
+	if err != nil {
+		return err
+	}
+
+	err = m.orderRepository.UpdateOrderStatus(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	history := entities.OrderStatusHistory{
+		OrderId:    req.OrderId,
+		FromStatus: sql.NullString{String: from, Valid: from != ""},
+		ToStatus:   req.OrderStatus,
+		ActorRole:  req.ActorRole,
+		ActorId:    sql.NullInt64{Int64: req.ActorId, Valid: req.ActorId != 0},
+		Reason:     sql.NullString{String: req.Reason, Valid: req.Reason != ""},
+	}
+
+	err = m.orderStatusHistoryRepository.CreateOne(ctx, history)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
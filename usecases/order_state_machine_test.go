@@ -0,0 +1,63 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+)
+
+func TestOrderStateMachineValidate(t *testing.T) {
+	m := newOrderStateMachine(nil, nil)
+
+	tests := []struct {
+		from    string
+		to      string
+		role    string
+		wantErr string
+	}{
+		{constants.Pending, constants.Processing, constants.AdminRole, ""},
+		{constants.Pending, constants.Processing, constants.SystemRole, ""},
+		{constants.Pending, constants.Processing, constants.UserRole, constants.OrderStatusNotPendingErrMsg},
+		{constants.Pending, constants.Processing, constants.PharmacyManagerRole, constants.OrderStatusNotPendingErrMsg},
+		{constants.Pending, constants.Canceled, constants.UserRole, ""},
+		{constants.Pending, constants.Canceled, constants.AdminRole, ""},
+		{constants.Pending, constants.Canceled, constants.SystemRole, ""},
+		{constants.Pending, constants.Canceled, constants.PharmacyManagerRole, constants.CannotCancelOrderErrMsg},
+		{constants.Pending, constants.Shipped, constants.PharmacyManagerRole, constants.OrderStatusNotProcessingErrMsg},
+		{constants.Processing, constants.Shipped, constants.PharmacyManagerRole, ""},
+		{constants.Processing, constants.Shipped, constants.AdminRole, constants.OrderStatusNotProcessingErrMsg},
+		{constants.Processing, constants.Canceled, constants.PharmacyManagerRole, ""},
+		{constants.Processing, constants.Canceled, constants.AdminRole, ""},
+		{constants.Processing, constants.Canceled, constants.UserRole, constants.CannotCancelOrderErrMsg},
+		{constants.Processing, constants.Canceled, constants.SystemRole, constants.CannotCancelOrderErrMsg},
+		{constants.Processing, constants.Completed, constants.UserRole, constants.OrderStatusNotShippedErrMsg},
+		{constants.Shipped, constants.Delivered, constants.PharmacyManagerRole, ""},
+		{constants.Shipped, constants.Delivered, constants.UserRole, constants.OrderNotDeliverableErrMsg},
+		{constants.Shipped, constants.Completed, constants.UserRole, ""},
+		{constants.Shipped, constants.Completed, constants.SystemRole, ""},
+		{constants.Shipped, constants.Completed, constants.PharmacyManagerRole, constants.OrderStatusNotShippedErrMsg},
+		{constants.Shipped, constants.Canceled, constants.AdminRole, constants.CannotCancelOrderErrMsg},
+		{constants.Delivered, constants.Completed, constants.UserRole, ""},
+		{constants.Delivered, constants.Completed, constants.SystemRole, ""},
+		{constants.Delivered, constants.Canceled, constants.AdminRole, constants.CannotCancelOrderErrMsg},
+		{constants.Completed, constants.Canceled, constants.AdminRole, constants.CannotCancelOrderErrMsg},
+		{constants.Canceled, constants.Processing, constants.SystemRole, constants.OrderStatusNotPendingErrMsg},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to+" as "+tt.role, func(t *testing.T) {
+			err := m.Validate(tt.from, tt.to, tt.role)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
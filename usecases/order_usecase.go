@@ -2,6 +2,10 @@ package usecases
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,133 +16,396 @@ import (
 	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
 	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type OrderUsecaseOpts struct {
-	OrderRepository           repositories.OrderRepository
-	CartRepository            repositories.CartRepository
-	PharmacyProductRepository repositories.PharmacyProductRepository
-	StockHistoryRepository    repositories.StockHistoryRepository
-	Transactor                repositories.Transactor
-	UploadFile                utils.FileUploader
+	OrderRepository              repositories.OrderRepository
+	CartRepository               repositories.CartRepository
+	CheckoutRepository           repositories.CheckoutRepository
+	PharmacyProductRepository    repositories.PharmacyProductRepository
+	StockHistoryRepository       repositories.StockHistoryRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	VoucherRepository            repositories.VoucherRepository
+	RefundRepository             repositories.RefundRepository
+	PaymentRepository            repositories.PaymentRepository
+	PrescriptionRepository       repositories.PrescriptionRepository
+	ShippingMethodUsecase        ShippingMethodUsecase
+	Transactor                   repositories.Transactor
+	UploadFile                   utils.FileUploader
+	AutoCompleteDays             int
 }
 
 type OrderUsecase interface {
-	CreateNewOrder(ctx context.Context, req dtos.OrderRequest) (*entities.Order, error)
-	CreateOrderWithTransaction(ctx context.Context, req dtos.OrderRequest) (*entities.Order, error)
+	CreateNewOrder(ctx context.Context, req dtos.OrderRequest) (*entities.Checkout, error)
+	CreateOrderWithTransaction(ctx context.Context, req dtos.OrderRequest) (*entities.Checkout, error)
 	GetAllOrderByUser(ctx context.Context, userId int64, params entities.OrderParams) ([]dtos.OrderResponse, int, error)
 	GetAllOrderByPharmacyManager(ctx context.Context, pharmacyManagerId int64, params entities.OrderParams) ([]dtos.OrderResponse, int, error)
 	GetAllOrderByAdmin(ctx context.Context, params entities.OrderParams) ([]dtos.OrderResponse, int, error)
-	UpdateOrderStatusToProcessing(ctx context.Context, orderId int64) error
+	UpdateOrderStatusToProcessing(ctx context.Context, orderId int64, adminId int64) error
 	UpdateOrderStatusToShipped(ctx context.Context, orderId int64, pharmacyManagerId int64) error
+	UpdateOrderStatusToDelivered(ctx context.Context, orderId int64, pharmacyManagerId int64) error
 	UpdateOrderStatusToCompleted(ctx context.Context, orderId int64, userId int64) error
 	UploadPaymentProof(ctx context.Context, req dtos.UploadPaymentProofResponse, userId int64) error
-	UpdateOrderStatusToCanceled(ctx context.Context, orderId int64, userId int64) error
-	CancelOrderByAdmin(ctx context.Context, orderId int64) error
-	CancelOrderByPharmacyManager(ctx context.Context, orderId int64, pharmacyManagerId int64) error
+	UpdateOrderStatusToCanceled(ctx context.Context, orderId int64, userId int64, reason string) error
+	CancelOrderByAdmin(ctx context.Context, orderId int64, adminId int64, reason string) error
+	CancelOrderByPharmacyManager(ctx context.Context, orderId int64, pharmacyManagerId int64, reason string) error
 	GetOrderDetail(ctx context.Context, orderId int64) (*dtos.OrderResponse, error)
+	GetOrderStatusHistories(ctx context.Context, orderId int64, actorId int64, actorRole string) ([]entities.OrderStatusHistory, error)
+	GetOrderInvoice(ctx context.Context, orderId int64, actorId int64, actorRole string) (string, error)
+	CancelExpiredOrders(ctx context.Context) (int, error)
+	CompleteShippedOrders(ctx context.Context) (int, error)
 }
 
 type OrderUsecaseImpl struct {
-	OrderRepository           repositories.OrderRepository
-	CartRepository            repositories.CartRepository
-	PharmacyProductRepository repositories.PharmacyProductRepository
-	StockHistoryRepository    repositories.StockHistoryRepository
-	Transactor                repositories.Transactor
-	UploadFile                utils.FileUploader
+	OrderRepository              repositories.OrderRepository
+	CartRepository               repositories.CartRepository
+	CheckoutRepository           repositories.CheckoutRepository
+	PharmacyProductRepository    repositories.PharmacyProductRepository
+	StockHistoryRepository       repositories.StockHistoryRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	VoucherRepository            repositories.VoucherRepository
+	RefundRepository             repositories.RefundRepository
+	PaymentRepository            repositories.PaymentRepository
+	PrescriptionRepository       repositories.PrescriptionRepository
+	ShippingMethodUsecase        ShippingMethodUsecase
+	Transactor                   repositories.Transactor
+	UploadFile                   utils.FileUploader
+	AutoCompleteDays             int
+	stateMachine                 *orderStateMachine
 }
 
 func NewOrderUsecaseImpl(oUseOpts *OrderUsecaseOpts) OrderUsecase {
 	return &OrderUsecaseImpl{
-		OrderRepository:           oUseOpts.OrderRepository,
-		CartRepository:            oUseOpts.CartRepository,
-		PharmacyProductRepository: oUseOpts.PharmacyProductRepository,
-		StockHistoryRepository:    oUseOpts.StockHistoryRepository,
-		Transactor:                oUseOpts.Transactor,
-		UploadFile:                oUseOpts.UploadFile,
+		OrderRepository:              oUseOpts.OrderRepository,
+		CartRepository:               oUseOpts.CartRepository,
+		CheckoutRepository:           oUseOpts.CheckoutRepository,
+		PharmacyProductRepository:    oUseOpts.PharmacyProductRepository,
+		StockHistoryRepository:       oUseOpts.StockHistoryRepository,
+		OrderStatusHistoryRepository: oUseOpts.OrderStatusHistoryRepository,
+		VoucherRepository:            oUseOpts.VoucherRepository,
+		RefundRepository:             oUseOpts.RefundRepository,
+		PaymentRepository:            oUseOpts.PaymentRepository,
+		PrescriptionRepository:       oUseOpts.PrescriptionRepository,
+		ShippingMethodUsecase:        oUseOpts.ShippingMethodUsecase,
+		Transactor:                   oUseOpts.Transactor,
+		UploadFile:                   oUseOpts.UploadFile,
+		AutoCompleteDays:             oUseOpts.AutoCompleteDays,
+		stateMachine:                 newOrderStateMachine(oUseOpts.OrderRepository, oUseOpts.OrderStatusHistoryRepository),
 	}
 }
 
-func (u *OrderUsecaseImpl) CreateNewOrder(ctx context.Context, req dtos.OrderRequest) (*entities.Order, error) {
+func (u *OrderUsecaseImpl) CreateNewOrder(ctx context.Context, req dtos.OrderRequest) (*entities.Checkout, error) {
 	timeNow := time.Now()
-	cart, err := u.CartRepository.FindPharmacyIdByCartId(ctx, req.CartItemId[0])
+	cartItems, err := u.CartRepository.FindCheckoutCartItems(ctx, req.UserId, req.CartItemId)
 	if err != nil {
 		return nil, err
 	}
 
-	uuid, err := uuid.NewUUID()
+	if len(cartItems) != len(req.CartItemId) {
+		return nil, custom_errors.BadRequest(nil, constants.CartItemNotFoundErrMsg)
+	}
+
+	// a prescription can expire while its items wait in the cart, and the
+	// lines tied to one prescription item share its prescribed quantity
+	prescriptionItemIds := []int64{}
+	prescribedQuantities := map[int64]int{}
+	for i, item := range cartItems {
+		if item.RequiresPrescription && !item.IsPrescribed {
+			return nil, custom_errors.BadRequest(nil, constants.PrescriptionRequiredErrMsg)
+		}
+		if item.PrescriptionItemId.Valid && item.IsPrescribed {
+			prescribedQuantities[item.PrescriptionItemId.Int64] += item.Quantity
+			if prescribedQuantities[item.PrescriptionItemId.Int64] > int(item.PrescribedQuantity.Int64) {
+				return nil, custom_errors.BadRequest(nil, constants.PrescribedQuantityErrMsg)
+			}
+			prescriptionItemIds = append(prescriptionItemIds, item.PrescriptionItemId.Int64)
+		} else {
+			// only lines that spend a fill keep their prescription item, so a
+			// canceled order gives back exactly what it took
+			cartItems[i].PrescriptionItemId = sql.NullInt64{}
+		}
+	}
+
+	var voucher *entities.Voucher
+	discount := decimal.Zero
+	if req.VoucherCode != "" {
+		voucher, discount, err = u.applyVoucher(ctx, req.VoucherCode, req.UserId, cartItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !discount.Equal(req.Discount) {
+		return nil, custom_errors.BadRequest(nil, constants.OrderDiscountChangedErrMsg)
+	}
+
+	groups := groupCartItemsByPharmacy(cartItems)
+
+	shippings := map[int64]dtos.OrderShippingRequest{}
+	for _, shipping := range req.Shippings {
+		shippings[shipping.PharmacyId] = shipping
+	}
+
+	if len(req.Shippings) == 0 && len(groups) == 1 {
+		shippings[groups[0].PharmacyId] = dtos.OrderShippingRequest{
+			PharmacyId:                  groups[0].PharmacyId,
+			ShippingFee:                 req.ShippingFee,
+			ShippingMethod:              req.ShippingMethod,
+			OfficialShippingMethodId:    req.OfficialShippingMethodId,
+			NonOfficialShippingMethodId: req.NonOfficialShippingMethodId,
+		}
+	}
+
+	checkoutUuid, err := uuid.NewUUID()
 	if err != nil {
 		return nil, err
 	}
 
-	order := entities.Order{
-		OrderNumber:     uuid.String(),
-		TotalPrice:      req.TotalPrice,
+	checkout := entities.Checkout{
+		CheckoutNumber:  checkoutUuid.String(),
+		UserId:          req.UserId,
+		TotalPrice:      decimal.Zero,
+		ShippingFee:     decimal.Zero,
+		Discount:        discount,
 		PaymentDeadline: timeNow.Add(time.Hour * 1),
-		ShippingFee:     req.ShippingFee,
-		ShippingMethod:  req.ShippingMethod,
-		UserAddressId:   req.UserAddressId,
-		OrderStatus:     constants.Pending,
-		PharmacyId:      cart.PharmacyId,
 	}
-	newOrder, err := u.OrderRepository.CreateOrder(ctx, order)
-	if err != nil {
-		return nil, err
+
+	for i, group := range groups {
+		shipping, ok := shippings[group.PharmacyId]
+		if !ok {
+			return nil, custom_errors.BadRequest(nil, constants.OrderShippingRequiredErrMsg)
+		}
+
+		shippingFee, shippingMethod, err := u.getShippingFee(ctx, req, group, shipping)
+		if err != nil {
+			return nil, err
+		}
+
+		if !shippingFee.Equal(shipping.ShippingFee) {
+			return nil, custom_errors.BadRequest(nil, constants.ShippingFeeChangedErrMsg)
+		}
+
+		groups[i].ShippingFee = shippingFee
+		groups[i].ShippingMethod = shippingMethod
+
+		checkout.TotalPrice = checkout.TotalPrice.Add(group.TotalPrice)
+		checkout.ShippingFee = checkout.ShippingFee.Add(shippingFee)
+	}
+
+	if !checkout.TotalPrice.Equal(req.TotalPrice) {
+		return nil, custom_errors.BadRequest(nil, constants.OrderPriceChangedErrMsg)
 	}
 
-	err = u.OrderRepository.CreateOrderItems(ctx, req.CartItemId, newOrder.Id)
+	newCheckout, err := u.CheckoutRepository.CreateOne(ctx, checkout)
 	if err != nil {
 		return nil, err
 	}
 
+	if voucher != nil {
+		err = u.VoucherRepository.CreateUsage(ctx, entities.VoucherUsage{
+			VoucherId:  voucher.Id,
+			UserId:     req.UserId,
+			CheckoutId: newCheckout.Id,
+			Discount:   discount,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, group := range groups {
+		uuid, err := uuid.NewUUID()
+		if err != nil {
+			return nil, err
+		}
+
+		order := entities.Order{
+			OrderNumber:     uuid.String(),
+			TotalPrice:      group.TotalPrice,
+			PaymentDeadline: newCheckout.PaymentDeadline,
+			ShippingFee:     group.ShippingFee,
+			Discount:        group.Discount,
+			ShippingMethod:  group.ShippingMethod,
+			UserAddressId:   req.UserAddressId,
+			OrderStatus:     constants.Pending,
+			PharmacyId:      group.PharmacyId,
+			CheckoutId:      newCheckout.Id,
+		}
+		newOrder, err := u.OrderRepository.CreateOrder(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.OrderRepository.CreateOrderItems(ctx, group.Items, newOrder.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.OrderStatusHistoryRepository.CreateOne(ctx, entities.OrderStatusHistory{
+			OrderId:   newOrder.Id,
+			ToStatus:  constants.Pending,
+			ActorRole: constants.UserRole,
+			ActorId:   sql.NullInt64{Int64: req.UserId, Valid: req.UserId != 0},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		newOrder.PharmacyId = group.PharmacyId
+		newCheckout.Orders = append(newCheckout.Orders, *newOrder)
+	}
+
+	// ordering prescribed items spends one fill of their prescription
+	if len(prescriptionItemIds) != 0 {
+		err = u.PrescriptionRepository.UpdateFillCountByItemIds(ctx, prescriptionItemIds)
+		if err != nil {
+			if err.Error() == constants.ResponseMsgErrorNotFound {
+				return nil, custom_errors.BadRequest(err, constants.PrescriptionNoRefillsErrMsg)
+			}
+			return nil, err
+		}
+	}
+
 	err = u.CartRepository.CartBulkDelete(ctx, req.CartItemId)
 	if err != nil {
 		return nil, err
 	}
 
-	return newOrder, nil
+	return newCheckout, nil
 }
 
-func (u *OrderUsecaseImpl) CreateOrderWithTransaction(ctx context.Context, req dtos.OrderRequest) (*entities.Order, error) {
-	var order *entities.Order
-	var err error
-
-	_, err = u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
-		order, err = u.CreateNewOrder(txCtx, req)
+// CreateOrderWithTransaction creates the orders, moves the cart items into them
+// and reserves their stock in one transaction, so a checkout either succeeds
+// as a whole or leaves nothing behind.
+func (u *OrderUsecaseImpl) CreateOrderWithTransaction(ctx context.Context, req dtos.OrderRequest) (*entities.Checkout, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		checkout, err := u.CreateNewOrder(txCtx, req)
 		if err != nil {
 			return nil, err
 		}
-		return nil, nil
+
+		orderItems := []dtos.OrderItem{}
+		for _, order := range checkout.Orders {
+			items, err := u.OrderRepository.GetOrderItems(txCtx, order.Id)
+			if err != nil {
+				return nil, err
+			}
+			orderItems = append(orderItems, items...)
+		}
+
+		// lock pharmacy products in a fixed order so concurrent checkouts don't deadlock
+		sort.Slice(orderItems, func(i, j int) bool {
+			return orderItems[i].PharmacyProductId < orderItems[j].PharmacyProductId
+		})
+
+		for _, item := range orderItems {
+			err := u.decreaseStock(txCtx, item.PharmacyProductId, item.Quantity)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return checkout, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	orderItems, err := u.OrderRepository.GetOrderItems(ctx, order.Id)
+	return res.(*entities.Checkout), nil
+}
+
+type pharmacyCartGroup struct {
+	PharmacyId     int64
+	Items          []entities.CartItem
+	TotalPrice     decimal.Decimal
+	TotalWeight    int
+	ShippingFee    decimal.Decimal
+	Discount       decimal.Decimal
+	ShippingMethod string
+}
+
+// groupCartItemsByPharmacy splits the checked out cart items into one group per
+// pharmacy, keeping the order in which pharmacies first appear.
+func groupCartItemsByPharmacy(cartItems []entities.CartItem) []pharmacyCartGroup {
+	groups := []pharmacyCartGroup{}
+	index := map[int64]int{}
+
+	for _, item := range cartItems {
+		i, ok := index[item.PharmacyId]
+		if !ok {
+			i = len(groups)
+			index[item.PharmacyId] = i
+			groups = append(groups, pharmacyCartGroup{PharmacyId: item.PharmacyId, TotalPrice: decimal.Zero, Discount: decimal.Zero})
+		}
+
+		groups[i].Items = append(groups[i].Items, item)
+		groups[i].TotalPrice = groups[i].TotalPrice.Add(cartItemTotal(item))
+		groups[i].Discount = groups[i].Discount.Add(item.Discount)
+		groups[i].TotalWeight += item.Weight * item.Quantity
+	}
+
+	return groups
+}
+
+// getShippingFee prices the shipping method the user picked for one pharmacy.
+// The method must be one the pharmacy offers; its name is taken from the
+// pharmacy's list rather than from the request.
+func (u *OrderUsecaseImpl) getShippingFee(ctx context.Context, req dtos.OrderRequest, group pharmacyCartGroup, shipping dtos.OrderShippingRequest) (decimal.Decimal, string, error) {
+	methods, err := u.ShippingMethodUsecase.GetShippingMethod(ctx, group.PharmacyId)
 	if err != nil {
-		return nil, err
+		return decimal.Zero, "", err
 	}
 
-	for _, item := range orderItems {
-		_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
-			err := u.decreaseStock(txCtx, item.PharmacyProductId, item.Quantity)
+	if shipping.OfficialShippingMethodId != 0 {
+		for _, method := range methods.Official {
+			if method.Id != shipping.OfficialShippingMethodId {
+				continue
+			}
+
+			fee, err := u.ShippingMethodUsecase.GetOfficialFee(ctx, dtos.OfficialShippingFeeRequest{
+				UserAddressId:            req.UserAddressId,
+				PharmacyId:               group.PharmacyId,
+				OfficialShippingMethodId: method.Id,
+			})
 			if err != nil {
-				return nil, err
+				return decimal.Zero, "", err
 			}
-			return nil, nil
-		})
 
-		if err != nil {
-			return nil, err
+			return decimal.NewFromFloat(fee), method.Name, nil
+		}
+	}
+
+	if shipping.NonOfficialShippingMethodId != 0 {
+		for _, method := range methods.NonOfficial {
+			if method.Id != shipping.NonOfficialShippingMethodId {
+				continue
+			}
+
+			fee, err := u.ShippingMethodUsecase.GetNonOfficialFee(ctx, dtos.NonOfficialShippingFeeRequest{
+				UserAddressId:               req.UserAddressId,
+				PharmacyId:                  group.PharmacyId,
+				TotalWeight:                 float64(group.TotalWeight),
+				NonOfficialShippingMethodId: method.Id,
+			}, int(req.UserId))
+			if err != nil {
+				return decimal.Zero, "", err
+			}
+
+			if fee == 0 {
+				break
+			}
+
+			return decimal.NewFromFloat(fee), method.Name, nil
 		}
 	}
 
-	return order, nil
+	return decimal.Zero, "", custom_errors.BadRequest(nil, constants.ShippingNotAvailableErrMsg)
 }
 
 func (u *OrderUsecaseImpl) GetAllOrderByUser(ctx context.Context, userId int64, params entities.OrderParams) ([]dtos.OrderResponse, int, error) {
 	var total int
-	
+
 	orders, err := u.OrderRepository.FindAllOrdersByUserId(ctx, userId, params)
 	if err != nil {
 		return nil, 0, err
@@ -188,7 +455,7 @@ func (u *OrderUsecaseImpl) GetAllOrderByPharmacyManager(ctx context.Context, pha
 
 func (u *OrderUsecaseImpl) GetAllOrderByAdmin(ctx context.Context, params entities.OrderParams) ([]dtos.OrderResponse, int, error) {
 	var total int
-	
+
 	orders, err := u.OrderRepository.FindAllOrdersByAdmin(ctx, params)
 	if err != nil {
 		return nil, 0, err
@@ -244,22 +511,28 @@ func (u *OrderUsecaseImpl) decreaseStock(ctx context.Context, pharmacyProductId
 }
 
 func (u *OrderUsecaseImpl) increaseStock(ctx context.Context, pharmacyProductId int64, quantity int, description string) error {
-	err := u.PharmacyProductRepository.LockRow(ctx, pharmacyProductId)
+	return restockPharmacyProduct(ctx, u.PharmacyProductRepository, u.StockHistoryRepository, pharmacyProductId, quantity, description)
+}
+
+// restockPharmacyProduct puts quantity back into a pharmacy product's stock and
+// records it in the stock history.
+func restockPharmacyProduct(ctx context.Context, pharmacyProductRepository repositories.PharmacyProductRepository, stockHistoryRepository repositories.StockHistoryRepository, pharmacyProductId int64, quantity int, description string) error {
+	err := pharmacyProductRepository.LockRow(ctx, pharmacyProductId)
 	if err != nil {
 		return err
 	}
 
-	pharmacyProduct, err := u.PharmacyProductRepository.GetOnePharmacyProduct(ctx, pharmacyProductId)
+	pharmacyProduct, err := pharmacyProductRepository.GetOnePharmacyProduct(ctx, pharmacyProductId)
 	if err != nil {
 		return err
 	}
 
-	err = u.PharmacyProductRepository.IncreaseStock(ctx, quantity, pharmacyProductId)
+	err = pharmacyProductRepository.IncreaseStock(ctx, quantity, pharmacyProductId)
 	if err != nil {
 		return err
 	}
 
-	err = u.StockHistoryRepository.CreateOne(ctx, entities.StockHistory{
+	err = stockHistoryRepository.CreateOne(ctx, entities.StockHistory{
 		Quantity:        quantity,
 		PharmacyProduct: entities.PharmacyProduct{Id: pharmacyProductId},
 		Pharmacy:        entities.Pharmacy{Id: pharmacyProduct.Pharmacy.Id},
@@ -272,79 +545,56 @@ func (u *OrderUsecaseImpl) increaseStock(ctx context.Context, pharmacyProductId
 	return nil
 }
 
-func (u *OrderUsecaseImpl) UpdateOrderStatusToProcessing(ctx context.Context, orderId int64) error {
-	order, err := u.OrderRepository.GetOrder(ctx, orderId)
-	if err != nil {
-		return err
-	}
-
-	if order.OrderStatus != constants.Pending {
-		return custom_errors.BadRequest(nil, constants.OrderStatusNotPendingErrMsg)
-	}
-
+func (u *OrderUsecaseImpl) UpdateOrderStatusToProcessing(ctx context.Context, orderId int64, adminId int64) error {
 	req := entities.UpdateOrderStatus{
 		OrderId:           orderId,
 		OrderStatus:       constants.Processing,
 		PharmacyManagerId: 0,
 		UserId:            0,
+		ActorRole:         constants.AdminRole,
+		ActorId:           adminId,
 	}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return u.changeOrderStatus(ctx, req, "")
 }
 
 func (u *OrderUsecaseImpl) UpdateOrderStatusToShipped(ctx context.Context, orderId int64, pharmacyManagerId int64) error {
-	order, err := u.OrderRepository.GetOrder(ctx, orderId)
-	if err != nil {
-		return err
-	}
-
-	if order.OrderStatus != constants.Processing {
-		return custom_errors.BadRequest(nil, constants.OrderStatusNotProcessingErrMsg)
-	}
-
 	req := entities.UpdateOrderStatus{
 		OrderId:           orderId,
 		OrderStatus:       constants.Shipped,
 		PharmacyManagerId: pharmacyManagerId,
 		UserId:            0,
+		ActorRole:         constants.PharmacyManagerRole,
+		ActorId:           pharmacyManagerId,
 	}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return u.changeOrderStatus(ctx, req, "")
 }
 
-func (u *OrderUsecaseImpl) UpdateOrderStatusToCompleted(ctx context.Context, orderId int64, userId int64) error {
-	order, err := u.OrderRepository.GetOrder(ctx, orderId)
-	if err != nil {
-		return err
+func (u *OrderUsecaseImpl) UpdateOrderStatusToDelivered(ctx context.Context, orderId int64, pharmacyManagerId int64) error {
+	req := entities.UpdateOrderStatus{
+		OrderId:           orderId,
+		OrderStatus:       constants.Delivered,
+		PharmacyManagerId: pharmacyManagerId,
+		UserId:            0,
+		ActorRole:         constants.PharmacyManagerRole,
+		ActorId:           pharmacyManagerId,
 	}
 
-	if order.OrderStatus != constants.Shipped {
-		return custom_errors.BadRequest(nil, constants.OrderStatusNotShippedErrMsg)
-	}
+	return u.changeOrderStatus(ctx, req, "")
+}
 
+func (u *OrderUsecaseImpl) UpdateOrderStatusToCompleted(ctx context.Context, orderId int64, userId int64) error {
 	req := entities.UpdateOrderStatus{
 		OrderId:           orderId,
 		OrderStatus:       constants.Completed,
 		PharmacyManagerId: 0,
 		UserId:            userId,
+		ActorRole:         constants.UserRole,
+		ActorId:           userId,
 	}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return u.changeOrderStatus(ctx, req, "")
 }
 
 func (u *OrderUsecaseImpl) UploadPaymentProof(ctx context.Context, req dtos.UploadPaymentProofResponse, userId int64) error {
@@ -385,7 +635,7 @@ func (u *OrderUsecaseImpl) UploadPaymentProof(ctx context.Context, req dtos.Uplo
 	return nil
 }
 
-func (u *OrderUsecaseImpl) UpdateOrderStatusToCanceled(ctx context.Context, orderId int64, userId int64) error {
+func (u *OrderUsecaseImpl) UpdateOrderStatusToCanceled(ctx context.Context, orderId int64, userId int64, reason string) error {
 	order, err := u.OrderRepository.GetOrder(ctx, orderId)
 	if err != nil {
 		return err
@@ -395,77 +645,217 @@ func (u *OrderUsecaseImpl) UpdateOrderStatusToCanceled(ctx context.Context, orde
 		return custom_errors.BadRequest(nil, constants.CannotCancelOrderErrMsg)
 	}
 
-	orderItems, err := u.OrderRepository.GetOrderItems(ctx, orderId)
-	if err != nil {
-		return err
+	req := entities.UpdateOrderStatus{
+		OrderId:           orderId,
+		OrderStatus:       constants.Canceled,
+		PharmacyManagerId: 0,
+		UserId:            userId,
+		ActorRole:         constants.UserRole,
+		ActorId:           userId,
+		Reason:            reason,
 	}
 
-	for _, item := range orderItems {
-		_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
-			err := u.increaseStock(txCtx, item.PharmacyProductId, item.Quantity, "cancel order")
-			if err != nil {
-				return nil, err
-			}
-			return nil, nil
-		})
+	return u.changeOrderStatus(ctx, req, "cancel order")
+}
 
-		if err != nil {
-			return err
-		}
+func (u *OrderUsecaseImpl) CancelOrderByAdmin(ctx context.Context, orderId int64, adminId int64, reason string) error {
+	req := entities.UpdateOrderStatus{
+		OrderId:           orderId,
+		OrderStatus:       constants.Canceled,
+		PharmacyManagerId: 0,
+		UserId:            0,
+		ActorRole:         constants.AdminRole,
+		ActorId:           adminId,
+		Reason:            reason,
 	}
 
+	return u.changeOrderStatus(ctx, req, "cancel order")
+}
+
+func (u *OrderUsecaseImpl) CancelOrderByPharmacyManager(ctx context.Context, orderId int64, pharmacyManagerId int64, reason string) error {
 	req := entities.UpdateOrderStatus{
 		OrderId:           orderId,
 		OrderStatus:       constants.Canceled,
-		PharmacyManagerId: 0,
-		UserId:            userId,
+		PharmacyManagerId: pharmacyManagerId,
+		UserId:            0,
+		ActorRole:         constants.PharmacyManagerRole,
+		ActorId:           pharmacyManagerId,
+		Reason:            reason,
 	}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
+	return u.changeOrderStatus(ctx, req, "cancel order")
+}
+
+func (u *OrderUsecaseImpl) GetOrderDetail(ctx context.Context, orderId int64) (*dtos.OrderResponse, error) {
+	order, err := u.OrderRepository.FindOrderDetail(ctx, orderId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	result := dtos.ConvertToOrderResponse(*order)
+
+	orderItems, err := u.OrderRepository.GetOrderItems(ctx, result.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	result.OrderItems = append(result.OrderItems, orderItems...)
+
+	return result, nil
 }
 
-func (u *OrderUsecaseImpl) CancelOrderByAdmin(ctx context.Context, orderId int64) error {
+func (u *OrderUsecaseImpl) GetOrderStatusHistories(ctx context.Context, orderId int64, actorId int64, actorRole string) ([]entities.OrderStatusHistory, error) {
 	order, err := u.OrderRepository.GetOrder(ctx, orderId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if order.OrderStatus != constants.Pending {
-		return custom_errors.BadRequest(nil, constants.CannotCancelOrderErrMsg)
+	if actorRole == constants.UserRole && order.UserId != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if actorRole == constants.PharmacyManagerRole && order.PharmacyManagerId != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	histories, err := u.OrderStatusHistoryRepository.FindAllByOrderId(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
+
+// GetOrderInvoice returns the url of the order's invoice PDF. The uploaded file
+// is reused until the order status or payment status changes.
+func (u *OrderUsecaseImpl) GetOrderInvoice(ctx context.Context, orderId int64, actorId int64, actorRole string) (string, error) {
+	order, err := u.OrderRepository.FindOrderInvoice(ctx, orderId)
+	if err != nil {
+		return "", err
+	}
+
+	if actorRole == constants.UserRole && order.UserId != actorId {
+		return "", custom_errors.Forbidden()
+	}
+
+	if actorRole == constants.PharmacyManagerRole && order.PharmacyManagerId != actorId {
+		return "", custom_errors.Forbidden()
+	}
+
+	paymentStatus := getInvoicePaymentStatus(order)
+	cacheKey := fmt.Sprintf("%s|%s", order.OrderStatus, paymentStatus)
+	if order.InvoiceUrl.Valid && order.InvoiceCacheKey.String == cacheKey {
+		return order.InvoiceUrl.String, nil
 	}
 
 	orderItems, err := u.OrderRepository.GetOrderItems(ctx, orderId)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	invoiceData := entities.InvoiceData{
+		OrderId:         order.Id,
+		OrderNumber:     order.OrderNumber,
+		OrderStatus:     order.OrderStatus,
+		PaymentStatus:   paymentStatus,
+		OrderedAt:       order.CreatedAt,
+		UserName:        order.UserName,
+		UserEmail:       order.UserEmail,
+		UserAddress:     order.UserAddress,
+		PharmacyName:    order.PharmacyName,
+		PharmacyAddress: order.PharmacyAddress,
+		ShippingMethod:  order.ShippingMethod,
+		ShippingFee:     order.ShippingFee,
+		Discount:        order.Discount,
+		TotalPrice:      order.TotalPrice,
 	}
 
 	for _, item := range orderItems {
-		_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
-			err := u.increaseStock(txCtx, item.PharmacyProductId, item.Quantity, "cancel order")
+		invoiceData.Items = append(invoiceData.Items, entities.InvoiceItem{
+			Name:        item.Name,
+			SellingUnit: item.SellingUnit,
+			Price:       item.Price,
+			Discount:    item.Discount,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	pdfName, err := utils.GenerateInvoicePdf(invoiceData)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.Remove(pdfName)
+
+	fileUrl, err := u.UploadFile.UploadFile(ctx, pdfName)
+	if err != nil {
+		return "", custom_errors.UploadFile()
+	}
+
+	err = u.OrderRepository.UpdateOrderInvoice(ctx, orderId, fileUrl, cacheKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fileUrl, nil
+}
+
+func getInvoicePaymentStatus(order *entities.Order) string {
+	switch {
+	case order.OrderStatus == constants.Canceled:
+		return constants.InvoicePaymentCanceled
+	case order.OrderStatus != constants.Pending:
+		return constants.InvoicePaymentPaid
+	case order.PaymentProof != nil:
+		return constants.InvoicePaymentWaiting
+	default:
+		return constants.InvoicePaymentUnpaid
+	}
+}
+
+// changeOrderStatus re-reads the order inside a transaction and applies the
+// transition through the state machine. When stockDescription is set the order
+// items are returned to stock in the same transaction. Canceling an order that
+// was already paid for records the refund owed to the user.
+func (u *OrderUsecaseImpl) changeOrderStatus(ctx context.Context, req entities.UpdateOrderStatus, stockDescription string) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		order, err := u.OrderRepository.GetOrder(txCtx, req.OrderId)
+		if err != nil {
+			return nil, err
+		}
+
+		err = u.stateMachine.Validate(order.OrderStatus, req.OrderStatus, req.ActorRole)
+		if err != nil {
+			return nil, err
+		}
+
+		if stockDescription != "" {
+			err = u.restoreOrderStock(txCtx, order.Id, stockDescription)
 			if err != nil {
 				return nil, err
 			}
-			return nil, nil
-		})
+		}
 
-		if err != nil {
-			return err
+		if req.OrderStatus == constants.Canceled {
+			err = u.PrescriptionRepository.RestoreFillCountByOrderId(txCtx, order.Id)
+			if err != nil {
+				return nil, err
+			}
 		}
-	}
 
-	req := entities.UpdateOrderStatus{
-		OrderId:           orderId,
-		OrderStatus:       constants.Canceled,
-		PharmacyManagerId: 0,
-		UserId:            0,
-	}
+		err = u.stateMachine.Transition(txCtx, order.OrderStatus, req)
+		if err != nil {
+			return nil, err
+		}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
+		if req.OrderStatus == constants.Canceled && isOrderPaid(order) {
+			err = u.createCancellationRefund(txCtx, order, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
 	if err != nil {
 		return err
 	}
@@ -473,64 +863,128 @@ func (u *OrderUsecaseImpl) CancelOrderByAdmin(ctx context.Context, orderId int64
 	return nil
 }
 
-func (u *OrderUsecaseImpl) CancelOrderByPharmacyManager(ctx context.Context, orderId int64, pharmacyManagerId int64) error {
-	order, err := u.OrderRepository.GetOrder(ctx, orderId)
+// isOrderPaid reports whether the user has paid, or sent proof of paying, for
+// the order.
+func isOrderPaid(order *entities.Order) bool {
+	return order.OrderStatus != constants.Pending || order.PaymentProof != nil
+}
+
+func (u *OrderUsecaseImpl) createCancellationRefund(ctx context.Context, order *entities.Order, req entities.UpdateOrderStatus) error {
+	refund := entities.Refund{
+		OrderId:     sql.NullInt64{Int64: order.Id, Valid: true},
+		UserId:      order.UserId,
+		Type:        constants.RefundTypeCancellation,
+		Status:      constants.RefundStatusApproved,
+		Amount:      order.TotalPrice.Add(order.ShippingFee).Sub(order.Discount),
+		Reason:      sql.NullString{String: req.Reason, Valid: req.Reason != ""},
+		ProcessedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+
+	if req.ActorRole == constants.AdminRole {
+		refund.AdminId = sql.NullInt64{Int64: req.ActorId, Valid: true}
+	}
+
+	_, err := u.RefundRepository.CreateOne(ctx, refund)
 	if err != nil {
 		return err
 	}
 
-	if order.OrderStatus != constants.Processing {
-		return custom_errors.BadRequest(nil, constants.CannotCancelOrderErrMsg)
-	}
+	return nil
+}
 
+func (u *OrderUsecaseImpl) restoreOrderStock(ctx context.Context, orderId int64, description string) error {
 	orderItems, err := u.OrderRepository.GetOrderItems(ctx, orderId)
 	if err != nil {
 		return err
 	}
 
 	for _, item := range orderItems {
-		_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
-			err := u.increaseStock(txCtx, item.PharmacyProductId, item.Quantity, "cancel order")
-			if err != nil {
-				return nil, err
-			}
-			return nil, nil
-		})
-
+		err := u.increaseStock(ctx, item.PharmacyProductId, item.Quantity, description)
 		if err != nil {
 			return err
 		}
 	}
 
-	req := entities.UpdateOrderStatus{
-		OrderId:           orderId,
-		OrderStatus:       constants.Canceled,
-		PharmacyManagerId: pharmacyManagerId,
-		UserId:            0,
-	}
+	return nil
+}
+
+func (u *OrderUsecaseImpl) CancelExpiredOrders(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		orders, err := u.OrderRepository.LockExpiredOrders(txCtx, constants.OrderExpiryBatchSize)
+		if err != nil {
+			return nil, err
+		}
 
-	err = u.OrderRepository.UpdateOrderStatus(ctx, req)
+		for _, order := range orders {
+			err := u.restoreOrderStock(txCtx, order.Id, "expired order")
+			if err != nil {
+				return nil, err
+			}
+
+			err = u.PrescriptionRepository.RestoreFillCountByOrderId(txCtx, order.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			req := entities.UpdateOrderStatus{
+				OrderId:           order.Id,
+				OrderStatus:       constants.Canceled,
+				PharmacyManagerId: 0,
+				UserId:            0,
+				ActorRole:         constants.SystemRole,
+				Reason:            constants.OrderExpiredReason,
+			}
+
+			err = u.stateMachine.Transition(txCtx, constants.Pending, req)
+			if err != nil {
+				return nil, err
+			}
+
+			// a charge of a lapsed checkout that is still paid afterwards is
+			// refunded once its callback comes in
+			err = u.PaymentRepository.ExpirePendingByCheckoutId(txCtx, order.CheckoutId)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return len(orders), nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return res.(int), nil
 }
 
-func (u *OrderUsecaseImpl) GetOrderDetail(ctx context.Context, orderId int64) (*dtos.OrderResponse, error) {
-	order, err := u.OrderRepository.FindOrderDetail(ctx, orderId)
-	if err != nil {
-		return nil, err
-	}
+func (u *OrderUsecaseImpl) CompleteShippedOrders(ctx context.Context) (int, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		orders, err := u.OrderRepository.LockShippedOrders(txCtx, u.AutoCompleteDays, constants.OrderAutoCompleteBatchSize)
+		if err != nil {
+			return nil, err
+		}
 
-	result := dtos.ConvertToOrderResponse(*order)
+		for _, order := range orders {
+			req := entities.UpdateOrderStatus{
+				OrderId:           order.Id,
+				OrderStatus:       constants.Completed,
+				PharmacyManagerId: 0,
+				UserId:            0,
+				ActorRole:         constants.SystemRole,
+				Reason:            constants.OrderAutoCompleteReason,
+			}
 
-	orderItems, err := u.OrderRepository.GetOrderItems(ctx, result.Id)
+			err = u.stateMachine.Transition(txCtx, order.OrderStatus, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return len(orders), nil
+	})
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	result.OrderItems = append(result.OrderItems, orderItems...)
-
-	return result, nil
+	return res.(int), nil
 }
@@ -0,0 +1,333 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type testTxKey struct{}
+
+// markingTransactor tags the context it hands out, so stubs can tell whether
+// they were called inside the transaction.
+type markingTransactor struct{}
+
+func (markingTransactor) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return tFunc(context.WithValue(ctx, testTxKey{}, true))
+}
+
+func inTestTx(ctx context.Context) bool {
+	return ctx.Value(testTxKey{}) != nil
+}
+
+type stubCancelOrderRepository struct {
+	repositories.OrderRepository
+	orders  map[int64]entities.Order
+	expired []entities.Order
+	items   []dtos.OrderItem
+	updated map[int64]string
+}
+
+func (r *stubCancelOrderRepository) GetOrder(ctx context.Context, orderId int64) (*entities.Order, error) {
+	order := r.orders[orderId]
+	return &order, nil
+}
+
+func (r *stubCancelOrderRepository) GetOrderItems(ctx context.Context, orderId int64) ([]dtos.OrderItem, error) {
+	return r.items, nil
+}
+
+func (r *stubCancelOrderRepository) LockExpiredOrders(ctx context.Context, limit int) ([]entities.Order, error) {
+	return r.expired, nil
+}
+
+func (r *stubCancelOrderRepository) UpdateOrderStatus(ctx context.Context, req entities.UpdateOrderStatus) error {
+	r.updated[req.OrderId] = req.OrderStatus
+	return nil
+}
+
+type stubPharmacyProductRepository struct {
+	repositories.PharmacyProductRepository
+	restocked map[int64]int
+	inTx      bool
+}
+
+func (r *stubPharmacyProductRepository) LockRow(ctx context.Context, pharmacyProductId int64) error {
+	return nil
+}
+
+func (r *stubPharmacyProductRepository) GetOnePharmacyProduct(ctx context.Context, id int64) (*entities.PharmacyProduct, error) {
+	return &entities.PharmacyProduct{Id: id}, nil
+}
+
+func (r *stubPharmacyProductRepository) IncreaseStock(ctx context.Context, quantity int, pharmacyProductId int64) error {
+	r.restocked[pharmacyProductId] += quantity
+	r.inTx = inTestTx(ctx)
+	return nil
+}
+
+type stubStockHistoryRepository struct {
+	repositories.StockHistoryRepository
+}
+
+func (r *stubStockHistoryRepository) CreateOne(ctx context.Context, stockHistory entities.StockHistory) error {
+	return nil
+}
+
+type stubPrescriptionRepository struct {
+	repositories.PrescriptionRepository
+	prescription     *entities.Prescription
+	upserted         []entities.Prescription
+	restoredOrderIds []int64
+	inTx             bool
+	err              error
+}
+
+func (r *stubPrescriptionRepository) RestoreFillCountByOrderId(ctx context.Context, orderId int64) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.restoredOrderIds = append(r.restoredOrderIds, orderId)
+	r.inTx = inTestTx(ctx)
+	return nil
+}
+
+func (r *stubPaymentRepository) ExpirePendingByCheckoutId(ctx context.Context, checkoutId int64) error {
+	r.expiredCheckouts = append(r.expiredCheckouts, checkoutId)
+	return nil
+}
+
+func newCancelTestUsecase(orderRepo *stubCancelOrderRepository, prescriptionRepo *stubPrescriptionRepository, pharmacyProductRepo *stubPharmacyProductRepository, refundRepo *stubRefundRepository) *OrderUsecaseImpl {
+	return NewOrderUsecaseImpl(&OrderUsecaseOpts{
+		OrderRepository:              orderRepo,
+		OrderStatusHistoryRepository: &stubOrderStatusHistoryRepository{},
+		PharmacyProductRepository:    pharmacyProductRepo,
+		StockHistoryRepository:       &stubStockHistoryRepository{},
+		RefundRepository:             refundRepo,
+		PaymentRepository:            &stubPaymentRepository{},
+		PrescriptionRepository:       prescriptionRepo,
+		Transactor:                   markingTransactor{},
+	}).(*OrderUsecaseImpl)
+}
+
+func TestCancelOrderRestoresPrescriptionFills(t *testing.T) {
+	proof := "proof.png"
+	pending := entities.Order{Id: 1, UserId: 7, OrderStatus: constants.Pending, TotalPrice: decimal.NewFromInt(30000)}
+	processing := entities.Order{Id: 2, UserId: 7, OrderStatus: constants.Processing, TotalPrice: decimal.NewFromInt(30000), PaymentProof: &proof}
+	items := []dtos.OrderItem{{PharmacyProductId: 5, Quantity: 2}}
+
+	tests := []struct {
+		name          string
+		cancel        func(u *OrderUsecaseImpl) error
+		restoreErr    error
+		wantErr       error
+		wantRestored  []int64
+		wantRestocked int
+		wantRefunds   int
+	}{
+		{
+			name: "user cancels a pending order",
+			cancel: func(u *OrderUsecaseImpl) error {
+				return u.UpdateOrderStatusToCanceled(context.Background(), 1, 7, "changed my mind")
+			},
+			wantRestored:  []int64{1},
+			wantRestocked: 2,
+		},
+		{
+			name: "admin cancels a pending order",
+			cancel: func(u *OrderUsecaseImpl) error {
+				return u.CancelOrderByAdmin(context.Background(), 1, 99, "duplicate order")
+			},
+			wantRestored:  []int64{1},
+			wantRestocked: 2,
+		},
+		{
+			name: "pharmacy manager cancels a paid order",
+			cancel: func(u *OrderUsecaseImpl) error {
+				return u.CancelOrderByPharmacyManager(context.Background(), 2, 3, "out of stock")
+			},
+			wantRestored:  []int64{2},
+			wantRestocked: 2,
+			wantRefunds:   1,
+		},
+		{
+			name: "shipping an order keeps its fills",
+			cancel: func(u *OrderUsecaseImpl) error {
+				return u.UpdateOrderStatusToShipped(context.Background(), 2, 3)
+			},
+		},
+		{
+			name: "a failed restore fails the cancel",
+			cancel: func(u *OrderUsecaseImpl) error {
+				return u.UpdateOrderStatusToCanceled(context.Background(), 1, 7, "changed my mind")
+			},
+			restoreErr:    errors.New("db down"),
+			wantErr:       errors.New("db down"),
+			wantRestocked: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepo := &stubCancelOrderRepository{
+				orders:  map[int64]entities.Order{1: pending, 2: processing},
+				items:   items,
+				updated: map[int64]string{},
+			}
+			prescriptionRepo := &stubPrescriptionRepository{err: tt.restoreErr}
+			pharmacyProductRepo := &stubPharmacyProductRepository{restocked: map[int64]int{}}
+			refundRepo := &stubRefundRepository{}
+
+			err := tt.cancel(newCancelTestUsecase(orderRepo, prescriptionRepo, pharmacyProductRepo, refundRepo))
+
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if len(prescriptionRepo.restoredOrderIds) != len(tt.wantRestored) {
+				t.Fatalf("restored fills of orders %v, want %v", prescriptionRepo.restoredOrderIds, tt.wantRestored)
+			}
+			for i, orderId := range tt.wantRestored {
+				if prescriptionRepo.restoredOrderIds[i] != orderId {
+					t.Fatalf("restored fills of orders %v, want %v", prescriptionRepo.restoredOrderIds, tt.wantRestored)
+				}
+			}
+			if len(tt.wantRestored) != 0 && (!prescriptionRepo.inTx || !pharmacyProductRepo.inTx) {
+				t.Fatal("fills and stock were not restored inside the transaction")
+			}
+
+			if got := pharmacyProductRepo.restocked[5]; got != tt.wantRestocked {
+				t.Fatalf("restocked %d, want %d", got, tt.wantRestocked)
+			}
+			if len(refundRepo.refunds) != tt.wantRefunds {
+				t.Fatalf("got %d refunds, want %d", len(refundRepo.refunds), tt.wantRefunds)
+			}
+		})
+	}
+}
+
+func TestCancelExpiredOrdersRestoresPrescriptionFills(t *testing.T) {
+	tests := []struct {
+		name         string
+		expired      []entities.Order
+		restoreErr   error
+		wantErr      bool
+		wantCanceled int
+		wantRestored []int64
+	}{
+		{
+			name:         "nothing expired",
+			wantRestored: []int64{},
+		},
+		{
+			name:         "every order of a lapsed checkout",
+			expired:      []entities.Order{{Id: 1, CheckoutId: 10}, {Id: 2, CheckoutId: 10}},
+			wantCanceled: 2,
+			wantRestored: []int64{1, 2},
+		},
+		{
+			name:         "a failed restore cancels nothing",
+			expired:      []entities.Order{{Id: 1, CheckoutId: 10}},
+			restoreErr:   errors.New("db down"),
+			wantErr:      true,
+			wantRestored: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepo := &stubCancelOrderRepository{
+				expired: tt.expired,
+				items:   []dtos.OrderItem{{PharmacyProductId: 5, Quantity: 1}},
+				updated: map[int64]string{},
+			}
+			prescriptionRepo := &stubPrescriptionRepository{err: tt.restoreErr}
+			pharmacyProductRepo := &stubPharmacyProductRepository{restocked: map[int64]int{}}
+
+			canceled, err := newCancelTestUsecase(orderRepo, prescriptionRepo, pharmacyProductRepo, &stubRefundRepository{}).CancelExpiredOrders(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if canceled != tt.wantCanceled {
+				t.Fatalf("canceled %d orders, want %d", canceled, tt.wantCanceled)
+			}
+
+			if len(prescriptionRepo.restoredOrderIds) != len(tt.wantRestored) {
+				t.Fatalf("restored fills of orders %v, want %v", prescriptionRepo.restoredOrderIds, tt.wantRestored)
+			}
+			for i, orderId := range tt.wantRestored {
+				if prescriptionRepo.restoredOrderIds[i] != orderId {
+					t.Fatalf("restored fills of orders %v, want %v", prescriptionRepo.restoredOrderIds, tt.wantRestored)
+				}
+				if orderRepo.updated[orderId] != constants.Canceled {
+					t.Fatalf("order %d is %q, want %q", orderId, orderRepo.updated[orderId], constants.Canceled)
+				}
+			}
+			if len(tt.wantRestored) != 0 && !prescriptionRepo.inTx {
+				t.Fatal("fills were not restored inside the transaction")
+			}
+		})
+	}
+}
+
+func TestCreateNewOrderPrescribedQuantity(t *testing.T) {
+	// checkouts that pass the prescription checks stop at the discount check
+	// right after them, so no other repository is needed
+	passed := constants.OrderDiscountChangedErrMsg
+
+	tests := []struct {
+		name    string
+		lines   []entities.CartItem
+		wantErr string
+	}{
+		{
+			name:    "one line within the prescribed quantity",
+			lines:   []entities.CartItem{testCartLine(1, 1, 5, 9)},
+			wantErr: passed,
+		},
+		{
+			name:    "lines at two pharmacies within the prescribed quantity",
+			lines:   []entities.CartItem{testCartLine(1, 1, 3, 9), testCartLine(2, 2, 2, 9)},
+			wantErr: passed,
+		},
+		{
+			name:    "lines at two pharmacies over the prescribed quantity",
+			lines:   []entities.CartItem{testCartLine(1, 1, 3, 9), testCartLine(2, 2, 3, 9)},
+			wantErr: constants.PrescribedQuantityErrMsg,
+		},
+		{
+			name:    "lines of two prescription items",
+			lines:   []entities.CartItem{testCartLine(1, 1, 5, 8), testCartLine(2, 2, 5, 9)},
+			wantErr: passed,
+		},
+		{
+			name:    "a line without a prescription item",
+			lines:   []entities.CartItem{testCartLine(1, 1, 1, 0)},
+			wantErr: constants.PrescriptionRequiredErrMsg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartIds := []int64{}
+			for _, line := range tt.lines {
+				cartIds = append(cartIds, line.Id)
+			}
+
+			u := NewOrderUsecaseImpl(&OrderUsecaseOpts{CartRepository: &stubCartRepository{lines: tt.lines}})
+
+			_, err := u.CreateNewOrder(context.Background(), dtos.OrderRequest{UserId: 7, CartItemId: cartIds, Discount: decimal.NewFromInt(1)})
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
+)
+
+// applyVoucher locks the voucher, checks that the user may still use it and
+// spreads its discount over the cart items it applies to. It must run inside
+// the checkout transaction so the usage caps hold under concurrent checkouts.
+func (u *OrderUsecaseImpl) applyVoucher(ctx context.Context, code string, userId int64, cartItems []entities.CartItem) (*entities.Voucher, decimal.Decimal, error) {
+	voucher, err := u.VoucherRepository.LockByCode(ctx, code)
+	if err != nil {
+		var appErr *custom_errors.AppError
+		if errors.As(err, &appErr) && appErr.Code == http.StatusNotFound {
+			return nil, decimal.Zero, custom_errors.BadRequest(err, constants.InvalidVoucherErrMsg)
+		}
+		return nil, decimal.Zero, err
+	}
+
+	now := time.Now()
+	if now.Before(voucher.StartsAt) || !now.Before(voucher.EndsAt) {
+		return nil, decimal.Zero, custom_errors.BadRequest(nil, constants.VoucherNotActiveErrMsg)
+	}
+
+	total, byUser, err := u.VoucherRepository.CountUsages(ctx, voucher.Id, userId)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	if voucher.GlobalQuota.Valid && int64(total) >= voucher.GlobalQuota.Int64 {
+		return nil, decimal.Zero, custom_errors.BadRequest(nil, constants.VoucherQuotaExceededErrMsg)
+	}
+
+	if voucher.PerUserQuota.Valid && int64(byUser) >= voucher.PerUserQuota.Int64 {
+		return nil, decimal.Zero, custom_errors.BadRequest(nil, constants.VoucherQuotaExceededErrMsg)
+	}
+
+	eligible, err := u.findVoucherEligibleItems(ctx, voucher, cartItems)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	if len(eligible) == 0 {
+		return nil, decimal.Zero, custom_errors.BadRequest(nil, constants.VoucherNotApplicableErrMsg)
+	}
+
+	subtotal := decimal.Zero
+	for _, i := range eligible {
+		subtotal = subtotal.Add(cartItemTotal(cartItems[i]))
+	}
+
+	if subtotal.LessThan(voucher.MinSpend) {
+		return nil, decimal.Zero, custom_errors.BadRequest(nil, constants.VoucherMinSpendErrMsg)
+	}
+
+	discount := voucher.DiscountValue
+	if voucher.DiscountType == constants.VoucherDiscountPercentage {
+		discount = subtotal.Mul(voucher.DiscountValue).Div(decimal.NewFromInt(100)).Floor()
+		if voucher.MaxDiscount.Valid && discount.GreaterThan(voucher.MaxDiscount.Decimal) {
+			discount = voucher.MaxDiscount.Decimal
+		}
+	}
+	if discount.GreaterThan(subtotal) {
+		discount = subtotal
+	}
+
+	allocateDiscount(cartItems, eligible, subtotal, discount)
+
+	return voucher, discount, nil
+}
+
+// findVoucherEligibleItems returns the indexes of the cart items that match
+// every scope set on the voucher. A voucher without scopes applies to all items.
+func (u *OrderUsecaseImpl) findVoucherEligibleItems(ctx context.Context, voucher *entities.Voucher, cartItems []entities.CartItem) ([]int, error) {
+	inCategory := map[int64]bool{}
+	if voucher.CategoryId.Valid {
+		productIds := []int64{}
+		for _, item := range cartItems {
+			productIds = append(productIds, item.ProductId)
+		}
+
+		ids, err := u.VoucherRepository.FindProductIdsInCategory(ctx, voucher.CategoryId.Int64, productIds)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			inCategory[id] = true
+		}
+	}
+
+	eligible := []int{}
+	for i, item := range cartItems {
+		if voucher.ProductId.Valid && item.ProductId != voucher.ProductId.Int64 {
+			continue
+		}
+		if voucher.PharmacyId.Valid && item.PharmacyId != voucher.PharmacyId.Int64 {
+			continue
+		}
+		if voucher.CategoryId.Valid && !inCategory[item.ProductId] {
+			continue
+		}
+
+		eligible = append(eligible, i)
+	}
+
+	return eligible, nil
+}
+
+// allocateDiscount splits the discount over the eligible items in proportion
+// to their totals. Rounding leftovers go to the largest item so no item is
+// discounted below zero.
+func allocateDiscount(cartItems []entities.CartItem, eligible []int, subtotal decimal.Decimal, discount decimal.Decimal) {
+	largest := eligible[0]
+	allocated := decimal.Zero
+
+	for _, i := range eligible {
+		itemTotal := cartItemTotal(cartItems[i])
+		if itemTotal.GreaterThan(cartItemTotal(cartItems[largest])) {
+			largest = i
+		}
+
+		cartItems[i].Discount = discount.Mul(itemTotal).Div(subtotal).Floor()
+		allocated = allocated.Add(cartItems[i].Discount)
+	}
+
+	cartItems[largest].Discount = cartItems[largest].Discount.Add(discount.Sub(allocated))
+}
+
+func cartItemTotal(item entities.CartItem) decimal.Decimal {
+	return item.Price.Mul(decimal.NewFromInt(int64(item.Quantity)))
+}
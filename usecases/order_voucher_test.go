@@ -0,0 +1,258 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type stubVoucherRepository struct {
+	repositories.VoucherRepository
+	voucher           *entities.Voucher
+	totalUsages       int
+	userUsages        int
+	productIdsInScope []int64
+}
+
+func (r *stubVoucherRepository) LockByCode(ctx context.Context, code string) (*entities.Voucher, error) {
+	if r.voucher == nil || r.voucher.Code != code {
+		return nil, custom_errors.NotFound(sql.ErrNoRows)
+	}
+	return r.voucher, nil
+}
+
+func (r *stubVoucherRepository) CountUsages(ctx context.Context, voucherId int64, userId int64) (int, int, error) {
+	return r.totalUsages, r.userUsages, nil
+}
+
+func (r *stubVoucherRepository) FindProductIdsInCategory(ctx context.Context, categoryId int64, productIds []int64) ([]int64, error) {
+	return r.productIdsInScope, nil
+}
+
+func testCartItem(productId int64, pharmacyId int64, price int64, quantity int) entities.CartItem {
+	return entities.CartItem{ProductId: productId, PharmacyId: pharmacyId, Price: decimal.NewFromInt(price), Quantity: quantity}
+}
+
+func TestApplyVoucher(t *testing.T) {
+	now := time.Now()
+	active := func(v entities.Voucher) *entities.Voucher {
+		v.Id = 1
+		v.Code = "SEHAT"
+		v.StartsAt = now.Add(-time.Hour)
+		v.EndsAt = now.Add(time.Hour)
+		return &v
+	}
+
+	// a 33333 subtotal across two pharmacies, so percentages do not divide evenly
+	cart := func() []entities.CartItem {
+		return []entities.CartItem{
+			testCartItem(1, 10, 10000, 2),
+			testCartItem(2, 10, 3333, 1),
+			testCartItem(3, 20, 10000, 1),
+		}
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		repo         *stubVoucherRepository
+		wantErr      string
+		wantDiscount int64
+		wantItems    []int64
+	}{
+		{
+			name:    "unknown code",
+			code:    "NOPE",
+			repo:    &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000)})},
+			wantErr: constants.InvalidVoucherErrMsg,
+		},
+		{
+			name: "not started yet",
+			code: "SEHAT",
+			repo: &stubVoucherRepository{voucher: &entities.Voucher{
+				Id: 1, Code: "SEHAT", DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000),
+				StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour),
+			}},
+			wantErr: constants.VoucherNotActiveErrMsg,
+		},
+		{
+			name: "already ended",
+			code: "SEHAT",
+			repo: &stubVoucherRepository{voucher: &entities.Voucher{
+				Id: 1, Code: "SEHAT", DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000),
+				StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour),
+			}},
+			wantErr: constants.VoucherNotActiveErrMsg,
+		},
+		{
+			name: "global quota used up",
+			code: "SEHAT",
+			repo: &stubVoucherRepository{
+				voucher:     active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000), GlobalQuota: sql.NullInt64{Int64: 5, Valid: true}}),
+				totalUsages: 5,
+			},
+			wantErr: constants.VoucherQuotaExceededErrMsg,
+		},
+		{
+			name: "per user quota used up",
+			code: "SEHAT",
+			repo: &stubVoucherRepository{
+				voucher:     active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000), PerUserQuota: sql.NullInt64{Int64: 1, Valid: true}}),
+				totalUsages: 1,
+				userUsages:  1,
+			},
+			wantErr: constants.VoucherQuotaExceededErrMsg,
+		},
+		{
+			name:    "no item in scope",
+			code:    "SEHAT",
+			repo:    &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000), ProductId: sql.NullInt64{Int64: 99, Valid: true}})},
+			wantErr: constants.VoucherNotApplicableErrMsg,
+		},
+		{
+			name:    "minimum spend counts eligible items only",
+			code:    "SEHAT",
+			repo:    &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000), MinSpend: decimal.NewFromInt(20000), PharmacyId: sql.NullInt64{Int64: 20, Valid: true}})},
+			wantErr: constants.VoucherMinSpendErrMsg,
+		},
+		{
+			name:         "percentage is floored",
+			code:         "SEHAT",
+			repo:         &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountPercentage, DiscountValue: decimal.NewFromInt(10)})},
+			wantDiscount: 3333,
+			wantItems:    []int64{2001, 333, 999},
+		},
+		{
+			name:         "percentage is capped",
+			code:         "SEHAT",
+			repo:         &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountPercentage, DiscountValue: decimal.NewFromInt(50), MaxDiscount: decimal.NullDecimal{Decimal: decimal.NewFromInt(5000), Valid: true}})},
+			wantDiscount: 5000,
+			wantItems:    []int64{3001, 499, 1500},
+		},
+		{
+			name:         "fixed amount never exceeds the eligible subtotal",
+			code:         "SEHAT",
+			repo:         &stubVoucherRepository{voucher: active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(50000), PharmacyId: sql.NullInt64{Int64: 20, Valid: true}})},
+			wantDiscount: 10000,
+			wantItems:    []int64{0, 0, 10000},
+		},
+		{
+			name: "category scope",
+			code: "SEHAT",
+			repo: &stubVoucherRepository{
+				voucher:           active(entities.Voucher{DiscountType: constants.VoucherDiscountFixed, DiscountValue: decimal.NewFromInt(1000), CategoryId: sql.NullInt64{Int64: 7, Valid: true}}),
+				productIdsInScope: []int64{2, 3},
+			},
+			wantDiscount: 1000,
+			wantItems:    []int64{0, 249, 751},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &OrderUsecaseImpl{VoucherRepository: tt.repo}
+			items := cart()
+
+			_, discount, err := u.applyVoucher(context.Background(), tt.code, 1, items)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !discount.Equal(decimal.NewFromInt(tt.wantDiscount)) {
+				t.Fatalf("got discount %s, want %d", discount, tt.wantDiscount)
+			}
+
+			for i, want := range tt.wantItems {
+				if !items[i].Discount.Equal(decimal.NewFromInt(want)) {
+					t.Fatalf("item %d got discount %s, want %d", i, items[i].Discount, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateDiscount(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []entities.CartItem
+		eligible  []int
+		discount  int64
+		wantItems []int64
+	}{
+		{
+			name:      "splits evenly",
+			items:     []entities.CartItem{testCartItem(1, 1, 5000, 1), testCartItem(2, 1, 5000, 1)},
+			eligible:  []int{0, 1},
+			discount:  1000,
+			wantItems: []int64{500, 500},
+		},
+		{
+			name:      "gives the rounding leftover to the largest item",
+			items:     []entities.CartItem{testCartItem(1, 1, 1000, 1), testCartItem(2, 1, 1000, 1), testCartItem(3, 1, 2000, 1)},
+			eligible:  []int{0, 1, 2},
+			discount:  999,
+			wantItems: []int64{249, 249, 501},
+		},
+		{
+			name:      "weighs items by quantity",
+			items:     []entities.CartItem{testCartItem(1, 1, 1000, 3), testCartItem(2, 1, 1000, 1)},
+			eligible:  []int{0, 1},
+			discount:  1000,
+			wantItems: []int64{750, 250},
+		},
+		{
+			name:      "leaves ineligible items alone",
+			items:     []entities.CartItem{testCartItem(1, 1, 7000, 1), testCartItem(2, 1, 3000, 1), testCartItem(3, 1, 9000, 1)},
+			eligible:  []int{0, 1},
+			discount:  1001,
+			wantItems: []int64{701, 300, 0},
+		},
+		{
+			name:      "a discount equal to the subtotal zeroes every item",
+			items:     []entities.CartItem{testCartItem(1, 1, 333, 1), testCartItem(2, 1, 667, 1)},
+			eligible:  []int{0, 1},
+			discount:  1000,
+			wantItems: []int64{333, 667},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtotal := decimal.Zero
+			for _, i := range tt.eligible {
+				subtotal = subtotal.Add(cartItemTotal(tt.items[i]))
+			}
+
+			allocateDiscount(tt.items, tt.eligible, subtotal, decimal.NewFromInt(tt.discount))
+
+			sum := decimal.Zero
+			for i, want := range tt.wantItems {
+				if !tt.items[i].Discount.Equal(decimal.NewFromInt(want)) {
+					t.Fatalf("item %d got discount %s, want %d", i, tt.items[i].Discount, want)
+				}
+				if tt.items[i].Discount.GreaterThan(cartItemTotal(tt.items[i])) {
+					t.Fatalf("item %d is discounted below zero", i)
+				}
+				sum = sum.Add(tt.items[i].Discount)
+			}
+
+			if !sum.Equal(decimal.NewFromInt(tt.discount)) {
+				t.Fatalf("allocated %s, want %d", sum, tt.discount)
+			}
+		})
+	}
+}
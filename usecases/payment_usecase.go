@@ -0,0 +1,575 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/shopspring/decimal"
+)
+
+type PaymentUsecaseOpts struct {
+	PaymentRepository            repositories.PaymentRepository
+	OrderRepository              repositories.OrderRepository
+	CheckoutRepository           repositories.CheckoutRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	ConsultationRepository       repositories.ConsultationRepository
+	RefundRepository             repositories.RefundRepository
+	Transactor                   repositories.Transactor
+	PaymentProviders             map[string]utils.PaymentProvider
+	PaymentGateway               string
+	EventPublisher               ConsultationEventPublisher
+}
+
+type PaymentUsecase interface {
+	CreatePayment(ctx context.Context, checkoutId int64, userId int64, req dtos.PaymentRequest) (*entities.Payment, error)
+	GetPayment(ctx context.Context, checkoutId int64, userId int64) (*entities.Payment, error)
+	CreateConsultationPayment(ctx context.Context, consultationId int64, userId int64, req dtos.PaymentRequest) (*entities.Payment, error)
+	GetConsultationPayment(ctx context.Context, consultationId int64, userId int64) (*entities.Payment, error)
+	HandleCallback(ctx context.Context, provider string, body []byte, signature string) error
+}
+
+type PaymentUsecaseImpl struct {
+	PaymentRepository            repositories.PaymentRepository
+	OrderRepository              repositories.OrderRepository
+	CheckoutRepository           repositories.CheckoutRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	ConsultationRepository       repositories.ConsultationRepository
+	RefundRepository             repositories.RefundRepository
+	Transactor                   repositories.Transactor
+	PaymentProviders             map[string]utils.PaymentProvider
+	PaymentGateway               string
+	EventPublisher               ConsultationEventPublisher
+	stateMachine                 *orderStateMachine
+}
+
+func NewPaymentUsecaseImpl(pOpts *PaymentUsecaseOpts) PaymentUsecase {
+	return &PaymentUsecaseImpl{
+		PaymentRepository:            pOpts.PaymentRepository,
+		OrderRepository:              pOpts.OrderRepository,
+		CheckoutRepository:           pOpts.CheckoutRepository,
+		OrderStatusHistoryRepository: pOpts.OrderStatusHistoryRepository,
+		ConsultationRepository:       pOpts.ConsultationRepository,
+		RefundRepository:             pOpts.RefundRepository,
+		Transactor:                   pOpts.Transactor,
+		PaymentProviders:             pOpts.PaymentProviders,
+		PaymentGateway:               pOpts.PaymentGateway,
+		EventPublisher:               pOpts.EventPublisher,
+		stateMachine:                 newOrderStateMachine(pOpts.OrderRepository, pOpts.OrderStatusHistoryRepository),
+	}
+}
+
+// CreatePayment opens a charge for a checkout. The charge is opened at the
+// provider between two transactions, since a retried transaction would open
+// it again, and is only stored as payable if the checkout did not change in
+// the meantime. It is dated from when its amount was summed, so orders
+// canceled after that are refunded if the charge is still paid.
+func (u *PaymentUsecaseImpl) CreatePayment(ctx context.Context, checkoutId int64, userId int64, req dtos.PaymentRequest) (*entities.Payment, error) {
+	var referenceId string
+	createdAt := time.Now()
+
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		checkout, err := u.CheckoutRepository.LockById(txCtx, checkoutId)
+		if err != nil {
+			return nil, err
+		}
+
+		if checkout.UserId != userId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		amount, payable, err := u.checkoutAmount(txCtx, checkoutId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !payable {
+			return nil, custom_errors.BadRequest(nil, constants.OrderNotPayableErrMsg)
+		}
+
+		existing, err := u.PaymentRepository.FindActiveByCheckoutId(txCtx, checkoutId)
+		if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+			return nil, err
+		}
+
+		if existing != nil {
+			if existing.Status == constants.PaymentStatusPaid {
+				return nil, custom_errors.BadRequest(nil, constants.OrderAlreadyPaidErrMsg)
+			}
+			if existing.Method == req.Method && existing.Channel == req.Channel && existing.Amount.Equal(amount) {
+				return existing, nil
+			}
+		}
+
+		referenceId = checkout.CheckoutNumber
+
+		return &entities.Payment{
+			CheckoutId: sql.NullInt64{Int64: checkoutId, Valid: true},
+			Method:     req.Method,
+			Channel:    req.Channel,
+			Amount:     amount,
+			ExpiredAt:  checkout.PaymentDeadline,
+			CreatedAt:  createdAt,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment := res.(*entities.Payment)
+	if payment.Id != 0 {
+		return payment, nil
+	}
+
+	requested := payment.Amount
+	err = u.openCharge(ctx, payment, referenceId)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := false
+	res, err = u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		_, err := u.CheckoutRepository.LockById(txCtx, checkoutId)
+		if err != nil {
+			return nil, err
+		}
+
+		amount, payable, err := u.checkoutAmount(txCtx, checkoutId)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := u.PaymentRepository.FindActiveByCheckoutId(txCtx, checkoutId)
+		if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+			return nil, err
+		}
+
+		// the charge is kept as expired when the checkout changed, so a late
+		// payment of it is still refunded
+		stale = !payable || !amount.Equal(requested) || (existing != nil && existing.Status == constants.PaymentStatusPaid)
+		if stale {
+			return u.storeCharge(txCtx, *payment, constants.PaymentStatusExpired)
+		}
+
+		// only the latest charge of a checkout stays payable
+		if existing != nil {
+			err = u.PaymentRepository.UpdateStatus(txCtx, existing.Id, constants.PaymentStatusExpired, sql.NullTime{})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return u.storeCharge(txCtx, *payment, constants.PaymentStatusPending)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		return nil, custom_errors.BadRequest(nil, constants.PaymentChargeStaleErrMsg)
+	}
+
+	return res.(*entities.Payment), nil
+}
+
+// checkoutAmount sums what is left to pay on a checkout. Orders the user
+// canceled before paying drop out of the amount, and the checkout is no longer
+// payable once any order moved past pending or every order was canceled.
+func (u *PaymentUsecaseImpl) checkoutAmount(ctx context.Context, checkoutId int64) (decimal.Decimal, bool, error) {
+	orders, err := u.OrderRepository.FindOrdersByCheckoutId(ctx, checkoutId)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+
+	amount := decimal.Zero
+	payableOrders := 0
+	for _, order := range orders {
+		if order.OrderStatus == constants.Canceled {
+			continue
+		}
+		if order.OrderStatus != constants.Pending {
+			return decimal.Zero, false, nil
+		}
+		amount = amount.Add(order.TotalPrice).Add(order.ShippingFee).Sub(order.Discount)
+		payableOrders++
+	}
+
+	return amount, payableOrders != 0, nil
+}
+
+// openCharge opens a charge for the payment at its provider. It must not run
+// inside a transaction, every call opens a new charge.
+func (u *PaymentUsecaseImpl) openCharge(ctx context.Context, payment *entities.Payment, referenceId string) error {
+	providerName := u.PaymentGateway
+	if payment.Method == constants.PaymentMethodManualTransfer {
+		providerName = constants.ManualPaymentProvider
+	}
+
+	provider, ok := u.PaymentProviders[providerName]
+	if !ok {
+		return custom_errors.PaymentMethodNotSupported()
+	}
+
+	chargeReq := utils.PaymentChargeRequest{
+		ReferenceId: referenceId,
+		Channel:     payment.Channel,
+		Amount:      payment.Amount,
+		ExpiredAt:   payment.ExpiredAt,
+	}
+
+	var charge *utils.PaymentCharge
+	var err error
+	switch payment.Method {
+	case constants.PaymentMethodEWallet:
+		charge, err = provider.CreateEWalletCharge(ctx, chargeReq)
+	default:
+		charge, err = provider.CreateVirtualAccount(ctx, chargeReq)
+	}
+	if err != nil {
+		return err
+	}
+
+	payment.Provider = provider.Name()
+	payment.ExternalId = charge.ExternalId
+	payment.AccountNumber = sql.NullString{String: charge.AccountNumber, Valid: charge.AccountNumber != ""}
+	payment.CheckoutUrl = sql.NullString{String: charge.CheckoutUrl, Valid: charge.CheckoutUrl != ""}
+	payment.Amount = charge.Amount
+	payment.ExpiredAt = charge.ExpiredAt
+
+	return nil
+}
+
+// storeCharge stores an opened charge, as pending until the provider calls
+// back, or as expired when it is no longer payable.
+func (u *PaymentUsecaseImpl) storeCharge(ctx context.Context, payment entities.Payment, status string) (*entities.Payment, error) {
+	payment.Status = status
+
+	newPayment, err := u.PaymentRepository.CreateOne(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPayment, nil
+}
+
+func (u *PaymentUsecaseImpl) GetPayment(ctx context.Context, checkoutId int64, userId int64) (*entities.Payment, error) {
+	checkout, err := u.CheckoutRepository.FindById(ctx, checkoutId)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkout.UserId != userId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	payment, err := u.PaymentRepository.FindActiveByCheckoutId(ctx, checkoutId)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CreateConsultationPayment charges the doctor's fee for a consultation
+// request. Manual transfers are left out because they wait on an admin, and
+// the request would time out long before that. Like CreatePayment, the charge
+// is opened at the provider outside of any transaction.
+func (u *PaymentUsecaseImpl) CreateConsultationPayment(ctx context.Context, consultationId int64, userId int64, req dtos.PaymentRequest) (*entities.Payment, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.User.Id != userId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if consultation.Status != constants.ConsultationStatusUnpaid || !consultation.PaymentDeadline.Valid {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationNotPayableErrMsg)
+	}
+
+	if req.Method == constants.PaymentMethodManualTransfer {
+		return nil, custom_errors.PaymentMethodNotSupported()
+	}
+
+	existing, err := u.PaymentRepository.FindActiveByConsultationId(ctx, consultationId)
+	if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+		return nil, err
+	}
+
+	if existing != nil && existing.Method == req.Method && existing.Channel == req.Channel {
+		return existing, nil
+	}
+
+	payment := &entities.Payment{
+		ConsultationId: sql.NullInt64{Int64: consultationId, Valid: true},
+		Method:         req.Method,
+		Channel:        req.Channel,
+		Amount:         consultation.Fee,
+		ExpiredAt:      consultation.PaymentDeadline.Time,
+		CreatedAt:      time.Now(),
+	}
+
+	err = u.openCharge(ctx, payment, fmt.Sprintf("%s%d", constants.ConsultationPaymentRefPrefix, consultationId))
+	if err != nil {
+		return nil, err
+	}
+
+	stale := false
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		locked, err := u.ConsultationRepository.LockRequestById(txCtx, consultationId)
+		if err != nil {
+			return nil, err
+		}
+
+		stale = locked.Status != constants.ConsultationStatusUnpaid
+		if stale {
+			return u.storeCharge(txCtx, *payment, constants.PaymentStatusExpired)
+		}
+
+		existing, err := u.PaymentRepository.FindActiveByConsultationId(txCtx, consultationId)
+		if err != nil && err.Error() != constants.ResponseMsgErrorNotFound {
+			return nil, err
+		}
+
+		if existing != nil {
+			err = u.PaymentRepository.UpdateStatus(txCtx, existing.Id, constants.PaymentStatusExpired, sql.NullTime{})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return u.storeCharge(txCtx, *payment, constants.PaymentStatusPending)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		return nil, custom_errors.BadRequest(nil, constants.ConsultationNotPayableErrMsg)
+	}
+
+	return res.(*entities.Payment), nil
+}
+
+func (u *PaymentUsecaseImpl) GetConsultationPayment(ctx context.Context, consultationId int64, userId int64) (*entities.Payment, error) {
+	consultation, err := u.ConsultationRepository.FindById(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.User.Id != userId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	payment, err := u.PaymentRepository.FindActiveByConsultationId(ctx, consultationId)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (u *PaymentUsecaseImpl) HandleCallback(ctx context.Context, providerName string, body []byte, signature string) error {
+	provider, ok := u.PaymentProviders[providerName]
+	if !ok {
+		return custom_errors.NotFound(nil)
+	}
+
+	callback, err := provider.VerifyCallback(body, signature)
+	if err != nil {
+		return err
+	}
+
+	switch callback.Status {
+	case constants.PaymentStatusPending, constants.PaymentStatusPaid, constants.PaymentStatusFailed, constants.PaymentStatusExpired:
+	default:
+		return custom_errors.BadRequest(nil, constants.InvalidPaymentStatusErrMsg)
+	}
+
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		payment, err := u.PaymentRepository.LockByExternalId(txCtx, providerName, callback.ExternalId)
+		if err != nil {
+			return nil, err
+		}
+
+		isNew, err := u.PaymentRepository.CreateCallback(txCtx, entities.PaymentCallback{
+			Provider:   providerName,
+			CallbackId: callback.CallbackId,
+			PaymentId:  payment.Id,
+			Payload:    string(body),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !isNew || payment.Status == constants.PaymentStatusPaid {
+			return []entities.ConsultationEvent{}, nil
+		}
+
+		if callback.Status != constants.PaymentStatusPaid {
+			if payment.Status != constants.PaymentStatusPending {
+				return []entities.ConsultationEvent{}, nil
+			}
+			return []entities.ConsultationEvent{}, u.PaymentRepository.UpdateStatus(txCtx, payment.Id, callback.Status, sql.NullTime{})
+		}
+
+		// a charge expired here can still be paid at the gateway, so the money
+		// settles whatever is still payable and the rest is refunded below
+
+		if !callback.Amount.Equal(payment.Amount) {
+			return nil, custom_errors.BadRequest(nil, constants.PaymentAmountNotMatchErrMsg)
+		}
+
+		paidAt := callback.PaidAt
+		if paidAt.IsZero() {
+			paidAt = time.Now()
+		}
+
+		err = u.PaymentRepository.UpdateStatus(txCtx, payment.Id, constants.PaymentStatusPaid, sql.NullTime{Time: paidAt, Valid: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if payment.ConsultationId.Valid {
+			return u.settleConsultationPayment(txCtx, payment, paidAt)
+		}
+
+		orders, err := u.OrderRepository.FindOrdersByCheckoutId(txCtx, payment.CheckoutId.Int64)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, order := range orders {
+			// orders canceled before the charge was opened were never part of
+			// its amount
+			if order.OrderStatus == constants.Canceled && !(order.CanceledAt.Valid && order.CanceledAt.Time.After(payment.CreatedAt)) {
+				continue
+			}
+
+			// an order canceled while the charge was open, or already paid by
+			// another charge, was still part of the amount, so its share goes
+			// back to the user
+			if order.OrderStatus != constants.Pending {
+				err = u.refundLatePaidOrder(txCtx, order)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			req := entities.UpdateOrderStatus{
+				OrderId:           order.Id,
+				OrderStatus:       constants.Processing,
+				PharmacyManagerId: 0,
+				UserId:            0,
+				ActorRole:         constants.SystemRole,
+				Reason:            "paid via " + provider.Name(),
+			}
+
+			err = u.stateMachine.Transition(txCtx, order.OrderStatus, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// the checkout is settled, so no other charge of it should be paid
+		err = u.PaymentRepository.ExpirePendingByCheckoutId(txCtx, payment.CheckoutId.Int64)
+		if err != nil {
+			return nil, err
+		}
+
+		return []entities.ConsultationEvent{}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	publishConsultationEvents(ctx, u.EventPublisher, res.([]entities.ConsultationEvent))
+
+	return nil
+}
+
+// refundLatePaidOrder refunds an order's share of a late settling charge. A
+// canceled order gets its cancellation refund, unless that was already made
+// for another charge, and an order paid twice is refunded as an overpayment.
+func (u *PaymentUsecaseImpl) refundLatePaidOrder(ctx context.Context, order entities.Order) error {
+	refundType := constants.RefundTypeOverpayment
+	reason := constants.OrderPaidTwiceReason
+
+	if order.OrderStatus == constants.Canceled {
+		refunds, err := u.RefundRepository.FindAllByOrderId(ctx, order.Id)
+		if err != nil {
+			return err
+		}
+
+		refundType = constants.RefundTypeCancellation
+		reason = constants.OrderLatePaidReason
+		for _, refund := range refunds {
+			if refund.Type == constants.RefundTypeCancellation {
+				refundType = constants.RefundTypeOverpayment
+				reason = constants.OrderPaidTwiceReason
+			}
+		}
+	}
+
+	_, err := u.RefundRepository.CreateOne(ctx, entities.Refund{
+		OrderId:     sql.NullInt64{Int64: order.Id, Valid: true},
+		UserId:      order.UserId,
+		Type:        refundType,
+		Status:      constants.RefundStatusApproved,
+		Amount:      order.TotalPrice.Add(order.ShippingFee).Sub(order.Discount),
+		Reason:      sql.NullString{String: reason, Valid: true},
+		ProcessedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+
+	return err
+}
+
+// settleConsultationPayment puts a paid request in front of its doctor, or
+// confirms a booked appointment. A payment that lands after the request
+// expired, or on top of an earlier one, is refunded instead.
+func (u *PaymentUsecaseImpl) settleConsultationPayment(ctx context.Context, payment *entities.Payment, paidAt time.Time) ([]entities.ConsultationEvent, error) {
+	consultation, err := u.ConsultationRepository.LockRequestById(ctx, payment.ConsultationId.Int64)
+	if err != nil {
+		return nil, err
+	}
+
+	if consultation.Status != constants.ConsultationStatusUnpaid {
+		err = createConsultationRefund(ctx, u.RefundRepository, *consultation, payment.Amount, constants.ConsultationLatePaidReason)
+		if err != nil {
+			return nil, err
+		}
+
+		return []entities.ConsultationEvent{}, nil
+	}
+
+	// a booked appointment waits for its slot instead of joining the queue
+	if consultation.ScheduledAt.Valid {
+		err = u.ConsultationRepository.UpdatePaid(ctx, consultation.Id, constants.ConsultationStatusScheduled, paidAt)
+		if err != nil {
+			return nil, err
+		}
+
+		return []entities.ConsultationEvent{}, nil
+	}
+
+	err = u.ConsultationRepository.UpdatePaid(ctx, consultation.Id, constants.ConsultationStatusWaiting, paidAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return []entities.ConsultationEvent{{
+		Type:           constants.ConsultationEventRequested,
+		ConsultationId: consultation.Id,
+		UserId:         consultation.User.Id,
+		DoctorId:       consultation.Doctor.Id,
+	}}, nil
+}
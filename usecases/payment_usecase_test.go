@@ -0,0 +1,473 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+	"github.com/shopspring/decimal"
+)
+
+type stubTransactor struct{}
+
+func (stubTransactor) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return tFunc(ctx)
+}
+
+type stubPaymentRepository struct {
+	repositories.PaymentRepository
+	payment          *entities.Payment
+	callbacks        map[string]bool
+	statuses         []string
+	created          []entities.Payment
+	expiredCheckouts []int64
+}
+
+func (r *stubPaymentRepository) FindActiveByCheckoutId(ctx context.Context, checkoutId int64) (*entities.Payment, error) {
+	if r.payment == nil || (r.payment.Status != constants.PaymentStatusPending && r.payment.Status != constants.PaymentStatusPaid) {
+		return nil, custom_errors.NotFound(sql.ErrNoRows)
+	}
+	p := *r.payment
+	return &p, nil
+}
+
+func (r *stubPaymentRepository) CreateOne(ctx context.Context, payment entities.Payment) (*entities.Payment, error) {
+	r.created = append(r.created, payment)
+	payment.Id = int64(len(r.created)) + 100
+	return &payment, nil
+}
+
+func (r *stubPaymentRepository) LockByExternalId(ctx context.Context, provider string, externalId string) (*entities.Payment, error) {
+	if r.payment == nil || r.payment.ExternalId != externalId {
+		return nil, custom_errors.NotFound(sql.ErrNoRows)
+	}
+	p := *r.payment
+	return &p, nil
+}
+
+func (r *stubPaymentRepository) CreateCallback(ctx context.Context, callback entities.PaymentCallback) (bool, error) {
+	if r.callbacks[callback.CallbackId] {
+		return false, nil
+	}
+	r.callbacks[callback.CallbackId] = true
+	return true, nil
+}
+
+func (r *stubPaymentRepository) UpdateStatus(ctx context.Context, paymentId int64, status string, paidAt sql.NullTime) error {
+	r.statuses = append(r.statuses, status)
+	r.payment.Status = status
+	return nil
+}
+
+type stubOrderRepository struct {
+	repositories.OrderRepository
+	orders  []entities.Order
+	updated map[int64]string
+}
+
+func (r *stubOrderRepository) FindOrdersByCheckoutId(ctx context.Context, checkoutId int64) ([]entities.Order, error) {
+	return r.orders, nil
+}
+
+func (r *stubOrderRepository) UpdateOrderStatus(ctx context.Context, req entities.UpdateOrderStatus) error {
+	r.updated[req.OrderId] = req.OrderStatus
+	return nil
+}
+
+type stubOrderStatusHistoryRepository struct {
+	repositories.OrderStatusHistoryRepository
+}
+
+func (r *stubOrderStatusHistoryRepository) CreateOne(ctx context.Context, history entities.OrderStatusHistory) error {
+	return nil
+}
+
+type stubRefundRepository struct {
+	repositories.RefundRepository
+	refunds []entities.Refund
+}
+
+func (r *stubRefundRepository) CreateOne(ctx context.Context, refund entities.Refund) (*entities.Refund, error) {
+	r.refunds = append(r.refunds, refund)
+	return &refund, nil
+}
+
+func (r *stubRefundRepository) FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.Refund, error) {
+	refunds := []entities.Refund{}
+	for _, refund := range r.refunds {
+		if refund.OrderId.Int64 == orderId {
+			refunds = append(refunds, refund)
+		}
+	}
+	return refunds, nil
+}
+
+func TestHandleCallback(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	fake := utils.NewFakePaymentProvider(utils.Config{PaymentCallbackKey: "secret"})
+
+	callbackBody := func(callbackId string, status string, amount int64) []byte {
+		body, _ := json.Marshal(utils.PaymentCallback{
+			CallbackId: callbackId,
+			ExternalId: "ext-1",
+			Status:     status,
+			Amount:     decimal.NewFromInt(amount),
+			PaidAt:     createdAt.Add(time.Hour),
+		})
+		return body
+	}
+
+	pendingOrder := entities.Order{Id: 1, UserId: 7, OrderStatus: constants.Pending, TotalPrice: decimal.NewFromInt(90000), ShippingFee: decimal.NewFromInt(10000)}
+	canceledLate := entities.Order{Id: 2, UserId: 7, OrderStatus: constants.Canceled, TotalPrice: decimal.NewFromInt(45000), ShippingFee: decimal.NewFromInt(5000), CanceledAt: sql.NullTime{Time: createdAt.Add(time.Minute), Valid: true}}
+	canceledEarly := entities.Order{Id: 3, UserId: 7, OrderStatus: constants.Canceled, TotalPrice: decimal.NewFromInt(20000), CanceledAt: sql.NullTime{Time: createdAt.Add(-time.Minute), Valid: true}}
+	paidOrder := entities.Order{Id: 1, UserId: 7, OrderStatus: constants.Processing, TotalPrice: decimal.NewFromInt(90000), ShippingFee: decimal.NewFromInt(10000)}
+
+	tests := []struct {
+		name          string
+		status        string
+		bodies        [][]byte
+		signature     string
+		orders        []entities.Order
+		refunds       []entities.Refund
+		wantErr       string
+		wantStatuses  []string
+		wantProcessed []int64
+		wantRefunds   map[int64]entities.Refund
+		wantSettled   bool
+	}{
+		{
+			name:      "rejects a body signed with another key",
+			bodies:    [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			signature: utils.NewFakePaymentProvider(utils.Config{PaymentCallbackKey: "other"}).SignCallback(callbackBody("cb-1", constants.PaymentStatusPaid, 150000)),
+			orders:    []entities.Order{pendingOrder},
+			wantErr:   constants.InvalidCallbackSignErrMsg,
+		},
+		{
+			name:      "rejects a malformed signature",
+			bodies:    [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			signature: "not-hex",
+			orders:    []entities.Order{pendingOrder},
+			wantErr:   constants.InvalidCallbackSignErrMsg,
+		},
+		{
+			name:    "rejects an unknown status",
+			bodies:  [][]byte{callbackBody("cb-1", "settled", 150000)},
+			orders:  []entities.Order{pendingOrder},
+			wantErr: constants.InvalidPaymentStatusErrMsg,
+		},
+		{
+			name:    "rejects an amount that does not match the charge",
+			bodies:  [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 149999)},
+			orders:  []entities.Order{pendingOrder},
+			wantErr: constants.PaymentAmountNotMatchErrMsg,
+		},
+		{
+			name:          "settles pending orders",
+			bodies:        [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:        []entities.Order{pendingOrder},
+			wantStatuses:  []string{constants.PaymentStatusPaid},
+			wantProcessed: []int64{1},
+			wantSettled:   true,
+		},
+		{
+			name:          "ignores a replayed callback",
+			bodies:        [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000), callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:        []entities.Order{pendingOrder},
+			wantStatuses:  []string{constants.PaymentStatusPaid},
+			wantProcessed: []int64{1},
+			wantSettled:   true,
+		},
+		{
+			name:         "ignores a callback id seen before",
+			bodies:       [][]byte{callbackBody("cb-1", constants.PaymentStatusPending, 150000), callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:       []entities.Order{pendingOrder},
+			wantStatuses: []string{constants.PaymentStatusPending},
+		},
+		{
+			name:         "ignores a status update of an expired charge",
+			status:       constants.PaymentStatusExpired,
+			bodies:       [][]byte{callbackBody("cb-1", constants.PaymentStatusFailed, 150000)},
+			orders:       []entities.Order{pendingOrder},
+			wantStatuses: []string{},
+		},
+		{
+			name:          "settles orders still pending when an expired charge is paid",
+			bodies:        [][]byte{callbackBody("cb-1", constants.PaymentStatusExpired, 150000), callbackBody("cb-2", constants.PaymentStatusPaid, 150000)},
+			orders:        []entities.Order{pendingOrder},
+			wantStatuses:  []string{constants.PaymentStatusExpired, constants.PaymentStatusPaid},
+			wantProcessed: []int64{1},
+			wantSettled:   true,
+		},
+		{
+			name:         "refunds orders paid by another charge when an expired charge is paid",
+			status:       constants.PaymentStatusExpired,
+			bodies:       [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:       []entities.Order{paidOrder, canceledLate},
+			wantStatuses: []string{constants.PaymentStatusPaid},
+			wantRefunds: map[int64]entities.Refund{
+				1: {Type: constants.RefundTypeOverpayment, Amount: decimal.NewFromInt(100000)},
+				2: {Type: constants.RefundTypeCancellation, Amount: decimal.NewFromInt(50000)},
+			},
+			wantSettled: true,
+		},
+		{
+			name:          "refunds orders canceled while the charge was open",
+			bodies:        [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:        []entities.Order{pendingOrder, canceledLate, canceledEarly},
+			wantStatuses:  []string{constants.PaymentStatusPaid},
+			wantProcessed: []int64{1},
+			wantRefunds:   map[int64]entities.Refund{2: {Type: constants.RefundTypeCancellation, Amount: decimal.NewFromInt(50000)}},
+			wantSettled:   true,
+		},
+		{
+			name:          "refunds a canceled order refunded before as an overpayment",
+			bodies:        [][]byte{callbackBody("cb-1", constants.PaymentStatusPaid, 150000)},
+			orders:        []entities.Order{pendingOrder, canceledLate},
+			refunds:       []entities.Refund{{OrderId: sql.NullInt64{Int64: 2, Valid: true}, UserId: 7, Type: constants.RefundTypeCancellation, Amount: decimal.NewFromInt(50000)}},
+			wantStatuses:  []string{constants.PaymentStatusPaid},
+			wantProcessed: []int64{1},
+			wantRefunds:   map[int64]entities.Refund{2: {Type: constants.RefundTypeOverpayment, Amount: decimal.NewFromInt(50000)}},
+			wantSettled:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == "" {
+				status = constants.PaymentStatusPending
+			}
+			paymentRepo := &stubPaymentRepository{
+				payment: &entities.Payment{
+					Id:         1,
+					CheckoutId: sql.NullInt64{Int64: 1, Valid: true},
+					Provider:   constants.FakePaymentProvider,
+					ExternalId: "ext-1",
+					Amount:     decimal.NewFromInt(150000),
+					Status:     status,
+					CreatedAt:  createdAt,
+				},
+				callbacks: map[string]bool{},
+			}
+			orderRepo := &stubOrderRepository{orders: tt.orders, updated: map[int64]string{}}
+			refundRepo := &stubRefundRepository{refunds: tt.refunds}
+
+			u := NewPaymentUsecaseImpl(&PaymentUsecaseOpts{
+				PaymentRepository:            paymentRepo,
+				OrderRepository:              orderRepo,
+				OrderStatusHistoryRepository: &stubOrderStatusHistoryRepository{},
+				RefundRepository:             refundRepo,
+				Transactor:                   stubTransactor{},
+				PaymentProviders:             map[string]utils.PaymentProvider{fake.Name(): fake},
+				PaymentGateway:               fake.Name(),
+			})
+
+			var err error
+			for _, body := range tt.bodies {
+				signature := tt.signature
+				if signature == "" {
+					signature = fake.SignCallback(body)
+				}
+				err = u.HandleCallback(context.Background(), fake.Name(), body, signature)
+				if err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(paymentRepo.statuses) != len(tt.wantStatuses) {
+				t.Fatalf("got payment statuses %v, want %v", paymentRepo.statuses, tt.wantStatuses)
+			}
+			for i, status := range tt.wantStatuses {
+				if paymentRepo.statuses[i] != status {
+					t.Fatalf("got payment statuses %v, want %v", paymentRepo.statuses, tt.wantStatuses)
+				}
+			}
+
+			if len(orderRepo.updated) != len(tt.wantProcessed) {
+				t.Fatalf("got order updates %v, want %v processed", orderRepo.updated, tt.wantProcessed)
+			}
+			for _, orderId := range tt.wantProcessed {
+				if orderRepo.updated[orderId] != constants.Processing {
+					t.Fatalf("order %d is %q, want %q", orderId, orderRepo.updated[orderId], constants.Processing)
+				}
+			}
+
+			newRefunds := refundRepo.refunds[len(tt.refunds):]
+			if len(newRefunds) != len(tt.wantRefunds) {
+				t.Fatalf("got %d refunds, want %d", len(newRefunds), len(tt.wantRefunds))
+			}
+			for _, refund := range newRefunds {
+				want, ok := tt.wantRefunds[refund.OrderId.Int64]
+				if !ok || !refund.Amount.Equal(want.Amount) || refund.Type != want.Type {
+					t.Fatalf("unexpected %s refund of %s for order %d", refund.Type, refund.Amount, refund.OrderId.Int64)
+				}
+				if refund.Status != constants.RefundStatusApproved || refund.UserId != 7 {
+					t.Fatalf("unexpected refund %+v", refund)
+				}
+			}
+
+			if settled := len(paymentRepo.expiredCheckouts) != 0; settled != tt.wantSettled {
+				t.Fatalf("expired the other charges of checkouts %v, want settled %v", paymentRepo.expiredCheckouts, tt.wantSettled)
+			}
+		})
+	}
+}
+
+// retryingTransactor runs every transaction twice, as if the first attempt
+// failed to commit with a serialization failure.
+type retryingTransactor struct{}
+
+func (retryingTransactor) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	_, err := tFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tFunc(ctx)
+}
+
+type stubCheckoutRepository struct {
+	repositories.CheckoutRepository
+	checkout entities.Checkout
+}
+
+func (r *stubCheckoutRepository) LockById(ctx context.Context, checkoutId int64) (*entities.Checkout, error) {
+	checkout := r.checkout
+	return &checkout, nil
+}
+
+type countingPaymentProvider struct {
+	*utils.FakePaymentProvider
+	charges  int
+	onCharge func()
+}
+
+func (p *countingPaymentProvider) CreateVirtualAccount(ctx context.Context, req utils.PaymentChargeRequest) (*utils.PaymentCharge, error) {
+	p.charges++
+	if p.onCharge != nil {
+		p.onCharge()
+	}
+	return p.FakePaymentProvider.CreateVirtualAccount(ctx, req)
+}
+
+func TestCreatePayment(t *testing.T) {
+	pending := entities.Order{Id: 1, OrderStatus: constants.Pending, TotalPrice: decimal.NewFromInt(90000), ShippingFee: decimal.NewFromInt(10000)}
+	canceled := entities.Order{Id: 2, OrderStatus: constants.Canceled, TotalPrice: decimal.NewFromInt(40000)}
+	req := dtos.PaymentRequest{Method: constants.PaymentMethodVirtualAccount, Channel: "bca"}
+
+	tests := []struct {
+		name         string
+		orders       []entities.Order
+		active       *entities.Payment
+		changeOrders []entities.Order
+		wantErr      string
+		wantCharges  int
+		wantStored   string
+		wantStatuses []string
+	}{
+		{
+			name:        "opens one charge even when the transactions are retried",
+			orders:      []entities.Order{pending, canceled},
+			wantCharges: 1,
+			wantStored:  constants.PaymentStatusPending,
+		},
+		{
+			name:        "returns the open charge of the same method",
+			orders:      []entities.Order{pending},
+			active:      &entities.Payment{Id: 9, Method: req.Method, Channel: req.Channel, Amount: decimal.NewFromInt(100000), Status: constants.PaymentStatusPending},
+			wantCharges: 0,
+		},
+		{
+			name:         "expires the open charge of another method",
+			orders:       []entities.Order{pending},
+			active:       &entities.Payment{Id: 9, Method: constants.PaymentMethodEWallet, Channel: "ovo", Amount: decimal.NewFromInt(100000), Status: constants.PaymentStatusPending},
+			wantCharges:  1,
+			wantStored:   constants.PaymentStatusPending,
+			wantStatuses: []string{constants.PaymentStatusExpired},
+		},
+		{
+			name:        "rejects a checkout with nothing left to pay",
+			orders:      []entities.Order{canceled},
+			wantErr:     constants.OrderNotPayableErrMsg,
+			wantCharges: 0,
+		},
+		{
+			name:        "rejects a checkout that is already paid",
+			orders:      []entities.Order{pending},
+			active:      &entities.Payment{Id: 9, Method: req.Method, Channel: req.Channel, Amount: decimal.NewFromInt(100000), Status: constants.PaymentStatusPaid},
+			wantErr:     constants.OrderAlreadyPaidErrMsg,
+			wantCharges: 0,
+		},
+		{
+			name:         "keeps the charge as expired when an order was canceled meanwhile",
+			orders:       []entities.Order{pending, {Id: 2, OrderStatus: constants.Pending, TotalPrice: decimal.NewFromInt(40000)}},
+			changeOrders: []entities.Order{pending, canceled},
+			wantErr:      constants.PaymentChargeStaleErrMsg,
+			wantCharges:  1,
+			wantStored:   constants.PaymentStatusExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paymentRepo := &stubPaymentRepository{payment: tt.active}
+			orderRepo := &stubOrderRepository{orders: tt.orders, updated: map[int64]string{}}
+			provider := &countingPaymentProvider{FakePaymentProvider: utils.NewFakePaymentProvider(utils.Config{})}
+			if tt.changeOrders != nil {
+				provider.onCharge = func() { orderRepo.orders = tt.changeOrders }
+			}
+
+			u := NewPaymentUsecaseImpl(&PaymentUsecaseOpts{
+				PaymentRepository:  paymentRepo,
+				OrderRepository:    orderRepo,
+				CheckoutRepository: &stubCheckoutRepository{checkout: entities.Checkout{Id: 1, CheckoutNumber: "CO-1", UserId: 7, PaymentDeadline: time.Now().Add(time.Hour)}},
+				Transactor:         retryingTransactor{},
+				PaymentProviders:   map[string]utils.PaymentProvider{provider.Name(): provider},
+				PaymentGateway:     provider.Name(),
+			})
+
+			payment, err := u.CreatePayment(context.Background(), 1, 7, req)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || payment == nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if provider.charges != tt.wantCharges {
+				t.Fatalf("opened %d charges, want %d", provider.charges, tt.wantCharges)
+			}
+
+			// the stub keeps every stored attempt, only the last one commits
+			if tt.wantStored == "" && len(paymentRepo.created) != 0 {
+				t.Fatalf("stored %d charges, want none", len(paymentRepo.created))
+			}
+			for _, created := range paymentRepo.created {
+				if created.Status != tt.wantStored || created.ExternalId == "" || created.CreatedAt.IsZero() {
+					t.Fatalf("stored %+v, want a %s charge", created, tt.wantStored)
+				}
+			}
+
+			if len(paymentRepo.statuses) != len(tt.wantStatuses) {
+				t.Fatalf("got payment statuses %v, want %v", paymentRepo.statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ type ProductFieldUsecase interface {
 	GetAllForm(ctx context.Context) ([]entities.ProductForm, error)
 	GetAllClassification(ctx context.Context) ([]entities.ProductClassification, error)
 	GetAllManufacture(ctx context.Context) ([]entities.Manufacture, error)
+	UpdateClassification(ctx context.Context, classification entities.ProductClassification) (*entities.ProductClassification, error)
 }
 
 type ProductFieldUsecaseImpl struct {
@@ -53,3 +54,7 @@ func (u *ProductFieldUsecaseImpl) GetAllManufacture(ctx context.Context) ([]enti
 
 	return manufacture, nil
 }
+
+func (u *ProductFieldUsecaseImpl) UpdateClassification(ctx context.Context, classification entities.ProductClassification) (*entities.ProductClassification, error) {
+	return u.ProductFieldRepository.UpdateClassification(ctx, classification)
+}
@@ -0,0 +1,304 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type RefundUsecaseOpts struct {
+	RefundRepository             repositories.RefundRepository
+	OrderRepository              repositories.OrderRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	PharmacyProductRepository    repositories.PharmacyProductRepository
+	StockHistoryRepository       repositories.StockHistoryRepository
+	Transactor                   repositories.Transactor
+}
+
+type RefundUsecase interface {
+	RequestReturn(ctx context.Context, orderId int64, userId int64, req dtos.ReturnRequest) (*entities.Refund, error)
+	GetOrderRefunds(ctx context.Context, orderId int64, actorId int64, actorRole string) ([]entities.Refund, error)
+	GetAllRefund(ctx context.Context, params entities.RefundParams) ([]entities.Refund, int, error)
+	GetRefundById(ctx context.Context, refundId int64) (*entities.Refund, error)
+	ApproveRefund(ctx context.Context, refundId int64, adminId int64) error
+	RejectRefund(ctx context.Context, refundId int64, adminId int64, note string) error
+	PayOutRefund(ctx context.Context, refundId int64, adminId int64) error
+}
+
+type RefundUsecaseImpl struct {
+	RefundRepository             repositories.RefundRepository
+	OrderRepository              repositories.OrderRepository
+	OrderStatusHistoryRepository repositories.OrderStatusHistoryRepository
+	PharmacyProductRepository    repositories.PharmacyProductRepository
+	StockHistoryRepository       repositories.StockHistoryRepository
+	Transactor                   repositories.Transactor
+}
+
+func NewRefundUsecaseImpl(rOpts *RefundUsecaseOpts) RefundUsecase {
+	return &RefundUsecaseImpl{
+		RefundRepository:             rOpts.RefundRepository,
+		OrderRepository:              rOpts.OrderRepository,
+		OrderStatusHistoryRepository: rOpts.OrderStatusHistoryRepository,
+		PharmacyProductRepository:    rOpts.PharmacyProductRepository,
+		StockHistoryRepository:       rOpts.StockHistoryRepository,
+		Transactor:                   rOpts.Transactor,
+	}
+}
+
+// RequestReturn lets a user send back some or all items of a completed order
+// within the return window. Each item is refunded at its snapshotted price
+// minus its share of the order discount; shipping is not refunded.
+func (u *RefundUsecaseImpl) RequestReturn(ctx context.Context, orderId int64, userId int64, req dtos.ReturnRequest) (*entities.Refund, error) {
+	res, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		order, err := u.OrderRepository.GetOrder(txCtx, orderId)
+		if err != nil {
+			return nil, err
+		}
+
+		if order.UserId != userId {
+			return nil, custom_errors.Forbidden()
+		}
+
+		if order.OrderStatus != constants.Completed {
+			return nil, custom_errors.BadRequest(nil, constants.OrderNotReturnableErrMsg)
+		}
+
+		completedAt, err := u.findCompletedAt(txCtx, orderId)
+		if err != nil {
+			return nil, err
+		}
+
+		if completedAt.IsZero() || time.Since(completedAt) > time.Hour*24*constants.OrderReturnWindowDays {
+			return nil, custom_errors.BadRequest(nil, constants.ReturnWindowPassedErrMsg)
+		}
+
+		orderItems, err := u.OrderRepository.GetOrderItems(txCtx, orderId)
+		if err != nil {
+			return nil, err
+		}
+
+		ordered := map[int64]dtos.OrderItem{}
+		for _, item := range orderItems {
+			ordered[item.PharmacyProductId] = item
+		}
+
+		returned, err := u.RefundRepository.SumReturnedQuantities(txCtx, orderId)
+		if err != nil {
+			return nil, err
+		}
+
+		refund := entities.Refund{
+			OrderId: sql.NullInt64{Int64: orderId, Valid: true},
+			UserId:  userId,
+			Type:    constants.RefundTypeReturn,
+			Status:  constants.RefundStatusRequested,
+			Amount:  decimal.Zero,
+			Reason:  sql.NullString{String: req.Reason, Valid: true},
+		}
+
+		for _, reqItem := range req.Items {
+			item, ok := ordered[reqItem.PharmacyProductId]
+			if !ok || returned[reqItem.PharmacyProductId]+reqItem.Quantity > item.Quantity {
+				return nil, custom_errors.BadRequest(nil, constants.InvalidReturnItemErrMsg)
+			}
+			amount := returnItemAmount(item, returned[reqItem.PharmacyProductId], reqItem.Quantity)
+			returned[reqItem.PharmacyProductId] += reqItem.Quantity
+
+			refund.Items = append(refund.Items, entities.RefundItem{
+				PharmacyProductId: reqItem.PharmacyProductId,
+				Quantity:          reqItem.Quantity,
+				Amount:            amount,
+			})
+			refund.Amount = refund.Amount.Add(amount)
+		}
+
+		return u.RefundRepository.CreateOne(txCtx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*entities.Refund), nil
+}
+
+// returnItemAmount prices quantity units of an order item given how many were
+// already returned. The discount share is taken on the running total so that
+// returning an item in several parts never refunds more than was paid for it.
+func returnItemAmount(item dtos.OrderItem, returnedBefore int, quantity int) decimal.Decimal {
+	ordered := decimal.NewFromInt(int64(item.Quantity))
+	discountShare := func(returned int) decimal.Decimal {
+		return item.Discount.Mul(decimal.NewFromInt(int64(returned))).Div(ordered).Floor()
+	}
+
+	discount := discountShare(returnedBefore + quantity).Sub(discountShare(returnedBefore))
+
+	return item.Price.Mul(decimal.NewFromInt(int64(quantity))).Sub(discount)
+}
+
+func (u *RefundUsecaseImpl) findCompletedAt(ctx context.Context, orderId int64) (time.Time, error) {
+	histories, err := u.OrderStatusHistoryRepository.FindAllByOrderId(ctx, orderId)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var completedAt time.Time
+	for _, history := range histories {
+		if history.ToStatus == constants.Completed {
+			completedAt = history.CreatedAt
+		}
+	}
+
+	return completedAt, nil
+}
+
+func (u *RefundUsecaseImpl) GetOrderRefunds(ctx context.Context, orderId int64, actorId int64, actorRole string) ([]entities.Refund, error) {
+	order, err := u.OrderRepository.GetOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorRole == constants.UserRole && order.UserId != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	if actorRole == constants.PharmacyManagerRole && order.PharmacyManagerId != actorId {
+		return nil, custom_errors.Forbidden()
+	}
+
+	refunds, err := u.RefundRepository.FindAllByOrderId(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range refunds {
+		refunds[i].Items, err = u.RefundRepository.FindItems(ctx, refunds[i].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return refunds, nil
+}
+
+func (u *RefundUsecaseImpl) GetAllRefund(ctx context.Context, params entities.RefundParams) ([]entities.Refund, int, error) {
+	var total int
+
+	refunds, err := u.RefundRepository.FindAll(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(refunds) > 0 {
+		total = refunds[0].Total
+	}
+
+	return refunds, total, nil
+}
+
+func (u *RefundUsecaseImpl) GetRefundById(ctx context.Context, refundId int64) (*entities.Refund, error) {
+	refund, err := u.RefundRepository.FindById(ctx, refundId)
+	if err != nil {
+		return nil, err
+	}
+
+	refund.Items, err = u.RefundRepository.FindItems(ctx, refundId)
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// ApproveRefund accepts a return request and puts the returned items back
+// into the pharmacy's stock.
+func (u *RefundUsecaseImpl) ApproveRefund(ctx context.Context, refundId int64, adminId int64) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		refund, err := u.RefundRepository.LockById(txCtx, refundId)
+		if err != nil {
+			return nil, err
+		}
+
+		if refund.Status != constants.RefundStatusRequested {
+			return nil, custom_errors.BadRequest(nil, constants.RefundNotRequestedErrMsg)
+		}
+
+		items, err := u.RefundRepository.FindItems(txCtx, refundId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			err := restockPharmacyProduct(txCtx, u.PharmacyProductRepository, u.StockHistoryRepository, item.PharmacyProductId, item.Quantity, constants.ReturnedStockDesc)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		refund.Status = constants.RefundStatusApproved
+		refund.AdminId = sql.NullInt64{Int64: adminId, Valid: true}
+		refund.ProcessedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		return nil, u.RefundRepository.UpdateStatus(txCtx, *refund)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *RefundUsecaseImpl) RejectRefund(ctx context.Context, refundId int64, adminId int64, note string) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		refund, err := u.RefundRepository.LockById(txCtx, refundId)
+		if err != nil {
+			return nil, err
+		}
+
+		if refund.Status != constants.RefundStatusRequested {
+			return nil, custom_errors.BadRequest(nil, constants.RefundNotRequestedErrMsg)
+		}
+
+		refund.Status = constants.RefundStatusRejected
+		refund.AdminId = sql.NullInt64{Int64: adminId, Valid: true}
+		refund.AdminNote = sql.NullString{String: note, Valid: true}
+		refund.ProcessedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		return nil, u.RefundRepository.UpdateStatus(txCtx, *refund)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PayOutRefund marks an approved refund as transferred back to the user.
+func (u *RefundUsecaseImpl) PayOutRefund(ctx context.Context, refundId int64, adminId int64) error {
+	_, err := u.Transactor.WithinTransaction(ctx, func(txCtx context.Context) (interface{}, error) {
+		refund, err := u.RefundRepository.LockById(txCtx, refundId)
+		if err != nil {
+			return nil, err
+		}
+
+		if refund.Status != constants.RefundStatusApproved {
+			return nil, custom_errors.BadRequest(nil, constants.RefundNotApprovedErrMsg)
+		}
+
+		refund.Status = constants.RefundStatusPaidOut
+		refund.AdminId = sql.NullInt64{Int64: adminId, Valid: true}
+		refund.PaidOutAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		return nil, u.RefundRepository.UpdateStatus(txCtx, *refund)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type stubReturnOrderRepository struct {
+	repositories.OrderRepository
+	order entities.Order
+	items []dtos.OrderItem
+}
+
+func (r *stubReturnOrderRepository) GetOrder(ctx context.Context, orderId int64) (*entities.Order, error) {
+	order := r.order
+	return &order, nil
+}
+
+func (r *stubReturnOrderRepository) GetOrderItems(ctx context.Context, orderId int64) ([]dtos.OrderItem, error) {
+	return r.items, nil
+}
+
+type stubCompletedHistoryRepository struct {
+	repositories.OrderStatusHistoryRepository
+	completedAt time.Time
+}
+
+func (r *stubCompletedHistoryRepository) FindAllByOrderId(ctx context.Context, orderId int64) ([]entities.OrderStatusHistory, error) {
+	return []entities.OrderStatusHistory{{OrderId: orderId, ToStatus: constants.Completed, CreatedAt: r.completedAt}}, nil
+}
+
+func (r *stubRefundRepository) SumReturnedQuantities(ctx context.Context, orderId int64) (map[int64]int, error) {
+	returned := map[int64]int{}
+	for _, refund := range r.refunds {
+		if refund.OrderId.Int64 != orderId || refund.Type != constants.RefundTypeReturn || refund.Status == constants.RefundStatusRejected {
+			continue
+		}
+		for _, item := range refund.Items {
+			returned[item.PharmacyProductId] += item.Quantity
+		}
+	}
+	return returned, nil
+}
+
+func TestReturnItemAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        dtos.OrderItem
+		returns     []int
+		wantAmounts []int64
+	}{
+		{
+			name:        "whole line at once",
+			item:        dtos.OrderItem{Price: decimal.NewFromInt(10000), Discount: decimal.NewFromInt(3000), Quantity: 3},
+			returns:     []int{3},
+			wantAmounts: []int64{27000},
+		},
+		{
+			name:        "no discount",
+			item:        dtos.OrderItem{Price: decimal.NewFromInt(10000), Discount: decimal.Zero, Quantity: 2},
+			returns:     []int{1, 1},
+			wantAmounts: []int64{10000, 10000},
+		},
+		{
+			name:        "uneven discount returned one by one",
+			item:        dtos.OrderItem{Price: decimal.NewFromInt(10000), Discount: decimal.NewFromInt(100), Quantity: 3},
+			returns:     []int{1, 1, 1},
+			wantAmounts: []int64{9967, 9967, 9966},
+		},
+		{
+			name:        "uneven discount returned in two parts",
+			item:        dtos.OrderItem{Price: decimal.NewFromInt(5000), Discount: decimal.NewFromInt(1001), Quantity: 4},
+			returns:     []int{1, 3},
+			wantAmounts: []int64{4750, 14249},
+		},
+		{
+			name:        "discount smaller than the quantity",
+			item:        dtos.OrderItem{Price: decimal.NewFromInt(1000), Discount: decimal.NewFromInt(2), Quantity: 5},
+			returns:     []int{2, 2, 1},
+			wantAmounts: []int64{2000, 1999, 999},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returned := 0
+			total := decimal.Zero
+			for i, quantity := range tt.returns {
+				amount := returnItemAmount(tt.item, returned, quantity)
+				if !amount.Equal(decimal.NewFromInt(tt.wantAmounts[i])) {
+					t.Fatalf("return %d got %s, want %d", i, amount, tt.wantAmounts[i])
+				}
+				returned += quantity
+				total = total.Add(amount)
+			}
+
+			paid := tt.item.Price.Mul(decimal.NewFromInt(int64(tt.item.Quantity))).Sub(tt.item.Discount)
+			if returned == tt.item.Quantity && !total.Equal(paid) {
+				t.Fatalf("refunded %s in total, want the %s paid", total, paid)
+			}
+		})
+	}
+}
+
+func TestRequestReturn(t *testing.T) {
+	items := []dtos.OrderItem{
+		{PharmacyProductId: 1, Price: decimal.NewFromInt(10000), Discount: decimal.NewFromInt(100), Quantity: 3},
+		{PharmacyProductId: 2, Price: decimal.NewFromInt(25000), Discount: decimal.Zero, Quantity: 1},
+	}
+	completed := entities.Order{Id: 1, UserId: 7, OrderStatus: constants.Completed}
+
+	tests := []struct {
+		name        string
+		order       entities.Order
+		completedAt time.Time
+		requests    [][]dtos.ReturnItemRequest
+		wantErr     string
+		wantAmounts []int64
+	}{
+		{
+			name:        "several items in one request",
+			order:       completed,
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 2}, {PharmacyProductId: 2, Quantity: 1}}},
+			wantAmounts: []int64{44934},
+		},
+		{
+			name:        "partial returns add up to what was paid",
+			order:       completed,
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 1}}, {{PharmacyProductId: 1, Quantity: 1}}, {{PharmacyProductId: 1, Quantity: 1}}},
+			wantAmounts: []int64{9967, 9967, 9966},
+		},
+		{
+			name:        "more than was ordered",
+			order:       completed,
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 4}}},
+			wantErr:     constants.InvalidReturnItemErrMsg,
+		},
+		{
+			name:        "more than is left after an earlier return",
+			order:       completed,
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 2}}, {{PharmacyProductId: 1, Quantity: 2}}},
+			wantErr:     constants.InvalidReturnItemErrMsg,
+			wantAmounts: []int64{19934},
+		},
+		{
+			name:        "an item that is not in the order",
+			order:       completed,
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 3, Quantity: 1}}},
+			wantErr:     constants.InvalidReturnItemErrMsg,
+		},
+		{
+			name:        "order not completed",
+			order:       entities.Order{Id: 1, UserId: 7, OrderStatus: constants.Shipped},
+			completedAt: time.Now(),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 1}}},
+			wantErr:     constants.OrderNotReturnableErrMsg,
+		},
+		{
+			name:        "return window passed",
+			order:       completed,
+			completedAt: time.Now().Add(-time.Hour * 24 * (constants.OrderReturnWindowDays + 1)),
+			requests:    [][]dtos.ReturnItemRequest{{{PharmacyProductId: 1, Quantity: 1}}},
+			wantErr:     constants.ReturnWindowPassedErrMsg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refundRepo := &stubRefundRepository{}
+			u := NewRefundUsecaseImpl(&RefundUsecaseOpts{
+				RefundRepository:             refundRepo,
+				OrderRepository:              &stubReturnOrderRepository{order: tt.order, items: items},
+				OrderStatusHistoryRepository: &stubCompletedHistoryRepository{completedAt: tt.completedAt},
+				Transactor:                   stubTransactor{},
+			})
+
+			var err error
+			for _, reqItems := range tt.requests {
+				_, err = u.RequestReturn(context.Background(), tt.order.Id, tt.order.UserId, dtos.ReturnRequest{Reason: "damaged", Items: reqItems})
+				if err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(refundRepo.refunds) != len(tt.wantAmounts) {
+				t.Fatalf("got %d refunds, want %d", len(refundRepo.refunds), len(tt.wantAmounts))
+			}
+			for i, refund := range refundRepo.refunds {
+				if !refund.Amount.Equal(decimal.NewFromInt(tt.wantAmounts[i])) {
+					t.Fatalf("refund %d got %s, want %d", i, refund.Amount, tt.wantAmounts[i])
+				}
+				if refund.Type != constants.RefundTypeReturn || refund.Status != constants.RefundStatusRequested {
+					t.Fatalf("unexpected refund %+v", refund)
+				}
+			}
+		})
+	}
+}
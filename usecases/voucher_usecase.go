@@ -0,0 +1,129 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/shopspring/decimal"
+)
+
+type VoucherUsecaseOpts struct {
+	VoucherRepo repositories.VoucherRepository
+}
+
+type VoucherUsecase interface {
+	GetAllVoucher(ctx context.Context, params entities.VoucherParams) ([]entities.Voucher, *entities.PaginationInfo, error)
+	GetVoucherById(ctx context.Context, voucherId int64) (*entities.Voucher, error)
+	CreateVoucher(ctx context.Context, voucher entities.Voucher) error
+	UpdateVoucher(ctx context.Context, voucher entities.Voucher) error
+	DeleteVoucher(ctx context.Context, voucherId int64) error
+}
+
+type VoucherUsecaseImpl struct {
+	VoucherRepository repositories.VoucherRepository
+}
+
+func NewVoucherUsecaseImpl(vuOpts *VoucherUsecaseOpts) VoucherUsecase {
+	return &VoucherUsecaseImpl{
+		VoucherRepository: vuOpts.VoucherRepo,
+	}
+}
+
+func (u *VoucherUsecaseImpl) GetAllVoucher(ctx context.Context, params entities.VoucherParams) ([]entities.Voucher, *entities.PaginationInfo, error) {
+	vouchers, totalData, err := u.VoucherRepository.FindAll(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var totalPage int
+	if params.Limit != 0 && params.Page != 0 {
+		totalPage = totalData / params.Limit
+		if totalData%params.Limit > 0 {
+			totalPage++
+		}
+	}
+
+	pagination := entities.PaginationInfo{
+		Page:      params.Page,
+		Limit:     params.Limit,
+		TotalData: totalData,
+		TotalPage: totalPage,
+	}
+
+	return vouchers, &pagination, nil
+}
+
+func (u *VoucherUsecaseImpl) GetVoucherById(ctx context.Context, voucherId int64) (*entities.Voucher, error) {
+	voucher, err := u.VoucherRepository.FindById(ctx, voucherId)
+	if err != nil {
+		return nil, err
+	}
+
+	return voucher, nil
+}
+
+func (u *VoucherUsecaseImpl) CreateVoucher(ctx context.Context, voucher entities.Voucher) error {
+	err := validateVoucher(voucher)
+	if err != nil {
+		return err
+	}
+
+	err = u.VoucherRepository.CreateOne(ctx, voucher)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *VoucherUsecaseImpl) UpdateVoucher(ctx context.Context, voucher entities.Voucher) error {
+	_, err := u.VoucherRepository.FindById(ctx, voucher.Id)
+	if err != nil {
+		return err
+	}
+
+	err = validateVoucher(voucher)
+	if err != nil {
+		return err
+	}
+
+	err = u.VoucherRepository.UpdateOne(ctx, voucher)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u *VoucherUsecaseImpl) DeleteVoucher(ctx context.Context, voucherId int64) error {
+	_, err := u.VoucherRepository.FindById(ctx, voucherId)
+	if err != nil {
+		return err
+	}
+
+	err = u.VoucherRepository.DeleteOne(ctx, voucherId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateVoucher(voucher entities.Voucher) error {
+	if !voucher.DiscountValue.IsPositive() || voucher.MinSpend.IsNegative() || (voucher.MaxDiscount.Valid && !voucher.MaxDiscount.Decimal.IsPositive()) {
+		return custom_errors.BadRequest(nil, constants.InvalidVoucherErrMsg)
+	}
+
+	if voucher.DiscountType == constants.VoucherDiscountPercentage && voucher.DiscountValue.GreaterThan(decimal.NewFromInt(100)) {
+		return custom_errors.BadRequest(nil, constants.InvalidVoucherDiscountErrMsg)
+	}
+
+	if !voucher.EndsAt.After(voucher.StartsAt) {
+		return custom_errors.BadRequest(nil, constants.InvalidVoucherPeriodErrMsg)
+	}
+
+	return nil
+}
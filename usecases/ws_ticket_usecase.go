@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+)
+
+type WsTicketUsecaseOpts struct {
+	WsTicketRepository repositories.WsTicketRepository
+	AuthTokenProvider  utils.AuthTokenProvider
+}
+
+type WsTicketUsecase interface {
+	RedeemTicket(ctx context.Context, ticket string) (*utils.ClaimsData, error)
+	PruneExpiredTickets(ctx context.Context) (int64, error)
+}
+
+type WsTicketUsecaseImpl struct {
+	WsTicketRepository repositories.WsTicketRepository
+	AuthTokenProvider  utils.AuthTokenProvider
+}
+
+func NewWsTicketUsecaseImpl(wtOpts *WsTicketUsecaseOpts) WsTicketUsecase {
+	return &WsTicketUsecaseImpl{
+		WsTicketRepository: wtOpts.WsTicketRepository,
+		AuthTokenProvider:  wtOpts.AuthTokenProvider,
+	}
+}
+
+// RedeemTicket verifies a ws ticket and spends it. Redemptions are stored, so
+// a ticket leaked through a URL cannot be replayed against any API instance.
+func (u *WsTicketUsecaseImpl) RedeemTicket(ctx context.Context, ticket string) (*utils.ClaimsData, error) {
+	parsed, err := u.AuthTokenProvider.ParseWsTicket(ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	redeemed, err := u.WsTicketRepository.Redeem(ctx, entities.WsTicketRedemption{
+		Jti:       parsed.Id,
+		ExpiresAt: parsed.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !redeemed {
+		return nil, custom_errors.InvalidAuthToken()
+	}
+
+	return parsed.Data, nil
+}
+
+// PruneExpiredTickets forgets redemptions of tickets that expired, since
+// those are rejected on their expiry anyway.
+func (u *WsTicketUsecaseImpl) PruneExpiredTickets(ctx context.Context) (int64, error) {
+	return u.WsTicketRepository.DeleteExpired(ctx, time.Now())
+}
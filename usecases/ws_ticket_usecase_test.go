@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/tsanaativa/sehatin-backend-v0.1/repositories"
+	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
+)
+
+// stubWsTicketRepository behaves like the unique jti column, shared by every
+// API instance.
+type stubWsTicketRepository struct {
+	repositories.WsTicketRepository
+	redeemed map[string]time.Time
+	err      error
+}
+
+func (r *stubWsTicketRepository) Redeem(ctx context.Context, redemption entities.WsTicketRedemption) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	if _, ok := r.redeemed[redemption.Jti]; ok {
+		return false, nil
+	}
+	r.redeemed[redemption.Jti] = redemption.ExpiresAt
+	return true, nil
+}
+
+func TestRedeemTicket(t *testing.T) {
+	provider := utils.NewJwtProvider(utils.Config{Issuer: "sehatin", SecretKey: "secret"})
+	data := map[string]interface{}{constants.Id: 1, constants.Role: constants.UserRole}
+
+	tests := []struct {
+		name       string
+		tickets    int
+		redeems    []int
+		repoErr    error
+		wantErrors []bool
+	}{
+		{
+			name:       "a ticket is redeemed once",
+			tickets:    1,
+			redeems:    []int{0},
+			wantErrors: []bool{false},
+		},
+		{
+			name:       "a replayed ticket is rejected",
+			tickets:    1,
+			redeems:    []int{0, 0},
+			wantErrors: []bool{false, true},
+		},
+		{
+			name:       "tickets are redeemed on their own",
+			tickets:    2,
+			redeems:    []int{0, 1, 0},
+			wantErrors: []bool{false, false, true},
+		},
+		{
+			name:       "a failed redemption does not let the ticket through",
+			tickets:    1,
+			redeems:    []int{0},
+			repoErr:    errors.New("db down"),
+			wantErrors: []bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tickets := []string{}
+			for i := 0; i < tt.tickets; i++ {
+				ticket, err := provider.CreateWsTicket(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tickets = append(tickets, ticket)
+			}
+
+			repo := &stubWsTicketRepository{redeemed: map[string]time.Time{}, err: tt.repoErr}
+			// two usecases stand in for two API instances sharing the table
+			instances := []WsTicketUsecase{
+				NewWsTicketUsecaseImpl(&WsTicketUsecaseOpts{WsTicketRepository: repo, AuthTokenProvider: provider}),
+				NewWsTicketUsecaseImpl(&WsTicketUsecaseOpts{WsTicketRepository: repo, AuthTokenProvider: provider}),
+			}
+
+			for i, ticket := range tt.redeems {
+				claims, err := instances[i%len(instances)].RedeemTicket(context.Background(), tickets[ticket])
+				if (err != nil) != tt.wantErrors[i] {
+					t.Fatalf("redeem %d got error %v, want error %v", i, err, tt.wantErrors[i])
+				}
+				if err == nil && claims.Id != 1 {
+					t.Fatalf("redeem %d got claims %+v", i, claims)
+				}
+			}
+		})
+	}
+}
+
+func TestRedeemTicketRejectsOtherTokens(t *testing.T) {
+	provider := utils.NewJwtProvider(utils.Config{Issuer: "sehatin", SecretKey: "secret", ExpDurationHour: 1})
+	repo := &stubWsTicketRepository{redeemed: map[string]time.Time{}}
+	u := NewWsTicketUsecaseImpl(&WsTicketUsecaseOpts{WsTicketRepository: repo, AuthTokenProvider: provider})
+
+	access, err := provider.CreateAndSign(map[string]interface{}{constants.Id: 1, constants.Role: constants.UserRole})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = u.RedeemTicket(context.Background(), access.AccessToken)
+	if err == nil || len(repo.redeemed) != 0 {
+		t.Fatalf("got error %v after %d redemptions, want an error before any", err, len(repo.redeemed))
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"strconv"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/joho/godotenv"
 )
 
@@ -27,6 +28,14 @@ type Config struct {
 	GoogleUri             string
 	ResetTokenExpDuration int
 	RajaOngkirKey         string
+	PaymentGateway        string
+	PaymentCallbackKey    string
+	ManualBankName        string
+	ManualBankAccount     string
+	AutoCompleteDays      int
+	WsPubSub              string
+	ConsultationMaxMins   int
+	ConsultationIdleMins  int
 }
 
 func ConfigInit() (Config, error) {
@@ -55,6 +64,21 @@ func ConfigInit() (Config, error) {
 		return Config{}, err
 	}
 
+	autoCompleteDays, err := strconv.Atoi(env["ORDER_AUTO_COMPLETE_DAYS"])
+	if err != nil || autoCompleteDays <= 0 {
+		autoCompleteDays = constants.OrderAutoCompleteDays
+	}
+
+	consultationMaxMins, err := strconv.Atoi(env["CONSULTATION_MAX_MINUTES"])
+	if err != nil || consultationMaxMins <= 0 {
+		consultationMaxMins = constants.ConsultationMaxMinutes
+	}
+
+	consultationIdleMins, err := strconv.Atoi(env["CONSULTATION_IDLE_MINUTES"])
+	if err != nil || consultationIdleMins <= 0 {
+		consultationIdleMins = constants.ConsultationIdleMinutes
+	}
+
 	return Config{
 		DbUrl:                 env["DATABASE_URL"],
 		Port:                  env["PORT"],
@@ -76,5 +100,13 @@ func ConfigInit() (Config, error) {
 		GoogleUri:             env["GOOGLE_URI"],
 		ResetTokenExpDuration: resetPasswordTokenExp,
 		RajaOngkirKey:         env["RAJA_ONGKIR_KEY"],
+		PaymentGateway:        env["PAYMENT_GATEWAY"],
+		PaymentCallbackKey:    env["PAYMENT_CALLBACK_KEY"],
+		ManualBankName:        env["MANUAL_BANK_NAME"],
+		ManualBankAccount:     env["MANUAL_BANK_ACCOUNT"],
+		AutoCompleteDays:      autoCompleteDays,
+		WsPubSub:              env["WS_PUBSUB"],
+		ConsultationMaxMins:   consultationMaxMins,
+		ConsultationIdleMins:  consultationIdleMins,
 	}, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type AuthTokenProvider interface {
@@ -23,6 +24,11 @@ type AuthTokenProvider interface {
 	ValidateDoctorRoleJwt(ctx *gin.Context) error
 	ValidateMultiRoleJwt(ctx *gin.Context, roles []string) error
 	GenerateResetPasswordToken(data map[string]interface{}) (string, error)
+	ParseClaimsData(signed string) (*ClaimsData, error)
+	CreateWsTicket(data map[string]interface{}) (string, error)
+	ParseWsTicket(signed string) (*WsTicket, error)
+	CreateDocumentToken(number string) (string, error)
+	ParseDocumentToken(signed string) (string, error)
 }
 
 type JwtProvider struct {
@@ -39,6 +45,14 @@ type ClaimsData struct {
 	Role string
 }
 
+// WsTicket is a verified ws ticket. It is only valid once, which is up to the
+// caller to enforce by redeeming its id.
+type WsTicket struct {
+	Id        string
+	ExpiresAt time.Time
+	Data      *ClaimsData
+}
+
 func NewJwtProvider(config Config) AuthTokenProvider {
 	return &JwtProvider{
 		config: config,
@@ -97,11 +111,18 @@ func (j *JwtProvider) ParseAndVerify(signed string) (jwt.MapClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		return claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, custom_errors.InvalidAuthToken()
 	}
 
-	return nil, custom_errors.InvalidAuthToken()
+	// tokens minted for a single purpose, like ws tickets, carry an audience
+	// and are not accepted as bearer tokens
+	if _, ok := claims["aud"]; ok {
+		return nil, custom_errors.InvalidAuthToken()
+	}
+
+	return claims, nil
 }
 
 func (j *JwtProvider) IsAuthorized(ctx *gin.Context) (bool, *ClaimsData, error) {
@@ -110,21 +131,35 @@ func (j *JwtProvider) IsAuthorized(ctx *gin.Context) (bool, *ClaimsData, error)
 		return false, nil, err
 	}
 
-	claims, err := j.ParseAndVerify(token)
+	data, err := j.ParseClaimsData(token)
 	if err != nil {
 		return false, nil, err
 	}
+
+	return true, data, nil
+}
+
+func (j *JwtProvider) ParseClaimsData(signed string) (*ClaimsData, error) {
+	claims, err := j.ParseAndVerify(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return toClaimsData(claims)
+}
+
+func toClaimsData(claims jwt.MapClaims) (*ClaimsData, error) {
 	dataMap := claims["data"]
 	data, _ := dataMap.(map[string]interface{})
 
-	id := int64(data[constants.Id].(float64))
-	role := data[constants.Role].(string)
+	id, _ := data[constants.Id].(float64)
+	role, _ := data[constants.Role].(string)
 
 	if id != 0 && role != "" {
-		return true, &ClaimsData{Id: id, Role: role}, nil
+		return &ClaimsData{Id: int64(id), Role: role}, nil
 	}
 
-	return false, nil, custom_errors.InvalidAuthToken()
+	return nil, custom_errors.InvalidAuthToken()
 }
 
 func (j *JwtProvider) ValidateAdminRoleJwt(ctx *gin.Context) error {
@@ -220,3 +255,91 @@ func (j *JwtProvider) GenerateResetPasswordToken(data map[string]interface{}) (s
 
 	return signed, nil
 }
+
+// CreateWsTicket signs a short-lived token for browsers, which cannot send an
+// Authorization header on a WebSocket handshake and pass it in the URL instead.
+func (j *JwtProvider) CreateWsTicket(data map[string]interface{}) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":  j.config.Issuer,
+		"aud":  constants.WsTicketAudience,
+		"jti":  uuid.New().String(),
+		"exp":  time.Now().Add(constants.WsTicketDuration).Unix(),
+		"iat":  time.Now(),
+		"data": data,
+	})
+
+	signed, err := token.SignedString([]byte(j.config.SecretKey))
+	if err != nil {
+		return "", err
+	}
+
+	return signed, nil
+}
+
+func (j *JwtProvider) ParseWsTicket(signed string) (*WsTicket, error) {
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return []byte(j.config.SecretKey), nil
+	}, jwt.WithIssuer(j.config.Issuer),
+		jwt.WithAudience(constants.WsTicketAudience),
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, custom_errors.InvalidAuthToken()
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, custom_errors.InvalidAuthToken()
+	}
+
+	ticketId, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if ticketId == "" || err != nil {
+		return nil, custom_errors.InvalidAuthToken()
+	}
+
+	data, err := toClaimsData(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WsTicket{Id: ticketId, ExpiresAt: exp.Time, Data: data}, nil
+}
+
+// CreateDocumentToken signs the number of an issued document. The token does
+// not expire; whether the document is still valid is decided on lookup.
+func (j *JwtProvider) CreateDocumentToken(number string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": j.config.Issuer,
+		"aud": constants.DocumentTokenAudience,
+		"sub": number,
+		"iat": time.Now().Unix(),
+	})
+
+	signed, err := token.SignedString([]byte(j.config.SecretKey))
+	if err != nil {
+		return "", err
+	}
+
+	return signed, nil
+}
+
+func (j *JwtProvider) ParseDocumentToken(signed string) (string, error) {
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return []byte(j.config.SecretKey), nil
+	}, jwt.WithIssuer(j.config.Issuer),
+		jwt.WithAudience(constants.DocumentTokenAudience),
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	number, err := token.Claims.GetSubject()
+	if err != nil || number == "" {
+		return "", errors.New("document token has no subject")
+	}
+
+	return number, nil
+}
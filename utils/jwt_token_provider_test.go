@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+)
+
+func TestWsTicketIsNotABearerToken(t *testing.T) {
+	provider := NewJwtProvider(Config{Issuer: "sehatin", SecretKey: "secret", ExpDurationHour: 1})
+	data := map[string]interface{}{constants.Id: 1, constants.Role: constants.UserRole}
+
+	access, err := provider.CreateAndSign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := provider.CreateWsTicket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := provider.CreateDocumentToken("RX-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"access token", access.AccessToken, false},
+		{"ws ticket", ticket, true},
+		{"document token", document, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.ParseClaimsData(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseWsTicket(t *testing.T) {
+	provider := NewJwtProvider(Config{Issuer: "sehatin", SecretKey: "secret", ExpDurationHour: 1})
+	data := map[string]interface{}{constants.Id: 1, constants.Role: constants.UserRole}
+
+	ticket, err := provider.CreateWsTicket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := provider.CreateWsTicket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := provider.CreateAndSign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := NewJwtProvider(Config{Issuer: "sehatin", SecretKey: "other"}).CreateWsTicket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := provider.ParseWsTicket(ticket)
+	if err != nil || parsed.Data.Id != 1 || parsed.Data.Role != constants.UserRole {
+		t.Fatalf("got %+v, %v", parsed, err)
+	}
+	if parsed.Id == "" || parsed.ExpiresAt.After(time.Now().Add(constants.WsTicketDuration)) {
+		t.Fatalf("got ticket id %q expiring at %s", parsed.Id, parsed.ExpiresAt)
+	}
+
+	// parsing does not spend a ticket, redeeming its id does
+	again, err := provider.ParseWsTicket(ticket)
+	if err != nil || again.Id != parsed.Id {
+		t.Fatalf("second parse got %+v, %v", again, err)
+	}
+
+	next, err := provider.ParseWsTicket(other)
+	if err != nil || next.Id == parsed.Id {
+		t.Fatalf("two tickets share the id %q", parsed.Id)
+	}
+
+	for name, token := range map[string]string{"access token": access.AccessToken, "ticket signed with another key": foreign} {
+		if _, err := provider.ParseWsTicket(token); err == nil {
+			t.Fatalf("%s was accepted as a ws ticket", name)
+		}
+	}
+}
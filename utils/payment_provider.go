@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type PaymentProvider interface {
+	Name() string
+	CreateVirtualAccount(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error)
+	CreateEWalletCharge(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error)
+	VerifyCallback(body []byte, signature string) (*PaymentCallback, error)
+}
+
+type PaymentChargeRequest struct {
+	ReferenceId string
+	Channel     string
+	Amount      decimal.Decimal
+	ExpiredAt   time.Time
+}
+
+type PaymentCharge struct {
+	ExternalId    string
+	AccountNumber string
+	CheckoutUrl   string
+	Amount        decimal.Decimal
+	ExpiredAt     time.Time
+}
+
+type PaymentCallback struct {
+	CallbackId string          `json:"callback_id"`
+	ExternalId string          `json:"external_id"`
+	Status     string          `json:"status"`
+	Amount     decimal.Decimal `json:"amount"`
+	PaidAt     time.Time       `json:"paid_at"`
+}
+
+func NewPaymentProviders(config Config) map[string]PaymentProvider {
+	providers := map[string]PaymentProvider{}
+
+	manual := NewManualPaymentProvider(config)
+	providers[manual.Name()] = manual
+
+	if config.PaymentGateway == constants.FakePaymentProvider {
+		fake := NewFakePaymentProvider(config)
+		providers[fake.Name()] = fake
+	}
+
+	return providers
+}
+
+type ManualPaymentProvider struct {
+	config Config
+}
+
+func NewManualPaymentProvider(config Config) *ManualPaymentProvider {
+	return &ManualPaymentProvider{
+		config: config,
+	}
+}
+
+func (p *ManualPaymentProvider) Name() string {
+	return constants.ManualPaymentProvider
+}
+
+// CreateVirtualAccount hands out the company bank account. The user transfers
+// to it, uploads a payment proof and an admin approves the order by hand.
+// Every attempt gets its own external id since a checkout may be charged again.
+func (p *ManualPaymentProvider) CreateVirtualAccount(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error) {
+	return &PaymentCharge{
+		ExternalId:    fmt.Sprintf("%s-%s", req.ReferenceId, uuid.New().String()),
+		AccountNumber: fmt.Sprintf("%s %s", p.config.ManualBankName, p.config.ManualBankAccount),
+		Amount:        req.Amount,
+		ExpiredAt:     req.ExpiredAt,
+	}, nil
+}
+
+func (p *ManualPaymentProvider) CreateEWalletCharge(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error) {
+	return nil, custom_errors.PaymentMethodNotSupported()
+}
+
+func (p *ManualPaymentProvider) VerifyCallback(body []byte, signature string) (*PaymentCallback, error) {
+	return nil, custom_errors.PaymentMethodNotSupported()
+}
+
+// FakePaymentProvider behaves like a gateway without leaving the process.
+// Callbacks are signed with HMAC-SHA256 over the raw body using the callback key.
+type FakePaymentProvider struct {
+	config Config
+}
+
+func NewFakePaymentProvider(config Config) *FakePaymentProvider {
+	return &FakePaymentProvider{
+		config: config,
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return constants.FakePaymentProvider
+}
+
+func (p *FakePaymentProvider) CreateVirtualAccount(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error) {
+	externalId := uuid.New().String()
+
+	return &PaymentCharge{
+		ExternalId:    externalId,
+		AccountNumber: fmt.Sprintf("8808%012d", time.Now().UnixNano()%1000000000000),
+		Amount:        req.Amount,
+		ExpiredAt:     req.ExpiredAt,
+	}, nil
+}
+
+func (p *FakePaymentProvider) CreateEWalletCharge(ctx context.Context, req PaymentChargeRequest) (*PaymentCharge, error) {
+	externalId := uuid.New().String()
+
+	return &PaymentCharge{
+		ExternalId:  externalId,
+		CheckoutUrl: fmt.Sprintf("%s/payments/fake/%s", p.config.FrontendUrl, externalId),
+		Amount:      req.Amount,
+		ExpiredAt:   req.ExpiredAt,
+	}, nil
+}
+
+func (p *FakePaymentProvider) VerifyCallback(body []byte, signature string) (*PaymentCallback, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.sign(body)) {
+		return nil, custom_errors.InvalidCallbackSignature()
+	}
+
+	callback := PaymentCallback{}
+	err = json.Unmarshal(body, &callback)
+	if err != nil {
+		return nil, custom_errors.BadRequest(err, constants.ResponseMsgErrorInvalidRequest)
+	}
+
+	return &callback, nil
+}
+
+// SignCallback returns the signature the fake gateway would send for body.
+func (p *FakePaymentProvider) SignCallback(body []byte) string {
+	return hex.EncodeToString(p.sign(body))
+}
+
+func (p *FakePaymentProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.config.PaymentCallbackKey))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
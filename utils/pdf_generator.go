@@ -3,8 +3,10 @@ package utils
 import (
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
@@ -12,6 +14,7 @@ import (
 	"github.com/johnfercher/maroto/pkg/consts"
 	"github.com/johnfercher/maroto/pkg/pdf"
 	"github.com/johnfercher/maroto/pkg/props"
+	"github.com/shopspring/decimal"
 )
 
 func GenerateCertificatePdf(certificateData entities.CertificateData) (string, error) {
@@ -21,14 +24,7 @@ func GenerateCertificatePdf(certificateData entities.CertificateData) (string, e
 	buildHeading(m)
 	buildCertificate(m, certificateData)
 
-	fileName := fmt.Sprintf("pdfs/certificate-%d.pdf", certificateData.ConsultationId)
-	err := m.OutputFileAndClose(fileName)
-
-	if err != nil {
-		return "", err
-	}
-
-	return fileName, nil
+	return outputPdf(m, fmt.Sprintf("certificate-%d-*.pdf", certificateData.ConsultationId))
 }
 
 func GeneratePrescriptionPdf(prescriptionData entities.PrescriptionData) (string, error) {
@@ -38,10 +34,53 @@ func GeneratePrescriptionPdf(prescriptionData entities.PrescriptionData) (string
 	buildHeading(m)
 	buildPrescription(m, prescriptionData)
 
-	fileName := fmt.Sprintf("pdfs/prescription-%d.pdf", prescriptionData.ConsultationId)
-	err := m.OutputFileAndClose(fileName)
+	return outputPdf(m, fmt.Sprintf("prescription-%d-*.pdf", prescriptionData.ConsultationId))
+}
+
+func GenerateInvoicePdf(invoiceData entities.InvoiceData) (string, error) {
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	m.SetPageMargins(20, 10, 20)
+
+	buildHeading(m)
+	buildInvoice(m, invoiceData)
+
+	return outputPdf(m, fmt.Sprintf("invoice-%d-*.pdf", invoiceData.OrderId))
+}
+
+func GenerateMedicalRecordPdf(record entities.MedicalRecord) (string, error) {
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	m.SetPageMargins(20, 10, 20)
+
+	buildHeading(m)
+	buildMedicalRecord(m, record)
+
+	pattern := fmt.Sprintf("medical-record-%d-*.pdf", record.User.Id)
+	if record.Dependent != nil {
+		pattern = fmt.Sprintf("medical-record-%d-%d-*.pdf", record.User.Id, record.Dependent.Id)
+	}
+
+	return outputPdf(m, pattern)
+}
+
+// outputPdf writes the document to a temporary file of its own, so two
+// requests rendering the same document never overwrite each other's file.
+// The caller removes the file once it is uploaded.
+func outputPdf(m pdf.Maroto, pattern string) (string, error) {
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
 
+	fileName := file.Name()
+	err = file.Close()
 	if err != nil {
+		_ = os.Remove(fileName)
+		return "", err
+	}
+
+	err = m.OutputFileAndClose(fileName)
+	if err != nil {
+		_ = os.Remove(fileName)
 		return "", err
 	}
 
@@ -199,6 +238,8 @@ func buildCertificate(m pdf.Maroto, certificateData entities.CertificateData) {
 			})
 		})
 	})
+
+	buildVerification(m, certificateData.DocumentNumber, certificateData.VerifyUrl)
 }
 
 func buildPrescription(m pdf.Maroto, prescriptionData entities.PrescriptionData) {
@@ -263,19 +304,24 @@ func buildPrescription(m pdf.Maroto, prescriptionData entities.PrescriptionData)
 	})
 
 	contentsProducts := [][]string{}
+	notes := [][]string{}
 
-	for i := 0; i < len(prescriptionData.Products); i++ {
-		contentsProducts = append(contentsProducts, []string{prescriptionData.Products[i].Name, fmt.Sprintf("%d Per %s", prescriptionData.Quantities[i], prescriptionData.Products[i].SellingUnit)})
+	for _, item := range prescriptionData.Items {
+		contentsProducts = append(contentsProducts, []string{item.Product.Name, fmt.Sprintf("%d Per %s", item.Quantity, item.Product.SellingUnit), item.Dosage, item.Frequency, item.Duration, item.Route})
+
+		if item.Notes.Valid && item.Notes.String != "" {
+			notes = append(notes, []string{item.Product.Name, fmt.Sprintf(" : %s", item.Notes.String)})
+		}
 	}
 
-	m.TableList([]string{"Product Name", "Quantity"}, contentsProducts, props.TableList{
+	m.TableList([]string{"Product Name", "Quantity", "Dosage", "Frequency", "Duration", "Route"}, contentsProducts, props.TableList{
 		HeaderProp: props.TableListContent{
 			Size:      10,
-			GridSizes: []uint{6, 6},
+			GridSizes: []uint{3, 2, 2, 2, 2, 1},
 		},
 		ContentProp: props.TableListContent{
 			Size:      10,
-			GridSizes: []uint{6, 6},
+			GridSizes: []uint{3, 2, 2, 2, 2, 1},
 		},
 		Align:                  consts.Left,
 		HeaderContentSpace:     1,
@@ -283,6 +329,17 @@ func buildPrescription(m pdf.Maroto, prescriptionData entities.PrescriptionData)
 		VerticalContentPadding: 2,
 	})
 
+	if len(notes) > 0 {
+		buildSpacer(m)
+		buildSectionTitle(m, "Notes")
+		m.TableList(headings, notes, getInfoTableProps())
+	}
+
+	buildSpacer(m)
+
+	contents = [][]string{{"Valid Until", fmt.Sprintf(" : %s", formatDate(prescriptionData.ExpiresAt))}, {"Refills", fmt.Sprintf(" : %d", prescriptionData.Refills)}}
+	m.TableList(headings, contents, getInfoTableProps())
+
 	m.Row(5, func() {
 		m.Col(12, func() {
 		})
@@ -314,6 +371,236 @@ func buildPrescription(m pdf.Maroto, prescriptionData entities.PrescriptionData)
 			})
 		})
 	})
+
+	buildVerification(m, prescriptionData.DocumentNumber, prescriptionData.VerifyUrl)
+}
+
+func buildInvoice(m pdf.Maroto, invoiceData entities.InvoiceData) {
+	headings := getHeadings()
+
+	buildSectionTitle(m, "Invoice")
+
+	contents := [][]string{{"Order Number", fmt.Sprintf(" : %s", invoiceData.OrderNumber)}, {"Order Date", fmt.Sprintf(" : %s", formatDate(invoiceData.OrderedAt.Format("2006-01-02")))}, {"Order Status", fmt.Sprintf(" : %s", invoiceData.OrderStatus)}, {"Payment Status", fmt.Sprintf(" : %s", invoiceData.PaymentStatus)}}
+	m.TableList(headings, contents, getInfoTableProps())
+
+	buildSpacer(m)
+	buildSectionTitle(m, "Billed To")
+
+	userAddress := invoiceData.UserAddress
+	contents = [][]string{{"Name", fmt.Sprintf(" : %s", invoiceData.UserName)}, {"Email", fmt.Sprintf(" : %s", invoiceData.UserEmail)}, {"Address", fmt.Sprintf(" : %s, %s, %s, %s, %s %s", userAddress.Address, userAddress.SubDistrict, userAddress.District, userAddress.City, userAddress.Province, userAddress.PostalCode)}}
+	m.TableList(headings, contents, getInfoTableProps())
+
+	buildSpacer(m)
+	buildSectionTitle(m, "Sold By")
+
+	pharmacyAddress := invoiceData.PharmacyAddress
+	contents = [][]string{{"Pharmacy", fmt.Sprintf(" : %s", invoiceData.PharmacyName)}, {"Address", fmt.Sprintf(" : %s, %s, %s, %s, %s %s", pharmacyAddress.Address, pharmacyAddress.SubDistrict, pharmacyAddress.District, pharmacyAddress.City, pharmacyAddress.Province, pharmacyAddress.PostalCode)}, {"Shipping Method", fmt.Sprintf(" : %s", invoiceData.ShippingMethod)}}
+	m.TableList(headings, contents, getInfoTableProps())
+
+	buildSpacer(m)
+	buildSectionTitle(m, "Items")
+
+	contentsItems := [][]string{}
+	for _, item := range invoiceData.Items {
+		subtotal := item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))).Sub(item.Discount)
+		contentsItems = append(contentsItems, []string{item.Name, fmt.Sprintf("%d %s", item.Quantity, item.SellingUnit), formatRupiah(item.Price), formatRupiah(item.Discount), formatRupiah(subtotal)})
+	}
+
+	m.TableList([]string{"Product Name", "Quantity", "Price", "Discount", "Subtotal"}, contentsItems, props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{4, 2, 2, 2, 2},
+		},
+		ContentProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{4, 2, 2, 2, 2},
+		},
+		Align:                  consts.Left,
+		HeaderContentSpace:     1,
+		Line:                   true,
+		VerticalContentPadding: 2,
+	})
+
+	buildSpacer(m)
+
+	total := invoiceData.TotalPrice.Add(invoiceData.ShippingFee).Sub(invoiceData.Discount)
+	summary := [][]string{{"Subtotal", formatRupiah(invoiceData.TotalPrice)}, {"Shipping Fee", formatRupiah(invoiceData.ShippingFee)}, {"Discount", fmt.Sprintf("-%s", formatRupiah(invoiceData.Discount))}, {"Total", formatRupiah(total)}}
+
+	for i, row := range summary {
+		style := consts.Normal
+		if i == len(summary)-1 {
+			style = consts.Bold
+		}
+
+		m.Row(8, func() {
+			m.Col(8, func() {
+				m.Text(row[0], props.Text{
+					Size:   11,
+					Family: consts.Arial,
+					Style:  style,
+					Align:  consts.Right,
+				})
+			})
+			m.Col(4, func() {
+				m.Text(row[1], props.Text{
+					Size:   11,
+					Family: consts.Arial,
+					Style:  style,
+					Align:  consts.Right,
+				})
+			})
+		})
+	}
+}
+
+func buildMedicalRecord(m pdf.Maroto, record entities.MedicalRecord) {
+	headings := getHeadings()
+
+	buildSectionTitle(m, "Medical Record")
+
+	contents := [][]string{{"Name", fmt.Sprintf(" : %s", record.User.Name)}, {"Email", fmt.Sprintf(" : %s", record.User.Email)}}
+	if record.Dependent != nil {
+		dependent := record.Dependent
+		contents = [][]string{{"Name", fmt.Sprintf(" : %s", dependent.Name)}, {"Birth Date", fmt.Sprintf(" : %s", formatDate(dependent.BirthDate.Format("2006-01-02")))}, {"Gender", fmt.Sprintf(" : %s", dependent.Gender.Name)}, {"Account Holder", fmt.Sprintf(" : %s (%s)", record.User.Name, dependent.Relationship)}}
+	}
+	contents = append(contents, []string{"Exported On", fmt.Sprintf(" : %s", formatDate(time.Now().Format("2006-01-02")))})
+	m.TableList(headings, contents, getInfoTableProps())
+
+	buildSpacer(m)
+	buildSectionTitle(m, "Allergies and Chronic Conditions")
+
+	contents = [][]string{}
+	for _, condition := range record.Conditions {
+		contents = append(contents, []string{condition.Type, condition.Name, condition.Notes.String})
+	}
+	if len(contents) == 0 {
+		contents = append(contents, []string{"-", "-", "-"})
+	}
+
+	m.TableList([]string{"Type", "Name", "Notes"}, contents, props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{2, 4, 6},
+		},
+		ContentProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{2, 4, 6},
+		},
+		Align:                  consts.Left,
+		HeaderContentSpace:     1,
+		Line:                   true,
+		VerticalContentPadding: 2,
+	})
+
+	for _, entry := range record.Entries {
+		buildSpacer(m)
+		buildSectionTitle(m, fmt.Sprintf("Consultation on %s", formatDate(entry.ConsultedAt.Format("2006-01-02"))))
+
+		diagnosis := "-"
+		if entry.Diagnosis.Valid {
+			diagnosis = entry.Diagnosis.String
+		}
+
+		contents = [][]string{{"Patient", fmt.Sprintf(" : %s", entry.PatientName)}, {"Doctor", fmt.Sprintf(" : %s (%s)", entry.DoctorName, entry.DoctorSpecialist)}, {"Diagnosis", fmt.Sprintf(" : %s", diagnosis)}}
+		m.TableList(headings, contents, getInfoTableProps())
+
+		if len(entry.PrescriptionItems) == 0 {
+			continue
+		}
+
+		contentsProducts := [][]string{}
+		for _, item := range entry.PrescriptionItems {
+			contentsProducts = append(contentsProducts, []string{item.Product.Name, fmt.Sprintf("%d %s", item.Quantity, item.Product.SellingUnit), item.Dosage, item.Frequency, item.Duration})
+		}
+
+		m.TableList([]string{"Product Name", "Quantity", "Dosage", "Frequency", "Duration"}, contentsProducts, props.TableList{
+			HeaderProp: props.TableListContent{
+				Size:      10,
+				GridSizes: []uint{4, 2, 2, 2, 2},
+			},
+			ContentProp: props.TableListContent{
+				Size:      10,
+				GridSizes: []uint{4, 2, 2, 2, 2},
+			},
+			Align:                  consts.Left,
+			HeaderContentSpace:     1,
+			Line:                   true,
+			VerticalContentPadding: 2,
+		})
+	}
+}
+
+// buildVerification prints the document number next to a QR code of the
+// link anyone can open to check the document.
+func buildVerification(m pdf.Maroto, documentNumber string, verifyUrl string) {
+	if verifyUrl == "" {
+		return
+	}
+
+	buildSpacer(m)
+
+	m.Row(30, func() {
+		m.Col(3, func() {
+			m.QrCode(verifyUrl, props.Rect{
+				Percent: 100,
+			})
+		})
+		m.Col(9, func() {
+			m.Text(fmt.Sprintf("Document No. %s", documentNumber), props.Text{
+				Top:    8,
+				Size:   10,
+				Family: consts.Arial,
+				Style:  consts.Bold,
+			})
+			m.Text("Scan the QR code to verify this document.", props.Text{
+				Top:    14,
+				Size:   9,
+				Family: consts.Arial,
+			})
+		})
+	})
+}
+
+func buildSectionTitle(m pdf.Maroto, title string) {
+	m.SetBackgroundColor(getTealColor())
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(title, props.Text{
+				Top:    2,
+				Size:   13,
+				Color:  color.NewWhite(),
+				Family: consts.Arial,
+				Style:  consts.Bold,
+				Align:  consts.Center,
+			})
+		})
+	})
+
+	m.SetBackgroundColor(color.NewWhite())
+}
+
+func buildSpacer(m pdf.Maroto) {
+	m.Row(10, func() {
+		m.Col(12, func() {
+		})
+	})
+}
+
+func getInfoTableProps() props.TableList {
+	return props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{3, 9},
+		},
+		ContentProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{3, 9},
+		},
+		Align:                  consts.Left,
+		HeaderContentSpace:     1,
+		Line:                   false,
+		VerticalContentPadding: 2,
+	}
 }
 
 func getHeadings() []string {
@@ -333,3 +620,20 @@ func formatDate(timestamp string) string {
 	date, _ := strconv.Atoi(timeArr[2])
 	return fmt.Sprintf("%s %d %s", constants.MonthsMap[timeArr[1]], date, timeArr[0])
 }
+
+func formatRupiah(amount decimal.Decimal) string {
+	digits := amount.Abs().StringFixed(0)
+
+	var sb strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteRune('.')
+		}
+		sb.WriteRune(digit)
+	}
+
+	if amount.IsNegative() {
+		return fmt.Sprintf("-Rp%s", sb.String())
+	}
+	return fmt.Sprintf("Rp%s", sb.String())
+}
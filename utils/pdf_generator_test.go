@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerateInvoicePdfUsesItsOwnFile(t *testing.T) {
+	// the header logo is looked up from the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	invoiceData := entities.InvoiceData{
+		OrderId:     1,
+		OrderNumber: "INV-1",
+		OrderedAt:   time.Now(),
+		Items:       []entities.InvoiceItem{{Name: "Paracetamol", SellingUnit: "strip", Price: decimal.NewFromInt(10000), Quantity: 1}},
+	}
+
+	first, err := GenerateInvoicePdf(invoiceData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(first)
+
+	second, err := GenerateInvoicePdf(invoiceData)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(second)
+
+	if first == second {
+		t.Fatalf("both invoices of order 1 were written to %s", first)
+	}
+
+	for _, fileName := range []string{first, second} {
+		info, err := os.Stat(fileName)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("%s is empty", fileName)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type AppointmentWorkerOpts struct {
+	AppointmentUsecase usecases.AppointmentUsecase
+	Interval           time.Duration
+}
+
+type AppointmentWorker struct {
+	AppointmentUsecase usecases.AppointmentUsecase
+	Interval           time.Duration
+}
+
+func NewAppointmentWorker(awOpts *AppointmentWorkerOpts) *AppointmentWorker {
+	return &AppointmentWorker{
+		AppointmentUsecase: awOpts.AppointmentUsecase,
+		Interval:           awOpts.Interval,
+	}
+}
+
+// Run reminds both sides of upcoming appointments and opens the consultation
+// once a slot starts.
+func (w *AppointmentWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reminded, err := w.AppointmentUsecase.SendAppointmentReminders(ctx)
+			if err != nil {
+				log.Printf("appointment worker: %s", err.Error())
+				continue
+			}
+
+			if reminded > 0 {
+				log.Printf("appointment worker: reminded %d appointments", reminded)
+			}
+
+			started, err := w.AppointmentUsecase.StartDueAppointments(ctx)
+			if err != nil {
+				log.Printf("appointment worker: %s", err.Error())
+				continue
+			}
+
+			if started > 0 {
+				log.Printf("appointment worker: started %d appointments", started)
+			}
+		}
+	}
+}
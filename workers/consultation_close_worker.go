@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type ConsultationCloseWorkerOpts struct {
+	ConsultationUsecase usecases.ConsultationUsecase
+	Interval            time.Duration
+}
+
+type ConsultationCloseWorker struct {
+	ConsultationUsecase usecases.ConsultationUsecase
+	Interval            time.Duration
+}
+
+func NewConsultationCloseWorker(ccwOpts *ConsultationCloseWorkerOpts) *ConsultationCloseWorker {
+	return &ConsultationCloseWorker{
+		ConsultationUsecase: ccwOpts.ConsultationUsecase,
+		Interval:            ccwOpts.Interval,
+	}
+}
+
+// Run ends consultations that ran too long or were abandoned, so their rooms
+// do not stay open forever.
+func (w *ConsultationCloseWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closed, err := w.ConsultationUsecase.CloseInactiveConsultations(ctx)
+			if err != nil {
+				log.Printf("consultation close worker: %s", err.Error())
+				continue
+			}
+
+			if closed > 0 {
+				log.Printf("consultation close worker: ended %d consultations", closed)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type ConsultationQueueWorkerOpts struct {
+	ConsultationUsecase usecases.ConsultationUsecase
+	Interval            time.Duration
+}
+
+type ConsultationQueueWorker struct {
+	ConsultationUsecase usecases.ConsultationUsecase
+	Interval            time.Duration
+}
+
+func NewConsultationQueueWorker(cqwOpts *ConsultationQueueWorkerOpts) *ConsultationQueueWorker {
+	return &ConsultationQueueWorker{
+		ConsultationUsecase: cqwOpts.ConsultationUsecase,
+		Interval:            cqwOpts.Interval,
+	}
+}
+
+// Run passes consultation requests a doctor left unanswered to another online
+// doctor of the same specialist and fee, or expires them when nobody is available.
+// Requests never paid before their deadline are expired as well.
+func (w *ConsultationQueueWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reassigned, err := w.ConsultationUsecase.ReassignTimedOutConsultations(ctx)
+			if err != nil {
+				log.Printf("consultation queue worker: %s", err.Error())
+				continue
+			}
+
+			if reassigned > 0 {
+				log.Printf("consultation queue worker: reassigned %d timed out requests", reassigned)
+			}
+
+			expired, err := w.ConsultationUsecase.ExpireUnpaidConsultations(ctx)
+			if err != nil {
+				log.Printf("consultation queue worker: %s", err.Error())
+				continue
+			}
+
+			if expired > 0 {
+				log.Printf("consultation queue worker: expired %d unpaid requests", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type OrderAutoCompleteWorkerOpts struct {
+	OrderUsecase usecases.OrderUsecase
+	Interval     time.Duration
+}
+
+type OrderAutoCompleteWorker struct {
+	OrderUsecase usecases.OrderUsecase
+	Interval     time.Duration
+}
+
+func NewOrderAutoCompleteWorker(oacwOpts *OrderAutoCompleteWorkerOpts) *OrderAutoCompleteWorker {
+	return &OrderAutoCompleteWorker{
+		OrderUsecase: oacwOpts.OrderUsecase,
+		Interval:     oacwOpts.Interval,
+	}
+}
+
+// Run confirms shipped or delivered orders the user has not completed within
+// the configured number of days.
+func (w *OrderAutoCompleteWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			completed, err := w.OrderUsecase.CompleteShippedOrders(ctx)
+			if err != nil {
+				log.Printf("order auto complete worker: %s", err.Error())
+				continue
+			}
+
+			if completed > 0 {
+				log.Printf("order auto complete worker: completed %d orders", completed)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type OrderExpiryWorkerOpts struct {
+	OrderUsecase usecases.OrderUsecase
+	Interval     time.Duration
+}
+
+type OrderExpiryWorker struct {
+	OrderUsecase usecases.OrderUsecase
+	Interval     time.Duration
+}
+
+func NewOrderExpiryWorker(oewOpts *OrderExpiryWorkerOpts) *OrderExpiryWorker {
+	return &OrderExpiryWorker{
+		OrderUsecase: oewOpts.OrderUsecase,
+		Interval:     oewOpts.Interval,
+	}
+}
+
+// Run cancels unpaid orders past their payment deadline on every tick. Rows are
+// claimed with SKIP LOCKED, so several API instances can run it side by side.
+func (w *OrderExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			canceled, err := w.OrderUsecase.CancelExpiredOrders(ctx)
+			if err != nil {
+				log.Printf("order expiry worker: %s", err.Error())
+				continue
+			}
+
+			if canceled > 0 {
+				log.Printf("order expiry worker: canceled %d expired orders", canceled)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+)
+
+type WsTicketPruneWorkerOpts struct {
+	WsTicketUsecase usecases.WsTicketUsecase
+	Interval        time.Duration
+}
+
+type WsTicketPruneWorker struct {
+	WsTicketUsecase usecases.WsTicketUsecase
+	Interval        time.Duration
+}
+
+func NewWsTicketPruneWorker(wtpwOpts *WsTicketPruneWorkerOpts) *WsTicketPruneWorker {
+	return &WsTicketPruneWorker{
+		WsTicketUsecase: wtpwOpts.WsTicketUsecase,
+		Interval:        wtpwOpts.Interval,
+	}
+}
+
+// Run deletes the redemptions of expired ws tickets, so the table only holds
+// tickets that could still be replayed.
+func (w *WsTicketPruneWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := w.WsTicketUsecase.PruneExpiredTickets(ctx)
+			if err != nil {
+				log.Printf("ws ticket prune worker: %s", err.Error())
+				continue
+			}
+
+			if pruned > 0 {
+				log.Printf("ws ticket prune worker: pruned %d expired tickets", pruned)
+			}
+		}
+	}
+}
@@ -1,43 +1,77 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"time"
 
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
 	"github.com/gorilla/websocket"
 )
 
 type Client struct {
-	Conn     *websocket.Conn
-	Message  chan *Message
-	Id       string `json:"id"`
-	UserId   int64  `json:"user_id,omitempty"`
-	UserRole string `json:"user_role"`
-	RoomId   string `json:"roomId"`
+	Conn           *websocket.Conn
+	Message        chan *Message
+	Id             string `json:"id"`
+	UserId         int64  `json:"user_id,omitempty"`
+	UserRole       string `json:"user_role"`
+	RoomId         string `json:"roomId"`
+	ConsultationId int64  `json:"consultation_id,omitempty"`
 }
 
 type Message struct {
-	Content  string `json:"content"`
-	RoomId   string `json:"roomId"`
-	Id       string `json:"id"`
-	UserId   int64  `json:"user_id,omitempty"`
-	UserRole string `json:"user_role,omitempty"`
-	Type     string `json:"type"`
+	Content        string `json:"content"`
+	RoomId         string `json:"roomId"`
+	Id             string `json:"id"`
+	UserId         int64  `json:"user_id,omitempty"`
+	UserRole       string `json:"user_role,omitempty"`
+	Type           string `json:"type"`
+	ChatId         int64  `json:"chat_id,omitempty"`
+	ConsultationId int64  `json:"consultation_id,omitempty"`
+	RecipientId    int64  `json:"recipient_id,omitempty"`
+}
+
+// replay writes persisted messages straight to the connection. It must run
+// before writeMessage starts, as a connection supports only one writer.
+func (c *Client) replay(messages []*Message) error {
+	for _, m := range messages {
+		c.Conn.SetWriteDeadline(time.Now().Add(constants.WsWriteWait))
+		if err := c.Conn.WriteJSON(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *Client) writeMessage() {
+	ticker := time.NewTicker(constants.WsPingPeriod)
 	defer func() {
+		ticker.Stop()
 		c.Conn.Close()
 	}()
 
 	for {
-		message, ok := <-c.Message
-		if !ok {
-			return
-		}
+		select {
+		case message, ok := <-c.Message:
+			c.Conn.SetWriteDeadline(time.Now().Add(constants.WsWriteWait))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
 
-		c.Conn.WriteJSON(message)
+			if err := c.Conn.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(constants.WsWriteWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
 	}
 }
 
@@ -47,6 +81,12 @@ func (c *Client) readMessage(hub *Hub) {
 		c.Conn.Close()
 	}()
 
+	c.Conn.SetReadLimit(constants.WsMaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(constants.WsPongWait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(constants.WsPongWait))
+	})
+
 	for {
 		_, m, err := c.Conn.ReadMessage()
 		if err != nil {
@@ -56,6 +96,11 @@ func (c *Client) readMessage(hub *Hub) {
 			break
 		}
 
+		// the doctor subscription only receives, nobody talks in it
+		if c.RoomId == constants.DoctorRole {
+			continue
+		}
+
 		var data dtos.SentMessage
 
 		err = json.Unmarshal([]byte(string(m)), &data)
@@ -73,6 +118,40 @@ func (c *Client) readMessage(hub *Hub) {
 			Type:     data.Type,
 		}
 
+		switch data.Type {
+		case constants.TextChatType, constants.FileChatType:
+		case constants.WsEventTypingStart, constants.WsEventTypingStop:
+			msg.Content = ""
+		case constants.WsEventDelivered, constants.WsEventRead:
+			if err := c.saveReceipt(hub, data); err != nil {
+				log.Printf("error: %v", err)
+				continue
+			}
+			msg.Content = ""
+			msg.ChatId = data.ChatId
+		default:
+			// join, leave and consultation events are only ever sent by the
+			// server, so a client cannot end a room or fake a request
+			continue
+		}
+
 		hub.Broadcast <- msg
 	}
 }
+
+// saveReceipt persists a delivered or read event, which covers every chat
+// from the other participant up to the given chat id.
+func (c *Client) saveReceipt(hub *Hub, data dtos.SentMessage) error {
+	if c.ConsultationId == 0 || data.ChatId <= 0 {
+		return custom_errors.BadRequest(nil, constants.InvalidChatReceiptErrMsg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.WsWriteWait)
+	defer cancel()
+
+	if data.Type == constants.WsEventRead {
+		return hub.consultationUsecase.MarkChatsRead(ctx, c.ConsultationId, data.ChatId, c.UserRole)
+	}
+
+	return hub.consultationUsecase.MarkChatsDelivered(ctx, c.ConsultationId, data.ChatId, c.UserRole)
+}
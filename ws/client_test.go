@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/gorilla/websocket"
+)
+
+func TestClientReadMessage(t *testing.T) {
+	h := newTestHub()
+	h.Unregister = make(chan *Client, 1)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		cl := &Client{Conn: conn, Id: "1-user", UserId: 1, UserRole: constants.UserRole, RoomId: "consult-1"}
+		cl.readMessage(h)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sent := []dtos.SentMessage{
+		{Type: constants.ConsultationEventEnded},
+		{Type: constants.ConsultationEventRequested},
+		{Type: constants.WsEventJoin, Content: "user joined the room"},
+		{Type: constants.WsEventLeave, Content: "user left the chat"},
+		{Type: "unknown", Content: "hello"},
+		{Type: constants.TextChatType, Content: "hello"},
+		{Type: constants.WsEventTypingStart, Content: "ignored"},
+		{Type: constants.FileChatType, Content: "https://files.example/a.pdf"},
+	}
+	for _, m := range sent {
+		if err := conn.WriteJSON(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []*Message{
+		{Type: constants.TextChatType, Content: "hello"},
+		{Type: constants.WsEventTypingStart},
+		{Type: constants.FileChatType, Content: "https://files.example/a.pdf"},
+	}
+	for _, w := range want {
+		select {
+		case got := <-h.Broadcast:
+			if got.Type != w.Type || got.Content != w.Content || got.Id != "1-user" || got.RoomId != "consult-1" {
+				t.Fatalf("got %+v, want type %q with content %q", got, w.Type, w.Content)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", w.Type)
+		}
+	}
+
+	select {
+	case got := <-h.Broadcast:
+		t.Fatalf("unexpected broadcast %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,52 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
+)
+
+type ConsultationEventPublisherOpts struct {
+	PubSub PubSub
+}
+
+// ConsultationEventPublisher sends request updates to the doctor's
+// subscription and to everyone in the consultation room.
+type ConsultationEventPublisher struct {
+	pubSub PubSub
+}
+
+func NewConsultationEventPublisher(cepOpts *ConsultationEventPublisherOpts) *ConsultationEventPublisher {
+	return &ConsultationEventPublisher{
+		pubSub: cepOpts.PubSub,
+	}
+}
+
+func (p *ConsultationEventPublisher) PublishConsultationEvent(ctx context.Context, event entities.ConsultationEvent) error {
+	err := p.pubSub.Publish(ctx, &Message{
+		RoomId:         constants.DoctorRole,
+		Id:             constants.WsServerSenderId,
+		Type:           event.Type,
+		ConsultationId: event.ConsultationId,
+		UserId:         event.DoctorId,
+		UserRole:       constants.DoctorRole,
+		RecipientId:    event.DoctorId,
+		ChatId:         event.ChatId,
+		Content:        event.Content,
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.pubSub.Publish(ctx, &Message{
+		RoomId:         consultationRoomId(event.ConsultationId),
+		Id:             constants.WsServerSenderId,
+		Type:           event.Type,
+		ConsultationId: event.ConsultationId,
+		UserId:         event.DoctorId,
+		UserRole:       constants.DoctorRole,
+		ChatId:         event.ChatId,
+		Content:        event.Content,
+	})
+}
@@ -1,7 +1,15 @@
 package ws
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
+	"github.com/google/uuid"
 )
 
 type Room struct {
@@ -11,14 +19,30 @@ type Room struct {
 	DoctorId int64              `json:"doctor_id,omitempty"`
 }
 
+type HubOpts struct {
+	PubSub              PubSub
+	ConsultationUsecase usecases.ConsultationUsecase
+	DoctorUsecase       usecases.DoctorUsecase
+}
+
+// Hub owns every room on this instance. Rooms and their client sets are only
+// touched while holding mu, so HTTP handlers and Run can share them safely.
 type Hub struct {
-	Rooms      map[string]*Room
-	Register   chan *Client
-	Unregister chan *Client
-	Broadcast  chan *Message
+	mu                  sync.RWMutex
+	rooms               map[string]*Room
+	Register            chan *Client
+	Unregister          chan *Client
+	Broadcast           chan *Message
+	pubSub              PubSub
+	consultationUsecase usecases.ConsultationUsecase
+	doctorUsecase       usecases.DoctorUsecase
+	instanceId          string
+	doctorConns         map[int64]int
+	presenceChanges     map[int64]bool
+	presence            chan struct{}
 }
 
-func NewHub() *Hub {
+func NewHub(hOpts *HubOpts) *Hub {
 	initialRooms := make(map[string]*Room)
 	initialRooms[constants.DoctorRole] = &Room{
 		Id:      constants.DoctorRole,
@@ -26,50 +50,298 @@ func NewHub() *Hub {
 	}
 
 	return &Hub{
-		Rooms:      initialRooms,
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
-		Broadcast:  make(chan *Message),
+		rooms:               initialRooms,
+		Register:            make(chan *Client),
+		Unregister:          make(chan *Client),
+		Broadcast:           make(chan *Message),
+		pubSub:              hOpts.PubSub,
+		consultationUsecase: hOpts.ConsultationUsecase,
+		doctorUsecase:       hOpts.DoctorUsecase,
+		instanceId:          uuid.New().String(),
+		doctorConns:         make(map[int64]int),
+		presenceChanges:     make(map[int64]bool),
+		presence:            make(chan struct{}, 1),
+	}
+}
+
+func consultationRoomId(consultationId int64) string {
+	return fmt.Sprintf("consult-%d", consultationId)
+}
+
+// RebuildRooms recreates a room for every consultation that has not ended, so
+// a restarted instance accepts joins the same way the previous one did.
+func (h *Hub) RebuildRooms(ctx context.Context) error {
+	consultations, err := h.consultationUsecase.GetAllActiveConsultation(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range consultations {
+		h.FindOrCreateRoom(&Room{
+			Id:       consultationRoomId(c.Id),
+			Clients:  make(map[string]*Client),
+			UserId:   c.User.Id,
+			DoctorId: c.Doctor.Id,
+		})
 	}
+
+	return nil
 }
 
-func (h *Hub) Run() {
+// CreateRoom adds room and reports false when a room with the same id exists.
+func (h *Hub) CreateRoom(room *Room) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.rooms[room.Id]; ok {
+		return false
+	}
+
+	h.rooms[room.Id] = room
+	return true
+}
+
+// FindOrCreateRoom returns the room registered under room.Id, adding room
+// first when there is none.
+func (h *Hub) FindOrCreateRoom(room *Room) *Room {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if existing, ok := h.rooms[room.Id]; ok {
+		return existing
+	}
+
+	h.rooms[room.Id] = room
+	return room
+}
+
+func (h *Hub) GetRooms() []*Room {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rooms := make([]*Room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+
+	return rooms
+}
+
+func (h *Hub) GetClients(roomId string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*Client, 0)
+
+	r, ok := h.rooms[roomId]
+	if !ok {
+		return clients
+	}
+
+	for _, cl := range r.Clients {
+		clients = append(clients, cl)
+	}
+
+	return clients
+}
+
+// Run serves registrations and delivers messages coming back from the pub/sub
+// backend to the clients connected to this instance.
+func (h *Hub) Run(ctx context.Context) {
+	go h.publish(ctx)
+	go h.trackPresence(ctx)
+
+	received := h.pubSub.Subscribe(ctx)
+
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case cl := <-h.Register:
-			if _, ok := h.Rooms[cl.RoomId]; ok {
-				r := h.Rooms[cl.RoomId]
-
-				if _, ok := r.Clients[cl.Id]; !ok {
-					r.Clients[cl.Id] = cl
-				}
-			}
+			h.register(cl)
 		case cl := <-h.Unregister:
-			if _, ok := h.Rooms[cl.RoomId]; ok {
-				if _, ok := h.Rooms[cl.RoomId].Clients[cl.Id]; ok {
-					if len(h.Rooms[cl.RoomId].Clients) != 0 {
-
-						h.Broadcast <- &Message{
-							Content:  "user left the chat",
-							RoomId:   cl.RoomId,
-							Id:       cl.Id,
-							UserId:   cl.UserId,
-							UserRole: cl.UserRole,
-							Type:     "read",
-						}
-					}
-
-					delete(h.Rooms[cl.RoomId].Clients, cl.Id)
-					close(cl.Message)
-				}
+			h.unregister(cl)
+		case m, ok := <-received:
+			if !ok {
+				return
 			}
+			h.deliver(m)
+		}
+	}
+}
+
+func (h *Hub) register(cl *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[cl.RoomId]
+	if !ok {
+		close(cl.Message)
+		return
+	}
+
+	// a reconnect replaces the stale connection of the same participant
+	if existing, ok := r.Clients[cl.Id]; ok && existing != cl {
+		h.dropClient(r, existing)
+	}
+
+	r.Clients[cl.Id] = cl
+
+	if r.Id == constants.DoctorRole && cl.UserId != 0 {
+		h.doctorConns[cl.UserId]++
+		if h.doctorConns[cl.UserId] == 1 {
+			h.notePresence(cl.UserId, true)
+		}
+	}
+}
 
+// dropClient removes cl from r and closes its channel. The caller holds mu.
+func (h *Hub) dropClient(r *Room, cl *Client) {
+	delete(r.Clients, cl.Id)
+	close(cl.Message)
+
+	if r.Id == constants.DoctorRole && cl.UserId != 0 {
+		h.doctorConns[cl.UserId]--
+		if h.doctorConns[cl.UserId] <= 0 {
+			delete(h.doctorConns, cl.UserId)
+			h.notePresence(cl.UserId, false)
+		}
+	}
+}
+
+// notePresence records the latest presence of a doctor and wakes
+// trackPresence without waiting on it. Changes made before it catches up are
+// coalesced, so only the final state of each doctor is written. The caller
+// holds mu.
+func (h *Hub) notePresence(doctorId int64, online bool) {
+	h.presenceChanges[doctorId] = online
+
+	select {
+	case h.presence <- struct{}{}:
+	default:
+	}
+}
+
+func (h *Hub) takePresenceChanges() map[int64]bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changes := h.presenceChanges
+	h.presenceChanges = make(map[int64]bool)
+
+	return changes
+}
+
+func (h *Hub) unregister(cl *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[cl.RoomId]
+	if !ok {
+		return
+	}
+
+	if existing, ok := r.Clients[cl.Id]; !ok || existing != cl {
+		return
+	}
+
+	h.dropClient(r, cl)
+
+	if len(r.Clients) != 0 && r.Id != constants.DoctorRole {
+		m := &Message{
+			Content:  "user left the chat",
+			RoomId:   cl.RoomId,
+			Id:       cl.Id,
+			UserId:   cl.UserId,
+			UserRole: cl.UserRole,
+			Type:     constants.WsEventLeave,
+		}
+
+		go func() {
+			h.Broadcast <- m
+		}()
+	}
+}
+
+// deliver never blocks on a client: one whose buffer is full is dropped from
+// the room and its connection closed, so it cannot stall everyone else.
+func (h *Hub) deliver(m *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[m.RoomId]
+	if !ok {
+		return
+	}
+
+	for _, cl := range r.Clients {
+		if m.RecipientId != 0 && cl.UserId != m.RecipientId {
+			continue
+		}
+
+		select {
+		case cl.Message <- m:
+		default:
+			log.Printf("ws: evicting slow client %s from room %s", cl.Id, r.Id)
+			h.dropClient(r, cl)
+		}
+	}
+
+	if m.Type == constants.ConsultationEventEnded && m.Id == constants.WsServerSenderId && r.Id != constants.DoctorRole {
+		h.closeRoom(r)
+	}
+}
+
+// closeRoom disconnects everyone left in r and forgets the room. Clients still
+// get the messages queued before it. The caller holds mu.
+func (h *Hub) closeRoom(r *Room) {
+	for _, cl := range r.Clients {
+		h.dropClient(r, cl)
+	}
+
+	delete(h.rooms, r.Id)
+}
+
+func (h *Hub) publish(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
 		case m := <-h.Broadcast:
-			if _, ok := h.Rooms[m.RoomId]; ok {
+			if err := h.pubSub.Publish(ctx, m); err != nil {
+				log.Printf("ws pubsub: %v", err)
+			}
+		}
+	}
+}
 
-				for _, cl := range h.Rooms[m.RoomId].Clients {
-					cl.Message <- m
+// trackPresence writes the doctor presence changes noted by the hub for this
+// instance, and refreshes it on a heartbeat so other instances can tell this
+// one is still alive.
+func (h *Hub) trackPresence(ctx context.Context) {
+	ticker := time.NewTicker(constants.DoctorPresenceHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.presence:
+			for doctorId, online := range h.takePresenceChanges() {
+				var err error
+				if online {
+					err = h.doctorUsecase.ConnectDoctor(ctx, doctorId, h.instanceId)
+				} else {
+					err = h.doctorUsecase.DisconnectDoctor(ctx, doctorId, h.instanceId)
 				}
+
+				if err != nil {
+					log.Printf("ws presence: %v", err)
+				}
+			}
+		case <-ticker.C:
+			if err := h.doctorUsecase.RefreshDoctorPresence(ctx, h.instanceId); err != nil {
+				log.Printf("ws presence: %v", err)
 			}
 		}
 	}
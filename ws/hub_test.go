@@ -0,0 +1,256 @@
+package ws
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+)
+
+func newTestHub() *Hub {
+	return NewHub(&HubOpts{PubSub: NewMemoryPubSub()})
+}
+
+func newTestClient(id string, roomId string, userId int64, buffer int) *Client {
+	return &Client{
+		Message: make(chan *Message, buffer),
+		Id:      id,
+		UserId:  userId,
+		RoomId:  roomId,
+	}
+}
+
+func isClosed(ch chan *Message) bool {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+func TestHubRegister(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        func(h *Hub) []*Client
+		wantClients  int
+		wantClosed   []bool
+		wantPresence map[int64]bool
+	}{
+		{
+			name: "closes a client joining an unknown room",
+			steps: func(h *Hub) []*Client {
+				cl := newTestClient("u-1", "consult-1", 1, 1)
+				h.register(cl)
+				return []*Client{cl}
+			},
+			wantClients:  0,
+			wantClosed:   []bool{true},
+			wantPresence: map[int64]bool{},
+		},
+		{
+			name: "replaces the stale connection on reconnect",
+			steps: func(h *Hub) []*Client {
+				stale := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				fresh := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				h.register(stale)
+				h.register(fresh)
+				return []*Client{stale, fresh}
+			},
+			wantClients:  1,
+			wantClosed:   []bool{true, false},
+			wantPresence: map[int64]bool{1: true},
+		},
+		{
+			name: "keeps a doctor online while one connection is left",
+			steps: func(h *Hub) []*Client {
+				first := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				second := newTestClient("d-2", constants.DoctorRole, 1, 1)
+				h.register(first)
+				h.register(second)
+				h.unregister(first)
+				return []*Client{first, second}
+			},
+			wantClients:  1,
+			wantClosed:   []bool{true, false},
+			wantPresence: map[int64]bool{1: true},
+		},
+		{
+			name: "takes a doctor offline with the last connection",
+			steps: func(h *Hub) []*Client {
+				cl := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				h.register(cl)
+				h.unregister(cl)
+				return []*Client{cl}
+			},
+			wantClients:  0,
+			wantClosed:   []bool{true},
+			wantPresence: map[int64]bool{1: false},
+		},
+		{
+			name: "ignores the unregister of a replaced connection",
+			steps: func(h *Hub) []*Client {
+				stale := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				fresh := newTestClient("d-1", constants.DoctorRole, 1, 1)
+				h.register(stale)
+				h.register(fresh)
+				h.unregister(stale)
+				return []*Client{stale, fresh}
+			},
+			wantClients:  1,
+			wantClosed:   []bool{true, false},
+			wantPresence: map[int64]bool{1: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHub()
+			clients := tt.steps(h)
+
+			if got := len(h.GetClients(constants.DoctorRole)); got != tt.wantClients {
+				t.Fatalf("got %d clients, want %d", got, tt.wantClients)
+			}
+
+			for i, cl := range clients {
+				if got := isClosed(cl.Message); got != tt.wantClosed[i] {
+					t.Fatalf("client %d closed = %v, want %v", i, got, tt.wantClosed[i])
+				}
+			}
+
+			presence := h.takePresenceChanges()
+			if len(presence) != len(tt.wantPresence) {
+				t.Fatalf("got presence %v, want %v", presence, tt.wantPresence)
+			}
+			for doctorId, online := range tt.wantPresence {
+				if got, ok := presence[doctorId]; !ok || got != online {
+					t.Fatalf("got presence %v, want %v", presence, tt.wantPresence)
+				}
+			}
+		})
+	}
+}
+
+func TestHubDeliverEvictsSlowClient(t *testing.T) {
+	h := newTestHub()
+	h.CreateRoom(&Room{Id: "consult-1", Clients: make(map[string]*Client)})
+
+	fast := newTestClient("u-1", "consult-1", 1, 2)
+	slow := newTestClient("d-1", "consult-1", 2, 1)
+	h.register(fast)
+	h.register(slow)
+
+	h.deliver(&Message{RoomId: "consult-1", Content: "first"})
+	h.deliver(&Message{RoomId: "consult-1", Content: "second"})
+
+	if got := len(fast.Message); got != 2 {
+		t.Fatalf("fast client got %d messages, want 2", got)
+	}
+
+	clients := h.GetClients("consult-1")
+	if len(clients) != 1 || clients[0] != fast {
+		t.Fatalf("slow client was not evicted: %v", clients)
+	}
+
+	if !isClosed(slow.Message) {
+		t.Fatal("slow client channel was not closed")
+	}
+}
+
+// Registrations must not wait on the presence writer, which is not running
+// here at all.
+func TestHubRegisterDoesNotBlockOnPresence(t *testing.T) {
+	h := newTestHub()
+	doctors := constants.WsPubSubBufferSize * 4
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= doctors; i++ {
+			wg.Add(1)
+			go func(doctorId int64) {
+				defer wg.Done()
+
+				cl := newTestClient(fmt.Sprintf("d-%d", doctorId), constants.DoctorRole, doctorId, 1)
+				h.register(cl)
+				h.deliver(&Message{RoomId: constants.DoctorRole, RecipientId: doctorId})
+				h.GetClients(constants.DoctorRole)
+				h.unregister(cl)
+			}(int64(i))
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hub blocked while registering doctors")
+	}
+
+	presence := h.takePresenceChanges()
+	if len(presence) != doctors {
+		t.Fatalf("got %d presence changes, want %d", len(presence), doctors)
+	}
+	for doctorId, online := range presence {
+		if online {
+			t.Fatalf("doctor %d is still online", doctorId)
+		}
+	}
+}
+
+func TestHubDeliverClosesEndedRoom(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    *Message
+		wantClosed bool
+	}{
+		{
+			name:       "consultation ended by the server",
+			message:    &Message{RoomId: "consult-1", Id: constants.WsServerSenderId, Type: constants.ConsultationEventEnded},
+			wantClosed: true,
+		},
+		{
+			name:    "consultation ended sent by a participant",
+			message: &Message{RoomId: "consult-1", Id: "1-user", Type: constants.ConsultationEventEnded},
+		},
+		{
+			name:    "chat from a participant",
+			message: &Message{RoomId: "consult-1", Id: "1-user", Type: constants.TextChatType, Content: "hello"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHub()
+			h.CreateRoom(&Room{Id: "consult-1", Clients: make(map[string]*Client)})
+
+			user := newTestClient("1-user", "consult-1", 1, 2)
+			doctor := newTestClient("2-doctor", "consult-1", 2, 2)
+			h.register(user)
+			h.register(doctor)
+
+			h.deliver(tt.message)
+
+			if got := <-doctor.Message; got != tt.message {
+				t.Fatalf("doctor got %v, want the delivered message", got)
+			}
+
+			if got := isClosed(doctor.Message); got != tt.wantClosed {
+				t.Fatalf("doctor closed = %v, want %v", got, tt.wantClosed)
+			}
+
+			_, roomExists := h.rooms["consult-1"]
+			if roomExists == tt.wantClosed {
+				t.Fatalf("room exists = %v, want %v", roomExists, !tt.wantClosed)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package ws
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	qNotify = `SELECT pg_notify($1, $2);`
+)
+
+type PostgresPubSubOpts struct {
+	Db      *sql.DB
+	DbUrl   string
+	Channel string
+}
+
+// PostgresPubSub publishes through NOTIFY on the shared pool and listens on a
+// dedicated connection, since a LISTEN must outlive any pooled query.
+// Payloads are bound by Postgres' 8000 byte NOTIFY limit.
+type PostgresPubSub struct {
+	db      *sql.DB
+	dbUrl   string
+	channel string
+}
+
+func NewPostgresPubSub(ppsOpts *PostgresPubSubOpts) *PostgresPubSub {
+	return &PostgresPubSub{
+		db:      ppsOpts.Db,
+		dbUrl:   ppsOpts.DbUrl,
+		channel: ppsOpts.Channel,
+	}
+}
+
+func (p *PostgresPubSub) Publish(ctx context.Context, m *Message) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.ExecContext(ctx, qNotify, p.channel, string(payload))
+	return err
+}
+
+func (p *PostgresPubSub) Subscribe(ctx context.Context) <-chan *Message {
+	sub := make(chan *Message, constants.WsPubSubBufferSize)
+
+	go func() {
+		defer close(sub)
+
+		for {
+			err := p.listen(ctx, sub)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("ws pubsub: %v, reconnecting", err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(constants.WsPubSubReconnectDelay):
+			}
+		}
+	}()
+
+	return sub
+}
+
+func (p *PostgresPubSub) listen(ctx context.Context, sub chan<- *Message) error {
+	conn, err := pgx.Connect(ctx, p.dbUrl)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize())
+	if err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var m Message
+		if err := json.Unmarshal([]byte(n.Payload), &m); err != nil {
+			log.Printf("ws pubsub: %v", err)
+			continue
+		}
+
+		select {
+		case sub <- &m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
+)
+
+// PubSub fans messages out to every hub subscribed to it, including the hub
+// that published them, so rooms spread over several instances see the same
+// stream.
+type PubSub interface {
+	Publish(ctx context.Context, m *Message) error
+	Subscribe(ctx context.Context) <-chan *Message
+}
+
+type MemoryPubSub struct {
+	mu          sync.Mutex
+	subscribers []chan *Message
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{}
+}
+
+func (p *MemoryPubSub) Publish(ctx context.Context, m *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, sub := range p.subscribers {
+		select {
+		case sub <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (p *MemoryPubSub) Subscribe(ctx context.Context) <-chan *Message {
+	sub := make(chan *Message, constants.WsPubSubBufferSize)
+
+	p.mu.Lock()
+	p.subscribers = append(p.subscribers, sub)
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		for i, s := range p.subscribers {
+			if s == sub {
+				p.subscribers = append(p.subscribers[:i], p.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(sub)
+	}()
+
+	return sub
+}
@@ -3,11 +3,14 @@ package ws
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/tsanaativa/sehatin-backend-v0.1/constants"
 	"github.com/tsanaativa/sehatin-backend-v0.1/custom_errors"
 	"github.com/tsanaativa/sehatin-backend-v0.1/dtos"
+	"github.com/tsanaativa/sehatin-backend-v0.1/entities"
 	"github.com/tsanaativa/sehatin-backend-v0.1/usecases"
 	"github.com/tsanaativa/sehatin-backend-v0.1/utils"
 	"github.com/gin-gonic/gin"
@@ -18,23 +21,56 @@ import (
 type WebSocketHandlerOpts struct {
 	Hub                 *Hub
 	ConsultationUsecase usecases.ConsultationUsecase
+	WsTicketUsecase     usecases.WsTicketUsecase
+	AuthTokenProvider   utils.AuthTokenProvider
+	AllowedOrigin       string
 }
 
 type WebSocketHandler struct {
 	hub                 *Hub
 	ConsultationUsecase usecases.ConsultationUsecase
+	WsTicketUsecase     usecases.WsTicketUsecase
+	AuthTokenProvider   utils.AuthTokenProvider
+	upgrader            websocket.Upgrader
 }
 
 func NewWebSocketHandler(wshOpts *WebSocketHandlerOpts) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:                 wshOpts.Hub,
 		ConsultationUsecase: wshOpts.ConsultationUsecase,
+		WsTicketUsecase:     wshOpts.WsTicketUsecase,
+		AuthTokenProvider:   wshOpts.AuthTokenProvider,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(wshOpts.AllowedOrigin),
+		},
+	}
+}
+
+// checkOrigin only lets browsers connect from the frontend. A request without
+// an Origin header is only let through when it brings its own Authorization
+// header, which browsers cannot set on a WebSocket handshake.
+func checkOrigin(allowedOrigin string) func(r *http.Request) bool {
+	allowed, _ := url.Parse(allowedOrigin)
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return r.Header.Get("Authorization") != ""
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || allowed == nil {
+			return false
+		}
+
+		return strings.EqualFold(u.Scheme, allowed.Scheme) && strings.EqualFold(u.Host, allowed.Host)
 	}
 }
 
 type CreateRoomRequest struct {
-	Id       int64 `json:"id"`
-	DoctorId int64 `json:"doctor_id"`
+	Id int64 `json:"id"`
 }
 
 func (h *WebSocketHandler) CreateRoom(ctx *gin.Context) {
@@ -51,22 +87,45 @@ func (h *WebSocketHandler) CreateRoom(ctx *gin.Context) {
 	}
 	userId := int64(id)
 
-	roomId := fmt.Sprintf("consult-%d", payload.Id)
+	consultation, err := h.ConsultationUsecase.GetConsultationById(ctx, payload.Id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-	_, exists := h.hub.Rooms[roomId]
-	if exists {
-		ctx.Error(custom_errors.BadRequest(custom_errors.ErrRoomAlreadyExists, constants.RoomNotUniqueErrMsg))
+	if consultation.User.Id != userId {
+		ctx.Error(custom_errors.Forbidden())
+		return
+	}
+
+	if consultation.Status == constants.ConsultationStatusUnpaid {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationNotPaidErrMsg))
+		return
+	}
+
+	if consultation.Status == constants.ConsultationStatusScheduled {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationNotStartedErrMsg))
+		return
+	}
+
+	if consultation.EndedAt.Valid {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationEndedErrMsg))
 		return
 	}
 
+	roomId := consultationRoomId(payload.Id)
+
 	room := &Room{
 		Id:       roomId,
 		Clients:  make(map[string]*Client),
 		UserId:   userId,
-		DoctorId: payload.DoctorId,
+		DoctorId: consultation.Doctor.Id,
 	}
 
-	h.hub.Rooms[roomId] = room
+	if !h.hub.CreateRoom(room) {
+		ctx.Error(custom_errors.BadRequest(custom_errors.ErrRoomAlreadyExists, constants.RoomNotUniqueErrMsg))
+		return
+	}
 
 	ctx.JSON(http.StatusOK, RoomResponse{
 		Id:       room.Id,
@@ -75,203 +134,267 @@ func (h *WebSocketHandler) CreateRoom(ctx *gin.Context) {
 	})
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+type TicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"`
 }
 
-func (h *WebSocketHandler) JoinRoomAsUser(ctx *gin.Context) {
-	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
-	if err != nil {
-		ctx.Error(err)
-		return
-	}
-
-	userIdParam, err := strconv.Atoi(ctx.Param("id"))
+func (h *WebSocketHandler) CreateTicket(ctx *gin.Context) {
+	data, err := utils.GetDataFromContext(ctx)
 	if err != nil {
 		ctx.Error(err)
-		conn.Close()
 		return
 	}
 
-	userId := int64(userIdParam)
-
-	consultationIdParam, err := strconv.Atoi(ctx.Param("consultationId"))
+	ticket, err := h.AuthTokenProvider.CreateWsTicket(map[string]interface{}{
+		constants.Id:   data.Id,
+		constants.Role: data.Role,
+	})
 	if err != nil {
 		ctx.Error(err)
-		conn.Close()
 		return
 	}
 
-	consultationId := int64(consultationIdParam)
+	ctx.JSON(http.StatusOK, dtos.ResponseMessage{
+		Message: constants.ResponseMsgCreated,
+		Data: TicketResponse{
+			Ticket:    ticket,
+			ExpiresIn: int(constants.WsTicketDuration.Seconds()),
+		},
+	})
+}
 
-	consultation, err := h.ConsultationUsecase.GetConsultationById(ctx, consultationId)
-	if err != nil {
-		ctx.Error(err)
-		conn.Close()
-		return
+// authorize reads the caller from the Authorization header, the access token
+// cookie or a ticket query parameter, in that order.
+func (h *WebSocketHandler) authorize(ctx *gin.Context) (*utils.ClaimsData, error) {
+	token, err := h.AuthTokenProvider.GetToken(ctx)
+	if err == nil {
+		return h.AuthTokenProvider.ParseClaimsData(token)
 	}
 
-	roomId := fmt.Sprintf("consult-%d", consultationId)
-
-	room, exists := h.hub.Rooms[roomId]
-	if !exists {
-		newRoom := &Room{
-			Id:       roomId,
-			Clients:  make(map[string]*Client),
-			UserId:   userId,
-			DoctorId: consultation.Doctor.Id,
-		}
-
-		h.hub.Rooms[roomId] = newRoom
-		room = newRoom
+	token, err = ctx.Cookie(constants.AccessToken)
+	if err == nil && token != "" {
+		return h.AuthTokenProvider.ParseClaimsData(token)
 	}
 
-	if room.UserId != userId {
-		ctx.Error(custom_errors.InvalidAuthToken())
-		conn.Close()
-		return
+	ticket, ok := ctx.GetQuery(constants.WsTicket)
+	if ok {
+		return h.WsTicketUsecase.RedeemTicket(ctx, ticket)
 	}
 
-	userRole := constants.UserRole
-	clientId := fmt.Sprintf("%d-%s", userId, userRole)
-
-	cl := &Client{
-		Conn:     conn,
-		Message:  make(chan *Message, 10),
-		Id:       clientId,
-		UserId:   userId,
-		UserRole: userRole,
-		RoomId:   roomId,
-	}
-
-	m := &Message{
-		Content:  "user has joined the room",
-		RoomId:   roomId,
-		Id:       clientId,
-		UserId:   userId,
-		UserRole: userRole,
-		Type:     "read",
-	}
-
-	h.hub.Register <- cl
-	h.hub.Broadcast <- m
+	return nil, custom_errors.InvalidAuthToken()
+}
 
-	go cl.writeMessage()
-	cl.readMessage(h.hub)
+func (h *WebSocketHandler) JoinRoomAsUser(ctx *gin.Context) {
+	h.joinConsultationRoom(ctx, constants.UserRole)
 }
 
 func (h *WebSocketHandler) JoinRoomAsDoctor(ctx *gin.Context) {
-	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	h.joinConsultationRoom(ctx, constants.DoctorRole)
+}
+
+// joinConsultationRoom checks everything before upgrading, so a rejected join
+// still gets a regular HTTP error response.
+func (h *WebSocketHandler) joinConsultationRoom(ctx *gin.Context, role string) {
+	data, err := h.authorize(ctx)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	doctorIdParam, err := strconv.Atoi(ctx.Param("id"))
+	id, err := utils.GetIdParamOrContext(ctx, constants.Id)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	doctorId := int64(doctorIdParam)
+	if data.Role != role || data.Id != int64(id) {
+		ctx.Error(custom_errors.Forbidden())
+		return
+	}
 
 	consultationIdParam, err := strconv.Atoi(ctx.Param("consultationId"))
 	if err != nil {
-		ctx.Error(err)
+		ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
 		return
 	}
 
 	consultationId := int64(consultationIdParam)
 
+	lastSeenId, replay, err := parseLastSeenMessageId(ctx)
+	if err != nil {
+		ctx.Error(custom_errors.BadRequest(err, constants.InvalidIntegerInputErrMsg))
+		return
+	}
+
 	consultation, err := h.ConsultationUsecase.GetConsultationById(ctx, consultationId)
 	if err != nil {
 		ctx.Error(err)
-		conn.Close()
 		return
 	}
 
-	roomId := fmt.Sprintf("consult-%d", consultationId)
+	memberId := consultation.User.Id
+	if role == constants.DoctorRole {
+		memberId = consultation.Doctor.Id
+	}
 
-	room, exists := h.hub.Rooms[roomId]
-	if !exists {
-		newRoom := &Room{
-			Id:       roomId,
-			Clients:  make(map[string]*Client),
-			UserId:   consultation.User.Id,
-			DoctorId: doctorId,
-		}
+	if memberId != data.Id {
+		ctx.Error(custom_errors.Forbidden())
+		return
+	}
 
-		h.hub.Rooms[roomId] = newRoom
-		room = newRoom
+	if role == constants.DoctorRole && consultation.Status != constants.ConsultationStatusAccepted {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationNotAcceptedErrMsg))
+		return
 	}
 
-	if room.DoctorId != int64(doctorId) {
-		ctx.Error(custom_errors.InvalidAuthToken())
-		conn.Close()
+	if consultation.Status == constants.ConsultationStatusUnpaid {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationNotPaidErrMsg))
 		return
 	}
 
-	userRole := constants.DoctorRole
-	clientId := fmt.Sprintf("%d-%s", doctorId, userRole)
+	if consultation.Status == constants.ConsultationStatusScheduled {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationNotStartedErrMsg))
+		return
+	}
+
+	if consultation.EndedAt.Valid {
+		ctx.Error(custom_errors.BadRequest(nil, constants.ConsultationEndedErrMsg))
+		return
+	}
+
+	roomId := consultationRoomId(consultationId)
+
+	h.hub.FindOrCreateRoom(&Room{
+		Id:       roomId,
+		Clients:  make(map[string]*Client),
+		UserId:   consultation.User.Id,
+		DoctorId: consultation.Doctor.Id,
+	})
+
+	conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	clientId := fmt.Sprintf("%d-%s", data.Id, role)
 
 	cl := &Client{
-		Conn:     conn,
-		Message:  make(chan *Message, 10),
-		Id:       clientId,
-		UserId:   doctorId,
-		UserRole: userRole,
-		RoomId:   roomId,
+		Conn:           conn,
+		Message:        make(chan *Message, constants.WsClientBufferSize),
+		Id:             clientId,
+		UserId:         data.Id,
+		UserRole:       role,
+		RoomId:         roomId,
+		ConsultationId: consultationId,
 	}
 
 	m := &Message{
-		Content:  "doctor has joined the room",
+		Content:  fmt.Sprintf("%s has joined the room", role),
 		RoomId:   roomId,
 		Id:       clientId,
-		UserId:   doctorId,
-		UserRole: userRole,
-		Type:     "read",
+		UserId:   data.Id,
+		UserRole: role,
+		Type:     constants.WsEventJoin,
 	}
 
 	h.hub.Register <- cl
 	h.hub.Broadcast <- m
 
+	if replay {
+		err = h.replayMissedChats(ctx, cl, consultation, lastSeenId)
+		if err != nil {
+			ctx.Error(err)
+			h.hub.Unregister <- cl
+			conn.Close()
+			return
+		}
+	}
+
 	go cl.writeMessage()
 	cl.readMessage(h.hub)
 }
 
+// JoinDoctorRoom subscribes a doctor to their consultation requests. The
+// doctor counts as online for as long as one of these connections is open.
 func (h *WebSocketHandler) JoinDoctorRoom(ctx *gin.Context) {
-	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	data, err := h.authorize(ctx)
 	if err != nil {
 		ctx.Error(err)
 		return
 	}
 
-	roomId := constants.DoctorRole
+	if data.Role != constants.DoctorRole {
+		ctx.Error(custom_errors.Forbidden())
+		return
+	}
 
-	cl := &Client{
-		Conn:    conn,
-		Message: make(chan *Message, 10),
-		Id:      uuid.New().String(),
-		RoomId:  roomId,
+	conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		ctx.Error(err)
+		return
 	}
 
-	m := &Message{
-		Content: "user has joined the room",
-		RoomId:  roomId,
-		Type:    "read",
+	cl := &Client{
+		Conn:     conn,
+		Message:  make(chan *Message, constants.WsClientBufferSize),
+		Id:       uuid.New().String(),
+		UserId:   data.Id,
+		UserRole: data.Role,
+		RoomId:   constants.DoctorRole,
 	}
 
 	h.hub.Register <- cl
-	h.hub.Broadcast <- m
 
 	go cl.writeMessage()
 	cl.readMessage(h.hub)
 }
 
+func parseLastSeenMessageId(ctx *gin.Context) (int64, bool, error) {
+	param, ok := ctx.GetQuery(constants.LastSeenMessageId)
+	if !ok {
+		return 0, false, nil
+	}
+
+	lastSeenId, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return lastSeenId, true, nil
+}
+
+// replayMissedChats sends the chats persisted after lastSeenId. The client is
+// registered first so nothing is lost in between, which means a message
+// arriving during the replay may be delivered twice.
+func (h *WebSocketHandler) replayMissedChats(ctx *gin.Context, cl *Client, consultation *entities.Consultation, lastSeenId int64) error {
+	chats, err := h.ConsultationUsecase.GetConsultationChatsAfter(ctx, consultation.Id, lastSeenId)
+	if err != nil {
+		return err
+	}
+
+	messages := []*Message{}
+	for _, chat := range chats {
+		senderId, senderRole := consultation.Doctor.Id, constants.DoctorRole
+		if chat.IsFromUser {
+			senderId, senderRole = consultation.User.Id, constants.UserRole
+		}
+
+		messages = append(messages, &Message{
+			Content:  chat.Content,
+			RoomId:   cl.RoomId,
+			Id:       fmt.Sprintf("%d-%s", senderId, senderRole),
+			UserId:   senderId,
+			UserRole: senderRole,
+			Type:     chat.Type,
+			ChatId:   chat.Id,
+		})
+	}
+
+	return cl.replay(messages)
+}
+
 type RoomResponse struct {
 	Id       string `json:"id"`
 	UserId   int64  `json:"user_id"`
@@ -281,7 +404,7 @@ type RoomResponse struct {
 func (h *WebSocketHandler) GetRooms(ctx *gin.Context) {
 	rooms := make([]RoomResponse, 0)
 
-	for _, r := range h.hub.Rooms {
+	for _, r := range h.hub.GetRooms() {
 		rooms = append(rooms, RoomResponse{
 			Id:       r.Id,
 			UserId:   r.UserId,
@@ -303,15 +426,10 @@ type ClientResponse struct {
 }
 
 func (h *WebSocketHandler) GetClients(ctx *gin.Context) {
-	var clients []ClientResponse
+	clients := make([]ClientResponse, 0)
 	roomId := ctx.Param("roomId")
 
-	if _, ok := h.hub.Rooms[roomId]; !ok {
-		clients = make([]ClientResponse, 0)
-		ctx.JSON(http.StatusOK, clients)
-	}
-
-	for _, c := range h.hub.Rooms[roomId].Clients {
+	for _, c := range h.hub.GetClients(roomId) {
 		clients = append(clients, ClientResponse{
 			Id:       c.Id,
 			UserId:   c.UserId,
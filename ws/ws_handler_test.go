@@ -0,0 +1,40 @@
+package ws
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	check := checkOrigin("https://sehatin.example")
+
+	tests := []struct {
+		name          string
+		origin        string
+		authorization string
+		want          bool
+	}{
+		{"frontend origin", "https://sehatin.example", "", true},
+		{"frontend origin in another case", "https://SEHATIN.example", "", true},
+		{"other origin", "https://evil.example", "", false},
+		{"other scheme", "http://sehatin.example", "", false},
+		{"no origin with a bearer token", "", "Bearer token", true},
+		{"no origin and no bearer token", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			if got := check(r); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}